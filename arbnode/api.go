@@ -8,6 +8,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"math/big"
+	"path/filepath"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -16,9 +17,13 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/offchainlabs/nitro/arbos/arbosState"
+	"github.com/offchainlabs/nitro/arbos/l1pricing"
 	"github.com/offchainlabs/nitro/arbos/retryables"
 	"github.com/offchainlabs/nitro/util/arbmath"
 	"github.com/offchainlabs/nitro/validator"
@@ -39,9 +44,50 @@ func (a *BlockValidatorAPI) LatestValidatedBlockHash(ctx context.Context) (commo
 	return hash, nil
 }
 
+func (a *BlockValidatorAPI) ValidationProgress(ctx context.Context) (*validator.ValidationProgress, error) {
+	return a.val.Progress(), nil
+}
+
+type StakerAPI struct {
+	staker *validator.Staker
+}
+
+func (a *StakerAPI) SpendReport(ctx context.Context) (validator.StakerSpendReport, error) {
+	return a.staker.SpendReport(), nil
+}
+
+type RetryableIndexerAPI struct {
+	indexer *RetryableIndexer
+}
+
+func (a *RetryableIndexerAPI) RetryableTicket(ctx context.Context, ticketId common.Hash) (*RetryableTicket, error) {
+	return a.indexer.Ticket(ticketId)
+}
+
+func (a *RetryableIndexerAPI) RetryableTickets(ctx context.Context, query RetryableTicketQuery) ([]*RetryableTicket, error) {
+	return a.indexer.Tickets(&query)
+}
+
+type OutboxIndexerAPI struct {
+	indexer *OutboxIndexer
+}
+
+func (a *OutboxIndexerAPI) OutboxMessage(ctx context.Context, position hexutil.Uint64) (*OutboxMessageStatus, error) {
+	return a.indexer.Message(ctx, uint64(position))
+}
+
+func (a *OutboxIndexerAPI) OutboxMessages(ctx context.Context, query OutboxMessageQuery) ([]*OutboxMessageStatus, error) {
+	return a.indexer.Messages(ctx, &query)
+}
+
+func (a *OutboxIndexerAPI) OutboxProof(ctx context.Context, position hexutil.Uint64) (*OutboxProof, error) {
+	return a.indexer.Proof(ctx, uint64(position))
+}
+
 type BlockValidatorDebugAPI struct {
 	val        *validator.StatelessBlockValidator
 	blockchain *core.BlockChain
+	bundleDir  string
 }
 
 type ValidateBlockResult struct {
@@ -61,15 +107,9 @@ func (a *BlockValidatorDebugAPI) ValidateBlock(
 	if !a.blockchain.Config().IsArbitrumNitro(header.Number) {
 		return result, types.ErrUseFallback
 	}
-	var moduleRoot common.Hash
-	if moduleRootOptional != nil {
-		moduleRoot = *moduleRootOptional
-	} else {
-		moduleRoots := a.val.GetModuleRootsToValidate()
-		if len(moduleRoots) == 0 {
-			return result, errors.New("no current WasmModuleRoot configured, must provide parameter")
-		}
-		moduleRoot = moduleRoots[0]
+	moduleRoot, err := a.moduleRoot(moduleRootOptional)
+	if err != nil {
+		return result, err
 	}
 	start_time := time.Now()
 	valid, err := a.val.ValidateBlock(ctx, header, full, moduleRoot)
@@ -78,6 +118,41 @@ func (a *BlockValidatorDebugAPI) ValidateBlock(
 	return result, err
 }
 
+func (a *BlockValidatorDebugAPI) moduleRoot(moduleRootOptional *common.Hash) (common.Hash, error) {
+	if moduleRootOptional != nil {
+		return *moduleRootOptional, nil
+	}
+	moduleRoots := a.val.GetModuleRootsToValidate()
+	if len(moduleRoots) == 0 {
+		return common.Hash{}, errors.New("no current WasmModuleRoot configured, must provide parameter")
+	}
+	return moduleRoots[0], nil
+}
+
+// WriteValidationBundle writes a self-contained validation bundle for the block,
+// which can be re-validated offline, and returns the path it was written to.
+func (a *BlockValidatorDebugAPI) WriteValidationBundle(
+	ctx context.Context, blockNum rpc.BlockNumberOrHash, moduleRootOptional *common.Hash,
+) (string, error) {
+	header, err := arbitrum.HeaderByNumberOrHash(a.blockchain, blockNum)
+	if err != nil {
+		return "", err
+	}
+	if !a.blockchain.Config().IsArbitrumNitro(header.Number) {
+		return "", types.ErrUseFallback
+	}
+	moduleRoot, err := a.moduleRoot(moduleRootOptional)
+	if err != nil {
+		return "", err
+	}
+	bundle, err := a.val.CreateValidationBundle(ctx, header, moduleRoot)
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(a.bundleDir, validator.ValidationBundleFileName(header.Number.Uint64(), moduleRoot))
+	return path, validator.WriteValidationBundle(path, bundle)
+}
+
 type ArbAPI struct {
 	txPublisher TransactionPublisher
 }
@@ -86,6 +161,116 @@ func (a *ArbAPI) CheckPublisherHealth(ctx context.Context) error {
 	return a.txPublisher.CheckHealth(ctx)
 }
 
+type TransactionFeeAPI struct {
+	blockchain *core.BlockChain
+	chainDb    ethdb.Database
+}
+
+// TransactionFeeBreakdown splits what a transaction paid between L1 calldata and L2 execution,
+// and L2 execution between the network and infra fee accounts, as the TxProcessor does.
+type TransactionFeeBreakdown struct {
+	TxHash            common.Hash    `json:"txHash"`
+	BlockNumber       uint64         `json:"blockNumber"`
+	ArbOSVersion      uint64         `json:"arbOSVersion"`
+	BaseFee           *big.Int       `json:"baseFee"`
+	GasUsed           uint64         `json:"gasUsed"`
+	GasUsedForL1      uint64         `json:"gasUsedForL1"`
+	GasUsedForL2      uint64         `json:"gasUsedForL2"`
+	PosterUnits       uint64         `json:"posterUnits"`
+	L1PricePerUnit    *big.Int       `json:"l1PricePerUnit"`
+	TotalFee          *big.Int       `json:"totalFee"`
+	L1Fee             *big.Int       `json:"l1Fee"`
+	L1FeeRecipient    common.Address `json:"l1FeeRecipient"`
+	L2Fee             *big.Int       `json:"l2Fee"`
+	NetworkFee        *big.Int       `json:"networkFee"`
+	NetworkFeeAccount common.Address `json:"networkFeeAccount"`
+	InfraFee          *big.Int       `json:"infraFee"`
+	InfraFeeAccount   common.Address `json:"infraFeeAccount"`
+}
+
+// GetTransactionFeeBreakdown recomputes the fees paid by a mined transaction.
+// The pricing parameters are read from the state the transaction ran on, as
+// earlier transactions in its block can change them.
+func (a *TransactionFeeAPI) GetTransactionFeeBreakdown(ctx context.Context, txHash common.Hash) (*TransactionFeeBreakdown, error) {
+	tx, blockHash, blockNumber, index := rawdb.ReadTransaction(a.chainDb, txHash)
+	if tx == nil {
+		return nil, nil
+	}
+	block := a.blockchain.GetBlockByHash(blockHash)
+	if block == nil {
+		return nil, fmt.Errorf("missing block %v", blockHash)
+	}
+	header := block.Header()
+	if !a.blockchain.Config().IsArbitrumNitro(header.Number) || blockNumber == 0 {
+		return nil, types.ErrUseFallback
+	}
+	receipts := a.blockchain.GetReceiptsByHash(blockHash)
+	if index >= uint64(len(receipts)) {
+		return nil, fmt.Errorf("missing receipt for transaction %v", txHash)
+	}
+	receipt := receipts[index]
+	state, err := stateAtTransaction(a.blockchain, block, index)
+	if err != nil {
+		return nil, err
+	}
+	l1Pricing := state.L1PricingState()
+	l1PricePerUnit, err := l1Pricing.PricePerUnit()
+	if err != nil {
+		return nil, err
+	}
+	_, posterUnits := l1Pricing.GetPosterInfo(tx, header.Coinbase)
+	networkFeeAccount, err := state.NetworkFeeAccount()
+	if err != nil {
+		return nil, err
+	}
+
+	version := state.FormatVersion()
+	baseFee := header.BaseFee
+	gasUsedForL2 := arbmath.SaturatingUSub(receipt.GasUsed, receipt.GasUsedForL1)
+	breakdown := &TransactionFeeBreakdown{
+		TxHash:            txHash,
+		BlockNumber:       blockNumber,
+		ArbOSVersion:      version,
+		BaseFee:           baseFee,
+		GasUsed:           receipt.GasUsed,
+		GasUsedForL1:      receipt.GasUsedForL1,
+		GasUsedForL2:      gasUsedForL2,
+		PosterUnits:       posterUnits,
+		L1PricePerUnit:    l1PricePerUnit,
+		TotalFee:          arbmath.BigMulByUint(baseFee, receipt.GasUsed),
+		L1Fee:             arbmath.BigMulByUint(baseFee, receipt.GasUsedForL1),
+		L1FeeRecipient:    header.Coinbase,
+		L2Fee:             arbmath.BigMulByUint(baseFee, gasUsedForL2),
+		NetworkFeeAccount: networkFeeAccount,
+		InfraFee:          big.NewInt(0),
+	}
+	if version >= 2 {
+		breakdown.L1FeeRecipient = l1pricing.L1PricerFundsPoolAddress
+	}
+	if tx.Type() == types.ArbitrumRetryTxType {
+		// retry txs are paid for from the retryable's deposit, which the network fee account already holds
+		breakdown.L1FeeRecipient = networkFeeAccount
+		breakdown.NetworkFee = breakdown.TotalFee
+		return breakdown, nil
+	}
+	if version > 4 {
+		infraFeeAccount, err := state.InfraFeeAccount()
+		if err != nil {
+			return nil, err
+		}
+		if infraFeeAccount != (common.Address{}) {
+			minBaseFee, err := state.L2PricingState().MinBaseFeeWei()
+			if err != nil {
+				return nil, err
+			}
+			breakdown.InfraFeeAccount = infraFeeAccount
+			breakdown.InfraFee = arbmath.BigMulByUint(arbmath.BigMin(baseFee, minBaseFee), gasUsedForL2)
+		}
+	}
+	breakdown.NetworkFee = arbmath.BigSub(breakdown.L2Fee, breakdown.InfraFee)
+	return breakdown, nil
+}
+
 type ArbDebugAPI struct {
 	blockchain        *core.BlockChain
 	blockRangeBound   uint64
@@ -310,6 +495,16 @@ func (api *ArbDebugAPI) TimeoutQueue(ctx context.Context, blockNum rpc.BlockNumb
 	return queue, err
 }
 
+// ArbOSState dumps every ArbOS subsystem at a block, with lists capped by the timeout queue bound
+func (api *ArbDebugAPI) ArbOSState(ctx context.Context, blockNum rpc.BlockNumber) (*arbosState.StateDump, error) {
+	blockNum, _ = api.blockchain.ClipToPostNitroGenesis(blockNum)
+	state, _, err := stateAndHeader(api.blockchain, uint64(blockNum))
+	if err != nil {
+		return nil, err
+	}
+	return state.Dump(api.timeoutQueueBound)
+}
+
 func stateAndHeader(blockchain *core.BlockChain, block uint64) (*arbosState.ArbosState, *types.Header, error) {
 	header := blockchain.GetHeaderByNumber(block)
 	if !blockchain.Config().IsArbitrumNitro(header.Number) {
@@ -323,6 +518,30 @@ func stateAndHeader(blockchain *core.BlockChain, block uint64) (*arbosState.Arbo
 	return state, header, err
 }
 
+// stateAtTransaction replays the transactions of a block before the one at
+// index on top of its parent's state, returning the state that one ran on.
+func stateAtTransaction(blockchain *core.BlockChain, block *types.Block, index uint64) (*arbosState.ArbosState, error) {
+	parent := blockchain.GetHeaderByHash(block.ParentHash())
+	if parent == nil {
+		return nil, fmt.Errorf("missing parent of block %v", block.Hash())
+	}
+	statedb, err := blockchain.StateAt(parent.Root)
+	if err != nil {
+		return nil, err
+	}
+	header := block.Header()
+	gasPool := new(core.GasPool).AddGas(header.GasLimit)
+	var gasUsed uint64
+	for i, tx := range block.Transactions()[:index] {
+		statedb.Prepare(tx.Hash(), i)
+		_, err := core.ApplyTransaction(blockchain.Config(), blockchain, &header.Coinbase, gasPool, statedb, header, tx, &gasUsed, vm.Config{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to replay transaction %v of block %v: %w", i, header.Number, err)
+		}
+	}
+	return arbosState.OpenSystemArbosState(statedb, nil, true)
+}
+
 type ArbTraceForwarderAPI struct {
 	fallbackClientUrl     string
 	fallbackClientTimeout time.Duration
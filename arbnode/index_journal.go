@@ -0,0 +1,204 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"encoding/binary"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// indexJournalBlocks is how many of the most recently indexed blocks an
+// indexer can roll back on a reorg without rebuilding its whole index.
+const indexJournalBlocks = 10000
+
+var (
+	indexBlockHashPrefix []byte = []byte("_h") // maps an indexed block number to its hash
+	indexJournalPrefix   []byte = []byte("_j") // maps the first block of an update to an indexJournalRecord
+)
+
+func indexJournalKey(prefix []byte, block uint64) []byte {
+	return append(common.CopyBytes(prefix), uint64ToKey(block)...)
+}
+
+type indexJournalEntry struct {
+	Key     []byte
+	Value   []byte
+	Existed bool
+}
+
+// indexJournalRecord holds the values an update overwrote, so it can be undone.
+type indexJournalRecord struct {
+	FirstBlock uint64
+	LastBlock  uint64
+	Entries    []indexJournalEntry
+}
+
+// indexJournal passes an update's writes on to a batch, recording the
+// previous value of each key it changes.
+type indexJournal struct {
+	db      ethdb.KeyValueStore
+	batch   ethdb.Batch
+	changed map[string]bool
+	record  indexJournalRecord
+}
+
+func newIndexJournal(db ethdb.KeyValueStore, batch ethdb.Batch) *indexJournal {
+	return &indexJournal{
+		db:      db,
+		batch:   batch,
+		changed: make(map[string]bool),
+	}
+}
+
+func (j *indexJournal) Put(key []byte, value []byte) error {
+	if err := j.save(key); err != nil {
+		return err
+	}
+	return j.batch.Put(key, value)
+}
+
+func (j *indexJournal) Delete(key []byte) error {
+	if err := j.save(key); err != nil {
+		return err
+	}
+	return j.batch.Delete(key)
+}
+
+func (j *indexJournal) save(key []byte) error {
+	if j.changed[string(key)] {
+		return nil
+	}
+	j.changed[string(key)] = true
+	entry := indexJournalEntry{Key: common.CopyBytes(key)}
+	has, err := j.db.Has(key)
+	if err != nil {
+		return err
+	}
+	if has {
+		entry.Value, err = j.db.Get(key)
+		if err != nil {
+			return err
+		}
+		entry.Existed = true
+	}
+	j.record.Entries = append(j.record.Entries, entry)
+	return nil
+}
+
+// commit adds the hashes of the indexed blocks and the journal record to the
+// batch, and prunes the records of blocks too old to roll back.
+func (j *indexJournal) commit(firstBlock uint64, hashes []common.Hash) error {
+	for i, hash := range hashes {
+		if err := j.Put(indexJournalKey(indexBlockHashPrefix, firstBlock+uint64(i)), hash.Bytes()); err != nil {
+			return err
+		}
+	}
+	j.record.FirstBlock = firstBlock
+	j.record.LastBlock = firstBlock + uint64(len(hashes)) - 1
+	data, err := rlp.EncodeToBytes(j.record)
+	if err != nil {
+		return err
+	}
+	if err := j.batch.Put(indexJournalKey(indexJournalPrefix, firstBlock), data); err != nil {
+		return err
+	}
+	if j.record.LastBlock < indexJournalBlocks {
+		return nil
+	}
+	oldest := j.record.LastBlock - indexJournalBlocks
+	for _, prefix := range [][]byte{indexJournalPrefix, indexBlockHashPrefix} {
+		if err := deleteKeysBefore(j.db, j.batch, prefix, oldest); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteKeysBefore deletes the keys under a prefix followed by a block number
+// lower than the given one.
+func deleteKeysBefore(db ethdb.Iteratee, batch ethdb.KeyValueWriter, prefix []byte, block uint64) error {
+	iter := db.NewIterator(prefix, nil)
+	defer iter.Release()
+	for iter.Next() {
+		key := iter.Key()
+		if binary.BigEndian.Uint64(key[len(prefix):]) >= block {
+			break
+		}
+		if err := batch.Delete(common.CopyBytes(key)); err != nil {
+			return err
+		}
+	}
+	return iter.Error()
+}
+
+// rollbackIndex adds to the batch the undoing of every journaled update that
+// indexed a block after the last one still canonical, and returns the block
+// to continue indexing from. It returns false if the reorg is older than the
+// journal, in which case the index has to be rebuilt.
+func rollbackIndex(db ethdb.KeyValueStore, batch ethdb.Batch, nextBlock uint64, canonicalHash func(uint64) common.Hash) (uint64, bool, error) {
+	var fork uint64
+	for number := nextBlock - 1; ; number-- {
+		key := indexJournalKey(indexBlockHashPrefix, number)
+		has, err := db.Has(key)
+		if err != nil || !has {
+			return 0, false, err
+		}
+		hash, err := db.Get(key)
+		if err != nil {
+			return 0, false, err
+		}
+		if canonicalHash(number) == common.BytesToHash(hash) {
+			fork = number
+			break
+		}
+		if number == 0 {
+			return 0, false, nil
+		}
+	}
+
+	var records [][]byte
+	iter := db.NewIterator(indexJournalPrefix, nil)
+	for iter.Next() {
+		records = append(records, common.CopyBytes(iter.Key()))
+	}
+	iter.Release()
+	if err := iter.Error(); err != nil {
+		return 0, false, err
+	}
+	for i := len(records) - 1; i >= 0 && nextBlock > fork+1; i-- {
+		data, err := db.Get(records[i])
+		if err != nil {
+			return 0, false, err
+		}
+		var record indexJournalRecord
+		if err := rlp.DecodeBytes(data, &record); err != nil {
+			return 0, false, err
+		}
+		if record.LastBlock+1 != nextBlock {
+			// the journal is missing the update that came after this one
+			return 0, false, nil
+		}
+		for _, entry := range record.Entries {
+			if entry.Existed {
+				err = batch.Put(entry.Key, entry.Value)
+			} else {
+				err = batch.Delete(entry.Key)
+			}
+			if err != nil {
+				return 0, false, err
+			}
+		}
+		if err := batch.Delete(records[i]); err != nil {
+			return 0, false, err
+		}
+		nextBlock = record.FirstBlock
+	}
+	if nextBlock > fork+1 {
+		return 0, false, nil
+	}
+	return nextBlock, true, nil
+}
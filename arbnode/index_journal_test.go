@@ -0,0 +1,88 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+func TestIndexJournalRollback(t *testing.T) {
+	db := rawdb.NewMemoryDatabase()
+	chain := make(map[uint64]common.Hash)
+	canonicalHash := func(number uint64) common.Hash { return chain[number] }
+	index := func(firstBlock uint64, lastBlock uint64, writes func(ethdb.KeyValueWriter)) {
+		batch := db.NewBatch()
+		journal := newIndexJournal(db, batch)
+		writes(journal)
+		var hashes []common.Hash
+		for number := firstBlock; number <= lastBlock; number++ {
+			chain[number] = common.BytesToHash(append([]byte{1}, uint64ToKey(number)...))
+			hashes = append(hashes, chain[number])
+		}
+		Require(t, journal.commit(firstBlock, hashes))
+		Require(t, batch.Write())
+	}
+	expect := func(key string, value string) {
+		t.Helper()
+		has, err := db.Has([]byte(key))
+		Require(t, err)
+		if value == "" {
+			if has {
+				Fail(t, "key", key, "wasn't removed")
+			}
+			return
+		}
+		data, err := db.Get([]byte(key))
+		Require(t, err)
+		if !bytes.Equal(data, []byte(value)) {
+			Fail(t, "key", key, "has value", string(data), "instead of", value)
+		}
+	}
+
+	index(0, 1, func(w ethdb.KeyValueWriter) {
+		Require(t, w.Put([]byte("a"), []byte("1")))
+		Require(t, w.Put([]byte("b"), []byte("1")))
+	})
+	index(2, 3, func(w ethdb.KeyValueWriter) {
+		Require(t, w.Put([]byte("a"), []byte("2")))
+		Require(t, w.Delete([]byte("b")))
+		Require(t, w.Put([]byte("c"), []byte("2")))
+	})
+	index(4, 5, func(w ethdb.KeyValueWriter) {
+		Require(t, w.Put([]byte("a"), []byte("3")))
+		Require(t, w.Put([]byte("a"), []byte("4")))
+	})
+
+	// block 3 is reorged out, so the updates for blocks 2 to 5 are undone
+	chain[3] = common.Hash{3}
+	delete(chain, 4)
+	delete(chain, 5)
+	batch := db.NewBatch()
+	nextBlock, ok, err := rollbackIndex(db, batch, 6, canonicalHash)
+	Require(t, err)
+	if !ok || nextBlock != 2 {
+		Fail(t, "rolled back to block", nextBlock, "ok", ok)
+	}
+	Require(t, batch.Write())
+	expect("a", "1")
+	expect("b", "1")
+	expect("c", "")
+	for number := uint64(2); number <= 5; number++ {
+		expect(string(indexJournalKey(indexBlockHashPrefix, number)), "")
+	}
+
+	// a reorg of every journaled block can't be rolled back
+	chain[0] = common.Hash{}
+	chain[1] = common.Hash{}
+	_, ok, err = rollbackIndex(db, db.NewBatch(), 2, canonicalHash)
+	Require(t, err)
+	if ok {
+		Fail(t, "rolled back past the start of the journal")
+	}
+}
@@ -38,6 +38,7 @@ import (
 	"github.com/offchainlabs/nitro/arbutil"
 	"github.com/offchainlabs/nitro/broadcastclient"
 	"github.com/offchainlabs/nitro/broadcaster"
+	"github.com/offchainlabs/nitro/cmd/genericconf"
 	"github.com/offchainlabs/nitro/das"
 	"github.com/offchainlabs/nitro/solgen/go/bridgegen"
 	"github.com/offchainlabs/nitro/solgen/go/challengegen"
@@ -415,6 +416,11 @@ type Config struct {
 	Caching                CachingConfig                  `koanf:"caching"`
 	Archive                bool                           `koanf:"archive"`
 	TxLookupLimit          uint64                         `koanf:"tx-lookup-limit"`
+	RetryableIndexer       RetryableIndexerConfig         `koanf:"retryable-indexer"`
+	OutboxIndexer          OutboxIndexerConfig            `koanf:"outbox-indexer"`
+	ParameterHistory       ParameterHistoryConfig         `koanf:"parameter-history"`
+	RetryableKeeper        RetryableKeeperConfig          `koanf:"retryable-keeper"`
+	FeedSignerRotation     FeedSignerRotationConfig       `koanf:"feed-signer-rotation"`
 }
 
 func (c *Config) Validate() error {
@@ -430,6 +436,24 @@ func (c *Config) Validate() error {
 	if err := c.BatchPoster.Validate(); err != nil {
 		return err
 	}
+	if err := c.RetryableKeeper.Validate(); err != nil {
+		return err
+	}
+	if c.FeedSignerRotation.Enable && !(c.Feed.Output.Enable && c.Feed.Output.Signed) {
+		return errors.New("feed signer rotation requires a signed feed output")
+	}
+	if c.FeedSignerRotation.Enable && c.FeedSignerRotation.Wallet.ExternalSigner.URL != "" && c.FeedSignerRotation.Wallet.ExternalSigner.DataMethod == "" {
+		return errors.New("rotating the feed signer to an external signer requires --node.feed-signer-rotation.wallet.external-signer.data-method")
+	}
+	if c.BlockValidator.Sampling.Enable {
+		strategy, err := c.Validator.ParseStrategy()
+		if err != nil {
+			return err
+		}
+		if strategy != validator.WatchtowerStrategy {
+			return errors.New("block validator sampling is only supported for watchtowers, as it doesn't validate every block")
+		}
+	}
 	return nil
 }
 
@@ -472,6 +496,11 @@ func ConfigAddOptions(prefix string, f *flag.FlagSet, feedInputEnable bool, feed
 	DangerousConfigAddOptions(prefix+".dangerous", f)
 	CachingConfigAddOptions(prefix+".caching", f)
 	f.Uint64(prefix+".tx-lookup-limit", ConfigDefault.TxLookupLimit, "retain the ability to lookup transactions by hash for the past N blocks (0 = all blocks)")
+	RetryableIndexerConfigAddOptions(prefix+".retryable-indexer", f)
+	OutboxIndexerConfigAddOptions(prefix+".outbox-indexer", f)
+	ParameterHistoryConfigAddOptions(prefix+".parameter-history", f)
+	RetryableKeeperConfigAddOptions(prefix+".retryable-keeper", f)
+	FeedSignerRotationConfigAddOptions(prefix+".feed-signer-rotation", f)
 
 	archiveMsg := fmt.Sprintf("retain past block state (deprecated, please use %v.caching.archive)", prefix)
 	f.Bool(prefix+".archive", ConfigDefault.Archive, archiveMsg)
@@ -497,6 +526,11 @@ var ConfigDefault = Config{
 	Archive:                false,
 	TxLookupLimit:          40_000_000,
 	Caching:                DefaultCachingConfig,
+	RetryableIndexer:       DefaultRetryableIndexerConfig,
+	OutboxIndexer:          DefaultOutboxIndexerConfig,
+	ParameterHistory:       DefaultParameterHistoryConfig,
+	RetryableKeeper:        DefaultRetryableKeeperConfig,
+	FeedSignerRotation:     DefaultFeedSignerRotationConfig,
 }
 
 func ConfigDefaultL1Test() *Config {
@@ -645,6 +679,22 @@ var DefaultCachingConfig = CachingConfig{
 	TrieTimeLimit: time.Hour,
 }
 
+// FeedSignerRotationConfig gives the key arbfeed_rotateSigner rotates the feed signer to
+type FeedSignerRotationConfig struct {
+	Enable bool                     `koanf:"enable"`
+	Wallet genericconf.WalletConfig `koanf:"wallet"`
+}
+
+var DefaultFeedSignerRotationConfig = FeedSignerRotationConfig{
+	Enable: false,
+	Wallet: genericconf.WalletConfigDefault,
+}
+
+func FeedSignerRotationConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultFeedSignerRotationConfig.Enable, "allow rotating the feed signer to the key in the wallet with arbfeed_rotateSigner")
+	genericconf.WalletConfigAddOptions(prefix+".wallet", f, "feed-signer-rotation-wallet")
+}
+
 type Node struct {
 	Stack                   *node.Node
 	Backend                 *arbitrum.Backend
@@ -666,6 +716,9 @@ type Node struct {
 	DASLifecycleManager     *das.LifecycleManager
 	ClassicOutboxRetriever  *ClassicOutboxRetriever
 	SyncMonitor             *SyncMonitor
+	RetryableIndexer        *RetryableIndexer
+	OutboxIndexer           *OutboxIndexer
+	RetryableKeeper         *RetryableKeeper // set by the caller before Start, as it needs an L2 wallet
 	configFetcher           ConfigFetcher
 	ctx                     context.Context
 }
@@ -766,6 +819,31 @@ func createNodeImpl(
 		classicOutbox = NewClassicOutboxRetriever(classicMsgDb)
 	}
 
+	var retryableIndexer *RetryableIndexer
+	if config.RetryableIndexer.Enable {
+		retryableIndexer, err = NewRetryableIndexer(
+			rawdb.NewTable(arbDb, retryableIndexerPrefix),
+			l2BlockChain,
+			func() *RetryableIndexerConfig { return &configFetcher.Get().RetryableIndexer },
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var outboxIndexer *OutboxIndexer
+	if config.OutboxIndexer.Enable {
+		outboxIndexer, err = NewOutboxIndexer(
+			rawdb.NewTable(arbDb, outboxIndexerPrefix),
+			stack,
+			l2BlockChain,
+			func() *OutboxIndexerConfig { return &configFetcher.Get().OutboxIndexer },
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	var broadcastServer *broadcaster.Broadcaster
 	if config.Feed.Output.Enable {
 		var maybeDataSigner signature.DataSignerFunc
@@ -776,6 +854,9 @@ func createNodeImpl(
 			maybeDataSigner = dataSigner
 		}
 		broadcastServer = broadcaster.NewBroadcaster(func() *wsbroadcastserver.BroadcasterConfig { return &config.Get().Feed.Output }, l2ChainId, fatalErrChan, maybeDataSigner)
+		if err := broadcastServer.LoadSignerRotation(rawdb.NewTable(arbDb, feedSignerRotationPrefix)); err != nil {
+			return nil, err
+		}
 	}
 
 	var l1Reader *headerreader.HeaderReader
@@ -892,6 +973,9 @@ func createNodeImpl(
 			nil,
 			classicOutbox,
 			syncMonitor,
+			retryableIndexer,
+			outboxIndexer,
+			nil,
 			configFetcher,
 			ctx,
 		}, nil
@@ -945,6 +1029,14 @@ func createNodeImpl(
 	if err != nil {
 		return nil, err
 	}
+	if outboxIndexer != nil {
+		rollup, err := validator.NewRollupWatcher(deployInfo.Rollup, l1client, bind.CallOpts{})
+		if err != nil {
+			return nil, err
+		}
+		outboxIndexer.SetInboxTracker(inboxTracker)
+		outboxIndexer.SetRollup(rollup)
+	}
 	txStreamer.SetInboxReader(inboxReader)
 
 	blockValidatorConf := &config.BlockValidator
@@ -1023,10 +1115,15 @@ func createNodeImpl(
 				return nil, err
 			}
 		}
-		staker, err = validator.NewStaker(l1Reader, wallet, bind.CallOpts{}, config.Validator, l2BlockChain, daReader, inboxReader, inboxTracker, txStreamer, blockValidator, nitroMachineLoader, deployInfo.ValidatorUtils)
+		validatorConfig := config.Validator
+		validatorConfig.MachineCheckpoints.Path = stack.ResolvePath(validatorConfig.MachineCheckpoints.Path)
+		staker, err = validator.NewStaker(l1Reader, wallet, bind.CallOpts{}, validatorConfig, l2BlockChain, daReader, inboxReader, inboxTracker, txStreamer, blockValidator, nitroMachineLoader, deployInfo.ValidatorUtils)
 		if err != nil {
 			return nil, err
 		}
+		if err := staker.LoadSpending(rawdb.NewTable(arbDb, stakerSpendingPrefix)); err != nil {
+			return nil, err
+		}
 		if staker.Strategy() != validator.WatchtowerStrategy {
 			err := wallet.Initialize(ctx)
 			if err != nil {
@@ -1082,6 +1179,9 @@ func createNodeImpl(
 		dasLifecycleManager,
 		classicOutbox,
 		syncMonitor,
+		retryableIndexer,
+		outboxIndexer,
+		nil,
 		configFetcher,
 		ctx,
 	}, nil
@@ -1330,10 +1430,39 @@ func CreateNode(
 			Service: &BlockValidatorDebugAPI{
 				val:        currentNode.StatelessBlockValidator,
 				blockchain: l2BlockChain,
+				bundleDir: filepath.Join(
+					currentNode.StatelessBlockValidator.MachineLoader.GetConfig().RootPath,
+					configFetcher.Get().BlockValidator.OutputPath,
+					"bundles",
+				),
 			},
 			Public: false,
 		})
 	}
+	if currentNode.RetryableIndexer != nil {
+		apis = append(apis, rpc.API{
+			Namespace: "arb",
+			Version:   "1.0",
+			Service:   &RetryableIndexerAPI{indexer: currentNode.RetryableIndexer},
+			Public:    false,
+		})
+	}
+	if currentNode.OutboxIndexer != nil {
+		apis = append(apis, rpc.API{
+			Namespace: "arb",
+			Version:   "1.0",
+			Service:   &OutboxIndexerAPI{indexer: currentNode.OutboxIndexer},
+			Public:    false,
+		})
+	}
+	if currentNode.Staker != nil {
+		apis = append(apis, rpc.API{
+			Namespace: "arbvalidator",
+			Version:   "1.0",
+			Service:   &StakerAPI{staker: currentNode.Staker},
+			Public:    false,
+		})
+	}
 
 	apis = append(apis, rpc.API{
 		Namespace: "arb",
@@ -1341,6 +1470,24 @@ func CreateNode(
 		Service:   &ArbAPI{currentNode.TxPublisher},
 		Public:    false,
 	})
+	apis = append(apis, rpc.API{
+		Namespace: "arb",
+		Version:   "1.0",
+		Service: &TransactionFeeAPI{
+			blockchain: l2BlockChain,
+			chainDb:    chainDb,
+		},
+		Public: false,
+	})
+	apis = append(apis, rpc.API{
+		Namespace: "arb",
+		Version:   "1.0",
+		Service: &ParameterHistoryAPI{
+			blockchain: l2BlockChain,
+			config:     func() *ParameterHistoryConfig { return &configFetcher.Get().ParameterHistory },
+		},
+		Public: false,
+	})
 	config := configFetcher.Get()
 	apis = append(apis, rpc.API{
 		Namespace: "arbdebug",
@@ -1352,6 +1499,14 @@ func CreateNode(
 		},
 		Public: false,
 	})
+	if currentNode.BroadcastServer != nil {
+		apis = append(apis, rpc.API{
+			Namespace: "arbfeed",
+			Version:   "1.0",
+			Service:   broadcaster.NewBroadcasterAdminAPI(currentNode.BroadcastServer),
+			Public:    false,
+		})
+	}
 	apis = append(apis, rpc.API{
 		Namespace: "arbtrace",
 		Version:   "1.0",
@@ -1432,6 +1587,15 @@ func (n *Node) Start(ctx context.Context) error {
 	if n.Staker != nil {
 		n.Staker.Start(ctx)
 	}
+	if n.RetryableIndexer != nil {
+		n.RetryableIndexer.Start(ctx)
+	}
+	if n.OutboxIndexer != nil {
+		n.OutboxIndexer.Start(ctx)
+	}
+	if n.RetryableKeeper != nil {
+		n.RetryableKeeper.Start(ctx)
+	}
 	if n.L1Reader != nil {
 		n.L1Reader.Start(ctx)
 	}
@@ -1463,6 +1627,15 @@ func (n *Node) StopAndWait() {
 	if n.L1Reader != nil {
 		n.L1Reader.StopAndWait()
 	}
+	if n.RetryableKeeper != nil {
+		n.RetryableKeeper.StopAndWait()
+	}
+	if n.OutboxIndexer != nil {
+		n.OutboxIndexer.StopAndWait()
+	}
+	if n.RetryableIndexer != nil {
+		n.RetryableIndexer.StopAndWait()
+	}
 	if n.BlockValidator != nil {
 		n.BlockValidator.StopAndWait()
 	}
@@ -0,0 +1,585 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/rlp"
+
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/solgen/go/node_interfacegen"
+	"github.com/offchainlabs/nitro/solgen/go/precompilesgen"
+	"github.com/offchainlabs/nitro/util/stopwaiter"
+	"github.com/offchainlabs/nitro/validator"
+)
+
+type OutboxIndexerConfig struct {
+	Enable             bool          `koanf:"enable"`
+	BlocksPerIteration uint64        `koanf:"blocks-per-iteration"`
+	PollInterval       time.Duration `koanf:"poll-interval"`
+	MaxQueryResults    int           `koanf:"max-query-results"`
+}
+
+var DefaultOutboxIndexerConfig = OutboxIndexerConfig{
+	Enable:             false,
+	BlocksPerIteration: 1000,
+	PollInterval:       time.Second,
+	MaxQueryResults:    1000,
+}
+
+func OutboxIndexerConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultOutboxIndexerConfig.Enable, "index L2 to L1 messages and serve queries and outbox proofs for them over rpc")
+	f.Uint64(prefix+".blocks-per-iteration", DefaultOutboxIndexerConfig.BlocksPerIteration, "maximum number of blocks to index at once")
+	f.Duration(prefix+".poll-interval", DefaultOutboxIndexerConfig.PollInterval, "how often to check for new blocks once caught up")
+	f.Int(prefix+".max-query-results", DefaultOutboxIndexerConfig.MaxQueryResults, "maximum number of messages returned by a query")
+}
+
+// OutboxMessage is an L2 to L1 message sent via ArbSys, which becomes a leaf of the send merkle tree.
+type OutboxMessage struct {
+	Position     uint64         `json:"position"` // the message's leaf in the send merkle tree
+	Hash         common.Hash    `json:"hash"`
+	Sender       common.Address `json:"sender"`
+	Destination  common.Address `json:"destination"`
+	CallValue    *big.Int       `json:"callValue"`
+	CalldataHash common.Hash    `json:"calldataHash"`
+	L1BlockNum   uint64         `json:"l1BlockNum"`
+	Timestamp    uint64         `json:"timestamp"`
+	BlockNumber  uint64         `json:"blockNumber"`
+	TxHash       common.Hash    `json:"txHash"`
+}
+
+// OutboxMessageStatus is an indexed message along with its progress towards being executable on L1.
+type OutboxMessageStatus struct {
+	*OutboxMessage
+	Batch       *uint64 `json:"batch"`       // the batch that posted the message, or nil if it hasn't been posted
+	Confirmable bool    `json:"confirmable"` // whether the latest confirmed rollup node includes the message
+}
+
+// OutboxProof has everything needed to call Outbox.executeTransaction on L1.
+type OutboxProof struct {
+	Proof       []common.Hash  `json:"proof"`
+	Index       uint64         `json:"index"`
+	L2Sender    common.Address `json:"l2Sender"`
+	To          common.Address `json:"to"`
+	L2Block     uint64         `json:"l2Block"`
+	L1Block     uint64         `json:"l1Block"`
+	L2Timestamp uint64         `json:"l2Timestamp"`
+	Value       *big.Int       `json:"value"`
+	Data        []byte         `json:"data"`
+	// the confirmed send merkle root the proof is against
+	Root common.Hash `json:"root"`
+	Size uint64      `json:"size"`
+}
+
+// OutboxMessageQuery filters messages; unset fields match any message.
+type OutboxMessageQuery struct {
+	Sender       *common.Address `json:"sender"`
+	Destination  *common.Address `json:"destination"`
+	FromPosition uint64          `json:"fromPosition"`
+	Limit        int             `json:"limit"`
+}
+
+func (q *OutboxMessageQuery) matches(message *OutboxMessage) bool {
+	if q.Sender != nil && message.Sender != *q.Sender {
+		return false
+	}
+	if q.Destination != nil && message.Destination != *q.Destination {
+		return false
+	}
+	return message.Position >= q.FromPosition
+}
+
+var (
+	outboxMessagePrefix   []byte = []byte("m") // maps a position to a rlp encoded OutboxMessage
+	outboxBySenderPrefix  []byte = []byte("s") // indexes messages by sender address
+	outboxByDestination   []byte = []byte("d") // indexes messages by destination address
+	outboxIndexerProgress []byte = []byte("_progress")
+	outboxL2ToL1TxID      common.Hash
+)
+
+func init() {
+	arbSysAbi, err := precompilesgen.ArbSysMetaData.GetAbi()
+	if err != nil {
+		panic(err)
+	}
+	outboxL2ToL1TxID = arbSysAbi.Events["L2ToL1Tx"].ID
+}
+
+type outboxIndexerProgressInfo struct {
+	NextBlock     uint64
+	LastBlockHash common.Hash
+}
+
+// the send count of the latest confirmed rollup node
+type confirmedSends struct {
+	nodeNum uint64
+	count   uint64
+	root    common.Hash
+}
+
+// OutboxIndexer follows block production and records every L2 to L1 message
+// sent after the nitro genesis.
+type OutboxIndexer struct {
+	stopwaiter.StopWaiter
+	db            ethdb.Database
+	bc            *core.BlockChain
+	config        func() *OutboxIndexerConfig
+	filterer      *precompilesgen.ArbSysFilterer
+	nodeInterface *node_interfacegen.NodeInterface
+	inboxTracker  *InboxTracker
+	rollup        *validator.RollupWatcher
+	progress      outboxIndexerProgressInfo
+	startBlock    uint64
+
+	confirmedMutex sync.Mutex
+	confirmed      *confirmedSends
+}
+
+func NewOutboxIndexer(db ethdb.Database, stack *node.Node, bc *core.BlockChain, config func() *OutboxIndexerConfig) (*OutboxIndexer, error) {
+	filterer, err := precompilesgen.NewArbSysFilterer(types.ArbSysAddress, nil)
+	if err != nil {
+		return nil, err
+	}
+	rpcClient, err := stack.Attach()
+	if err != nil {
+		return nil, err
+	}
+	nodeInterface, err := node_interfacegen.NewNodeInterface(types.NodeInterfaceAddress, ethclient.NewClient(rpcClient))
+	if err != nil {
+		return nil, err
+	}
+	x := &OutboxIndexer{
+		db:            db,
+		bc:            bc,
+		config:        config,
+		filterer:      filterer,
+		nodeInterface: nodeInterface,
+		startBlock:    bc.Config().ArbitrumChainParams.GenesisBlockNum,
+	}
+	x.progress.NextBlock = x.startBlock
+	hasProgress, err := db.Has(outboxIndexerProgress)
+	if err != nil {
+		return nil, err
+	}
+	if hasProgress {
+		data, err := db.Get(outboxIndexerProgress)
+		if err != nil {
+			return nil, err
+		}
+		if err := rlp.DecodeBytes(data, &x.progress); err != nil {
+			return nil, err
+		}
+	}
+	return x, nil
+}
+
+// SetInboxTracker lets the indexer report which batch posted each message.
+func (x *OutboxIndexer) SetInboxTracker(inboxTracker *InboxTracker) {
+	x.inboxTracker = inboxTracker
+}
+
+// SetRollup lets the indexer check which messages the latest confirmed rollup node includes.
+func (x *OutboxIndexer) SetRollup(rollup *validator.RollupWatcher) {
+	x.rollup = rollup
+}
+
+func (x *OutboxIndexer) Start(ctxIn context.Context) {
+	x.StopWaiter.Start(ctxIn, x)
+	x.CallIteratively(func(ctx context.Context) time.Duration {
+		caughtUp, err := x.update(ctx)
+		if err != nil {
+			log.Error("error indexing outbox messages", "err", err)
+			return x.config().PollInterval
+		}
+		if caughtUp {
+			return x.config().PollInterval
+		}
+		return 0
+	})
+}
+
+// Message returns the status of an indexed message, or nil if it hasn't been indexed.
+func (x *OutboxIndexer) Message(ctx context.Context, position uint64) (*OutboxMessageStatus, error) {
+	message, err := x.readMessage(position)
+	if err != nil || message == nil {
+		return nil, err
+	}
+	confirmed, err := x.confirmedSends(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return x.messageStatus(message, confirmed)
+}
+
+// Messages returns messages matching the query in the order they were sent, using the most selective index.
+func (x *OutboxIndexer) Messages(ctx context.Context, query *OutboxMessageQuery) ([]*OutboxMessageStatus, error) {
+	limit := x.config().MaxQueryResults
+	if query.Limit > 0 && query.Limit < limit {
+		limit = query.Limit
+	}
+	var prefix []byte
+	switch {
+	case query.Sender != nil:
+		prefix = indexKey(outboxBySenderPrefix, query.Sender.Bytes())
+	case query.Destination != nil:
+		prefix = indexKey(outboxByDestination, query.Destination.Bytes())
+	default:
+		prefix = outboxMessagePrefix
+	}
+	confirmed, err := x.confirmedSends(ctx)
+	if err != nil {
+		return nil, err
+	}
+	messages := []*OutboxMessageStatus{}
+	iter := x.db.NewIterator(prefix, uint64ToKey(query.FromPosition))
+	defer iter.Release()
+	for iter.Next() && len(messages) < limit {
+		key := iter.Key()
+		if len(key) < len(prefix)+8 {
+			continue
+		}
+		message, err := x.readMessage(binary.BigEndian.Uint64(key[len(key)-8:]))
+		if err != nil {
+			return nil, err
+		}
+		if message == nil || !query.matches(message) {
+			continue
+		}
+		status, err := x.messageStatus(message, confirmed)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, status)
+	}
+	return messages, iter.Error()
+}
+
+// Proof returns the arguments to execute a message on L1, against the latest confirmed send root.
+func (x *OutboxIndexer) Proof(ctx context.Context, position uint64) (*OutboxProof, error) {
+	message, err := x.readMessage(position)
+	if err != nil {
+		return nil, err
+	}
+	if message == nil {
+		return nil, fmt.Errorf("outbox message %v hasn't been indexed", position)
+	}
+	confirmed, err := x.confirmedSends(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if confirmed == nil {
+		return nil, errors.New("no rollup to check for confirmed messages")
+	}
+	if position >= confirmed.count {
+		return nil, fmt.Errorf("outbox message %v isn't confirmed yet, as only %v messages are", position, confirmed.count)
+	}
+	event, err := x.findEvent(message)
+	if err != nil {
+		return nil, err
+	}
+	result, err := x.nodeInterface.ConstructOutboxProof(&bind.CallOpts{Context: ctx}, confirmed.count, position)
+	if err != nil {
+		return nil, err
+	}
+	if common.Hash(result.Send) != message.Hash || common.Hash(result.Root) != confirmed.root {
+		return nil, fmt.Errorf("outbox proof for message %v doesn't match the confirmed send root %v", position, confirmed.root)
+	}
+	proof := &OutboxProof{
+		Proof:       make([]common.Hash, len(result.Proof)),
+		Index:       position,
+		L2Sender:    event.Caller,
+		To:          event.Destination,
+		L2Block:     event.ArbBlockNum.Uint64(),
+		L1Block:     event.EthBlockNum.Uint64(),
+		L2Timestamp: event.Timestamp.Uint64(),
+		Value:       event.Callvalue,
+		Data:        event.Data,
+		Root:        confirmed.root,
+		Size:        confirmed.count,
+	}
+	for i, hash := range result.Proof {
+		proof.Proof[i] = hash
+	}
+	return proof, nil
+}
+
+func (x *OutboxIndexer) messageStatus(message *OutboxMessage, confirmed *confirmedSends) (*OutboxMessageStatus, error) {
+	batch, err := x.findBatch(message.BlockNumber)
+	if err != nil {
+		return nil, err
+	}
+	return &OutboxMessageStatus{
+		OutboxMessage: message,
+		Batch:         batch,
+		Confirmable:   confirmed != nil && message.Position < confirmed.count,
+	}, nil
+}
+
+// findBatch searches for the batch that posted a block, returning nil if it hasn't been posted.
+func (x *OutboxIndexer) findBatch(blockNumber uint64) (*uint64, error) {
+	if x.inboxTracker == nil {
+		return nil, nil
+	}
+	messageIndex := arbutil.BlockNumberToMessageCount(blockNumber, x.startBlock) - 1
+	batchCount, err := x.inboxTracker.GetBatchCount()
+	if err != nil || batchCount == 0 {
+		return nil, err
+	}
+	posted, err := x.inboxTracker.GetBatchMessageCount(batchCount - 1)
+	if err != nil || posted <= messageIndex {
+		return nil, err
+	}
+	low, high := uint64(0), batchCount-1
+	for low < high {
+		mid := (low + high) / 2
+		count, err := x.inboxTracker.GetBatchMessageCount(mid)
+		if err != nil {
+			return nil, err
+		}
+		if count > messageIndex {
+			high = mid
+		} else {
+			low = mid + 1
+		}
+	}
+	return &low, nil
+}
+
+// confirmedSends returns how many messages the latest confirmed rollup node includes, or nil without a rollup.
+func (x *OutboxIndexer) confirmedSends(ctx context.Context) (*confirmedSends, error) {
+	if x.rollup == nil {
+		return nil, nil
+	}
+	nodeNum, err := x.rollup.LatestConfirmed(&bind.CallOpts{Context: ctx})
+	if err != nil {
+		return nil, err
+	}
+	x.confirmedMutex.Lock()
+	defer x.confirmedMutex.Unlock()
+	if x.confirmed != nil && x.confirmed.nodeNum == nodeNum {
+		return x.confirmed, nil
+	}
+	rollupNode, err := x.rollup.LookupNode(ctx, nodeNum)
+	if err != nil {
+		return nil, err
+	}
+	globalState := rollupNode.AfterState().GlobalState
+	if globalState.BlockHash == (common.Hash{}) {
+		// the rollup's initial node, which confirms nothing
+		x.confirmed = &confirmedSends{nodeNum: nodeNum}
+		return x.confirmed, nil
+	}
+	header := x.bc.GetHeaderByHash(globalState.BlockHash)
+	if header == nil {
+		return nil, fmt.Errorf("block %v of confirmed node %v not found", globalState.BlockHash, nodeNum)
+	}
+	info, err := types.DeserializeHeaderExtraInformation(header)
+	if err != nil {
+		return nil, err
+	}
+	x.confirmed = &confirmedSends{
+		nodeNum: nodeNum,
+		count:   info.SendCount,
+		root:    globalState.SendRoot,
+	}
+	return x.confirmed, nil
+}
+
+// findEvent reads back the full event that sent a message, as only part of it is indexed.
+func (x *OutboxIndexer) findEvent(message *OutboxMessage) (*precompilesgen.ArbSysL2ToL1Tx, error) {
+	blockHash := x.bc.GetCanonicalHash(message.BlockNumber)
+	for _, receipt := range x.bc.GetReceiptsByHash(blockHash) {
+		if receipt.TxHash != message.TxHash {
+			continue
+		}
+		for _, txLog := range receipt.Logs {
+			if !isOutboxLog(txLog) || txLog.Topics[3].Big().Uint64() != message.Position {
+				continue
+			}
+			return x.filterer.ParseL2ToL1Tx(*txLog)
+		}
+	}
+	return nil, fmt.Errorf("event sending outbox message %v not found in block %v", message.Position, message.BlockNumber)
+}
+
+func isOutboxLog(txLog *types.Log) bool {
+	return txLog.Address == types.ArbSysAddress && len(txLog.Topics) == 4 && txLog.Topics[0] == outboxL2ToL1TxID
+}
+
+func (x *OutboxIndexer) readMessage(position uint64) (*OutboxMessage, error) {
+	key := indexKey(outboxMessagePrefix, uint64ToKey(position))
+	has, err := x.db.Has(key)
+	if err != nil || !has {
+		return nil, err
+	}
+	data, err := x.db.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	var message OutboxMessage
+	if err := rlp.DecodeBytes(data, &message); err != nil {
+		return nil, err
+	}
+	return &message, nil
+}
+
+// update indexes the next range of blocks, and returns whether it's caught up.
+func (x *OutboxIndexer) update(ctx context.Context) (bool, error) {
+	if x.progress.NextBlock > x.startBlock {
+		canonical := x.bc.GetCanonicalHash(x.progress.NextBlock - 1)
+		if canonical != x.progress.LastBlockHash {
+			if err := x.rollback(); err != nil {
+				return false, err
+			}
+		}
+	}
+	head := x.bc.CurrentBlock().NumberU64()
+	if x.progress.NextBlock > head {
+		return true, nil
+	}
+	end := head
+	if blocks := x.config().BlocksPerIteration; blocks > 0 && x.progress.NextBlock+blocks-1 < end {
+		end = x.progress.NextBlock + blocks - 1
+	}
+	batch := x.db.NewBatch()
+	journal := newIndexJournal(x.db, batch)
+	var lastBlock *types.Block
+	var hashes []common.Hash
+	for number := x.progress.NextBlock; number <= end; number++ {
+		if ctx.Err() != nil {
+			return false, ctx.Err()
+		}
+		block := x.bc.GetBlockByNumber(number)
+		if block == nil {
+			return false, fmt.Errorf("block %v not found", number)
+		}
+		receipts := x.bc.GetReceiptsByHash(block.Hash())
+		if len(receipts) != len(block.Transactions()) {
+			return false, fmt.Errorf("missing receipts for block %v", number)
+		}
+		if err := x.indexBlock(journal, block, receipts); err != nil {
+			return false, err
+		}
+		lastBlock = block
+		hashes = append(hashes, block.Hash())
+	}
+	if err := journal.commit(x.progress.NextBlock, hashes); err != nil {
+		return false, err
+	}
+	progress := outboxIndexerProgressInfo{
+		NextBlock:     end + 1,
+		LastBlockHash: lastBlock.Hash(),
+	}
+	data, err := rlp.EncodeToBytes(progress)
+	if err != nil {
+		return false, err
+	}
+	if err := batch.Put(outboxIndexerProgress, data); err != nil {
+		return false, err
+	}
+	if err := batch.Write(); err != nil {
+		return false, err
+	}
+	x.progress = progress
+	return end == head, nil
+}
+
+func (x *OutboxIndexer) indexBlock(batch ethdb.KeyValueWriter, block *types.Block, receipts types.Receipts) error {
+	for _, receipt := range receipts {
+		for _, txLog := range receipt.Logs {
+			if !isOutboxLog(txLog) {
+				continue
+			}
+			event, err := x.filterer.ParseL2ToL1Tx(*txLog)
+			if err != nil {
+				return err
+			}
+			message := &OutboxMessage{
+				Position:     event.Position.Uint64(),
+				Hash:         common.BigToHash(event.Hash),
+				Sender:       event.Caller,
+				Destination:  event.Destination,
+				CallValue:    event.Callvalue,
+				CalldataHash: crypto.Keccak256Hash(event.Data),
+				L1BlockNum:   event.EthBlockNum.Uint64(),
+				Timestamp:    event.Timestamp.Uint64(),
+				BlockNumber:  block.NumberU64(),
+				TxHash:       receipt.TxHash,
+			}
+			position := uint64ToKey(message.Position)
+			data, err := rlp.EncodeToBytes(message)
+			if err != nil {
+				return err
+			}
+			if err := batch.Put(indexKey(outboxMessagePrefix, position), data); err != nil {
+				return err
+			}
+			if err := batch.Put(indexKey(outboxBySenderPrefix, message.Sender.Bytes(), position), []byte{}); err != nil {
+				return err
+			}
+			if err := batch.Put(indexKey(outboxByDestination, message.Destination.Bytes(), position), []byte{}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// rollback undoes the indexing of the blocks a reorg removed, or rebuilds the
+// index if the reorg goes back further than the journal of recent updates.
+func (x *OutboxIndexer) rollback() error {
+	batch := x.db.NewBatch()
+	nextBlock, ok, err := rollbackIndex(x.db, batch, x.progress.NextBlock, x.bc.GetCanonicalHash)
+	if err != nil {
+		return err
+	}
+	if !ok || nextBlock < x.startBlock {
+		log.Warn("reorg detected by outbox indexer beyond its journal; reindexing", "block", x.progress.NextBlock-1)
+		return x.reset()
+	}
+	progress := outboxIndexerProgressInfo{NextBlock: nextBlock}
+	if nextBlock > x.startBlock {
+		progress.LastBlockHash = x.bc.GetCanonicalHash(nextBlock - 1)
+	}
+	data, err := rlp.EncodeToBytes(progress)
+	if err != nil {
+		return err
+	}
+	if err := batch.Put(outboxIndexerProgress, data); err != nil {
+		return err
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	log.Warn("reorg detected by outbox indexer; rolled back", "from", x.progress.NextBlock-1, "to", nextBlock-1)
+	x.progress = progress
+	return nil
+}
+
+// reset deletes the index so it's rebuilt from the start block.
+func (x *OutboxIndexer) reset() error {
+	if err := deleteAllKeys(x.db); err != nil {
+		return err
+	}
+	x.progress = outboxIndexerProgressInfo{NextBlock: x.startBlock}
+	return nil
+}
@@ -0,0 +1,395 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"context"
+	"fmt"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/offchainlabs/nitro/arbos/arbosState"
+	"github.com/offchainlabs/nitro/solgen/go/precompilesgen"
+)
+
+type ParameterHistoryConfig struct {
+	MaxBlockRange uint64 `koanf:"max-block-range"`
+}
+
+var DefaultParameterHistoryConfig = ParameterHistoryConfig{
+	MaxBlockRange: 100_000,
+}
+
+func ParameterHistoryConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Uint64(prefix+".max-block-range", DefaultParameterHistoryConfig.MaxBlockRange, "maximum number of blocks scanned by a single arb_getParameterHistory call")
+}
+
+// ParameterChange is a change to an owner-settable ArbOS parameter.
+// Chain owner membership changes use the "chainOwner" parameter, with an empty
+// old value when an owner is added and an empty new value when one is removed.
+type ParameterChange struct {
+	Parameter    string        `json:"parameter"`
+	OldValue     string        `json:"oldValue"`
+	NewValue     string        `json:"newValue"`
+	BlockNumber  uint64        `json:"blockNumber"`
+	BlockHash    common.Hash   `json:"blockHash"`
+	Timestamp    uint64        `json:"timestamp"`
+	Transactions []common.Hash `json:"transactions"` // the block's successful ArbOwner calls setting the parameter
+}
+
+type ParameterHistory struct {
+	Start              uint64             `json:"start"`
+	End                uint64             `json:"end"`
+	Initial            map[string]string  `json:"initial"` // values before the start block
+	InitialChainOwners []common.Address   `json:"initialChainOwners"`
+	Changes            []*ParameterChange `json:"changes"`
+}
+
+type ownerParameter struct {
+	name    string
+	methods []string // the ArbOwner methods that set it
+	read    func(state *arbosState.ArbosState) (interface{}, error)
+}
+
+// The parameters ArbOwner can set, excluding the base fee and L1 price per unit which ArbOS updates itself
+var ownerParameters = []ownerParameter{
+	{"arbOSVersion", nil, func(state *arbosState.ArbosState) (interface{}, error) {
+		return state.FormatVersion(), nil
+	}},
+	{"scheduledUpgradeVersion", []string{"scheduleArbOSUpgrade"}, func(state *arbosState.ArbosState) (interface{}, error) {
+		version, _, err := state.GetScheduledUpgrade()
+		return version, err
+	}},
+	{"scheduledUpgradeTimestamp", []string{"scheduleArbOSUpgrade"}, func(state *arbosState.ArbosState) (interface{}, error) {
+		_, timestamp, err := state.GetScheduledUpgrade()
+		return timestamp, err
+	}},
+	{"networkFeeAccount", []string{"setNetworkFeeAccount"}, func(state *arbosState.ArbosState) (interface{}, error) {
+		return state.NetworkFeeAccount()
+	}},
+	{"infraFeeAccount", []string{"setInfraFeeAccount"}, func(state *arbosState.ArbosState) (interface{}, error) {
+		return state.InfraFeeAccount()
+	}},
+	{"ownerActionDelay", []string{"setOwnerActionDelay"}, func(state *arbosState.ArbosState) (interface{}, error) {
+		return state.OwnerTimelock().Delay()
+	}},
+	{"l1PricingInertia", []string{"setL1PricingInertia", "setL1BaseFeeEstimateInertia"}, func(state *arbosState.ArbosState) (interface{}, error) {
+		return state.L1PricingState().Inertia()
+	}},
+	{"l1EquilibrationUnits", []string{"setL1PricingEquilibrationUnits"}, func(state *arbosState.ArbosState) (interface{}, error) {
+		return state.L1PricingState().EquilibrationUnits()
+	}},
+	{"l1PayRewardsTo", []string{"setL1PricingRewardRecipient"}, func(state *arbosState.ArbosState) (interface{}, error) {
+		return state.L1PricingState().PayRewardsTo()
+	}},
+	{"l1PerUnitReward", []string{"setL1PricingRewardRate"}, func(state *arbosState.ArbosState) (interface{}, error) {
+		return state.L1PricingState().PerUnitReward()
+	}},
+	{"l1PerBatchGasCost", []string{"setPerBatchGasCharge"}, func(state *arbosState.ArbosState) (interface{}, error) {
+		return state.L1PricingState().PerBatchGasCost()
+	}},
+	{"l1AmortizedCostCapBips", []string{"setAmortizedCostCapBips"}, func(state *arbosState.ArbosState) (interface{}, error) {
+		return state.L1PricingState().AmortizedCostCapBips()
+	}},
+	{"l2MinBaseFee", []string{"setMinimumL2BaseFee"}, func(state *arbosState.ArbosState) (interface{}, error) {
+		return state.L2PricingState().MinBaseFeeWei()
+	}},
+	{"l2SpeedLimitPerSecond", []string{"setSpeedLimit"}, func(state *arbosState.ArbosState) (interface{}, error) {
+		return state.L2PricingState().SpeedLimitPerSecond()
+	}},
+	{"l2PerBlockGasLimit", []string{"setMaxTxGasLimit"}, func(state *arbosState.ArbosState) (interface{}, error) {
+		return state.L2PricingState().PerBlockGasLimit()
+	}},
+	{"l2PricingInertia", []string{"setL2GasPricingInertia"}, func(state *arbosState.ArbosState) (interface{}, error) {
+		return state.L2PricingState().PricingInertia()
+	}},
+	{"l2BacklogTolerance", []string{"setL2GasBacklogTolerance"}, func(state *arbosState.ArbosState) (interface{}, error) {
+		return state.L2PricingState().BacklogTolerance()
+	}},
+}
+
+// The parameters ArbOwner can set that ArbOS also updates itself, so changes
+// to them are found from the ArbOwner calls rather than by comparing states
+var ownerPricingParameters = []ownerParameter{
+	{"l2BaseFee", []string{"setL2BaseFee"}, func(state *arbosState.ArbosState) (interface{}, error) {
+		return state.L2PricingState().BaseFeeWei()
+	}},
+	{"l1PricePerUnit", []string{"setL1PricePerUnit"}, func(state *arbosState.ArbosState) (interface{}, error) {
+		return state.L1PricingState().PricePerUnit()
+	}},
+}
+
+var chainOwnerMethods = []string{"addChainOwner", "removeChainOwner"}
+
+var (
+	arbOwnerAddress = common.HexToAddress("0x70")
+	arbOwnerAbi     *abi.ABI
+	ownerActsID     common.Hash
+)
+
+func init() {
+	var err error
+	arbOwnerAbi, err = precompilesgen.ArbOwnerMetaData.GetAbi()
+	if err != nil {
+		panic(err)
+	}
+	ownerActsID = arbOwnerAbi.Events["OwnerActs"].ID
+}
+
+// ownerAct is a successful ArbOwner call, found from its OwnerActs event.
+type ownerAct struct {
+	txHash  common.Hash
+	txIndex uint64
+	method  *abi.Method
+	data    []byte
+}
+
+// transactionsCalling returns the transactions among acts that called any of the methods.
+func transactionsCalling(acts []ownerAct, methods []string) []common.Hash {
+	txs := []common.Hash{}
+	for _, act := range acts {
+		for _, method := range methods {
+			if act.method.RawName == method && (len(txs) == 0 || txs[len(txs)-1] != act.txHash) {
+				txs = append(txs, act.txHash)
+				break
+			}
+		}
+	}
+	return txs
+}
+
+type parameterSnapshot struct {
+	values         map[string]string
+	owners         []common.Address
+	arbosVersion   uint64
+	upgradeVersion uint64
+	upgradeTime    uint64
+}
+
+func takeParameterSnapshot(state *arbosState.ArbosState) (*parameterSnapshot, error) {
+	snapshot := &parameterSnapshot{
+		values:       make(map[string]string, len(ownerParameters)),
+		arbosVersion: state.FormatVersion(),
+	}
+	for _, param := range ownerParameters {
+		value, err := param.read(state)
+		if err != nil {
+			return nil, err
+		}
+		snapshot.values[param.name] = fmt.Sprint(value)
+	}
+	owners, err := state.ChainOwners().AllMembers(65536)
+	if err != nil {
+		return nil, err
+	}
+	snapshot.owners = owners
+	snapshot.upgradeVersion, snapshot.upgradeTime, err = state.GetScheduledUpgrade()
+	return snapshot, err
+}
+
+// whether ArbOS will upgrade itself in a block with the given timestamp
+func (s *parameterSnapshot) upgradeDue(timestamp uint64) bool {
+	return s.arbosVersion < s.upgradeVersion && timestamp >= s.upgradeTime
+}
+
+func (s *parameterSnapshot) diff(next *parameterSnapshot) []*ParameterChange {
+	var changes []*ParameterChange
+	for _, param := range ownerParameters {
+		if s.values[param.name] != next.values[param.name] {
+			changes = append(changes, &ParameterChange{
+				Parameter: param.name,
+				OldValue:  s.values[param.name],
+				NewValue:  next.values[param.name],
+			})
+		}
+	}
+	owners := make(map[common.Address]bool, len(s.owners))
+	for _, owner := range s.owners {
+		owners[owner] = true
+	}
+	nextOwners := make(map[common.Address]bool, len(next.owners))
+	for _, owner := range next.owners {
+		nextOwners[owner] = true
+		if !owners[owner] {
+			changes = append(changes, &ParameterChange{Parameter: "chainOwner", NewValue: owner.Hex()})
+		}
+	}
+	for _, owner := range s.owners {
+		if !nextOwners[owner] {
+			changes = append(changes, &ParameterChange{Parameter: "chainOwner", OldValue: owner.Hex()})
+		}
+	}
+	return changes
+}
+
+type ParameterHistoryAPI struct {
+	blockchain *core.BlockChain
+	config     func() *ParameterHistoryConfig
+}
+
+// GetParameterHistory returns how the owner-settable ArbOS parameters changed over a range of blocks.
+// Rather than replaying the chain, it only inspects the state of blocks with ArbOwner calls or ArbOS upgrades,
+// so the node must retain the state of those blocks.
+func (api *ParameterHistoryAPI) GetParameterHistory(ctx context.Context, start, end rpc.BlockNumber) (*ParameterHistory, error) {
+	start, _ = api.blockchain.ClipToPostNitroGenesis(start)
+	end, _ = api.blockchain.ClipToPostNitroGenesis(end)
+	if start > end {
+		return nil, fmt.Errorf("invalid block range: %v to %v", start.Int64(), end.Int64())
+	}
+	first, last := uint64(start), uint64(end)
+	if maxRange := api.config().MaxBlockRange; maxRange != 0 && last-first+1 > maxRange {
+		return nil, fmt.Errorf("block range of %v exceeds the limit of %v", last-first+1, maxRange)
+	}
+
+	// compare the first block against its parent, unless it's the nitro genesis
+	parent := first
+	if first > api.blockchain.Config().ArbitrumChainParams.GenesisBlockNum {
+		parent = first - 1
+	}
+	state, _, err := stateAndHeader(api.blockchain, parent)
+	if err != nil {
+		return nil, err
+	}
+	prev, err := takeParameterSnapshot(state)
+	if err != nil {
+		return nil, err
+	}
+
+	history := &ParameterHistory{
+		Start:              first,
+		End:                last,
+		Initial:            prev.values,
+		InitialChainOwners: prev.owners,
+		Changes:            []*ParameterChange{},
+	}
+
+	for number := first; number <= last; number++ {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if number == parent {
+			continue
+		}
+		header := api.blockchain.GetHeaderByNumber(number)
+		if header == nil {
+			return nil, fmt.Errorf("block %v not found", number)
+		}
+		if !types.BloomLookup(header.Bloom, arbOwnerAddress) && !prev.upgradeDue(header.Time) {
+			// neither the chain owners nor an upgrade could have changed anything
+			continue
+		}
+		state, _, err := stateAndHeader(api.blockchain, number)
+		if err != nil {
+			return nil, err
+		}
+		next, err := takeParameterSnapshot(state)
+		if err != nil {
+			return nil, err
+		}
+		block := api.blockchain.GetBlockByHash(header.Hash())
+		if block == nil {
+			return nil, fmt.Errorf("block %v not found", number)
+		}
+		acts, err := api.ownerActs(block)
+		if err != nil {
+			return nil, err
+		}
+		changes := prev.diff(next)
+		for _, change := range changes {
+			change.Transactions = transactionsCalling(acts, parameterMethods[change.Parameter])
+		}
+		pricingChanges, err := api.pricingChanges(block, acts)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, pricingChanges...)
+		for _, change := range changes {
+			change.BlockNumber = number
+			change.BlockHash = header.Hash()
+			change.Timestamp = header.Time
+		}
+		history.Changes = append(history.Changes, changes...)
+		prev = next
+	}
+	return history, nil
+}
+
+// parameterMethods maps each parameter to the ArbOwner methods that set it.
+var parameterMethods = func() map[string][]string {
+	methods := map[string][]string{"chainOwner": chainOwnerMethods}
+	for _, param := range ownerParameters {
+		methods[param.name] = param.methods
+	}
+	return methods
+}()
+
+// ownerActs returns the block's successful ArbOwner calls, including timelocked actions.
+func (api *ParameterHistoryAPI) ownerActs(block *types.Block) ([]ownerAct, error) {
+	var acts []ownerAct
+	for i, receipt := range api.blockchain.GetReceiptsByHash(block.Hash()) {
+		for _, txLog := range receipt.Logs {
+			if txLog.Address != arbOwnerAddress || len(txLog.Topics) < 2 || txLog.Topics[0] != ownerActsID {
+				continue
+			}
+			method, err := arbOwnerAbi.MethodById(txLog.Topics[1][:4])
+			if err != nil {
+				return nil, err
+			}
+			event, err := arbOwnerAbi.Unpack("OwnerActs", txLog.Data)
+			if err != nil || len(event) != 1 {
+				return nil, fmt.Errorf("failed to unpack OwnerActs event in transaction %v: %w", receipt.TxHash, err)
+			}
+			data, _ := event[0].([]byte)
+			acts = append(acts, ownerAct{
+				txHash:  receipt.TxHash,
+				txIndex: uint64(i),
+				method:  method,
+				data:    data,
+			})
+		}
+	}
+	return acts, nil
+}
+
+// pricingChanges returns the changes the block's ArbOwner calls made to the
+// parameters ArbOS also updates itself, reading the old values from the state
+// each call ran on.
+func (api *ParameterHistoryAPI) pricingChanges(block *types.Block, acts []ownerAct) ([]*ParameterChange, error) {
+	var changes []*ParameterChange
+	for _, act := range acts {
+		for _, param := range ownerPricingParameters {
+			if act.method.RawName != param.methods[0] {
+				continue
+			}
+			if len(act.data) < 4 {
+				return nil, fmt.Errorf("ArbOwner call in transaction %v is missing its arguments", act.txHash)
+			}
+			args, err := act.method.Inputs.Unpack(act.data[4:])
+			if err != nil {
+				return nil, err
+			}
+			state, err := stateAtTransaction(api.blockchain, block, act.txIndex)
+			if err != nil {
+				return nil, err
+			}
+			oldValue, err := param.read(state)
+			if err != nil {
+				return nil, err
+			}
+			changes = append(changes, &ParameterChange{
+				Parameter:    param.name,
+				OldValue:     fmt.Sprint(oldValue),
+				NewValue:     fmt.Sprint(args[0]),
+				Transactions: []common.Hash{act.txHash},
+			})
+		}
+	}
+	return changes, nil
+}
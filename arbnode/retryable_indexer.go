@@ -0,0 +1,667 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+
+	"github.com/offchainlabs/nitro/arbos/retryables"
+	"github.com/offchainlabs/nitro/solgen/go/precompilesgen"
+	"github.com/offchainlabs/nitro/util/stopwaiter"
+)
+
+type RetryableIndexerConfig struct {
+	Enable             bool          `koanf:"enable"`
+	BlocksPerIteration uint64        `koanf:"blocks-per-iteration"`
+	PollInterval       time.Duration `koanf:"poll-interval"`
+	MaxQueryResults    int           `koanf:"max-query-results"`
+}
+
+var DefaultRetryableIndexerConfig = RetryableIndexerConfig{
+	Enable:             false,
+	BlocksPerIteration: 1000,
+	PollInterval:       time.Second,
+	MaxQueryResults:    1000,
+}
+
+func RetryableIndexerConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultRetryableIndexerConfig.Enable, "index the lifecycle of retryable tickets and serve queries for them over rpc")
+	f.Uint64(prefix+".blocks-per-iteration", DefaultRetryableIndexerConfig.BlocksPerIteration, "maximum number of blocks to index at once")
+	f.Duration(prefix+".poll-interval", DefaultRetryableIndexerConfig.PollInterval, "how often to check for new blocks once caught up")
+	f.Int(prefix+".max-query-results", DefaultRetryableIndexerConfig.MaxQueryResults, "maximum number of tickets returned by a query")
+}
+
+type RetryableStatus string
+
+const (
+	RetryableOpen     RetryableStatus = "open"
+	RetryableRedeemed RetryableStatus = "redeemed"
+	RetryableCanceled RetryableStatus = "canceled"
+	RetryableExpired  RetryableStatus = "expired" // past its timeout, but not yet reaped
+	RetryableReaped   RetryableStatus = "reaped"
+)
+
+type RetryableRedeemStatus string
+
+const (
+	RedeemPending   RetryableRedeemStatus = "pending"
+	RedeemSucceeded RetryableRedeemStatus = "succeeded"
+	RedeemFailed    RetryableRedeemStatus = "failed"
+)
+
+// RetryableRedeem is an attempt to redeem a retryable, either automatically on
+// creation or by an explicit call to ArbRetryableTx.redeem.
+type RetryableRedeem struct {
+	RetryTxHash common.Hash           `json:"retryTxHash"`
+	SequenceNum uint64                `json:"sequenceNum"`
+	DonatedGas  uint64                `json:"donatedGas"`
+	GasDonor    common.Address        `json:"gasDonor"`
+	BlockNumber uint64                `json:"blockNumber"`
+	Status      RetryableRedeemStatus `json:"status"`
+	GasUsed     uint64                `json:"gasUsed"`
+}
+
+// RetryableTicket is the indexed lifecycle of a retryable.
+type RetryableTicket struct {
+	TicketId      common.Hash       `json:"ticketId"`
+	Status        RetryableStatus   `json:"status"`
+	From          common.Address    `json:"from"`
+	To            *common.Address   `json:"to" rlp:"nil"`
+	Beneficiary   common.Address    `json:"beneficiary"`
+	FeeRefundAddr common.Address    `json:"feeRefundAddr"`
+	CallValue     *big.Int          `json:"callValue"`
+	Deposit       *big.Int          `json:"deposit"`
+	CalldataSize  uint64            `json:"calldataSize"`
+	CreatedBlock  uint64            `json:"createdBlock"`
+	CreatedTime   uint64            `json:"createdTime"`
+	Expiry        uint64            `json:"expiry"`
+	Keepalives    uint64            `json:"keepalives"`
+	Redeems       []RetryableRedeem `json:"redeems"`
+	// the block the ticket was redeemed, canceled or reaped in, or 0 while it's open or expired
+	ClosedBlock uint64 `json:"closedBlock"`
+}
+
+// RetryableTicketQuery filters tickets; unset fields match any ticket.
+type RetryableTicketQuery struct {
+	Sender        *common.Address  `json:"sender"`
+	Beneficiary   *common.Address  `json:"beneficiary"`
+	Destination   *common.Address  `json:"destination"`
+	Status        *RetryableStatus `json:"status"`
+	ExpiresAfter  *uint64          `json:"expiresAfter"`
+	ExpiresBefore *uint64          `json:"expiresBefore"`
+	Limit         int              `json:"limit"`
+}
+
+func (q *RetryableTicketQuery) matches(ticket *RetryableTicket) bool {
+	if q.Sender != nil && ticket.From != *q.Sender {
+		return false
+	}
+	if q.Beneficiary != nil && ticket.Beneficiary != *q.Beneficiary {
+		return false
+	}
+	if q.Destination != nil && (ticket.To == nil || *ticket.To != *q.Destination) {
+		return false
+	}
+	if q.Status != nil && ticket.Status != *q.Status {
+		return false
+	}
+	if q.ExpiresAfter != nil && ticket.Expiry < *q.ExpiresAfter {
+		return false
+	}
+	if q.ExpiresBefore != nil && ticket.Expiry >= *q.ExpiresBefore {
+		return false
+	}
+	return true
+}
+
+var (
+	retryableTicketPrefix      []byte = []byte("t") // maps a ticket id to a rlp encoded RetryableTicket
+	retryableBySenderPrefix    []byte = []byte("f") // indexes tickets by sender address
+	retryableByBeneficiary     []byte = []byte("b") // indexes tickets by beneficiary address
+	retryableByDestination     []byte = []byte("d") // indexes tickets by destination address
+	retryableOpenByExpiry      []byte = []byte("e") // indexes open tickets by expiry time
+	retryableExpiredPrefix     []byte = []byte("x") // the expired tickets that haven't been reaped yet
+	retryableIndexerProgress   []byte = []byte("_progress")
+	retryableTicketCreatedID   common.Hash
+	retryableLifetimeExtendID  common.Hash
+	retryableRedeemScheduledID common.Hash
+	retryableCanceledID        common.Hash
+)
+
+func init() {
+	retryableAbi, err := precompilesgen.ArbRetryableTxMetaData.GetAbi()
+	if err != nil {
+		panic(err)
+	}
+	retryableTicketCreatedID = retryableAbi.Events["TicketCreated"].ID
+	retryableLifetimeExtendID = retryableAbi.Events["LifetimeExtended"].ID
+	retryableRedeemScheduledID = retryableAbi.Events["RedeemScheduled"].ID
+	retryableCanceledID = retryableAbi.Events["Canceled"].ID
+}
+
+func indexKey(prefix []byte, parts ...[]byte) []byte {
+	key := append([]byte{}, prefix...)
+	for _, part := range parts {
+		key = append(key, part...)
+	}
+	return key
+}
+
+type retryableIndexerProgressInfo struct {
+	NextBlock     uint64
+	LastBlockHash common.Hash
+}
+
+// RetryableIndexer follows block production and records the lifecycle of
+// every retryable created after the nitro genesis.
+type RetryableIndexer struct {
+	stopwaiter.StopWaiter
+	db         ethdb.Database
+	bc         *core.BlockChain
+	config     func() *RetryableIndexerConfig
+	filterer   *precompilesgen.ArbRetryableTxFilterer
+	progress   retryableIndexerProgressInfo
+	startBlock uint64
+}
+
+func NewRetryableIndexer(db ethdb.Database, bc *core.BlockChain, config func() *RetryableIndexerConfig) (*RetryableIndexer, error) {
+	filterer, err := precompilesgen.NewArbRetryableTxFilterer(types.ArbRetryableTxAddress, nil)
+	if err != nil {
+		return nil, err
+	}
+	x := &RetryableIndexer{
+		db:         db,
+		bc:         bc,
+		config:     config,
+		filterer:   filterer,
+		startBlock: bc.Config().ArbitrumChainParams.GenesisBlockNum,
+	}
+	x.progress.NextBlock = x.startBlock
+	hasProgress, err := db.Has(retryableIndexerProgress)
+	if err != nil {
+		return nil, err
+	}
+	if hasProgress {
+		data, err := db.Get(retryableIndexerProgress)
+		if err != nil {
+			return nil, err
+		}
+		if err := rlp.DecodeBytes(data, &x.progress); err != nil {
+			return nil, err
+		}
+	}
+	return x, nil
+}
+
+func (x *RetryableIndexer) Start(ctxIn context.Context) {
+	x.StopWaiter.Start(ctxIn, x)
+	x.CallIteratively(func(ctx context.Context) time.Duration {
+		caughtUp, err := x.update(ctx)
+		if err != nil {
+			log.Error("error indexing retryables", "err", err)
+			return x.config().PollInterval
+		}
+		if caughtUp {
+			return x.config().PollInterval
+		}
+		return 0
+	})
+}
+
+// Ticket returns the indexed ticket, or nil if it hasn't been indexed.
+func (x *RetryableIndexer) Ticket(ticketId common.Hash) (*RetryableTicket, error) {
+	return x.readTicket(x.db, ticketId)
+}
+
+// Tickets returns tickets matching the query, using the most selective index.
+func (x *RetryableIndexer) Tickets(query *RetryableTicketQuery) ([]*RetryableTicket, error) {
+	limit := x.config().MaxQueryResults
+	if query.Limit > 0 && query.Limit < limit {
+		limit = query.Limit
+	}
+	var prefix, start []byte
+	byExpiry := false
+	switch {
+	case query.Sender != nil:
+		prefix = indexKey(retryableBySenderPrefix, query.Sender.Bytes())
+	case query.Beneficiary != nil:
+		prefix = indexKey(retryableByBeneficiary, query.Beneficiary.Bytes())
+	case query.Destination != nil:
+		prefix = indexKey(retryableByDestination, query.Destination.Bytes())
+	case query.Status != nil && *query.Status == RetryableOpen:
+		prefix, byExpiry = retryableOpenByExpiry, true
+		if query.ExpiresAfter != nil {
+			start = uint64ToKey(*query.ExpiresAfter)
+		}
+	case query.Status != nil && *query.Status == RetryableExpired:
+		prefix = retryableExpiredPrefix
+	default:
+		prefix = retryableTicketPrefix
+	}
+	tickets := []*RetryableTicket{}
+	iter := x.db.NewIterator(prefix, start)
+	defer iter.Release()
+	for iter.Next() && len(tickets) < limit {
+		key := iter.Key()
+		if len(key) < common.HashLength {
+			continue
+		}
+		if byExpiry && query.ExpiresBefore != nil && bytes.Compare(key[len(prefix):len(prefix)+8], uint64ToKey(*query.ExpiresBefore)) >= 0 {
+			break
+		}
+		ticket, err := x.readTicket(x.db, common.BytesToHash(key[len(key)-common.HashLength:]))
+		if err != nil {
+			return nil, err
+		}
+		if ticket != nil && query.matches(ticket) {
+			tickets = append(tickets, ticket)
+		}
+	}
+	return tickets, iter.Error()
+}
+
+func (x *RetryableIndexer) readTicket(db ethdb.KeyValueReader, ticketId common.Hash) (*RetryableTicket, error) {
+	key := indexKey(retryableTicketPrefix, ticketId.Bytes())
+	has, err := db.Has(key)
+	if err != nil || !has {
+		return nil, err
+	}
+	data, err := db.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	var ticket RetryableTicket
+	if err := rlp.DecodeBytes(data, &ticket); err != nil {
+		return nil, err
+	}
+	return &ticket, nil
+}
+
+// retryableIndexUpdate accumulates the changes from indexing a range of blocks,
+// to be written atomically with the indexer's progress.
+type retryableIndexUpdate struct {
+	x       *RetryableIndexer
+	batch   ethdb.KeyValueWriter
+	tickets map[common.Hash]*RetryableTicket
+	// the expiry of each ticket before this update, to remove stale index entries
+	oldExpiry map[common.Hash]uint64
+	oldStatus map[common.Hash]RetryableStatus
+}
+
+func (u *retryableIndexUpdate) ticket(ticketId common.Hash) (*RetryableTicket, error) {
+	if ticket, ok := u.tickets[ticketId]; ok {
+		return ticket, nil
+	}
+	ticket, err := u.x.readTicket(u.x.db, ticketId)
+	if err != nil || ticket == nil {
+		return nil, err
+	}
+	u.tickets[ticketId] = ticket
+	u.oldExpiry[ticketId] = ticket.Expiry
+	u.oldStatus[ticketId] = ticket.Status
+	return ticket, nil
+}
+
+func (u *retryableIndexUpdate) write() error {
+	for ticketId, ticket := range u.tickets {
+		id := ticketId.Bytes()
+		if status, existed := u.oldStatus[ticketId]; existed {
+			if status == RetryableOpen {
+				if err := u.batch.Delete(indexKey(retryableOpenByExpiry, uint64ToKey(u.oldExpiry[ticketId]), id)); err != nil {
+					return err
+				}
+			}
+			if status == RetryableExpired && ticket.Status != RetryableExpired {
+				if err := u.batch.Delete(indexKey(retryableExpiredPrefix, id)); err != nil {
+					return err
+				}
+			}
+		} else {
+			if err := u.batch.Put(indexKey(retryableBySenderPrefix, ticket.From.Bytes(), id), []byte{}); err != nil {
+				return err
+			}
+			if err := u.batch.Put(indexKey(retryableByBeneficiary, ticket.Beneficiary.Bytes(), id), []byte{}); err != nil {
+				return err
+			}
+			if ticket.To != nil {
+				if err := u.batch.Put(indexKey(retryableByDestination, ticket.To.Bytes(), id), []byte{}); err != nil {
+					return err
+				}
+			}
+		}
+		if ticket.Status == RetryableOpen {
+			if err := u.batch.Put(indexKey(retryableOpenByExpiry, uint64ToKey(ticket.Expiry), id), []byte{}); err != nil {
+				return err
+			}
+		}
+		if ticket.Status == RetryableExpired {
+			if err := u.batch.Put(indexKey(retryableExpiredPrefix, id), []byte{}); err != nil {
+				return err
+			}
+		}
+		data, err := rlp.EncodeToBytes(ticket)
+		if err != nil {
+			return err
+		}
+		if err := u.batch.Put(indexKey(retryableTicketPrefix, id), data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// update indexes the next range of blocks, and returns whether it's caught up.
+func (x *RetryableIndexer) update(ctx context.Context) (bool, error) {
+	if x.progress.NextBlock > x.startBlock {
+		canonical := x.bc.GetCanonicalHash(x.progress.NextBlock - 1)
+		if canonical != x.progress.LastBlockHash {
+			if err := x.rollback(); err != nil {
+				return false, err
+			}
+		}
+	}
+	head := x.bc.CurrentBlock().NumberU64()
+	if x.progress.NextBlock > head {
+		return true, nil
+	}
+	end := head
+	if blocks := x.config().BlocksPerIteration; blocks > 0 && x.progress.NextBlock+blocks-1 < end {
+		end = x.progress.NextBlock + blocks - 1
+	}
+	batch := x.db.NewBatch()
+	journal := newIndexJournal(x.db, batch)
+	update := &retryableIndexUpdate{
+		x:         x,
+		batch:     journal,
+		tickets:   make(map[common.Hash]*RetryableTicket),
+		oldExpiry: make(map[common.Hash]uint64),
+		oldStatus: make(map[common.Hash]RetryableStatus),
+	}
+	var lastBlock *types.Block
+	var hashes []common.Hash
+	for number := x.progress.NextBlock; number <= end; number++ {
+		if ctx.Err() != nil {
+			return false, ctx.Err()
+		}
+		block := x.bc.GetBlockByNumber(number)
+		if block == nil {
+			return false, fmt.Errorf("block %v not found", number)
+		}
+		receipts := x.bc.GetReceiptsByHash(block.Hash())
+		if len(receipts) != len(block.Transactions()) {
+			return false, fmt.Errorf("missing receipts for block %v", number)
+		}
+		if err := x.indexBlock(update, block, receipts); err != nil {
+			return false, err
+		}
+		lastBlock = block
+		hashes = append(hashes, block.Hash())
+	}
+	if err := x.expireTickets(update, lastBlock); err != nil {
+		return false, err
+	}
+	if err := update.write(); err != nil {
+		return false, err
+	}
+	if err := journal.commit(x.progress.NextBlock, hashes); err != nil {
+		return false, err
+	}
+	progress := retryableIndexerProgressInfo{
+		NextBlock:     end + 1,
+		LastBlockHash: lastBlock.Hash(),
+	}
+	data, err := rlp.EncodeToBytes(progress)
+	if err != nil {
+		return false, err
+	}
+	if err := batch.Put(retryableIndexerProgress, data); err != nil {
+		return false, err
+	}
+	if err := batch.Write(); err != nil {
+		return false, err
+	}
+	x.progress = progress
+	return end == head, nil
+}
+
+func (x *RetryableIndexer) indexBlock(update *retryableIndexUpdate, block *types.Block, receipts types.Receipts) error {
+	for i, tx := range block.Transactions() {
+		receipt := receipts[i]
+		for _, txLog := range receipt.Logs {
+			if txLog.Address != types.ArbRetryableTxAddress || len(txLog.Topics) < 2 {
+				continue
+			}
+			if err := x.indexLog(update, block, tx, txLog); err != nil {
+				return err
+			}
+		}
+		retryTx, ok := tx.GetInner().(*types.ArbitrumRetryTx)
+		if !ok {
+			continue
+		}
+		ticket, err := update.ticket(retryTx.TicketId)
+		if err != nil {
+			return err
+		}
+		if ticket == nil {
+			continue
+		}
+		for j := range ticket.Redeems {
+			redeem := &ticket.Redeems[j]
+			if redeem.RetryTxHash != tx.Hash() {
+				continue
+			}
+			redeem.GasUsed = receipt.GasUsed
+			if receipt.Status == types.ReceiptStatusSuccessful {
+				redeem.Status = RedeemSucceeded
+				ticket.Status = RetryableRedeemed
+				ticket.ClosedBlock = block.NumberU64()
+			} else {
+				redeem.Status = RedeemFailed
+			}
+		}
+	}
+	return nil
+}
+
+func (x *RetryableIndexer) indexLog(update *retryableIndexUpdate, block *types.Block, tx *types.Transaction, txLog *types.Log) error {
+	ticketId := txLog.Topics[1]
+	switch txLog.Topics[0] {
+	case retryableTicketCreatedID:
+		submit, ok := tx.GetInner().(*types.ArbitrumSubmitRetryableTx)
+		if !ok {
+			return fmt.Errorf("ticket %v created by transaction %v of type %v", ticketId, tx.Hash(), tx.Type())
+		}
+		update.tickets[ticketId] = &RetryableTicket{
+			TicketId:      ticketId,
+			Status:        RetryableOpen,
+			From:          submit.From,
+			To:            submit.RetryTo,
+			Beneficiary:   submit.Beneficiary,
+			FeeRefundAddr: submit.FeeRefundAddr,
+			CallValue:     submit.RetryValue,
+			Deposit:       submit.DepositValue,
+			CalldataSize:  uint64(len(submit.RetryData)),
+			CreatedBlock:  block.NumberU64(),
+			CreatedTime:   block.Time(),
+			Expiry:        block.Time() + retryables.RetryableLifetimeSeconds,
+			Redeems:       []RetryableRedeem{},
+		}
+		return nil
+	case retryableLifetimeExtendID, retryableRedeemScheduledID, retryableCanceledID:
+	default:
+		return nil
+	}
+	ticket, err := update.ticket(ticketId)
+	if err != nil || ticket == nil {
+		// created before the indexer's start block
+		return err
+	}
+	switch txLog.Topics[0] {
+	case retryableLifetimeExtendID:
+		event, err := x.filterer.ParseLifetimeExtended(*txLog)
+		if err != nil {
+			return err
+		}
+		ticket.Expiry = event.NewTimeout.Uint64()
+		ticket.Keepalives++
+	case retryableRedeemScheduledID:
+		event, err := x.filterer.ParseRedeemScheduled(*txLog)
+		if err != nil {
+			return err
+		}
+		ticket.Redeems = append(ticket.Redeems, RetryableRedeem{
+			RetryTxHash: event.RetryTxHash,
+			SequenceNum: event.SequenceNum,
+			DonatedGas:  event.DonatedGas,
+			GasDonor:    event.GasDonor,
+			BlockNumber: block.NumberU64(),
+			Status:      RedeemPending,
+		})
+	case retryableCanceledID:
+		ticket.Status = RetryableCanceled
+		ticket.ClosedBlock = block.NumberU64()
+	}
+	return nil
+}
+
+// expireTickets marks open tickets past their expiry as expired, and expired
+// tickets that TryToReapOneRetryable has since deleted as reaped. As this is
+// checked once per update, the block a ticket is reaped in is approximate.
+func (x *RetryableIndexer) expireTickets(update *retryableIndexUpdate, block *types.Block) error {
+	now := block.Time()
+	var expired []common.Hash
+	iter := x.db.NewIterator(retryableOpenByExpiry, nil)
+	for iter.Next() {
+		key := iter.Key()
+		if bytes.Compare(key[len(retryableOpenByExpiry):len(retryableOpenByExpiry)+8], uint64ToKey(now)) >= 0 {
+			break
+		}
+		expired = append(expired, common.BytesToHash(key[len(key)-common.HashLength:]))
+	}
+	iter.Release()
+	if err := iter.Error(); err != nil {
+		return err
+	}
+	for ticketId, ticket := range update.tickets {
+		if ticket.Status == RetryableOpen && ticket.Expiry < now {
+			expired = append(expired, ticketId)
+		}
+	}
+	iter = x.db.NewIterator(retryableExpiredPrefix, nil)
+	for iter.Next() {
+		expired = append(expired, common.BytesToHash(iter.Key()[len(retryableExpiredPrefix):]))
+	}
+	iter.Release()
+	if err := iter.Error(); err != nil {
+		return err
+	}
+	if len(expired) == 0 {
+		return nil
+	}
+
+	state, _, err := stateAndHeader(x.bc, block.NumberU64())
+	if err != nil {
+		// the state may be pruned while catching up, so check again next time
+		state = nil
+	}
+	for _, ticketId := range expired {
+		ticket, err := update.ticket(ticketId)
+		if err != nil {
+			return err
+		}
+		// the ticket may have been kept alive or closed within the update
+		if ticket == nil || ticket.Expiry >= now || (ticket.Status != RetryableOpen && ticket.Status != RetryableExpired) {
+			continue
+		}
+		ticket.Status = RetryableExpired
+		if state == nil {
+			continue
+		}
+		retryable, err := state.RetryableState().OpenRetryable(ticketId, 0)
+		if err != nil {
+			return err
+		}
+		if retryable == nil {
+			ticket.Status = RetryableReaped
+			ticket.ClosedBlock = block.NumberU64()
+		}
+	}
+	return nil
+}
+
+// rollback undoes the indexing of the blocks a reorg removed, or rebuilds the
+// index if the reorg goes back further than the journal of recent updates.
+func (x *RetryableIndexer) rollback() error {
+	batch := x.db.NewBatch()
+	nextBlock, ok, err := rollbackIndex(x.db, batch, x.progress.NextBlock, x.bc.GetCanonicalHash)
+	if err != nil {
+		return err
+	}
+	if !ok || nextBlock < x.startBlock {
+		log.Warn("reorg detected by retryable indexer beyond its journal; reindexing", "block", x.progress.NextBlock-1)
+		return x.reset()
+	}
+	progress := retryableIndexerProgressInfo{NextBlock: nextBlock}
+	if nextBlock > x.startBlock {
+		progress.LastBlockHash = x.bc.GetCanonicalHash(nextBlock - 1)
+	}
+	data, err := rlp.EncodeToBytes(progress)
+	if err != nil {
+		return err
+	}
+	if err := batch.Put(retryableIndexerProgress, data); err != nil {
+		return err
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	log.Warn("reorg detected by retryable indexer; rolled back", "from", x.progress.NextBlock-1, "to", nextBlock-1)
+	x.progress = progress
+	return nil
+}
+
+// reset deletes the index so it's rebuilt from the start block.
+func (x *RetryableIndexer) reset() error {
+	if err := deleteAllKeys(x.db); err != nil {
+		return err
+	}
+	x.progress = retryableIndexerProgressInfo{NextBlock: x.startBlock}
+	return nil
+}
+
+// deleteAllKeys empties an indexer's database table.
+func deleteAllKeys(db ethdb.Database) error {
+	batch := db.NewBatch()
+	iter := db.NewIterator(nil, nil)
+	defer iter.Release()
+	for iter.Next() {
+		if err := batch.Delete(iter.Key()); err != nil {
+			return err
+		}
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+	}
+	if err := iter.Error(); err != nil {
+		return err
+	}
+	return batch.Write()
+}
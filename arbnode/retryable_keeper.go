@@ -0,0 +1,340 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/params"
+
+	"github.com/offchainlabs/nitro/cmd/genericconf"
+	"github.com/offchainlabs/nitro/solgen/go/precompilesgen"
+	"github.com/offchainlabs/nitro/util/arbmath"
+	"github.com/offchainlabs/nitro/util/stopwaiter"
+)
+
+var (
+	retryableKeeperKeepaliveCounter = metrics.NewRegisteredCounter("arb/retryablekeeper/keepalive", nil)
+	retryableKeeperRedeemCounter    = metrics.NewRegisteredCounter("arb/retryablekeeper/redeem", nil)
+	retryableKeeperFailureCounter   = metrics.NewRegisteredCounter("arb/retryablekeeper/failure", nil)
+	retryableKeeperSkippedCounter   = metrics.NewRegisteredCounter("arb/retryablekeeper/skipped", nil)
+	retryableKeeperWatchedGauge     = metrics.NewRegisteredGauge("arb/retryablekeeper/watched", nil)
+	retryableKeeperSpentGauge       = metrics.NewRegisteredGauge("arb/retryablekeeper/spent_last_day_gwei", nil)
+)
+
+type RetryableKeeperConfig struct {
+	Enable          bool                     `koanf:"enable"`
+	Beneficiaries   []string                 `koanf:"beneficiaries"`
+	Destinations    []string                 `koanf:"destinations"`
+	Keepalive       bool                     `koanf:"keepalive"`
+	Redeem          bool                     `koanf:"redeem"`
+	KeepaliveMargin time.Duration            `koanf:"keepalive-margin"`
+	RedeemGasLimit  uint64                   `koanf:"redeem-gas-limit"`
+	DailySpendCap   float64                  `koanf:"daily-spend-cap"`
+	PollInterval    time.Duration            `koanf:"poll-interval"`
+	Wallet          genericconf.WalletConfig `koanf:"wallet"`
+}
+
+var DefaultRetryableKeeperConfig = RetryableKeeperConfig{
+	Enable:          false,
+	Beneficiaries:   []string{},
+	Destinations:    []string{},
+	Keepalive:       true,
+	Redeem:          false,
+	KeepaliveMargin: 24 * time.Hour,
+	RedeemGasLimit:  0,
+	DailySpendCap:   0.1,
+	PollInterval:    time.Minute,
+	Wallet:          genericconf.WalletConfigDefault,
+}
+
+func RetryableKeeperConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultRetryableKeeperConfig.Enable, "automatically keep alive or redeem retryables for the configured beneficiaries and destinations")
+	f.StringSlice(prefix+".beneficiaries", DefaultRetryableKeeperConfig.Beneficiaries, "act on retryables with these beneficiaries")
+	f.StringSlice(prefix+".destinations", DefaultRetryableKeeperConfig.Destinations, "act on retryables calling these addresses")
+	f.Bool(prefix+".keepalive", DefaultRetryableKeeperConfig.Keepalive, "extend the lifetime of retryables about to expire")
+	f.Bool(prefix+".redeem", DefaultRetryableKeeperConfig.Redeem, "try to redeem each retryable once, before keeping it alive")
+	f.Duration(prefix+".keepalive-margin", DefaultRetryableKeeperConfig.KeepaliveMargin, "keep a retryable alive once it's this close to expiring")
+	f.Uint64(prefix+".redeem-gas-limit", DefaultRetryableKeeperConfig.RedeemGasLimit, "gas limit of redeem transactions, all of which is donated to the retry (0 to estimate)")
+	f.Float64(prefix+".daily-spend-cap", DefaultRetryableKeeperConfig.DailySpendCap, "maximum ETH to spend on keepalive and redeem transactions in any 24 hours (0 for no limit)")
+	f.Duration(prefix+".poll-interval", DefaultRetryableKeeperConfig.PollInterval, "how often to check the retryable timeout queue")
+	genericconf.WalletConfigAddOptions(prefix+".wallet", f, "retryable-keeper-wallet")
+}
+
+func (c *RetryableKeeperConfig) Validate() error {
+	for _, address := range append(append([]string{}, c.Beneficiaries...), c.Destinations...) {
+		if !common.IsHexAddress(address) {
+			return fmt.Errorf("invalid retryable keeper address \"%v\"", address)
+		}
+	}
+	return nil
+}
+
+func (c *RetryableKeeperConfig) dailySpendCapWei() *big.Int {
+	if c.DailySpendCap <= 0 {
+		return nil
+	}
+	limit, _ := new(big.Float).Mul(big.NewFloat(c.DailySpendCap), big.NewFloat(params.Ether)).Int(nil)
+	return limit
+}
+
+var retryableKeeperSpendPrefix []byte = []byte("s") // maps the time of a spend in the last day to the wei spent
+
+type retryableKeeperSpend struct {
+	time  time.Time
+	spent *big.Int
+}
+
+func retryableKeeperSpendKey(at time.Time) []byte {
+	return indexKey(retryableKeeperSpendPrefix, uint64ToKey(uint64(at.UnixNano())))
+}
+
+// RetryableKeeper watches the retryable timeout queue, and keeps alive or
+// redeems the retryables of configured beneficiaries and destinations before
+// they expire.
+type RetryableKeeper struct {
+	stopwaiter.StopWaiter
+	bc       *core.BlockChain
+	client   *ethclient.Client
+	con      *precompilesgen.ArbRetryableTx
+	txOpts   *bind.TransactOpts
+	config   func() *RetryableKeeperConfig
+	redeemed map[common.Hash]bool
+
+	// the spends of the last day, kept in db so the daily spend cap holds across restarts
+	spendMutex sync.Mutex
+	spends     []retryableKeeperSpend
+	db         ethdb.Database
+}
+
+func NewRetryableKeeper(stack *node.Node, bc *core.BlockChain, arbDb ethdb.Database, txOpts *bind.TransactOpts, config func() *RetryableKeeperConfig) (*RetryableKeeper, error) {
+	rpcClient, err := stack.Attach()
+	if err != nil {
+		return nil, err
+	}
+	client := ethclient.NewClient(rpcClient)
+	con, err := precompilesgen.NewArbRetryableTx(types.ArbRetryableTxAddress, client)
+	if err != nil {
+		return nil, err
+	}
+	k := &RetryableKeeper{
+		bc:       bc,
+		client:   client,
+		con:      con,
+		txOpts:   txOpts,
+		config:   config,
+		redeemed: make(map[common.Hash]bool),
+		db:       rawdb.NewTable(arbDb, retryableKeeperPrefix),
+	}
+	if err := k.loadSpends(); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// loadSpends reads back the spends made in the last day before a restart.
+func (k *RetryableKeeper) loadSpends() error {
+	iter := k.db.NewIterator(retryableKeeperSpendPrefix, nil)
+	defer iter.Release()
+	for iter.Next() {
+		key := iter.Key()
+		nanos := binary.BigEndian.Uint64(key[len(retryableKeeperSpendPrefix):])
+		k.spends = append(k.spends, retryableKeeperSpend{
+			time:  time.Unix(0, int64(nanos)),
+			spent: new(big.Int).SetBytes(iter.Value()),
+		})
+	}
+	return iter.Error()
+}
+
+func (k *RetryableKeeper) Start(ctxIn context.Context) {
+	k.StopWaiter.Start(ctxIn, k)
+	k.CallIteratively(func(ctx context.Context) time.Duration {
+		if err := k.update(ctx); err != nil {
+			log.Error("error keeping retryables", "err", err)
+		}
+		return k.config().PollInterval
+	})
+}
+
+type keptRetryable struct {
+	ticketId common.Hash
+	timeout  uint64
+}
+
+// watched returns the live retryables in the timeout queue that match the config.
+func (k *RetryableKeeper) watched(config *RetryableKeeperConfig) ([]keptRetryable, uint64, error) {
+	state, header, err := stateAndHeader(k.bc, k.bc.CurrentBlock().NumberU64())
+	if err != nil {
+		return nil, 0, err
+	}
+	beneficiaries := make(map[common.Address]bool)
+	for _, address := range config.Beneficiaries {
+		beneficiaries[common.HexToAddress(address)] = true
+	}
+	destinations := make(map[common.Address]bool)
+	for _, address := range config.Destinations {
+		destinations[common.HexToAddress(address)] = true
+	}
+	retryableState := state.RetryableState()
+	seen := make(map[common.Hash]bool)
+	var watched []keptRetryable
+	err = retryableState.TimeoutQueue.ForEach(func(_ uint64, ticketId common.Hash) (bool, error) {
+		// keepalives add duplicate entries to the queue
+		if seen[ticketId] {
+			return false, nil
+		}
+		seen[ticketId] = true
+		retryable, err := retryableState.OpenRetryable(ticketId, header.Time)
+		if err != nil || retryable == nil {
+			return false, err
+		}
+		beneficiary, err := retryable.Beneficiary()
+		if err != nil {
+			return false, err
+		}
+		to, err := retryable.To()
+		if err != nil {
+			return false, err
+		}
+		if !beneficiaries[beneficiary] && (to == nil || !destinations[*to]) {
+			return false, nil
+		}
+		timeout, err := retryable.CalculateTimeout()
+		if err != nil {
+			return false, err
+		}
+		watched = append(watched, keptRetryable{ticketId, timeout})
+		return false, nil
+	})
+	return watched, header.Time, err
+}
+
+func (k *RetryableKeeper) update(ctx context.Context) error {
+	config := k.config()
+	watched, now, err := k.watched(config)
+	if err != nil {
+		return err
+	}
+	retryableKeeperWatchedGauge.Update(int64(len(watched)))
+
+	live := make(map[common.Hash]bool, len(watched))
+	for _, kept := range watched {
+		live[kept.ticketId] = true
+		if config.Redeem && !k.redeemed[kept.ticketId] {
+			k.redeemed[kept.ticketId] = true
+			if err := k.send(ctx, config, "redeem", kept.ticketId); err != nil {
+				log.Warn("failed to redeem retryable", "ticketId", kept.ticketId, "err", err)
+			}
+			// check whether it still needs keeping alive once the redeem lands
+			continue
+		}
+		if config.Keepalive && kept.timeout <= now+uint64(config.KeepaliveMargin.Seconds()) {
+			if err := k.send(ctx, config, "keepalive", kept.ticketId); err != nil {
+				log.Warn("failed to keep retryable alive", "ticketId", kept.ticketId, "timeout", kept.timeout, "err", err)
+			}
+		}
+	}
+	for ticketId := range k.redeemed {
+		if !live[ticketId] {
+			delete(k.redeemed, ticketId)
+		}
+	}
+	return nil
+}
+
+// send submits a keepalive or redeem for the ticket, unless that'd exceed the
+// daily spend cap, and waits for it to be included.
+func (k *RetryableKeeper) send(ctx context.Context, config *RetryableKeeperConfig, action string, ticketId common.Hash) error {
+	opts := *k.txOpts
+	opts.Context = ctx
+	opts.NoSend = true
+	if action == "redeem" {
+		opts.GasLimit = config.RedeemGasLimit
+	}
+	var tx *types.Transaction
+	var err error
+	if action == "redeem" {
+		tx, err = k.con.Redeem(&opts, ticketId)
+	} else {
+		tx, err = k.con.Keepalive(&opts, ticketId)
+	}
+	if err != nil {
+		retryableKeeperFailureCounter.Inc(1)
+		return err
+	}
+	maxCost := arbmath.BigMulByUint(tx.GasFeeCap(), tx.Gas())
+	if limit := config.dailySpendCapWei(); limit != nil && arbmath.BigGreaterThan(arbmath.BigAdd(k.spentLastDay(), maxCost), limit) {
+		retryableKeeperSkippedCounter.Inc(1)
+		log.Warn("not sending retryable "+action+" as it could exceed the daily spend cap", "ticketId", ticketId, "maxCost", maxCost)
+		return nil
+	}
+	if err := k.client.SendTransaction(ctx, tx); err != nil {
+		retryableKeeperFailureCounter.Inc(1)
+		return err
+	}
+	receipt, err := bind.WaitMined(ctx, k.client, tx)
+	if err != nil {
+		retryableKeeperFailureCounter.Inc(1)
+		return err
+	}
+	spent := arbmath.BigMulByUint(receipt.EffectiveGasPrice, receipt.GasUsed)
+	k.recordSpend(spent)
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		retryableKeeperFailureCounter.Inc(1)
+		return fmt.Errorf("retryable %v transaction %v failed", action, tx.Hash())
+	}
+	if action == "redeem" {
+		retryableKeeperRedeemCounter.Inc(1)
+	} else {
+		retryableKeeperKeepaliveCounter.Inc(1)
+	}
+	log.Info("sent retryable "+action, "ticketId", ticketId, "tx", tx.Hash(), "spent", spent)
+	return nil
+}
+
+func (k *RetryableKeeper) recordSpend(spent *big.Int) {
+	k.spendMutex.Lock()
+	defer k.spendMutex.Unlock()
+	spend := retryableKeeperSpend{time.Now(), spent}
+	k.spends = append(k.spends, spend)
+	if err := k.db.Put(retryableKeeperSpendKey(spend.time), spent.Bytes()); err != nil {
+		log.Error("error storing retryable keeper spend", "err", err)
+	}
+}
+
+func (k *RetryableKeeper) spentLastDay() *big.Int {
+	k.spendMutex.Lock()
+	defer k.spendMutex.Unlock()
+	cutoff := time.Now().Add(-24 * time.Hour)
+	for len(k.spends) > 0 && k.spends[0].time.Before(cutoff) {
+		if err := k.db.Delete(retryableKeeperSpendKey(k.spends[0].time)); err != nil {
+			log.Warn("error deleting old retryable keeper spend", "err", err)
+		}
+		k.spends = k.spends[1:]
+	}
+	total := new(big.Int)
+	for _, spend := range k.spends {
+		total.Add(total, spend.spent)
+	}
+	retryableKeeperSpentGauge.Update(arbmath.BigDivByUint(total, params.GWei).Int64())
+	return total
+}
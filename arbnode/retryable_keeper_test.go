@@ -0,0 +1,31 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbnode
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/rawdb"
+)
+
+func TestRetryableKeeperSpendsStored(t *testing.T) {
+	db := rawdb.NewMemoryDatabase()
+	Require(t, db.Put(retryableKeeperSpendKey(time.Now().Add(-25*time.Hour)), big.NewInt(5000).Bytes()))
+	keeper := &RetryableKeeper{db: db}
+	keeper.recordSpend(big.NewInt(1000))
+	keeper.recordSpend(big.NewInt(234))
+
+	restarted := &RetryableKeeper{db: db}
+	Require(t, restarted.loadSpends())
+	if spent := restarted.spentLastDay(); spent.Cmp(big.NewInt(1234)) != 0 {
+		Fail(t, "unexpected spend in the last day after restarting", spent)
+	}
+	restarted = &RetryableKeeper{db: db}
+	Require(t, restarted.loadSpends())
+	if len(restarted.spends) != 2 {
+		Fail(t, "spend older than a day wasn't deleted", len(restarted.spends))
+	}
+}
@@ -9,6 +9,11 @@ var (
 	delayedMessagePrefix     []byte = []byte("d") // maps a delayed sequence number to an accumulator and a message
 	sequencerBatchMetaPrefix []byte = []byte("s") // maps a batch sequence number to BatchMetadata
 	delayedSequencedPrefix   []byte = []byte("a") // maps a delayed message count to the first sequencer batch sequence number with this delayed count
+	retryableIndexerPrefix   string = "r"         // the prefix for all retryable indexer keys
+	outboxIndexerPrefix      string = "o"         // the prefix for all outbox indexer keys
+	stakerSpendingPrefix     string = "p"         // the prefix for all staker spending keys
+	retryableKeeperPrefix    string = "k"         // the prefix for all retryable keeper keys
+	feedSignerRotationPrefix string = "f"         // the prefix for all feed signer rotation keys
 
 	messageCountKey        []byte = []byte("_messageCount")        // contains the current message count
 	delayedMessageCountKey []byte = []byte("_delayedMessageCount") // contains the current delayed message count
@@ -25,6 +25,7 @@ import (
 	"github.com/offchainlabs/nitro/arbos/merkleAccumulator"
 	"github.com/offchainlabs/nitro/arbos/retryables"
 	"github.com/offchainlabs/nitro/arbos/storage"
+	"github.com/offchainlabs/nitro/arbos/timelock"
 	"github.com/offchainlabs/nitro/arbos/util"
 )
 
@@ -48,6 +49,7 @@ type ArbosState struct {
 	chainId           storage.StorageBackedBigInt
 	genesisBlockNum   storage.StorageBackedUint64
 	infraFeeAccount   storage.StorageBackedAddress
+	ownerTimelock     *timelock.Timelock
 	backingStorage    *storage.Storage
 	Burner            burn.Burner
 }
@@ -79,6 +81,7 @@ func OpenArbosState(stateDB vm.StateDB, burner burn.Burner) (*ArbosState, error)
 		backingStorage.OpenStorageBackedBigInt(uint64(chainIdOffset)),
 		backingStorage.OpenStorageBackedUint64(uint64(genesisBlockNumOffset)),
 		backingStorage.OpenStorageBackedAddress(uint64(infraFeeAccountOffset)),
+		timelock.Open(backingStorage.OpenSubStorage(ownerTimelockSubspace)),
 		backingStorage,
 		burner,
 	}, nil
@@ -140,13 +143,14 @@ const (
 type SubspaceID []byte
 
 var (
-	l1PricingSubspace    SubspaceID = []byte{0}
-	l2PricingSubspace    SubspaceID = []byte{1}
-	retryablesSubspace   SubspaceID = []byte{2}
-	addressTableSubspace SubspaceID = []byte{3}
-	chainOwnerSubspace   SubspaceID = []byte{4}
-	sendMerkleSubspace   SubspaceID = []byte{5}
-	blockhashesSubspace  SubspaceID = []byte{6}
+	l1PricingSubspace     SubspaceID = []byte{0}
+	l2PricingSubspace     SubspaceID = []byte{1}
+	retryablesSubspace    SubspaceID = []byte{2}
+	addressTableSubspace  SubspaceID = []byte{3}
+	chainOwnerSubspace    SubspaceID = []byte{4}
+	sendMerkleSubspace    SubspaceID = []byte{5}
+	blockhashesSubspace   SubspaceID = []byte{6}
+	ownerTimelockSubspace SubspaceID = []byte{7}
 )
 
 // Returns a list of precompiles that only appear in Arbitrum chains (i.e. ArbOS precompiles) at the genesis block
@@ -275,6 +279,8 @@ func (state *ArbosState) UpgradeArbosVersion(upgradeTo uint64, firstTime bool) e
 			// no state changes needed
 		case 6:
 			// no state changes needed
+		case 7:
+			ensure(timelock.Initialize(state.backingStorage.OpenSubStorage(ownerTimelockSubspace)))
 		default:
 			return fmt.Errorf("unrecognized ArbOS version %v, %w", state.arbosVersion, ErrFatalNodeOutOfDate)
 		}
@@ -301,6 +307,16 @@ func (state *ArbosState) ScheduleArbOSUpgrade(newVersion uint64, timestamp uint6
 	return state.upgradeTimestamp.Set(timestamp)
 }
 
+// Returns the scheduled ArbOS upgrade and when it takes effect, which may already be in the past
+func (state *ArbosState) GetScheduledUpgrade() (uint64, uint64, error) {
+	version, err := state.upgradeVersion.Get()
+	if err != nil {
+		return 0, 0, err
+	}
+	timestamp, err := state.upgradeTimestamp.Get()
+	return version, timestamp, err
+}
+
 func (state *ArbosState) BackingStorage() *storage.Storage {
 	return state.backingStorage
 }
@@ -365,6 +381,10 @@ func (state *ArbosState) SetInfraFeeAccount(account common.Address) error {
 	return state.infraFeeAccount.Set(account)
 }
 
+func (state *ArbosState) OwnerTimelock() *timelock.Timelock {
+	return state.ownerTimelock
+}
+
 func (state *ArbosState) Keccak(data ...[]byte) ([]byte, error) {
 	return state.backingStorage.Keccak(data...)
 }
@@ -0,0 +1,302 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbosState
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// StateDump is a human-readable view of every ArbOS subsystem, for debugging state discrepancies.
+// Lists that could be arbitrarily long are capped, and marked as truncated when the cap is reached.
+type StateDump struct {
+	ArbOSVersion      uint64           `json:"arbOSVersion"`
+	UpgradeVersion    uint64           `json:"upgradeVersion"`
+	UpgradeTimestamp  uint64           `json:"upgradeTimestamp"`
+	ChainId           *big.Int         `json:"chainId"`
+	GenesisBlockNum   uint64           `json:"genesisBlockNum"`
+	NetworkFeeAccount common.Address   `json:"networkFeeAccount"`
+	InfraFeeAccount   common.Address   `json:"infraFeeAccount"`
+	ChainOwners       []common.Address `json:"chainOwners"`
+
+	L1Pricing     L1PricingDump      `json:"l1Pricing"`
+	L2Pricing     L2PricingDump      `json:"l2Pricing"`
+	Retryables    RetryablesDump     `json:"retryables"`
+	AddressTable  AddressTableDump   `json:"addressTable"`
+	SendMerkle    SendMerkleDump     `json:"sendMerkle"`
+	Blockhashes   BlockhashesDump    `json:"blockhashes"`
+	OwnerTimelock *OwnerTimelockDump `json:"ownerTimelock,omitempty"` // only present from ArbOS version 8
+}
+
+type L1PricingDump struct {
+	PayRewardsTo         common.Address    `json:"payRewardsTo"`
+	EquilibrationUnits   *big.Int          `json:"equilibrationUnits"`
+	Inertia              uint64            `json:"inertia"`
+	PerUnitReward        uint64            `json:"perUnitReward"`
+	LastUpdateTime       uint64            `json:"lastUpdateTime"`
+	FundsDueForRewards   *big.Int          `json:"fundsDueForRewards"`
+	UnitsSinceUpdate     uint64            `json:"unitsSinceUpdate"`
+	PricePerUnit         *big.Int          `json:"pricePerUnit"`
+	LastSurplus          *big.Int          `json:"lastSurplus"`
+	PerBatchGasCost      int64             `json:"perBatchGasCost"`
+	AmortizedCostCapBips uint64            `json:"amortizedCostCapBips"`
+	TotalFundsDue        *big.Int          `json:"totalFundsDue"`
+	BatchPosters         []BatchPosterDump `json:"batchPosters"`
+}
+
+type BatchPosterDump struct {
+	Address  common.Address `json:"address"`
+	PayTo    common.Address `json:"payTo"`
+	FundsDue *big.Int       `json:"fundsDue"`
+}
+
+type L2PricingDump struct {
+	BaseFee             *big.Int `json:"baseFee"`
+	MinBaseFee          *big.Int `json:"minBaseFee"`
+	SpeedLimitPerSecond uint64   `json:"speedLimitPerSecond"`
+	PerBlockGasLimit    uint64   `json:"perBlockGasLimit"`
+	GasBacklog          uint64   `json:"gasBacklog"`
+	PricingInertia      uint64   `json:"pricingInertia"`
+	BacklogTolerance    uint64   `json:"backlogTolerance"`
+}
+
+type RetryablesDump struct {
+	TimeoutQueueSize uint64          `json:"timeoutQueueSize"`
+	Tickets          []RetryableDump `json:"tickets"` // in timeout queue order
+	Truncated        bool            `json:"truncated"`
+}
+
+// RetryableDump is a retryable in the timeout queue, which may have been redeemed or reaped
+// if its entry hasn't yet been removed from the queue.
+type RetryableDump struct {
+	TicketId           common.Hash     `json:"ticketId"`
+	Exists             bool            `json:"exists"`
+	From               common.Address  `json:"from"`
+	To                 *common.Address `json:"to,omitempty"`
+	Callvalue          *big.Int        `json:"callvalue,omitempty"`
+	Beneficiary        common.Address  `json:"beneficiary"`
+	CalldataSize       uint64          `json:"calldataSize,omitempty"`
+	NumTries           uint64          `json:"numTries,omitempty"`
+	Timeout            uint64          `json:"timeout,omitempty"`
+	TimeoutWindowsLeft uint64          `json:"timeoutWindowsLeft,omitempty"`
+}
+
+type AddressTableDump struct {
+	Size      uint64           `json:"size"`
+	Addresses []common.Address `json:"addresses"` // indexed by their position in the table
+	Truncated bool             `json:"truncated"`
+}
+
+type SendMerkleDump struct {
+	Size     uint64        `json:"size"`
+	Root     common.Hash   `json:"root"`
+	Partials []common.Hash `json:"partials"`
+}
+
+type BlockhashesDump struct {
+	NextBlockNumber uint64                 `json:"nextBlockNumber"`
+	Hashes          map[uint64]common.Hash `json:"hashes"` // the L1 block hashes ArbOS still remembers
+}
+
+type OwnerTimelockDump struct {
+	Delay   uint64            `json:"delay"`
+	Pending []OwnerActionDump `json:"pending"`
+}
+
+type OwnerActionDump struct {
+	Id        uint64         `json:"id"`
+	Owner     common.Address `json:"owner"`
+	Timestamp uint64         `json:"timestamp"`
+	Data      hexutil.Bytes  `json:"data"`
+}
+
+// collects the first error encountered while dumping, so fields can be read without checking each one
+type dumper struct {
+	err error
+}
+
+func (d *dumper) check(err error) bool {
+	if d.err == nil {
+		d.err = err
+	}
+	return d.err == nil
+}
+
+func (d *dumper) u64(value uint64, err error) uint64 {
+	d.check(err)
+	return value
+}
+
+func (d *dumper) i64(value int64, err error) int64 {
+	d.check(err)
+	return value
+}
+
+func (d *dumper) big(value *big.Int, err error) *big.Int {
+	d.check(err)
+	return value
+}
+
+func (d *dumper) address(value common.Address, err error) common.Address {
+	d.check(err)
+	return value
+}
+
+func (d *dumper) hash(value common.Hash, err error) common.Hash {
+	d.check(err)
+	return value
+}
+
+// Dump reads the entirety of the ArbOS state, returning at most maxEntries retryables and address table entries.
+func (state *ArbosState) Dump(maxEntries uint64) (*StateDump, error) {
+	d := &dumper{}
+	dump := &StateDump{
+		ArbOSVersion:      state.arbosVersion,
+		UpgradeVersion:    d.u64(state.upgradeVersion.Get()),
+		UpgradeTimestamp:  d.u64(state.upgradeTimestamp.Get()),
+		ChainId:           d.big(state.ChainId()),
+		GenesisBlockNum:   d.u64(state.GenesisBlockNum()),
+		NetworkFeeAccount: d.address(state.NetworkFeeAccount()),
+		InfraFeeAccount:   d.address(state.InfraFeeAccount()),
+	}
+	owners, err := state.ChainOwners().AllMembers(maxEntries)
+	d.check(err)
+	dump.ChainOwners = owners
+
+	l1p := state.L1PricingState()
+	dump.L1Pricing = L1PricingDump{
+		PayRewardsTo:         d.address(l1p.PayRewardsTo()),
+		EquilibrationUnits:   d.big(l1p.EquilibrationUnits()),
+		Inertia:              d.u64(l1p.Inertia()),
+		PerUnitReward:        d.u64(l1p.PerUnitReward()),
+		LastUpdateTime:       d.u64(l1p.LastUpdateTime()),
+		FundsDueForRewards:   d.big(l1p.FundsDueForRewards()),
+		UnitsSinceUpdate:     d.u64(l1p.UnitsSinceUpdate()),
+		PricePerUnit:         d.big(l1p.PricePerUnit()),
+		LastSurplus:          d.big(l1p.LastSurplus()),
+		PerBatchGasCost:      d.i64(l1p.PerBatchGasCost()),
+		AmortizedCostCapBips: d.u64(l1p.AmortizedCostCapBips()),
+		TotalFundsDue:        d.big(l1p.BatchPosterTable().TotalFundsDue()),
+		BatchPosters:         []BatchPosterDump{},
+	}
+	posters, err := l1p.BatchPosterTable().AllPosters(maxEntries)
+	d.check(err)
+	for _, address := range posters {
+		poster, err := l1p.BatchPosterTable().OpenPoster(address, false)
+		if !d.check(err) {
+			break
+		}
+		dump.L1Pricing.BatchPosters = append(dump.L1Pricing.BatchPosters, BatchPosterDump{
+			Address:  address,
+			PayTo:    d.address(poster.PayTo()),
+			FundsDue: d.big(poster.FundsDue()),
+		})
+	}
+
+	l2p := state.L2PricingState()
+	dump.L2Pricing = L2PricingDump{
+		BaseFee:             d.big(l2p.BaseFeeWei()),
+		MinBaseFee:          d.big(l2p.MinBaseFeeWei()),
+		SpeedLimitPerSecond: d.u64(l2p.SpeedLimitPerSecond()),
+		PerBlockGasLimit:    d.u64(l2p.PerBlockGasLimit()),
+		GasBacklog:          d.u64(l2p.GasBacklog()),
+		PricingInertia:      d.u64(l2p.PricingInertia()),
+		BacklogTolerance:    d.u64(l2p.BacklogTolerance()),
+	}
+
+	timeoutQueue := state.RetryableState().TimeoutQueue
+	dump.Retryables = RetryablesDump{
+		TimeoutQueueSize: d.u64(timeoutQueue.Size()),
+		Tickets:          []RetryableDump{},
+	}
+	d.check(timeoutQueue.ForEach(func(_ uint64, ticketId common.Hash) (bool, error) {
+		if uint64(len(dump.Retryables.Tickets)) >= maxEntries {
+			dump.Retryables.Truncated = true
+			return true, nil
+		}
+		ticket, err := state.dumpRetryable(ticketId)
+		if err != nil {
+			return false, err
+		}
+		dump.Retryables.Tickets = append(dump.Retryables.Tickets, *ticket)
+		return false, nil
+	}))
+
+	atab := state.AddressTable()
+	dump.AddressTable = AddressTableDump{
+		Size:      d.u64(atab.Size()),
+		Addresses: []common.Address{},
+	}
+	for i := uint64(0); i < dump.AddressTable.Size && d.err == nil; i++ {
+		if i >= maxEntries {
+			dump.AddressTable.Truncated = true
+			break
+		}
+		address, _, err := atab.LookupIndex(i)
+		d.check(err)
+		dump.AddressTable.Addresses = append(dump.AddressTable.Addresses, address)
+	}
+
+	size, root, partials, err := state.SendMerkleAccumulator().StateForExport()
+	d.check(err)
+	dump.SendMerkle = SendMerkleDump{
+		Size:     size,
+		Root:     root,
+		Partials: partials,
+	}
+
+	blockhashes := state.Blockhashes()
+	dump.Blockhashes = BlockhashesDump{
+		NextBlockNumber: d.u64(blockhashes.NextBlockNumber()),
+		Hashes:          make(map[uint64]common.Hash),
+	}
+	for i := uint64(1); i <= 256 && i <= dump.Blockhashes.NextBlockNumber && d.err == nil; i++ {
+		number := dump.Blockhashes.NextBlockNumber - i
+		dump.Blockhashes.Hashes[number] = d.hash(blockhashes.BlockHash(number))
+	}
+
+	if state.arbosVersion >= 8 {
+		dump.OwnerTimelock = &OwnerTimelockDump{
+			Delay:   d.u64(state.OwnerTimelock().Delay()),
+			Pending: []OwnerActionDump{},
+		}
+		pending, err := state.OwnerTimelock().Pending()
+		d.check(err)
+		for _, action := range pending {
+			dump.OwnerTimelock.Pending = append(dump.OwnerTimelock.Pending, OwnerActionDump{
+				Id:        action.Id,
+				Owner:     action.Owner,
+				Timestamp: action.Timestamp,
+				Data:      action.Data,
+			})
+		}
+	}
+
+	return dump, d.err
+}
+
+func (state *ArbosState) dumpRetryable(ticketId common.Hash) (*RetryableDump, error) {
+	// we don't care if the retryable has expired
+	retryable, err := state.RetryableState().OpenRetryable(ticketId, 0)
+	if err != nil || retryable == nil {
+		return &RetryableDump{TicketId: ticketId}, err
+	}
+	d := &dumper{}
+	to, err := retryable.To()
+	d.check(err)
+	return &RetryableDump{
+		TicketId:           ticketId,
+		Exists:             true,
+		From:               d.address(retryable.From()),
+		To:                 to,
+		Callvalue:          d.big(retryable.Callvalue()),
+		Beneficiary:        d.address(retryable.Beneficiary()),
+		CalldataSize:       d.u64(retryable.CalldataSize()),
+		NumTries:           d.u64(retryable.NumTries()),
+		Timeout:            d.u64(retryable.CalculateTimeout()),
+		TimeoutWindowsLeft: d.u64(retryable.TimeoutWindowsLeft()),
+	}, d.err
+}
@@ -0,0 +1,89 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbosState
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/offchainlabs/nitro/util/testhelpers"
+)
+
+func TestDump(t *testing.T) {
+	state, _ := NewArbosMemoryBackedArbOSState()
+
+	owner := testhelpers.RandomAddress()
+	Require(t, state.ChainOwners().Add(owner))
+
+	addresses := []common.Address{testhelpers.RandomAddress(), testhelpers.RandomAddress(), testhelpers.RandomAddress()}
+	for _, address := range addresses {
+		_, err := state.AddressTable().Register(address)
+		Require(t, err)
+	}
+
+	ticketIds := []common.Hash{randomHash(), randomHash()}
+	for i, id := range ticketIds {
+		to := testhelpers.RandomAddress()
+		_, err := state.RetryableState().CreateRetryable(id, uint64(1000+i), owner, &to, common.Big1, owner, []byte{1, 2, 3})
+		Require(t, err)
+	}
+
+	for i := uint64(0); i < 5; i++ {
+		Require(t, state.Blockhashes().RecordNewL1Block(i, randomHash()))
+		_, err := state.SendMerkleAccumulator().Append(randomHash())
+		Require(t, err)
+	}
+
+	dump, err := state.Dump(2)
+	Require(t, err)
+
+	if dump.ArbOSVersion != state.FormatVersion() || (dump.OwnerTimelock != nil) != (state.FormatVersion() >= 8) {
+		Fail(t, "unexpected version", dump.ArbOSVersion)
+	}
+	isOwner := false
+	for _, member := range dump.ChainOwners {
+		isOwner = isOwner || member == owner
+	}
+	if !isOwner {
+		Fail(t, "missing chain owner", dump.ChainOwners)
+	}
+	if dump.AddressTable.Size != 3 || len(dump.AddressTable.Addresses) != 2 || !dump.AddressTable.Truncated {
+		Fail(t, "unexpected address table", dump.AddressTable)
+	}
+	if dump.AddressTable.Addresses[1] != addresses[1] {
+		Fail(t, "unexpected address", dump.AddressTable.Addresses[1])
+	}
+	if dump.Retryables.TimeoutQueueSize != 2 || len(dump.Retryables.Tickets) != 2 || dump.Retryables.Truncated {
+		Fail(t, "unexpected retryables", dump.Retryables)
+	}
+	ticket := dump.Retryables.Tickets[1]
+	if ticket.TicketId != ticketIds[1] || !ticket.Exists || ticket.From != owner || ticket.Timeout != 1001 || ticket.CalldataSize != 3 {
+		Fail(t, "unexpected retryable", ticket)
+	}
+	if dump.SendMerkle.Size != 5 || len(dump.SendMerkle.Partials) == 0 {
+		Fail(t, "unexpected send merkle", dump.SendMerkle)
+	}
+	if dump.Blockhashes.NextBlockNumber != 5 || len(dump.Blockhashes.Hashes) != 5 {
+		Fail(t, "unexpected blockhashes", dump.Blockhashes)
+	}
+	l1BaseFee, err := state.L1PricingState().PricePerUnit()
+	Require(t, err)
+	if dump.L1Pricing.PricePerUnit.Cmp(l1BaseFee) != 0 {
+		Fail(t, "unexpected L1 price", dump.L1Pricing.PricePerUnit)
+	}
+
+	// the dump should survive a round trip through json, as it does over rpc
+	encoded, err := json.Marshal(dump)
+	Require(t, err)
+	var decoded StateDump
+	Require(t, json.Unmarshal(encoded, &decoded))
+	if decoded.Blockhashes.Hashes[4] != dump.Blockhashes.Hashes[4] || decoded.SendMerkle.Root != dump.SendMerkle.Root {
+		Fail(t, "dump changed when encoded")
+	}
+}
+
+func randomHash() common.Hash {
+	return common.BytesToHash(testhelpers.RandomizeSlice(make([]byte, 32)))
+}
@@ -84,6 +84,10 @@ func ApplyInternalTxUpdate(tx *types.ArbitrumInternalTx, state *arbosState.Arbos
 
 		state.L2PricingState().UpdatePricingModel(l2BaseFee, timePassed, false)
 
+		if state.FormatVersion() >= 8 {
+			executeDueOwnerActions(state, evm, currentTime)
+		}
+
 		return state.UpgradeArbosVersionIfNecessary(currentTime)
 	case InternalTxBatchPostingReportMethodID:
 		inputs, err := util.UnpackInternalTxDataBatchPostingReport(tx.Data)
@@ -121,3 +125,24 @@ func ApplyInternalTxUpdate(tx *types.ArbitrumInternalTx, state *arbosState.Arbos
 		return fmt.Errorf("unknown internal tx method selector: %v", hex.EncodeToString(tx.Data[:4]))
 	}
 }
+
+// Executes the chain owner actions whose timelock has passed, in the order they were scheduled
+func executeDueOwnerActions(state *arbosState.ArbosState, evm *vm.EVM, currentTime uint64) {
+	for {
+		action, err := state.OwnerTimelock().PopDue(currentTime)
+		if err != nil || action == nil {
+			state.Restrict(err)
+			return
+		}
+		isOwner, err := state.ChainOwners().IsMember(action.Owner)
+		state.Restrict(err)
+		if !isOwner {
+			// owners removed during the delay can't act
+			log.Warn("skipping owner action scheduled by a removed owner", "id", action.Id, "owner", action.Owner)
+			continue
+		}
+		if err := ExecuteOwnerAction(evm, action.Id, action.Owner, action.Data); err != nil {
+			log.Warn("timelocked owner action failed", "id", action.Id, "owner", action.Owner, "err", err)
+		}
+	}
+}
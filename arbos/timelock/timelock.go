@@ -0,0 +1,227 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package timelock
+
+import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/offchainlabs/nitro/arbos/storage"
+	"github.com/offchainlabs/nitro/arbos/util"
+	"github.com/offchainlabs/nitro/util/arbmath"
+)
+
+// Timelock delays chain owner actions by a configurable amount of time.
+// Actions are queued and execute in the order they were scheduled, so an action
+// never executes before one scheduled ahead of it, even if the delay was lowered.
+type Timelock struct {
+	delay         storage.StorageBackedUint64
+	lastActionId  storage.StorageBackedUint64
+	lastTimestamp storage.StorageBackedUint64
+	queue         *storage.Queue
+	actions       *storage.Storage
+}
+
+// A queued owner action, which is the calldata of a call to ArbOwner.
+type Action struct {
+	Id        uint64
+	Owner     common.Address
+	Timestamp uint64
+	Data      []byte
+}
+
+const (
+	delayOffset uint64 = iota
+	lastActionIdOffset
+	lastTimestampOffset
+)
+
+var (
+	queueKey   = []byte{0}
+	actionsKey = []byte{1}
+)
+
+// fields of each action
+const (
+	actionOwnerOffset uint64 = iota
+	actionTimestampOffset
+)
+
+var actionDataKey = []byte{0}
+
+// MaxDelay is the longest owner actions can be delayed by, so a mistaken delay can always be undone
+const MaxDelay uint64 = 30 * 24 * 60 * 60
+
+var ErrDelayTooLong = errors.New("owner action delay exceeds the maximum of 30 days")
+
+func Initialize(sto *storage.Storage) error {
+	// the delay starts at 0, which means owner actions aren't timelocked
+	return storage.InitializeQueue(sto.OpenSubStorage(queueKey))
+}
+
+func Open(sto *storage.Storage) *Timelock {
+	return &Timelock{
+		sto.OpenStorageBackedUint64(delayOffset),
+		sto.OpenStorageBackedUint64(lastActionIdOffset),
+		sto.OpenStorageBackedUint64(lastTimestampOffset),
+		storage.OpenQueue(sto.OpenSubStorage(queueKey)),
+		sto.OpenSubStorage(actionsKey),
+	}
+}
+
+// Delay returns how long owner actions are delayed by, in seconds, or 0 if they aren't.
+func (t *Timelock) Delay() (uint64, error) {
+	return t.delay.Get()
+}
+
+func (t *Timelock) SetDelay(delay uint64) error {
+	if delay > MaxDelay {
+		return ErrDelayTooLong
+	}
+	return t.delay.Set(delay)
+}
+
+func (t *Timelock) openAction(id uint64) *storage.Storage {
+	return t.actions.OpenSubStorage(util.UintToHash(id).Bytes())
+}
+
+// Schedule queues an owner action to execute once the delay has passed, returning its id and timestamp.
+func (t *Timelock) Schedule(owner common.Address, data []byte, currentTime uint64) (uint64, uint64, error) {
+	delay, err := t.delay.Get()
+	if err != nil {
+		return 0, 0, err
+	}
+	lastTimestamp, err := t.lastTimestamp.Get()
+	if err != nil {
+		return 0, 0, err
+	}
+	timestamp := arbmath.SaturatingUAdd(currentTime, delay)
+	if timestamp < lastTimestamp {
+		timestamp = lastTimestamp
+	}
+	id, err := t.lastActionId.Increment()
+	if err != nil {
+		return 0, 0, err
+	}
+	action := t.openAction(id)
+	if err := action.SetByUint64(actionOwnerOffset, util.AddressToHash(owner)); err != nil {
+		return 0, 0, err
+	}
+	if err := action.SetUint64ByUint64(actionTimestampOffset, timestamp); err != nil {
+		return 0, 0, err
+	}
+	if err := action.OpenSubStorage(actionDataKey).SetBytes(data); err != nil {
+		return 0, 0, err
+	}
+	if err := t.lastTimestamp.Set(timestamp); err != nil {
+		return 0, 0, err
+	}
+	return id, timestamp, t.queue.Put(util.UintToHash(id))
+}
+
+// Action returns a pending action, or nil if it doesn't exist or has already executed or been canceled.
+func (t *Timelock) Action(id uint64) (*Action, error) {
+	action := t.openAction(id)
+	timestamp, err := action.GetUint64ByUint64(actionTimestampOffset)
+	if err != nil || timestamp == 0 {
+		return nil, err
+	}
+	owner, err := action.GetByUint64(actionOwnerOffset)
+	if err != nil {
+		return nil, err
+	}
+	data, err := action.OpenSubStorage(actionDataKey).GetBytes()
+	if err != nil {
+		return nil, err
+	}
+	return &Action{
+		Id:        id,
+		Owner:     common.BytesToAddress(owner.Bytes()),
+		Timestamp: timestamp,
+		Data:      data,
+	}, nil
+}
+
+func (t *Timelock) clearAction(id uint64) error {
+	action := t.openAction(id)
+	if err := action.ClearByUint64(actionOwnerOffset); err != nil {
+		return err
+	}
+	if err := action.ClearByUint64(actionTimestampOffset); err != nil {
+		return err
+	}
+	return action.OpenSubStorage(actionDataKey).ClearBytes()
+}
+
+// Cancel removes a pending action, returning false if there wasn't one.
+// The action's queue entry is skipped when it's reached.
+func (t *Timelock) Cancel(id uint64) (bool, error) {
+	action, err := t.Action(id)
+	if err != nil || action == nil {
+		return false, err
+	}
+	if err := t.clearAction(id); err != nil {
+		return false, err
+	}
+	return true, t.updateLastTimestamp()
+}
+
+// updateLastTimestamp lowers the time new actions can't execute before to
+// that of the last pending action, so actions no longer pending don't hold
+// back later ones.
+func (t *Timelock) updateLastTimestamp() error {
+	pending, err := t.Pending()
+	if err != nil {
+		return err
+	}
+	var lastTimestamp uint64
+	if len(pending) > 0 {
+		lastTimestamp = pending[len(pending)-1].Timestamp
+	}
+	return t.lastTimestamp.Set(lastTimestamp)
+}
+
+// Pending returns the queued actions that haven't executed or been canceled, in execution order.
+func (t *Timelock) Pending() ([]*Action, error) {
+	var pending []*Action
+	err := t.queue.ForEach(func(_ uint64, entry common.Hash) (bool, error) {
+		action, err := t.Action(entry.Big().Uint64())
+		if err != nil {
+			return false, err
+		}
+		if action != nil {
+			pending = append(pending, action)
+		}
+		return false, nil
+	})
+	return pending, err
+}
+
+// PopDue removes and returns the next action if it's due, or nil if no action is due.
+func (t *Timelock) PopDue(currentTime uint64) (*Action, error) {
+	for {
+		entry, err := t.queue.Peek()
+		if err != nil || entry == nil {
+			return nil, err
+		}
+		id := entry.Big().Uint64()
+		action, err := t.Action(id)
+		if err != nil {
+			return nil, err
+		}
+		if action != nil && action.Timestamp > currentTime {
+			return nil, nil
+		}
+		if _, err := t.queue.Get(); err != nil {
+			return nil, err
+		}
+		if action != nil {
+			if err := t.clearAction(id); err != nil {
+				return nil, err
+			}
+			return action, t.updateLastTimestamp()
+		}
+		// the action was canceled, so move on to the next one
+	}
+}
@@ -0,0 +1,153 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package timelock
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/offchainlabs/nitro/arbos/burn"
+	"github.com/offchainlabs/nitro/arbos/storage"
+	"github.com/offchainlabs/nitro/util/testhelpers"
+)
+
+func TestTimelock(t *testing.T) {
+	sto := storage.NewMemoryBacked(burn.NewSystemBurner(nil, false))
+	Require(t, Initialize(sto))
+	timelock := Open(sto)
+
+	owner := testhelpers.RandomAddress()
+	Require(t, timelock.SetDelay(100))
+
+	id1, timestamp1, err := timelock.Schedule(owner, []byte{1, 2, 3, 4}, 1000)
+	Require(t, err)
+	if id1 != 1 || timestamp1 != 1100 {
+		Fail(t, "unexpected first action", id1, timestamp1)
+	}
+
+	// lowering the delay mustn't let a later action jump the queue
+	Require(t, timelock.SetDelay(10))
+	id2, timestamp2, err := timelock.Schedule(owner, []byte{5, 6, 7, 8}, 1010)
+	Require(t, err)
+	if id2 != 2 || timestamp2 != timestamp1 {
+		Fail(t, "unexpected second action", id2, timestamp2)
+	}
+	id3, _, err := timelock.Schedule(common.Address{}, []byte{9, 10, 11, 12}, 1020)
+	Require(t, err)
+
+	action, err := timelock.Action(id2)
+	Require(t, err)
+	if action == nil || action.Owner != owner || !bytes.Equal(action.Data, []byte{5, 6, 7, 8}) {
+		Fail(t, "unexpected action", action)
+	}
+
+	canceled, err := timelock.Cancel(id2)
+	Require(t, err)
+	if !canceled {
+		Fail(t, "failed to cancel action")
+	}
+	canceled, err = timelock.Cancel(id2)
+	Require(t, err)
+	if canceled {
+		Fail(t, "canceled an action twice")
+	}
+
+	pending, err := timelock.Pending()
+	Require(t, err)
+	if len(pending) != 2 || pending[0].Id != id1 || pending[1].Id != id3 {
+		Fail(t, "unexpected pending actions", pending)
+	}
+
+	due, err := timelock.PopDue(1099)
+	Require(t, err)
+	if due != nil {
+		Fail(t, "action executed early", due.Id)
+	}
+	due, err = timelock.PopDue(1100)
+	Require(t, err)
+	if due == nil || due.Id != id1 {
+		Fail(t, "expected the first action to be due", due)
+	}
+	due, err = timelock.PopDue(1100)
+	Require(t, err)
+	if due != nil {
+		Fail(t, "action executed early", due.Id)
+	}
+
+	// the canceled action should be skipped
+	due, err = timelock.PopDue(2000)
+	Require(t, err)
+	if due == nil || due.Id != id3 || due.Owner != (common.Address{}) {
+		Fail(t, "expected the third action to be due", due)
+	}
+	due, err = timelock.PopDue(2000)
+	Require(t, err)
+	if due != nil {
+		Fail(t, "unexpected action", due.Id)
+	}
+	action, err = timelock.Action(id1)
+	Require(t, err)
+	if action != nil {
+		Fail(t, "executed action wasn't cleared")
+	}
+}
+
+func TestTimelockRecoversFromLongDelay(t *testing.T) {
+	sto := storage.NewMemoryBacked(burn.NewSystemBurner(nil, false))
+	Require(t, Initialize(sto))
+	timelock := Open(sto)
+	owner := testhelpers.RandomAddress()
+
+	if timelock.SetDelay(^uint64(0)) == nil {
+		Fail(t, "set a delay longer than the maximum")
+	}
+	Require(t, timelock.SetDelay(MaxDelay))
+	id, timestamp, err := timelock.Schedule(owner, []byte{1, 2, 3, 4}, 1000)
+	Require(t, err)
+	if timestamp != 1000+MaxDelay {
+		Fail(t, "unexpected timestamp", timestamp)
+	}
+
+	// canceling the action must stop it holding back later ones
+	canceled, err := timelock.Cancel(id)
+	Require(t, err)
+	if !canceled {
+		Fail(t, "failed to cancel action")
+	}
+	Require(t, timelock.SetDelay(10))
+	_, timestamp, err = timelock.Schedule(owner, []byte{5, 6, 7, 8}, 1020)
+	Require(t, err)
+	if timestamp != 1030 {
+		Fail(t, "canceled action still delays later ones", timestamp)
+	}
+
+	// as must executing it
+	Require(t, timelock.SetDelay(MaxDelay))
+	_, timestamp, err = timelock.Schedule(owner, []byte{9, 10, 11, 12}, 1040)
+	Require(t, err)
+	for {
+		due, err := timelock.PopDue(timestamp)
+		Require(t, err)
+		if due == nil {
+			break
+		}
+	}
+	Require(t, timelock.SetDelay(0))
+	_, timestamp2, err := timelock.Schedule(owner, []byte{13, 14, 15, 16}, timestamp+1)
+	Require(t, err)
+	if timestamp2 != timestamp+1 {
+		Fail(t, "executed action still delays later ones", timestamp2)
+	}
+}
+
+func Require(t *testing.T, err error, printables ...interface{}) {
+	t.Helper()
+	testhelpers.RequireImpl(t, err, printables...)
+}
+
+func Fail(t *testing.T, printables ...interface{}) {
+	t.Helper()
+	testhelpers.FailImpl(t, printables...)
+}
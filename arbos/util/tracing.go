@@ -19,6 +19,32 @@ const (
 	TracingAfterEVM
 )
 
+// PrecompileCall is a call to an ArbOS precompile decoded with the precompile's ABI.
+type PrecompileCall struct {
+	Address  common.Address         `json:"address"`
+	Contract string                 `json:"contract"`
+	Method   string                 `json:"method"`
+	Args     map[string]interface{} `json:"args"`
+	Results  map[string]interface{} `json:"results,omitempty"`
+	Events   []PrecompileEvent      `json:"events,omitempty"`
+	Error    string                 `json:"error,omitempty"` // the rendered SolError, or why the call otherwise failed
+	Input    []byte                 `json:"-"`
+	Depth    int                    `json:"-"`
+}
+
+// PrecompileEvent is an event emitted by a precompile, decoded with the precompile's ABI.
+type PrecompileEvent struct {
+	Name string                 `json:"name"`
+	Args map[string]interface{} `json:"args"`
+}
+
+// PrecompileTracer may be implemented by a tracer to also receive decoded precompile calls.
+// The call is filled in as it executes, and is complete once CapturePrecompileExit is called.
+type PrecompileTracer interface {
+	CapturePrecompileEnter(call *PrecompileCall)
+	CapturePrecompileExit(call *PrecompileCall)
+}
+
 type TracingInfo struct {
 	Tracer   vm.EVMLogger
 	Scenario TracingScenario
@@ -47,6 +73,15 @@ func NewTracingInfo(evm *vm.EVM, from, to common.Address, scenario TracingScenar
 	}
 }
 
+// PrecompileTracer returns the tracer if it wants decoded precompile calls, or nil otherwise.
+func (info *TracingInfo) PrecompileTracer() PrecompileTracer {
+	if info == nil {
+		return nil
+	}
+	tracer, _ := info.Tracer.(PrecompileTracer)
+	return tracer
+}
+
 func (info *TracingInfo) RecordStorageGet(key common.Hash) {
 	tracer := info.Tracer
 	if info.Scenario == TracingDuringEVM {
@@ -34,6 +34,10 @@ import (
 var (
 	sourcesConnectedGauge    = metrics.NewRegisteredGauge("arb/feed/sources/connected", nil)
 	sourcesDisconnectedGauge = metrics.NewRegisteredGauge("arb/feed/sources/disconnected", nil)
+	gapsCounter              = metrics.NewRegisteredCounter("arb/feed/gaps", nil)
+	gapMessagesCounter       = metrics.NewRegisteredCounter("arb/feed/gaps/messages", nil)
+	gapsBackfilledCounter    = metrics.NewRegisteredCounter("arb/feed/gaps/backfilled", nil)
+	gapsUnrecoveredCounter   = metrics.NewRegisteredCounter("arb/feed/gaps/unrecovered", nil)
 )
 
 type FeedConfig struct {
@@ -56,6 +60,9 @@ var FeedConfigDefault = FeedConfig{
 }
 
 type Config struct {
+	AuthToken          string                   `koanf:"auth-token"`
+	Backfill           bool                     `koanf:"backfill"`
+	KeySchedule        []string                 `koanf:"key-schedule"`
 	RequireChainId     bool                     `koanf:"require-chain-id"`
 	RequireFeedVersion bool                     `koanf:"require-feed-version"`
 	Timeout            time.Duration            `koanf:"timeout"`
@@ -68,6 +75,9 @@ func (c *Config) Enable() bool {
 }
 
 func ConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.String(prefix+".auth-token", DefaultConfig.AuthToken, "token to provide to the sequencer feed for authentication")
+	f.Bool(prefix+".backfill", DefaultConfig.Backfill, "reconnect to request missing messages when a sequence number gap is detected")
+	f.StringArray(prefix+".key-schedule", DefaultConfig.KeySchedule, "feed signing keys only valid for a range of sequence numbers, as address:from[:until]")
 	f.Bool(prefix+".require-chain-id", DefaultConfig.RequireChainId, "require chain id to be present on connect")
 	f.Bool(prefix+".require-feed-version", DefaultConfig.RequireFeedVersion, "require feed version to be present on connect")
 	f.Duration(prefix+".timeout", DefaultConfig.Timeout, "duration to wait before timing out connection to sequencer feed")
@@ -76,6 +86,9 @@ func ConfigAddOptions(prefix string, f *flag.FlagSet) {
 }
 
 var DefaultConfig = Config{
+	AuthToken:          "",
+	Backfill:           true,
+	KeySchedule:        []string{},
 	RequireChainId:     false,
 	RequireFeedVersion: false,
 	Verifier:           signature.DefultFeedVerifierConfig,
@@ -84,6 +97,9 @@ var DefaultConfig = Config{
 }
 
 var DefaultTestConfig = Config{
+	AuthToken:          "",
+	Backfill:           true,
+	KeySchedule:        []string{},
 	RequireChainId:     false,
 	RequireFeedVersion: false,
 	Verifier:           signature.DefultFeedVerifierConfig,
@@ -102,6 +118,8 @@ type BroadcastClient struct {
 	websocketUrl string
 	nextSeqNum   arbutil.MessageIndex
 	sigVerifier  *signature.Verifier
+	// Only accessed by the background reader thread
+	keySchedule *KeySchedule
 
 	chainId uint64
 
@@ -109,7 +127,16 @@ type BroadcastClient struct {
 	connMutex sync.Mutex
 	conn      net.Conn
 
-	retryCount int64
+	retryCount            int64
+	signatureFailureCount int64
+	gapCount              int64
+	connected             int32
+
+	// Only accessed by the background reader thread
+	haveExpectedSeqNum bool
+	expectedSeqNum     arbutil.MessageIndex
+	backfilling        bool
+	backfillSeqNum     arbutil.MessageIndex
 
 	retrying                        bool
 	shuttingDown                    bool
@@ -136,6 +163,10 @@ func NewBroadcastClient(
 	if err != nil {
 		return nil, err
 	}
+	keySchedule, err := ParseKeySchedule(config.KeySchedule)
+	if err != nil {
+		return nil, err
+	}
 	return &BroadcastClient{
 		config:       config,
 		websocketUrl: websocketUrl,
@@ -144,6 +175,7 @@ func NewBroadcastClient(
 		txStreamer:   txStreamer,
 		fatalErrChan: fatalErrChan,
 		sigVerifier:  sigVerifier,
+		keySchedule:  keySchedule,
 	}, err
 }
 
@@ -181,10 +213,14 @@ func (bc *BroadcastClient) connect(ctx context.Context, nextSeqNum arbutil.Messa
 		return nil, nil
 	}
 
-	header := ws.HandshakeHeaderHTTP(http.Header{
+	httpHeader := http.Header{
 		wsbroadcastserver.HTTPHeaderFeedClientVersion:       []string{strconv.Itoa(wsbroadcastserver.FeedClientVersion)},
 		wsbroadcastserver.HTTPHeaderRequestedSequenceNumber: []string{strconv.FormatUint(uint64(nextSeqNum), 10)},
-	})
+	}
+	if bc.config.AuthToken != "" {
+		httpHeader[wsbroadcastserver.HTTPHeaderFeedAuthToken] = []string{bc.config.AuthToken}
+	}
+	header := ws.HandshakeHeaderHTTP(httpHeader)
 
 	log.Info("connecting to arbitrum inbox message broadcaster", "url", bc.websocketUrl)
 	var foundChainId bool
@@ -278,6 +314,7 @@ func (bc *BroadcastClient) connect(ctx context.Context, nextSeqNum arbutil.Messa
 	bc.connMutex.Lock()
 	bc.conn = conn
 	bc.connMutex.Unlock()
+	atomic.StoreInt32(&bc.connected, 1)
 
 	log.Info("Feed connected", "feedServerVersion", feedServerVersion, "chainId", chainId, "requestedSeqNum", nextSeqNum)
 
@@ -307,6 +344,7 @@ func (bc *BroadcastClient) startBackgroundReader(earlyFrameData io.Reader) {
 				} else {
 					log.Error("error calling readData", "url", bc.websocketUrl, "opcode", int(op), "err", err)
 				}
+				atomic.StoreInt32(&bc.connected, 0)
 				if connected {
 					connected = false
 					sourcesConnectedGauge.Dec(1)
@@ -339,39 +377,131 @@ func (bc *BroadcastClient) startBackgroundReader(earlyFrameData io.Reader) {
 				}
 
 				if res.Version == 1 {
-					if len(res.Messages) > 0 {
-						for _, message := range res.Messages {
-							if message == nil {
-								log.Warn("ignoring nil feed message")
-								continue
-							}
-
-							err := bc.isValidSignature(ctx, message)
-							if err != nil {
-								log.Error("error validating feed signature", "error", err, "sequence number", message.SequenceNumber)
-								bc.fatalErrChan <- errors.Wrapf(err, "error validating feed signature %v", message.SequenceNumber)
-								continue
-							}
-
-							bc.nextSeqNum = message.SequenceNumber
-						}
-						if err := bc.txStreamer.AddBroadcastMessages(res.Messages); err != nil {
-							log.Error("Error adding message from Sequencer Feed", "err", err)
+					if res.KeyRotationMessage != nil {
+						// Applied before any messages, which may already be signed by the new signer
+						if err := bc.processKeyRotation(ctx, res.KeyRotationMessage); err != nil {
+							atomic.AddInt64(&bc.signatureFailureCount, 1)
+							log.Error("error validating feed signer rotation", "url", bc.websocketUrl, "newSigner", res.KeyRotationMessage.NewSigner, "err", err)
 						}
 					}
+					backfill := false
+					if len(res.Messages) > 0 {
+						var messages []*broadcaster.BroadcastFeedMessage
+						messages, backfill = bc.checkForGap(res.Messages)
+						bc.processMessages(ctx, messages)
+					}
 					if res.ConfirmedSequenceNumberMessage != nil && bc.ConfirmedSequenceNumberListener != nil {
 						bc.ConfirmedSequenceNumberListener <- res.ConfirmedSequenceNumberMessage.SequenceNumber
 					}
+					if backfill {
+						// Reconnect requesting the first missing sequence number, the server
+						// will resend everything from there out of its catchup buffer
+						bc.nextSeqNum = bc.backfillSeqNum
+						atomic.StoreInt32(&bc.connected, 0)
+						if connected {
+							connected = false
+							sourcesConnectedGauge.Dec(1)
+							sourcesDisconnectedGauge.Inc(1)
+						}
+						_ = bc.conn.Close()
+						earlyFrameData = bc.retryConnect(ctx)
+						continue
+					}
 				}
 			}
 		}
 	})
 }
 
+func (bc *BroadcastClient) processMessages(ctx context.Context, messages []*broadcaster.BroadcastFeedMessage) {
+	if len(messages) == 0 {
+		return
+	}
+	for _, message := range messages {
+		if message == nil {
+			log.Warn("ignoring nil feed message")
+			continue
+		}
+
+		err := bc.isValidSignature(ctx, message)
+		if err != nil {
+			atomic.AddInt64(&bc.signatureFailureCount, 1)
+			log.Error("error validating feed signature", "error", err, "sequence number", message.SequenceNumber)
+			bc.fatalErrChan <- errors.Wrapf(err, "error validating feed signature %v", message.SequenceNumber)
+			continue
+		}
+
+		bc.nextSeqNum = message.SequenceNumber
+	}
+	if err := bc.txStreamer.AddBroadcastMessages(messages); err != nil {
+		log.Error("Error adding message from Sequencer Feed", "err", err)
+	}
+}
+
+// checkForGap tracks the next expected sequence number and returns the messages
+// which can be processed now. If a message skips past the expected sequence number
+// and backfill is enabled, the messages from the gap onwards are dropped and
+// backfill is returned as true so the missing range can be requested. If the
+// missing range was already requested and still not received, the gap is
+// logged and the messages are returned as is.
+func (bc *BroadcastClient) checkForGap(messages []*broadcaster.BroadcastFeedMessage) (_ []*broadcaster.BroadcastFeedMessage, backfill bool) {
+	for i, message := range messages {
+		if message == nil {
+			continue
+		}
+		seqNum := message.SequenceNumber
+		if bc.backfilling && seqNum == bc.backfillSeqNum {
+			log.Info("received missing feed messages", "url", bc.websocketUrl, "seqNum", seqNum)
+			gapsBackfilledCounter.Inc(1)
+			bc.backfilling = false
+		}
+		if bc.haveExpectedSeqNum && seqNum > bc.expectedSeqNum {
+			// a gap the backfill didn't fill was already counted when it was found
+			requested := bc.backfilling && bc.backfillSeqNum == bc.expectedSeqNum
+			if !requested {
+				atomic.AddInt64(&bc.gapCount, 1)
+				gapsCounter.Inc(1)
+				gapMessagesCounter.Inc(int64(seqNum - bc.expectedSeqNum))
+			}
+			if bc.config.Backfill && !requested {
+				log.Warn("gap in feed sequence numbers, requesting missing messages", "url", bc.websocketUrl, "expectedSeqNum", bc.expectedSeqNum, "seqNum", seqNum)
+				bc.backfilling = true
+				bc.backfillSeqNum = bc.expectedSeqNum
+				return messages[:i], true
+			}
+			log.Warn("gap in feed sequence numbers, unable to request missing messages", "url", bc.websocketUrl, "expectedSeqNum", bc.expectedSeqNum, "seqNum", seqNum)
+			gapsUnrecoveredCounter.Inc(1)
+			bc.backfilling = false
+		}
+		if !bc.haveExpectedSeqNum || seqNum >= bc.expectedSeqNum {
+			bc.expectedSeqNum = seqNum + 1
+			bc.haveExpectedSeqNum = true
+		}
+	}
+	return messages, false
+}
+
 func (bc *BroadcastClient) GetRetryCount() int64 {
 	return atomic.LoadInt64(&bc.retryCount)
 }
 
+func (bc *BroadcastClient) GetGapCount() int64 {
+	return atomic.LoadInt64(&bc.gapCount)
+}
+
+func (bc *BroadcastClient) GetSignatureFailureCount() int64 {
+	return atomic.LoadInt64(&bc.signatureFailureCount)
+}
+
+// IsConnected returns true if the client is currently connected to the feed.
+func (bc *BroadcastClient) IsConnected() bool {
+	return atomic.LoadInt32(&bc.connected) == 1
+}
+
+func (bc *BroadcastClient) URL() string {
+	return bc.websocketUrl
+}
+
 func (bc *BroadcastClient) isShuttingDown() bool {
 	bc.connMutex.Lock()
 	defer bc.connMutex.Unlock()
@@ -419,13 +549,13 @@ func (bc *BroadcastClient) StopAndWait() {
 }
 
 func (bc *BroadcastClient) isValidSignature(ctx context.Context, message *broadcaster.BroadcastFeedMessage) error {
-	if bc.config.Verifier.Dangerous.AcceptMissing && bc.sigVerifier == nil {
-		// Verifier disabled
+	if bc.config.Verifier.Dangerous.AcceptMissing && (bc.sigVerifier == nil || !bc.hasTrustedSigners()) {
+		// Verifier disabled, or nothing to verify against while unsigned messages are accepted anyway
 		return nil
 	}
 	hash, err := message.Hash(bc.chainId)
 	if err != nil {
 		return errors.Wrapf(err, "error getting message hash for sequence number %v", message.SequenceNumber)
 	}
-	return bc.sigVerifier.VerifyHash(ctx, message.Signature, hash)
+	return bc.verifyHashAt(ctx, message.Signature, hash, message.SequenceNumber)
 }
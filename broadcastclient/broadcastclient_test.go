@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"sort"
 	"strconv"
 	"sync"
 	"testing"
@@ -265,6 +266,67 @@ func TestServerClientDisconnect(t *testing.T) {
 	}
 }
 
+func TestServerRequiresAuthToken(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	config := wsbroadcastserver.DefaultTestBroadcasterConfig
+	config.AuthTokens = []string{"secret"}
+
+	privateKey, err := crypto.GenerateKey()
+	Require(t, err)
+	sequencerAddr := crypto.PubkeyToAddress(privateKey.PublicKey)
+	dataSigner := signature.DataSignerFromPrivateKey(privateKey)
+
+	chainId := uint64(8742)
+	feedErrChan := make(chan error, 10)
+	b := broadcaster.NewBroadcaster(func() *wsbroadcastserver.BroadcasterConfig { return &config }, chainId, feedErrChan, dataSigner)
+
+	Require(t, b.Initialize())
+	Require(t, b.Start(ctx))
+	defer b.StopAndWait()
+
+	badTs := NewDummyTransactionStreamer(chainId, nil)
+	badBroadcastClient, err := newTestBroadcastClient(DefaultTestConfig, b.ListenerAddr(), chainId, 0, badTs, feedErrChan, &sequencerAddr)
+	Require(t, err)
+	badBroadcastClient.Start(ctx)
+	defer badBroadcastClient.StopAndWait()
+
+	clientConfig := DefaultTestConfig
+	clientConfig.AuthToken = "secret"
+	ts := NewDummyTransactionStreamer(chainId, nil)
+	broadcastClient, err := newTestBroadcastClient(clientConfig, b.ListenerAddr(), chainId, 0, ts, feedErrChan, &sequencerAddr)
+	Require(t, err)
+	broadcastClient.Start(ctx)
+	defer broadcastClient.StopAndWait()
+
+	Require(t, b.BroadcastSingle(arbstate.EmptyTestMessageWithMetadata, 0))
+
+	timer := time.NewTimer(5 * time.Second)
+	defer timer.Stop()
+	select {
+	case err := <-feedErrChan:
+		t.Fatalf("Broadcaster error: %s\n", err.Error())
+	case <-ts.messageReceiver:
+	case <-badTs.messageReceiver:
+		t.Fatal("Client without auth token received message")
+	case <-timer.C:
+		t.Fatal("Client with auth token did not receive batch item")
+	}
+
+	infos := b.ClientInfos()
+	if len(infos) != 1 {
+		t.Fatalf("Expected 1 authenticated client, got %d", len(infos))
+	}
+	if infos[0].BytesSent == 0 {
+		t.Error("Expected bytes sent to authenticated client to be recorded")
+	}
+	if disconnected := b.DisconnectClient(infos[0].Name); disconnected != 1 {
+		t.Errorf("Expected 1 client to be disconnected, got %d", disconnected)
+	}
+}
+
 func TestServerIncorrectChainId(t *testing.T) {
 	t.Parallel()
 	ctx, cancel := context.WithCancel(context.Background())
@@ -498,6 +560,225 @@ func TestBroadcastClientReconnectsOnServerDisconnect(t *testing.T) {
 	}
 }
 
+func TestCheckForGap(t *testing.T) {
+	bc := &BroadcastClient{config: DefaultTestConfig}
+	feedMessages := func(seqNums ...arbutil.MessageIndex) []*broadcaster.BroadcastFeedMessage {
+		var messages []*broadcaster.BroadcastFeedMessage
+		for _, seqNum := range seqNums {
+			messages = append(messages, &broadcaster.BroadcastFeedMessage{SequenceNumber: seqNum})
+		}
+		return messages
+	}
+
+	messages, backfill := bc.checkForGap(feedMessages(5, 6, 7))
+	if backfill || len(messages) != 3 {
+		t.Fatal("unexpected gap in contiguous messages")
+	}
+
+	// Gap after message 8, messages from 10 onwards should be dropped
+	messages, backfill = bc.checkForGap(feedMessages(8, 10, 11))
+	if !backfill {
+		t.Fatal("gap not detected")
+	}
+	if len(messages) != 1 || messages[0].SequenceNumber != 8 {
+		t.Fatal("unexpected messages returned before gap", messages)
+	}
+	if bc.backfillSeqNum != 9 {
+		t.Fatal("expected to backfill from 9, got", bc.backfillSeqNum)
+	}
+
+	// Server resends from the requested sequence number
+	messages, backfill = bc.checkForGap(feedMessages(9, 10, 11))
+	if backfill || len(messages) != 3 {
+		t.Fatal("unexpected gap after backfill")
+	}
+	if bc.backfilling {
+		t.Fatal("backfill not marked complete")
+	}
+
+	// Gap which can't be filled is only requested once
+	_, backfill = bc.checkForGap(feedMessages(14))
+	if !backfill {
+		t.Fatal("gap not detected")
+	}
+	messages, backfill = bc.checkForGap(feedMessages(14, 15))
+	if backfill || len(messages) != 2 {
+		t.Fatal("gap which couldn't be backfilled should be passed through")
+	}
+	if bc.GetGapCount() != 2 {
+		t.Fatal("expected 2 gaps, got", bc.GetGapCount())
+	}
+
+	bc.config.Backfill = false
+	messages, backfill = bc.checkForGap(feedMessages(20))
+	if backfill || len(messages) != 1 {
+		t.Fatal("backfill requested when disabled")
+	}
+}
+
+func TestBroadcastClientReconnectsOnGap(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	config := wsbroadcastserver.DefaultTestBroadcasterConfig
+
+	privateKey, err := crypto.GenerateKey()
+	Require(t, err)
+	sequencerAddr := crypto.PubkeyToAddress(privateKey.PublicKey)
+	dataSigner := signature.DataSignerFromPrivateKey(privateKey)
+
+	chainId := uint64(8745)
+	feedErrChan := make(chan error, 10)
+	b := broadcaster.NewBroadcaster(func() *wsbroadcastserver.BroadcasterConfig { return &config }, chainId, feedErrChan, dataSigner)
+
+	Require(t, b.Initialize())
+	Require(t, b.Start(ctx))
+	defer b.StopAndWait()
+
+	ts := NewDummyTransactionStreamer(chainId, nil)
+	broadcastClient, err := newTestBroadcastClient(DefaultTestConfig, b.ListenerAddr(), chainId, 0, ts, feedErrChan, &sequencerAddr)
+	Require(t, err)
+	broadcastClient.Start(ctx)
+	defer broadcastClient.StopAndWait()
+
+	expectMessage := func(expected arbutil.MessageIndex) {
+		t.Helper()
+		timer := time.NewTimer(5 * time.Second)
+		defer timer.Stop()
+		select {
+		case err := <-feedErrChan:
+			t.Fatalf("Broadcaster error: %s\n", err.Error())
+		case msg := <-ts.messageReceiver:
+			if msg.SequenceNumber != expected {
+				t.Fatalf("Expected sequence number %v, got %v", expected, msg.SequenceNumber)
+			}
+		case <-timer.C:
+			t.Fatalf("Client did not receive sequence number %v", expected)
+		}
+	}
+
+	Require(t, b.BroadcastSingle(arbstate.EmptyTestMessageWithMetadata, 0))
+	expectMessage(0)
+	Require(t, b.BroadcastSingle(arbstate.EmptyTestMessageWithMetadata, 1))
+	expectMessage(1)
+
+	// Skipping 2 clears the catchup buffer, so the client reconnects requesting 2
+	// but only gets 3 back, which it then accepts.
+	Require(t, b.BroadcastSingle(arbstate.EmptyTestMessageWithMetadata, 3))
+	expectMessage(3)
+
+	if broadcastClient.GetRetryCount() <= 0 {
+		t.Error("Client should have reconnected to request missing messages")
+	}
+	if broadcastClient.GetGapCount() != 1 {
+		t.Error("Expected 1 gap, got", broadcastClient.GetGapCount())
+	}
+}
+
+// gappyCatchupBuffer keeps every message it's given, including ones which
+// were never broadcast, so clients can backfill them.
+type gappyCatchupBuffer struct {
+	mutex    sync.Mutex
+	messages []*broadcaster.BroadcastFeedMessage
+}
+
+func (b *gappyCatchupBuffer) add(message *broadcaster.BroadcastFeedMessage) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.messages = append(b.messages, message)
+	sort.Slice(b.messages, func(i, j int) bool { return b.messages[i].SequenceNumber < b.messages[j].SequenceNumber })
+}
+
+func (b *gappyCatchupBuffer) OnRegisterClient(ctx context.Context, clientConnection *wsbroadcastserver.ClientConnection) error {
+	b.mutex.Lock()
+	var messages []*broadcaster.BroadcastFeedMessage
+	for _, message := range b.messages {
+		if message.SequenceNumber >= clientConnection.RequestedSeqNum() {
+			messages = append(messages, message)
+		}
+	}
+	b.mutex.Unlock()
+	if len(messages) == 0 {
+		return nil
+	}
+	return clientConnection.Write(broadcaster.BroadcastMessage{Version: 1, Messages: messages})
+}
+
+func (b *gappyCatchupBuffer) OnDoBroadcast(bmi interface{}) error {
+	for _, message := range bmi.(broadcaster.BroadcastMessage).Messages {
+		b.add(message)
+	}
+	return nil
+}
+
+func (b *gappyCatchupBuffer) GetMessageCount() int {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return len(b.messages)
+}
+
+func TestBroadcastClientBackfillsGap(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	config := wsbroadcastserver.DefaultTestBroadcasterConfig
+	chainId := uint64(8747)
+	feedErrChan := make(chan error, 10)
+	catchupBuffer := &gappyCatchupBuffer{}
+	server := wsbroadcastserver.NewWSBroadcastServer(func() *wsbroadcastserver.BroadcasterConfig { return &config }, catchupBuffer, chainId, feedErrChan)
+	Require(t, server.Initialize())
+	Require(t, server.Start(ctx))
+	defer server.StopAndWait()
+
+	ts := NewDummyTransactionStreamer(chainId, nil)
+	broadcastClient, err := newTestBroadcastClient(DefaultTestConfig, server.ListenerAddr(), chainId, 0, ts, feedErrChan, nil)
+	Require(t, err)
+	broadcastClient.Start(ctx)
+	defer broadcastClient.StopAndWait()
+
+	expectMessage := func(expected arbutil.MessageIndex) {
+		t.Helper()
+		timer := time.NewTimer(5 * time.Second)
+		defer timer.Stop()
+		select {
+		case err := <-feedErrChan:
+			t.Fatalf("Broadcaster error: %s\n", err.Error())
+		case msg := <-ts.messageReceiver:
+			if msg.SequenceNumber != expected {
+				t.Fatalf("Expected sequence number %v, got %v", expected, msg.SequenceNumber)
+			}
+		case <-timer.C:
+			t.Fatalf("Client did not receive sequence number %v", expected)
+		}
+	}
+	broadcast := func(seqNum arbutil.MessageIndex) {
+		server.Broadcast(broadcaster.BroadcastMessage{
+			Version:  1,
+			Messages: []*broadcaster.BroadcastFeedMessage{{SequenceNumber: seqNum, Message: arbstate.EmptyTestMessageWithMetadata}},
+		})
+	}
+
+	broadcast(0)
+	expectMessage(0)
+	broadcast(1)
+	expectMessage(1)
+
+	// The server has message 2 but never sends it, so the client reconnects to request it
+	catchupBuffer.add(&broadcaster.BroadcastFeedMessage{SequenceNumber: 2, Message: arbstate.EmptyTestMessageWithMetadata})
+	broadcast(3)
+	expectMessage(2)
+	expectMessage(3)
+
+	if broadcastClient.GetRetryCount() <= 0 {
+		t.Error("Client should have reconnected to request missing messages")
+	}
+	if broadcastClient.GetGapCount() != 1 {
+		t.Error("Expected 1 gap, got", broadcastClient.GetGapCount())
+	}
+}
+
 func TestBroadcasterSendsCachedMessagesOnClientConnect(t *testing.T) {
 	t.Parallel()
 	/* Uncomment to enable logging
@@ -626,6 +907,172 @@ func connectAndGetCachedMessages(ctx context.Context, addr net.Addr, chainId uin
 	}()
 }
 
+func TestParseKeySchedule(t *testing.T) {
+	addr := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	schedule, err := ParseKeySchedule([]string{addr.Hex() + ":10:20"})
+	Require(t, err)
+	for _, tc := range []struct {
+		seqNum arbutil.MessageIndex
+		valid  bool
+	}{{9, false}, {10, true}, {19, true}, {20, false}} {
+		known, valid := schedule.check(addr, tc.seqNum)
+		if !known || valid != tc.valid {
+			t.Errorf("sequence number %v: expected valid %v, got known %v valid %v", tc.seqNum, tc.valid, known, valid)
+		}
+	}
+	if known, _ := schedule.check(common.Address{}, 10); known {
+		t.Error("unscheduled address reported as known")
+	}
+
+	schedule, err = ParseKeySchedule([]string{addr.Hex() + "::20"})
+	Require(t, err)
+	if _, valid := schedule.check(addr, 0); !valid {
+		t.Error("expected key to be valid from the start")
+	}
+
+	for _, invalid := range []string{"0x1234", addr.Hex() + ":abc", addr.Hex() + ":20:10", addr.Hex() + ":1:2:3"} {
+		if _, err := ParseKeySchedule([]string{invalid}); err == nil {
+			t.Error("expected error parsing", invalid)
+		}
+	}
+}
+
+func TestBroadcastClientFollowsKeyRotation(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	config := wsbroadcastserver.DefaultTestBroadcasterConfig
+
+	oldKey, err := crypto.GenerateKey()
+	Require(t, err)
+	oldAddr := crypto.PubkeyToAddress(oldKey.PublicKey)
+	newKey, err := crypto.GenerateKey()
+	Require(t, err)
+	newAddr := crypto.PubkeyToAddress(newKey.PublicKey)
+
+	chainId := uint64(8746)
+	feedErrChan := make(chan error, 10)
+	b := broadcaster.NewBroadcaster(func() *wsbroadcastserver.BroadcasterConfig { return &config }, chainId, feedErrChan, signature.DataSignerFromPrivateKey(oldKey))
+
+	Require(t, b.Initialize())
+	Require(t, b.Start(ctx))
+	defer b.StopAndWait()
+
+	// The client only trusts the old key
+	clientConfig := DefaultTestConfig
+	clientConfig.Verifier.AllowedAddresses = []string{oldAddr.Hex()}
+	clientConfig.Verifier.Dangerous.AcceptMissing = false
+	ts := NewDummyTransactionStreamer(chainId, nil)
+	broadcastClient, err := newTestBroadcastClient(clientConfig, b.ListenerAddr(), chainId, 0, ts, feedErrChan, nil)
+	Require(t, err)
+	broadcastClient.Start(ctx)
+	defer broadcastClient.StopAndWait()
+
+	expectMessage := func(expected arbutil.MessageIndex) {
+		t.Helper()
+		timer := time.NewTimer(5 * time.Second)
+		defer timer.Stop()
+		select {
+		case err := <-feedErrChan:
+			t.Fatalf("Broadcaster error: %s\n", err.Error())
+		case msg := <-ts.messageReceiver:
+			if msg.SequenceNumber != expected {
+				t.Fatalf("Expected sequence number %v, got %v", expected, msg.SequenceNumber)
+			}
+		case <-timer.C:
+			t.Fatalf("Client did not receive sequence number %v", expected)
+		}
+	}
+
+	Require(t, b.BroadcastSingle(arbstate.EmptyTestMessageWithMetadata, 0))
+	expectMessage(0)
+
+	b.SetNextSigner(signature.DataSignerFromPrivateKey(newKey), newAddr)
+	rotatedTo, err := b.RotateSigner(2, 3)
+	Require(t, err)
+	if rotatedTo != newAddr {
+		t.Fatal("rotated to", rotatedTo, "instead of", newAddr)
+	}
+	for i := arbutil.MessageIndex(1); i < 4; i++ {
+		Require(t, b.BroadcastSingle(arbstate.EmptyTestMessageWithMetadata, i))
+		expectMessage(i)
+	}
+
+	// The old key is retired from sequence number 3 onwards
+	message := &broadcaster.BroadcastFeedMessage{SequenceNumber: 3, Message: arbstate.EmptyTestMessageWithMetadata}
+	hash, err := message.Hash(chainId)
+	Require(t, err)
+	oldSignature, err := crypto.Sign(hash.Bytes(), oldKey)
+	Require(t, err)
+	if err := broadcastClient.verifyHashAt(ctx, oldSignature, hash, 3); !errors.Is(err, signature.ErrSignerNotApproved) {
+		t.Error("expected retired key to be rejected, got", err)
+	}
+	if broadcastClient.GetSignatureFailureCount() != 0 {
+		t.Error("unexpected signature failures", broadcastClient.GetSignatureFailureCount())
+	}
+}
+
+type keyRotationRecorder struct {
+	*dummyTransactionStreamer
+	rotations []*broadcaster.FeedKeyRotationMessage
+}
+
+func (r *keyRotationRecorder) AddKeyRotation(rotation *broadcaster.FeedKeyRotationMessage) error {
+	r.rotations = append(r.rotations, rotation)
+	return nil
+}
+
+func TestKeyRotationVerifiedBeforePassingOn(t *testing.T) {
+	trustedKey, err := crypto.GenerateKey()
+	Require(t, err)
+	untrustedKey, err := crypto.GenerateKey()
+	Require(t, err)
+	newKey, err := crypto.GenerateKey()
+	Require(t, err)
+
+	chainId := uint64(8746)
+	clientConfig := DefaultTestConfig
+	clientConfig.Verifier.AllowedAddresses = []string{crypto.PubkeyToAddress(trustedKey.PublicKey).Hex()}
+	clientConfig.Verifier.Dangerous.AcceptMissing = false
+	recorder := &keyRotationRecorder{dummyTransactionStreamer: NewDummyTransactionStreamer(chainId, nil)}
+	// the client isn't started, so it never connects
+	broadcastClient, err := newTestBroadcastClient(clientConfig, &net.TCPAddr{}, chainId, 0, recorder, nil, nil)
+	Require(t, err)
+
+	rotation := func(key *ecdsa.PrivateKey) *broadcaster.FeedKeyRotationMessage {
+		announcement := &broadcaster.FeedKeyRotationMessage{
+			NewSigner:                crypto.PubkeyToAddress(newKey.PublicKey),
+			ActivationSequenceNumber: 10,
+			RetireSequenceNumber:     20,
+		}
+		announcement.Signature, err = crypto.Sign(announcement.Hash(chainId).Bytes(), key)
+		Require(t, err)
+		return announcement
+	}
+	ctx := context.Background()
+
+	if err := broadcastClient.processKeyRotation(ctx, rotation(untrustedKey)); err == nil {
+		t.Error("accepted a signer rotation signed by an untrusted key")
+	}
+	if len(recorder.rotations) != 0 {
+		t.Error("passed on an unverified signer rotation")
+	}
+	if known, _ := broadcastClient.keySchedule.check(crypto.PubkeyToAddress(newKey.PublicKey), 10); known {
+		t.Error("applied an unverified signer rotation")
+	}
+
+	trusted := rotation(trustedKey)
+	Require(t, broadcastClient.processKeyRotation(ctx, trusted))
+	Require(t, broadcastClient.processKeyRotation(ctx, trusted))
+	if len(recorder.rotations) != 2 {
+		t.Error("expected the verified signer rotation to be passed on each time, got", len(recorder.rotations))
+	}
+	if _, valid := broadcastClient.keySchedule.check(crypto.PubkeyToAddress(newKey.PublicKey), 10); !valid {
+		t.Error("verified signer rotation wasn't applied")
+	}
+}
+
 func Require(t *testing.T, err error, printables ...interface{}) {
 	t.Helper()
 	testhelpers.RequireImpl(t, err, printables...)
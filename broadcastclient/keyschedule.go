@@ -0,0 +1,179 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package broadcastclient
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/broadcaster"
+	"github.com/offchainlabs/nitro/util/signature"
+)
+
+// KeyRotationListener can optionally be implemented by the TransactionStreamerInterface
+// given to a BroadcastClient to receive the signer rotation announcements seen on
+// the feed, for example so a relay can pass them on to its own clients. Only
+// announcements verified against the client's key schedule are passed on, unless
+// the client has no trusted signers to verify them with, in which case they're
+// passed on as is for the listener's own clients to verify.
+type KeyRotationListener interface {
+	AddKeyRotation(rotation *broadcaster.FeedKeyRotationMessage) error
+}
+
+// KeyScheduleEntry is a feed signing key along with the sequence numbers it is
+// allowed to sign, from ValidFrom up to but excluding ValidUntil.
+// A ValidUntil of zero means the key does not expire.
+type KeyScheduleEntry struct {
+	Address    common.Address
+	ValidFrom  arbutil.MessageIndex
+	ValidUntil arbutil.MessageIndex
+}
+
+func (e *KeyScheduleEntry) validAt(seqNum arbutil.MessageIndex) bool {
+	return seqNum >= e.ValidFrom && (e.ValidUntil == 0 || seqNum < e.ValidUntil)
+}
+
+// KeySchedule holds the feed signing keys with a limited validity, either
+// configured or learned from signer rotations announced on the feed. Keys that
+// aren't in the schedule are checked by the regular signature.Verifier.
+type KeySchedule struct {
+	entries map[common.Address]*KeyScheduleEntry
+	// hashes of the signer rotations already verified and applied
+	applied map[common.Hash]bool
+}
+
+// ParseKeySchedule parses entries of the form address[:from[:until]].
+func ParseKeySchedule(entries []string) (*KeySchedule, error) {
+	schedule := &KeySchedule{
+		entries: make(map[common.Address]*KeyScheduleEntry),
+		applied: make(map[common.Hash]bool),
+	}
+	for _, entry := range entries {
+		parts := strings.Split(entry, ":")
+		if len(parts) > 3 || !common.IsHexAddress(parts[0]) {
+			return nil, fmt.Errorf("invalid key schedule entry %v, expected address[:from[:until]]", entry)
+		}
+		parsed := KeyScheduleEntry{Address: common.HexToAddress(parts[0])}
+		bounds := []*arbutil.MessageIndex{&parsed.ValidFrom, &parsed.ValidUntil}
+		for i, part := range parts[1:] {
+			if part == "" {
+				continue
+			}
+			value, err := strconv.ParseUint(part, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid sequence number in key schedule entry %v: %w", entry, err)
+			}
+			*bounds[i] = arbutil.MessageIndex(value)
+		}
+		if parsed.ValidUntil != 0 && parsed.ValidUntil <= parsed.ValidFrom {
+			return nil, fmt.Errorf("key schedule entry %v expires before it becomes valid", entry)
+		}
+		schedule.add(parsed)
+	}
+	return schedule, nil
+}
+
+func (s *KeySchedule) add(entry KeyScheduleEntry) {
+	s.entries[entry.Address] = &entry
+}
+
+// check returns whether addr is in the schedule, and if so whether it may sign seqNum.
+func (s *KeySchedule) check(addr common.Address, seqNum arbutil.MessageIndex) (known bool, valid bool) {
+	entry, known := s.entries[addr]
+	if !known {
+		return false, false
+	}
+	return true, entry.validAt(seqNum)
+}
+
+// retire limits addr to signing sequence numbers before until.
+func (s *KeySchedule) retire(addr common.Address, until arbutil.MessageIndex) {
+	entry, ok := s.entries[addr]
+	if !ok {
+		s.add(KeyScheduleEntry{Address: addr, ValidUntil: until})
+		return
+	}
+	if entry.ValidUntil == 0 || until < entry.ValidUntil {
+		entry.ValidUntil = until
+	}
+}
+
+// verifyHashAt checks that sig is a valid signature of hash by a key allowed to
+// sign seqNum. Keys in the schedule are only accepted within their validity
+// range, other keys are passed on to the signature.Verifier.
+func (bc *BroadcastClient) verifyHashAt(ctx context.Context, sig []byte, hash common.Hash, seqNum arbutil.MessageIndex) error {
+	if len(sig) > 0 {
+		if sigPublicKey, err := crypto.SigToPub(hash.Bytes(), sig); err == nil {
+			signer := crypto.PubkeyToAddress(*sigPublicKey)
+			if known, valid := bc.keySchedule.check(signer, seqNum); known {
+				if !valid {
+					return errors.Wrapf(signature.ErrSignerNotApproved, "signer %v not scheduled for sequence number %v", signer, seqNum)
+				}
+				return nil
+			}
+		}
+	}
+	return bc.sigVerifier.VerifyHash(ctx, sig, hash)
+}
+
+// processKeyRotation applies a signer rotation announced on the feed, provided it
+// is signed by a key trusted for the current sequence number, and only then passes
+// it on to a KeyRotationListener. Announcements are resent to every client on
+// connect, so ones already applied are passed on again without being reapplied.
+// A client without trusted signers doesn't verify the feed, so it passes
+// announcements on without applying them.
+func (bc *BroadcastClient) processKeyRotation(ctx context.Context, rotation *broadcaster.FeedKeyRotationMessage) error {
+	hash := rotation.Hash(bc.chainId)
+	if bc.hasTrustedSigners() && !bc.keySchedule.applied[hash] {
+		if err := bc.applyKeyRotation(ctx, rotation, hash); err != nil {
+			return err
+		}
+	}
+	if listener, ok := bc.txStreamer.(KeyRotationListener); ok {
+		if err := listener.AddKeyRotation(rotation); err != nil {
+			log.Error("error passing on feed signer rotation", "err", err)
+		}
+	}
+	return nil
+}
+
+// hasTrustedSigners returns whether the client has any feed signers to verify against.
+func (bc *BroadcastClient) hasTrustedSigners() bool {
+	return len(bc.config.Verifier.AllowedAddresses) > 0 || bc.config.Verifier.AcceptSequencer || len(bc.keySchedule.entries) > 0
+}
+
+func (bc *BroadcastClient) applyKeyRotation(ctx context.Context, rotation *broadcaster.FeedKeyRotationMessage, hash common.Hash) error {
+	if len(rotation.Signature) == 0 {
+		return signature.ErrMissingSignature
+	}
+	if err := bc.verifyHashAt(ctx, rotation.Signature, hash, bc.nextSeqNum); err != nil {
+		return err
+	}
+	sigPublicKey, err := crypto.SigToPub(hash.Bytes(), rotation.Signature)
+	if err != nil {
+		return signature.ErrSignatureNotVerified
+	}
+	oldSigner := crypto.PubkeyToAddress(*sigPublicKey)
+
+	if entry, ok := bc.keySchedule.entries[rotation.NewSigner]; !ok || entry.ValidFrom > rotation.ActivationSequenceNumber {
+		// unless the new signer was configured ahead of time
+		bc.keySchedule.add(KeyScheduleEntry{
+			Address:   rotation.NewSigner,
+			ValidFrom: rotation.ActivationSequenceNumber,
+		})
+	}
+	bc.keySchedule.retire(oldSigner, rotation.RetireSequenceNumber)
+	bc.keySchedule.applied[hash] = true
+	log.Info("feed signer rotation announced", "url", bc.websocketUrl, "oldSigner", oldSigner, "newSigner", rotation.NewSigner, "activation", rotation.ActivationSequenceNumber, "retire", rotation.RetireSequenceNumber)
+	return nil
+}
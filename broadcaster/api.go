@@ -0,0 +1,49 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package broadcaster
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/wsbroadcastserver"
+)
+
+// BroadcasterAdminAPI exposes the connected feed clients over RPC so operators
+// can inspect them and forcibly disconnect abusive ones, and lets them rotate
+// the key signing the feed.
+type BroadcasterAdminAPI struct {
+	broadcaster *Broadcaster
+}
+
+func NewBroadcasterAdminAPI(broadcaster *Broadcaster) *BroadcasterAdminAPI {
+	return &BroadcasterAdminAPI{broadcaster}
+}
+
+func (a *BroadcasterAdminAPI) Clients(ctx context.Context) ([]wsbroadcastserver.ClientInfo, error) {
+	return a.broadcaster.ClientInfos(), nil
+}
+
+func (a *BroadcasterAdminAPI) ClientCount(ctx context.Context) (int32, error) {
+	return a.broadcaster.ClientCount(), nil
+}
+
+func (a *BroadcasterAdminAPI) DisconnectClient(ctx context.Context, name string) (int, error) {
+	return a.broadcaster.DisconnectClient(name), nil
+}
+
+func (a *BroadcasterAdminAPI) DisconnectIP(ctx context.Context, ip string) (int, error) {
+	return a.broadcaster.DisconnectIP(ip), nil
+}
+
+// RotateSigner switches to signing the feed with the node's configured next
+// signer from sequence number activation on, announcing the new signer to
+// clients, which keep accepting the current key until retire. The rotation is
+// stored, so it's resumed if the node restarts before it completes.
+func (a *BroadcasterAdminAPI) RotateSigner(ctx context.Context, activation hexutil.Uint64, retire hexutil.Uint64) (common.Address, error) {
+	return a.broadcaster.RotateSigner(arbutil.MessageIndex(activation), arbutil.MessageIndex(retire))
+}
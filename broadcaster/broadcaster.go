@@ -6,10 +6,12 @@ package broadcaster
 import (
 	"context"
 	"net"
+	"sync"
 
 	"github.com/gobwas/ws"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/log"
 
 	"github.com/offchainlabs/nitro/arbstate"
@@ -22,7 +24,18 @@ type Broadcaster struct {
 	server        *wsbroadcastserver.WSBroadcastServer
 	catchupBuffer *SequenceNumberCatchupBuffer
 	chainId       uint64
-	dataSigner    signature.DataSignerFunc
+
+	// Protects the signers, which can be rotated while broadcasting
+	signerMutex          sync.Mutex
+	dataSigner           signature.DataSignerFunc
+	nextDataSigner       signature.DataSignerFunc
+	nextSignerActivation arbutil.MessageIndex
+
+	// The key RotateSigner rotates to, the last rotation announced, and where it's stored
+	rotationSigner        signature.DataSignerFunc
+	rotationSignerAddress common.Address
+	rotation              *FeedKeyRotationMessage
+	rotationDb            ethdb.KeyValueStore
 }
 
 /*
@@ -46,6 +59,7 @@ type BroadcastMessage struct {
 	// TODO better name than messages since there are different types of messages
 	Messages                       []*BroadcastFeedMessage         `json:"messages,omitempty"`
 	ConfirmedSequenceNumberMessage *ConfirmedSequenceNumberMessage `json:"confirmedSequenceNumberMessage,omitempty"`
+	KeyRotationMessage             *FeedKeyRotationMessage         `json:"keyRotationMessage,omitempty"`
 }
 
 type BroadcastFeedMessage struct {
@@ -74,12 +88,12 @@ func NewBroadcaster(config wsbroadcastserver.BroadcasterConfigFetcher, chainId u
 
 func (b *Broadcaster) newBroadcastFeedMessage(message arbstate.MessageWithMetadata, sequenceNumber arbutil.MessageIndex) (*BroadcastFeedMessage, error) {
 	var messageSignature []byte
-	if b.dataSigner != nil {
+	if dataSigner := b.signerFor(sequenceNumber); dataSigner != nil {
 		hash, err := message.Hash(sequenceNumber, b.chainId)
 		if err != nil {
 			return nil, err
 		}
-		messageSignature, err = b.dataSigner(hash.Bytes())
+		messageSignature, err = dataSigner(hash.Bytes())
 		if err != nil {
 			return nil, err
 		}
@@ -131,6 +145,18 @@ func (b *Broadcaster) ClientCount() int32 {
 	return b.server.ClientCount()
 }
 
+func (b *Broadcaster) ClientInfos() []wsbroadcastserver.ClientInfo {
+	return b.server.ClientInfos()
+}
+
+func (b *Broadcaster) DisconnectClient(name string) int {
+	return b.server.DisconnectClient(name)
+}
+
+func (b *Broadcaster) DisconnectIP(ip string) int {
+	return b.server.DisconnectIP(ip)
+}
+
 func (b *Broadcaster) ListenerAddr() net.Addr {
 	return b.server.ListenerAddr()
 }
@@ -140,11 +166,24 @@ func (b *Broadcaster) GetCachedMessageCount() int {
 }
 
 func (b *Broadcaster) Initialize() error {
+	if err := b.checkSignerRotation(); err != nil {
+		return err
+	}
 	return b.server.Initialize()
 }
 
 func (b *Broadcaster) Start(ctx context.Context) error {
-	return b.server.Start(ctx)
+	if err := b.server.Start(ctx); err != nil {
+		return err
+	}
+	b.signerMutex.Lock()
+	rotation := b.rotation
+	b.signerMutex.Unlock()
+	if rotation != nil {
+		// announce the rotation again for clients that haven't seen it yet
+		b.BroadcastKeyRotation(rotation)
+	}
+	return nil
 }
 
 func (b *Broadcaster) StartWithHeader(ctx context.Context, header ws.HandshakeHeader) error {
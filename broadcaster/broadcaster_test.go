@@ -9,7 +9,13 @@ import (
 	"testing"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/crypto"
+
 	"github.com/offchainlabs/nitro/arbstate"
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/util/signature"
 	"github.com/offchainlabs/nitro/util/testhelpers"
 	"github.com/offchainlabs/nitro/wsbroadcastserver"
 )
@@ -102,6 +108,60 @@ func TestBroadcasterMessagesRemovedOnConfirmation(t *testing.T) {
 		"clear all messages after confirmed 1 beyond latest"))
 }
 
+func TestSignerRotationStored(t *testing.T) {
+	config := wsbroadcastserver.DefaultTestBroadcasterConfig
+	chainId := uint64(5555)
+	oldKey, err := crypto.GenerateKey()
+	Require(t, err)
+	newKey, err := crypto.GenerateKey()
+	Require(t, err)
+	newAddr := crypto.PubkeyToAddress(newKey.PublicKey)
+	db := rawdb.NewMemoryDatabase()
+
+	newBroadcaster := func() *Broadcaster {
+		b := NewBroadcaster(func() *wsbroadcastserver.BroadcasterConfig { return &config }, chainId, nil, signature.DataSignerFromPrivateKey(oldKey))
+		Require(t, b.LoadSignerRotation(db))
+		return b
+	}
+	signerAt := func(b *Broadcaster, sequenceNumber arbutil.MessageIndex) common.Address {
+		t.Helper()
+		message, err := b.newBroadcastFeedMessage(arbstate.EmptyTestMessageWithMetadata, sequenceNumber)
+		Require(t, err)
+		hash, err := message.Hash(chainId)
+		Require(t, err)
+		publicKey, err := crypto.SigToPub(hash.Bytes(), message.Signature)
+		Require(t, err)
+		return crypto.PubkeyToAddress(*publicKey)
+	}
+
+	b := newBroadcaster()
+	if _, err := b.RotateSigner(5, 10); err == nil {
+		Fail(t, "rotated without a next signer")
+	}
+	b.SetNextSigner(signature.DataSignerFromPrivateKey(newKey), newAddr)
+	_, err = b.RotateSigner(5, 10)
+	Require(t, err)
+
+	// after a restart, the rotation resumes once its key is configured again
+	b = newBroadcaster()
+	if b.checkSignerRotation() == nil {
+		Fail(t, "restarted with an announced rotation to a key that isn't configured")
+	}
+	b.SetNextSigner(signature.DataSignerFromPrivateKey(newKey), newAddr)
+	Require(t, b.checkSignerRotation())
+	if signerAt(b, 4) == newAddr {
+		Fail(t, "switched signer before the rotation's activation")
+	}
+	if signerAt(b, 5) != newAddr {
+		Fail(t, "didn't switch signer at the rotation's activation")
+	}
+
+	// or once the node signs with the new key
+	b = NewBroadcaster(func() *wsbroadcastserver.BroadcasterConfig { return &config }, chainId, nil, signature.DataSignerFromPrivateKey(newKey))
+	Require(t, b.LoadSignerRotation(db))
+	Require(t, b.checkSignerRotation())
+}
+
 func Require(t *testing.T, err error, printables ...interface{}) {
 	t.Helper()
 	testhelpers.RequireImpl(t, err, printables...)
@@ -0,0 +1,178 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package broadcaster
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/util/signature"
+)
+
+var keyRotationPrefix = []byte("Arbitrum Nitro Feed Key Rotation:")
+
+// FeedKeyRotationMessage announces that feed messages will be signed by NewSigner
+// from ActivationSequenceNumber onwards. The announcement is signed by the key
+// being rotated out, which stays valid until RetireSequenceNumber so that messages
+// signed before the switch can still be verified during the overlap window.
+type FeedKeyRotationMessage struct {
+	NewSigner                common.Address       `json:"newSigner"`
+	ActivationSequenceNumber arbutil.MessageIndex `json:"activationSequenceNumber"`
+	RetireSequenceNumber     arbutil.MessageIndex `json:"retireSequenceNumber"`
+	Signature                []byte               `json:"signature"`
+}
+
+func (m *FeedKeyRotationMessage) Hash(chainId uint64) common.Hash {
+	serialized := make([]byte, 24)
+	binary.BigEndian.PutUint64(serialized[:8], chainId)
+	binary.BigEndian.PutUint64(serialized[8:16], uint64(m.ActivationSequenceNumber))
+	binary.BigEndian.PutUint64(serialized[16:], uint64(m.RetireSequenceNumber))
+	return crypto.Keccak256Hash(keyRotationPrefix, serialized, m.NewSigner.Bytes())
+}
+
+// rotation key in the database given to LoadSignerRotation
+var signerRotationKey = []byte("rotation")
+
+// LoadSignerRotation restores the last signer rotation from db, where later
+// rotations will be stored, so a pending rotation survives a restart.
+func (b *Broadcaster) LoadSignerRotation(db ethdb.KeyValueStore) error {
+	b.signerMutex.Lock()
+	defer b.signerMutex.Unlock()
+	b.rotationDb = db
+	has, err := db.Has(signerRotationKey)
+	if err != nil || !has {
+		return err
+	}
+	data, err := db.Get(signerRotationKey)
+	if err != nil {
+		return err
+	}
+	var rotation FeedKeyRotationMessage
+	if err := rlp.DecodeBytes(data, &rotation); err != nil {
+		return err
+	}
+	b.rotation = &rotation
+	b.armRotation()
+	return nil
+}
+
+// SetNextSigner gives the key that RotateSigner rotates to. If a rotation to
+// it was already announced before a restart, the broadcaster switches to it
+// at the announced sequence number.
+func (b *Broadcaster) SetNextSigner(signer signature.DataSignerFunc, address common.Address) {
+	b.signerMutex.Lock()
+	defer b.signerMutex.Unlock()
+	b.rotationSigner = signer
+	b.rotationSignerAddress = address
+	b.armRotation()
+}
+
+// armRotation schedules the switch to the next signer if the last rotation
+// announced it. It must be called with the signerMutex held.
+func (b *Broadcaster) armRotation() {
+	if b.rotation != nil && b.rotationSigner != nil && b.rotation.NewSigner == b.rotationSignerAddress {
+		b.nextDataSigner = b.rotationSigner
+		b.nextSignerActivation = b.rotation.ActivationSequenceNumber
+	}
+}
+
+// RotateSigner announces the key given to SetNextSigner to clients and
+// switches to signing with it once sequence number activation is reached.
+// The current signer stays valid for clients until retire, which must not be
+// before activation.
+func (b *Broadcaster) RotateSigner(activation arbutil.MessageIndex, retire arbutil.MessageIndex) (common.Address, error) {
+	if retire < activation {
+		return common.Address{}, errors.New("signer cannot be retired before the new signer is activated")
+	}
+
+	b.signerMutex.Lock()
+	defer b.signerMutex.Unlock()
+
+	if b.dataSigner == nil {
+		return common.Address{}, errors.New("cannot rotate signer of an unsigned feed")
+	}
+	if b.rotationSigner == nil {
+		return common.Address{}, errors.New("no key configured to rotate the feed signer to")
+	}
+	announcement := &FeedKeyRotationMessage{
+		NewSigner:                b.rotationSignerAddress,
+		ActivationSequenceNumber: activation,
+		RetireSequenceNumber:     retire,
+	}
+	hash := announcement.Hash(b.chainId)
+	var err error
+	announcement.Signature, err = b.dataSigner(hash.Bytes())
+	if err != nil {
+		return common.Address{}, err
+	}
+	if b.rotationDb != nil {
+		data, err := rlp.EncodeToBytes(announcement)
+		if err != nil {
+			return common.Address{}, err
+		}
+		if err := b.rotationDb.Put(signerRotationKey, data); err != nil {
+			return common.Address{}, err
+		}
+	}
+	b.rotation = announcement
+	b.armRotation()
+
+	log.Info("announcing feed signer rotation", "newSigner", announcement.NewSigner, "activation", activation, "retire", retire)
+	b.BroadcastKeyRotation(announcement)
+	return announcement.NewSigner, nil
+}
+
+// checkSignerRotation returns an error if a rotation was announced before a
+// restart, but the broadcaster can't switch to the new signer because its key
+// isn't configured and the node isn't already signing with it.
+func (b *Broadcaster) checkSignerRotation() error {
+	b.signerMutex.Lock()
+	defer b.signerMutex.Unlock()
+	if b.rotation == nil || b.nextDataSigner != nil || b.dataSigner == nil {
+		return nil
+	}
+	hash := crypto.Keccak256(keyRotationPrefix, []byte("current signer"))
+	sig, err := b.dataSigner(hash)
+	if err != nil {
+		return err
+	}
+	publicKey, err := crypto.SigToPub(hash, sig)
+	if err != nil {
+		return err
+	}
+	if crypto.PubkeyToAddress(*publicKey) != b.rotation.NewSigner {
+		return fmt.Errorf("feed signer rotation to %v was announced, but its key isn't configured", b.rotation.NewSigner)
+	}
+	return nil
+}
+
+// BroadcastKeyRotation sends an already signed key rotation announcement to
+// all clients, and to clients connecting later as part of their catchup.
+func (b *Broadcaster) BroadcastKeyRotation(announcement *FeedKeyRotationMessage) {
+	b.server.Broadcast(BroadcastMessage{
+		Version:            1,
+		KeyRotationMessage: announcement,
+	})
+}
+
+// signerFor returns the signer to use for sequenceNumber, switching over to
+// the next signer once its activation sequence number is reached.
+func (b *Broadcaster) signerFor(sequenceNumber arbutil.MessageIndex) signature.DataSignerFunc {
+	b.signerMutex.Lock()
+	defer b.signerMutex.Unlock()
+	if b.nextDataSigner != nil && sequenceNumber >= b.nextSignerActivation {
+		log.Info("switching to new feed signer", "sequenceNumber", sequenceNumber)
+		b.dataSigner = b.nextDataSigner
+		b.nextDataSigner = nil
+	}
+	return b.dataSigner
+}
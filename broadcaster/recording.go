@@ -0,0 +1,238 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package broadcaster
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	flag "github.com/spf13/pflag"
+)
+
+const recordingFilePrefix = "feed-"
+const recordingFileSuffix = ".jsonl"
+const recordingFileTimeFormat = "20060102-150405.000000000"
+
+// RecordedBroadcastMessage is a single line of a feed recording, the
+// BroadcastMessage as received along with the time it was received. Besides
+// feed messages it holds confirmations and signer rotations, which replays
+// need to be verifiable across a rotation.
+type RecordedBroadcastMessage struct {
+	Timestamp time.Time        `json:"timestamp"`
+	Message   BroadcastMessage `json:"message"`
+}
+
+type RecordingConfig struct {
+	Dir            string        `koanf:"dir"`
+	RotateInterval time.Duration `koanf:"rotate-interval"`
+	MaxFileSize    int64         `koanf:"max-file-size"`
+}
+
+var DefaultRecordingConfig = RecordingConfig{
+	Dir:            "feed-recording",
+	RotateInterval: time.Hour,
+	MaxFileSize:    256 * 1024 * 1024,
+}
+
+func RecordingConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.String(prefix+".dir", DefaultRecordingConfig.Dir, "directory to write recorded feed files to")
+	f.Duration(prefix+".rotate-interval", DefaultRecordingConfig.RotateInterval, "start a new recording file after this duration, 0 to disable")
+	f.Int64(prefix+".max-file-size", DefaultRecordingConfig.MaxFileSize, "start a new recording file once the current one exceeds this many bytes, 0 to disable")
+}
+
+// RecordingWriter writes BroadcastMessages as JSON lines to files in a
+// directory, starting a new file once the current one is too old or too large.
+type RecordingWriter struct {
+	config RecordingConfig
+
+	mutex    sync.Mutex
+	file     *os.File
+	writer   *bufio.Writer
+	openedAt time.Time
+	size     int64
+}
+
+func NewRecordingWriter(config RecordingConfig) (*RecordingWriter, error) {
+	if err := os.MkdirAll(config.Dir, 0755); err != nil {
+		return nil, err
+	}
+	return &RecordingWriter{config: config}, nil
+}
+
+func (w *RecordingWriter) needsRotation(now time.Time) bool {
+	if w.file == nil {
+		return true
+	}
+	if w.config.RotateInterval > 0 && now.Sub(w.openedAt) >= w.config.RotateInterval {
+		return true
+	}
+	return w.config.MaxFileSize > 0 && w.size >= w.config.MaxFileSize
+}
+
+func (w *RecordingWriter) rotate(now time.Time) error {
+	if err := w.closeFile(); err != nil {
+		return err
+	}
+	name := filepath.Join(w.config.Dir, recordingFilePrefix+now.UTC().Format(recordingFileTimeFormat)+recordingFileSuffix)
+	file, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	w.file = file
+	w.writer = bufio.NewWriter(file)
+	w.openedAt = now
+	w.size = 0
+	return nil
+}
+
+func (w *RecordingWriter) closeFile() error {
+	if w.file == nil {
+		return nil
+	}
+	flushErr := w.writer.Flush()
+	closeErr := w.file.Close()
+	w.file = nil
+	w.writer = nil
+	if flushErr != nil {
+		return flushErr
+	}
+	return closeErr
+}
+
+// Write records bm as received at timestamp.
+func (w *RecordingWriter) Write(timestamp time.Time, bm *BroadcastMessage) error {
+	line, err := json.Marshal(RecordedBroadcastMessage{Timestamp: timestamp, Message: *bm})
+	if err != nil {
+		return errors.Wrap(err, "unable to encode recorded message")
+	}
+	line = append(line, '\n')
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.needsRotation(timestamp) {
+		if err := w.rotate(timestamp); err != nil {
+			return err
+		}
+	}
+	n, err := w.writer.Write(line)
+	w.size += int64(n)
+	if err != nil {
+		return err
+	}
+	// Flush every message so a crash loses at most the message being written
+	return w.writer.Flush()
+}
+
+func (w *RecordingWriter) Close() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	return w.closeFile()
+}
+
+// ListRecordingFiles returns the recording files in dir, oldest first.
+func ListRecordingFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, recordingFilePrefix) || !strings.HasSuffix(name, recordingFileSuffix) {
+			continue
+		}
+		files = append(files, filepath.Join(dir, name))
+	}
+	// The timestamp format sorts lexicographically
+	sort.Strings(files)
+	return files, nil
+}
+
+// RecordingReader reads recorded messages from a sequence of recording files.
+type RecordingReader struct {
+	files  []string
+	file   *os.File
+	reader *bufio.Reader
+	line   int
+}
+
+func NewRecordingReader(files []string) *RecordingReader {
+	return &RecordingReader{files: files}
+}
+
+// Next returns the next recorded message, or io.EOF once all files are read.
+func (r *RecordingReader) Next() (*RecordedBroadcastMessage, error) {
+	for {
+		if r.reader == nil {
+			if len(r.files) == 0 {
+				return nil, io.EOF
+			}
+			file, err := os.Open(r.files[0])
+			if err != nil {
+				return nil, err
+			}
+			r.file = file
+			r.reader = bufio.NewReader(file)
+			r.line = 0
+		}
+		data, err := r.reader.ReadBytes('\n')
+		if len(data) > 0 && (err == nil || errors.Is(err, io.EOF)) {
+			r.line++
+			trimmed := strings.TrimSpace(string(data))
+			if trimmed == "" {
+				continue
+			}
+			var recorded RecordedBroadcastMessage
+			if err := json.Unmarshal([]byte(trimmed), &recorded); err != nil {
+				return nil, fmt.Errorf("error parsing %v line %v: %w", r.files[0], r.line, err)
+			}
+			return &recorded, nil
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+		// Done with this file, move on to the next
+		_ = r.file.Close()
+		r.file = nil
+		r.reader = nil
+		r.files = r.files[1:]
+	}
+}
+
+func (r *RecordingReader) Close() error {
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	r.reader = nil
+	return err
+}
+
+// BroadcastRecorded sends a recorded message to clients as it was received:
+// feed messages keep their original signatures, and signer rotations and
+// confirmations are passed on too.
+func (b *Broadcaster) BroadcastRecorded(bm *BroadcastMessage) {
+	if bm.KeyRotationMessage != nil {
+		b.BroadcastKeyRotation(bm.KeyRotationMessage)
+	}
+	for _, feedMessage := range bm.Messages {
+		if feedMessage != nil {
+			b.BroadcastSingleFeedMessage(feedMessage)
+		}
+	}
+	if bm.ConfirmedSequenceNumberMessage != nil {
+		b.Confirm(bm.ConfirmedSequenceNumberMessage.SequenceNumber)
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package broadcaster
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/offchainlabs/nitro/arbstate"
+	"github.com/offchainlabs/nitro/arbutil"
+)
+
+func TestRecordingRoundTrip(t *testing.T) {
+	config := RecordingConfig{
+		Dir:            t.TempDir(),
+		RotateInterval: time.Minute,
+	}
+	writer, err := NewRecordingWriter(config)
+	Require(t, err)
+
+	// Spread the messages over three rotation intervals
+	start := time.Now()
+	const messageCount = 6
+	for i := 0; i < messageCount; i++ {
+		bm := &BroadcastMessage{
+			Version: 1,
+			Messages: []*BroadcastFeedMessage{{
+				SequenceNumber: arbutil.MessageIndex(i),
+				Message:        arbstate.EmptyTestMessageWithMetadata,
+			}},
+		}
+		Require(t, writer.Write(start.Add(time.Duration(i)*30*time.Second), bm))
+	}
+	Require(t, writer.Write(start.Add(3*time.Minute), &BroadcastMessage{
+		Version:                        1,
+		ConfirmedSequenceNumberMessage: &ConfirmedSequenceNumberMessage{SequenceNumber: 3},
+	}))
+	rotation := &FeedKeyRotationMessage{
+		NewSigner:                common.HexToAddress("0x1234"),
+		ActivationSequenceNumber: 7,
+		RetireSequenceNumber:     9,
+		Signature:                []byte{1, 2, 3},
+	}
+	Require(t, writer.Write(start.Add(3*time.Minute), &BroadcastMessage{
+		Version:            1,
+		KeyRotationMessage: rotation,
+	}))
+	Require(t, writer.Close())
+
+	files, err := ListRecordingFiles(config.Dir)
+	Require(t, err)
+	if len(files) != 4 {
+		Fail(t, "expected 4 recording files, got", len(files))
+	}
+
+	reader := NewRecordingReader(files)
+	defer reader.Close()
+	for i := 0; i < messageCount; i++ {
+		recorded, err := reader.Next()
+		Require(t, err)
+		if !recorded.Timestamp.Equal(start.Add(time.Duration(i) * 30 * time.Second)) {
+			Fail(t, "unexpected timestamp for message", i, recorded.Timestamp)
+		}
+		if len(recorded.Message.Messages) != 1 || recorded.Message.Messages[0].SequenceNumber != arbutil.MessageIndex(i) {
+			Fail(t, "unexpected message", i, recorded.Message)
+		}
+	}
+	recorded, err := reader.Next()
+	Require(t, err)
+	if recorded.Message.ConfirmedSequenceNumberMessage == nil || recorded.Message.ConfirmedSequenceNumberMessage.SequenceNumber != 3 {
+		Fail(t, "expected confirmed sequence number message", recorded.Message)
+	}
+	recorded, err = reader.Next()
+	Require(t, err)
+	if recorded.Message.KeyRotationMessage == nil || recorded.Message.KeyRotationMessage.Hash(1) != rotation.Hash(1) || !bytes.Equal(recorded.Message.KeyRotationMessage.Signature, rotation.Signature) {
+		Fail(t, "expected key rotation message", recorded.Message)
+	}
+	if _, err := reader.Next(); !errors.Is(err, io.EOF) {
+		Fail(t, "expected EOF, got", err)
+	}
+}
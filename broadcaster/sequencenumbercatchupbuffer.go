@@ -23,6 +23,9 @@ var (
 type SequenceNumberCatchupBuffer struct {
 	messages     []*BroadcastFeedMessage
 	messageCount int32
+	// The most recent signer rotation, sent to every client on connect so that
+	// clients which only know the previous signer can follow the rotation
+	keyRotation *FeedKeyRotationMessage
 }
 
 func NewSequenceNumberCatchupBuffer() *SequenceNumberCatchupBuffer {
@@ -31,7 +34,7 @@ func NewSequenceNumberCatchupBuffer() *SequenceNumberCatchupBuffer {
 
 func (b *SequenceNumberCatchupBuffer) getCacheMessages(requestedSeqNum arbutil.MessageIndex) *BroadcastMessage {
 	if b.messageCount == 0 {
-		return nil
+		return b.keyRotationMessage()
 	}
 	var startingIndex int32
 	// Ignore messages older than requested sequence number
@@ -40,7 +43,7 @@ func (b *SequenceNumberCatchupBuffer) getCacheMessages(requestedSeqNum arbutil.M
 		lastCachedSeqNum := firstCachedSeqNum + arbutil.MessageIndex(len(b.messages))
 		if lastCachedSeqNum < requestedSeqNum {
 			// Past end, nothing to return
-			return nil
+			return b.keyRotationMessage()
 		}
 		startingIndex = int32(requestedSeqNum - firstCachedSeqNum)
 		if b.messages[startingIndex].SequenceNumber != requestedSeqNum {
@@ -52,14 +55,25 @@ func (b *SequenceNumberCatchupBuffer) getCacheMessages(requestedSeqNum arbutil.M
 	messagesToSend := b.messages[startingIndex:]
 	if len(messagesToSend) > 0 {
 		bm := BroadcastMessage{
-			Version:  1,
-			Messages: messagesToSend,
+			Version:            1,
+			Messages:           messagesToSend,
+			KeyRotationMessage: b.keyRotation,
 		}
 
 		return &bm
 	}
 
-	return nil
+	return b.keyRotationMessage()
+}
+
+func (b *SequenceNumberCatchupBuffer) keyRotationMessage() *BroadcastMessage {
+	if b.keyRotation == nil {
+		return nil
+	}
+	return &BroadcastMessage{
+		Version:            1,
+		KeyRotationMessage: b.keyRotation,
+	}
 }
 
 func (b *SequenceNumberCatchupBuffer) OnRegisterClient(ctx context.Context, clientConnection *wsbroadcastserver.ClientConnection) error {
@@ -123,6 +137,10 @@ func (b *SequenceNumberCatchupBuffer) OnDoBroadcast(bmi interface{}) error {
 	}
 	defer func() { atomic.StoreInt32(&b.messageCount, int32(len(b.messages))) }()
 
+	if broadcastMessage.KeyRotationMessage != nil {
+		b.keyRotation = broadcastMessage.KeyRotationMessage
+	}
+
 	if confirmMsg := broadcastMessage.ConfirmedSequenceNumberMessage; confirmMsg != nil {
 		b.deleteConfirmed(confirmMsg.SequenceNumber)
 		confirmedSequenceNumberGauge.Update(int64(confirmMsg.SequenceNumber))
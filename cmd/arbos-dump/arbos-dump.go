@@ -0,0 +1,174 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/node"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/offchainlabs/nitro/arbos/arbosState"
+	"github.com/offchainlabs/nitro/cmd/genericconf"
+	"github.com/offchainlabs/nitro/cmd/util/confighelpers"
+)
+
+// ArbosDumpConfig configures dumping the ArbOS state at a block, either from a running
+// node's arbdebug rpc or directly from the chain database of a stopped node.
+type ArbosDumpConfig struct {
+	URL        string                 `koanf:"url"`
+	Chain      string                 `koanf:"chain"`
+	Block      int64                  `koanf:"block"`
+	MaxEntries uint64                 `koanf:"max-entries"`
+	Output     string                 `koanf:"output"`
+	LogLevel   int                    `koanf:"log-level"`
+	ConfConfig genericconf.ConfConfig `koanf:"conf"`
+}
+
+var DefaultArbosDumpConfig = ArbosDumpConfig{
+	URL:        "",
+	Chain:      "",
+	Block:      int64(rpc.LatestBlockNumber),
+	MaxEntries: 10000,
+	Output:     "",
+	LogLevel:   int(log.LvlWarn),
+	ConfConfig: genericconf.ConfConfigDefault,
+}
+
+func main() {
+	if err := dump(os.Args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+}
+
+func printSampleUsage(progname string) {
+	fmt.Printf("\n")
+	fmt.Printf("Sample usage:                  %s --url http://localhost:8547 [--block 1234]\n", progname)
+	fmt.Printf("                               %s --chain <persistent.chain directory> [--block 1234] --output <dump.json>\n", progname)
+}
+
+func parseArbosDumpConfig(args []string) (*ArbosDumpConfig, error) {
+	f := flag.NewFlagSet("arbos-dump", flag.ContinueOnError)
+	f.String("url", DefaultArbosDumpConfig.URL, "rpc url of a running node with the arbdebug api enabled")
+	f.String("chain", DefaultArbosDumpConfig.Chain, "chain directory of a stopped node to read the state from directly, as passed to --persistent.chain")
+	f.Int64("block", DefaultArbosDumpConfig.Block, "block to dump the state at (-1 for the latest)")
+	f.Uint64("max-entries", DefaultArbosDumpConfig.MaxEntries, "maximum number of retryables and address table entries to dump, when reading the database directly")
+	f.String("output", DefaultArbosDumpConfig.Output, "json file to write the dump to (stdout if unset)")
+	f.Int("log-level", DefaultArbosDumpConfig.LogLevel, "log level; 1: ERROR, 2: WARN, 3: INFO, 4: DEBUG, 5: TRACE")
+	genericconf.ConfConfigAddOptions("conf", f)
+
+	k, err := confighelpers.BeginCommonParse(f, args)
+	if err != nil {
+		return nil, err
+	}
+
+	var config ArbosDumpConfig
+	if err := confighelpers.EndCommonParse(k, &config); err != nil {
+		return nil, err
+	}
+	if (config.URL == "") == (config.Chain == "") {
+		return nil, errors.New("exactly one of --url and --chain must be specified")
+	}
+	if config.MaxEntries == 0 {
+		return nil, errors.New("--max-entries must be positive")
+	}
+	return &config, nil
+}
+
+func dump(args []string) error {
+	config, err := parseArbosDumpConfig(args)
+	if err != nil {
+		confighelpers.HandleError(err, printSampleUsage)
+		return nil
+	}
+
+	glogger := log.NewGlogHandler(log.StreamHandler(os.Stderr, log.TerminalFormat(false)))
+	glogger.Verbosity(log.Lvl(config.LogLevel))
+	log.Root().SetHandler(glogger)
+
+	var stateDump *arbosState.StateDump
+	if config.URL != "" {
+		stateDump, err = dumpFromRPC(config)
+	} else {
+		stateDump, err = dumpFromDatabase(config)
+	}
+	if err != nil {
+		return err
+	}
+
+	output := os.Stdout
+	if config.Output != "" {
+		output, err = os.Create(config.Output)
+		if err != nil {
+			return err
+		}
+		defer output.Close()
+	}
+	encoder := json.NewEncoder(output)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(stateDump)
+}
+
+func dumpFromRPC(config *ArbosDumpConfig) (*arbosState.StateDump, error) {
+	client, err := rpc.Dial(config.URL)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+	var stateDump arbosState.StateDump
+	err = client.CallContext(context.Background(), &stateDump, "arbdebug_arbOSState", rpc.BlockNumber(config.Block))
+	return &stateDump, err
+}
+
+// reads the chain database the same way the node does, so it must not be running
+func dumpFromDatabase(config *ArbosDumpConfig) (*arbosState.StateDump, error) {
+	stackConf := node.DefaultConfig
+	stackConf.DataDir = config.Chain
+	stackConf.P2P.ListenAddr = ""
+	stackConf.P2P.NoDial = true
+	stackConf.P2P.NoDiscovery = true
+	stack, err := node.New(&stackConf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the chain directory, is the node still running? %w", err)
+	}
+	defer stack.Close()
+	chainDb, err := stack.OpenDatabaseWithFreezer("l2chaindata", 0, 0, "", "", true)
+	if err != nil {
+		return nil, err
+	}
+	defer chainDb.Close()
+
+	blockHash := rawdb.ReadHeadBlockHash(chainDb)
+	if config.Block >= 0 {
+		blockHash = rawdb.ReadCanonicalHash(chainDb, uint64(config.Block))
+	}
+	blockNumber := rawdb.ReadHeaderNumber(chainDb, blockHash)
+	if blockNumber == nil {
+		return nil, fmt.Errorf("block %v not found", config.Block)
+	}
+	header := rawdb.ReadHeader(chainDb, blockHash, *blockNumber)
+	if header == nil {
+		return nil, fmt.Errorf("header %v not found", blockHash)
+	}
+	statedb, err := state.New(header.Root, state.NewDatabase(chainDb), nil)
+	if err != nil {
+		return nil, fmt.Errorf("state of block %v is unavailable: %w", *blockNumber, err)
+	}
+	arbState, err := arbosState.OpenSystemArbosState(statedb, nil, true)
+	if err != nil {
+		return nil, err
+	}
+	log.Info("dumping ArbOS state", "block", *blockNumber, "hash", blockHash, "root", header.Root)
+	return arbState.Dump(config.MaxEntries)
+}
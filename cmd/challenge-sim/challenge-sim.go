@@ -0,0 +1,173 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/offchainlabs/nitro/cmd/genericconf"
+	"github.com/offchainlabs/nitro/cmd/util/confighelpers"
+	"github.com/offchainlabs/nitro/validator"
+	"github.com/offchainlabs/nitro/validator/challengesim"
+)
+
+// ChallengeSimConfig configures playing an execution challenge on a simulated L1
+// between a machine and a copy of it that goes wrong at a given step.
+type ChallengeSimConfig struct {
+	Wasm            string                 `koanf:"wasm"`
+	Modules         []string               `koanf:"module"`
+	IncorrectStep   uint64                 `koanf:"incorrect-step"`
+	AsserterCorrect bool                   `koanf:"asserter-correct"`
+	MaxInboxMessage uint64                 `koanf:"max-inbox-message"`
+	TargetMachines  int                    `koanf:"target-machines"`
+	MaxMoves        int                    `koanf:"max-moves"`
+	TimeLeft        time.Duration          `koanf:"time-left"`
+	Output          string                 `koanf:"output"`
+	LogLevel        int                    `koanf:"log-level"`
+	ConfConfig      genericconf.ConfConfig `koanf:"conf"`
+}
+
+var DefaultChallengeSimConfig = ChallengeSimConfig{
+	Wasm:            "",
+	Modules:         []string{},
+	IncorrectStep:   200,
+	AsserterCorrect: false,
+	MaxInboxMessage: 0,
+	TargetMachines:  4,
+	MaxMoves:        100,
+	TimeLeft:        time.Hour,
+	Output:          "",
+	LogLevel:        int(log.LvlWarn),
+	ConfConfig:      genericconf.ConfConfigDefault,
+}
+
+func main() {
+	if err := simulate(os.Args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+}
+
+func printSampleUsage(progname string) {
+	fmt.Printf("\n")
+	fmt.Printf("Sample usage:                  %s --wasm <machine.wasm> [--module <library.wasm>] [--incorrect-step 200] \n", progname)
+}
+
+func parseChallengeSimConfig(args []string) (*ChallengeSimConfig, error) {
+	f := flag.NewFlagSet("challenge-sim", flag.ContinueOnError)
+	f.String("wasm", DefaultChallengeSimConfig.Wasm, "wasm binary to execute in the challenge")
+	f.StringSlice("module", DefaultChallengeSimConfig.Modules, "wasm libraries to link the binary with")
+	f.Uint64("incorrect-step", DefaultChallengeSimConfig.IncorrectStep, "step at which the incorrect machine's state diverges")
+	f.Bool("asserter-correct", DefaultChallengeSimConfig.AsserterCorrect, "give the asserter the correct machine, instead of the challenger")
+	f.Uint64("max-inbox-message", DefaultChallengeSimConfig.MaxInboxMessage, "maximum inbox message the machine may read")
+	f.Int("target-machines", DefaultChallengeSimConfig.TargetMachines, "number of machines each participant keeps to speed up bisections")
+	f.Int("max-moves", DefaultChallengeSimConfig.MaxMoves, "give up if the challenge hasn't finished after this many moves")
+	f.Duration("time-left", DefaultChallengeSimConfig.TimeLeft, "time each participant has to make their moves")
+	f.String("output", DefaultChallengeSimConfig.Output, "file to write every move of the challenge to as json")
+	f.Int("log-level", DefaultChallengeSimConfig.LogLevel, "log level; 1: ERROR, 2: WARN, 3: INFO, 4: DEBUG, 5: TRACE")
+	genericconf.ConfConfigAddOptions("conf", f)
+
+	k, err := confighelpers.BeginCommonParse(f, args)
+	if err != nil {
+		return nil, err
+	}
+
+	var config ChallengeSimConfig
+	if err := confighelpers.EndCommonParse(k, &config); err != nil {
+		return nil, err
+	}
+	if config.Wasm == "" {
+		return nil, errors.New("--wasm must be specified")
+	}
+	return &config, nil
+}
+
+func simulate(args []string) error {
+	config, err := parseChallengeSimConfig(args)
+	if err != nil {
+		confighelpers.HandleError(err, printSampleUsage)
+		return nil
+	}
+
+	glogger := log.NewGlogHandler(log.StreamHandler(os.Stderr, log.TerminalFormat(false)))
+	glogger.Verbosity(log.Lvl(config.LogLevel))
+	log.Root().SetHandler(glogger)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	machine, err := validator.LoadSimpleMachine(config.Wasm, config.Modules)
+	if err != nil {
+		return err
+	}
+	incorrectMachine := challengesim.NewIncorrectMachine(machine, config.IncorrectStep)
+
+	sim, err := challengesim.NewSimulation(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to deploy challenge contracts: %w", err)
+	}
+	var asserterMachine, challengerMachine validator.MachineInterface = incorrectMachine, machine.Clone()
+	if config.AsserterCorrect {
+		asserterMachine, challengerMachine = machine.Clone(), incorrectMachine
+	}
+	challenge, err := sim.CreateExecutionChallenge(ctx, asserterMachine, config.MaxInboxMessage, config.TimeLeft)
+	if err != nil {
+		return fmt.Errorf("failed to create challenge: %w", err)
+	}
+	asserter, challenger, err := sim.NewExecutionChallengeManagers(challenge, asserterMachine, challengerMachine, config.TargetMachines)
+	if err != nil {
+		return err
+	}
+	result, err := sim.Play(ctx, challenge, asserter, challenger, config.MaxMoves)
+	if err != nil {
+		return err
+	}
+
+	names := map[common.Address]string{sim.Asserter.From: "asserter", sim.Challenger.From: "challenger"}
+	for _, move := range result.Moves {
+		fmt.Printf("%3d %-10s %-9s %-22s steps %v-%v gas %v", move.Turn, names[move.Participant], move.Mode, move.Method, move.SegmentStart, move.SegmentEnd, move.GasUsed)
+		if move.ProofSize > 0 {
+			fmt.Printf(" proof %v bytes", move.ProofSize)
+		}
+		if move.Error != "" {
+			fmt.Printf(" failed: %v", move.Error)
+		}
+		fmt.Printf("\n")
+	}
+	gasByMethod := result.GasByMethod()
+	methods := make([]string, 0, len(gasByMethod))
+	for method := range gasByMethod {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	for _, method := range methods {
+		if method != "" {
+			fmt.Printf("gas used by %v: %v\n", method, gasByMethod[method])
+		}
+	}
+	fmt.Printf("total gas used: %v\n", result.TotalGas())
+	fmt.Printf("winner: %v\n", names[result.Winner])
+
+	if config.Output != "" {
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(config.Output, data, 0600); err != nil {
+			return err
+		}
+	}
+	return nil
+}
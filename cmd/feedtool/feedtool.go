@@ -0,0 +1,384 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/broadcastclient"
+	"github.com/offchainlabs/nitro/broadcaster"
+	"github.com/offchainlabs/nitro/cmd/genericconf"
+	"github.com/offchainlabs/nitro/cmd/util/confighelpers"
+	"github.com/offchainlabs/nitro/wsbroadcastserver"
+)
+
+func main() {
+	args := os.Args
+	if len(args) < 2 {
+		panic("Usage: feedtool [record|replay] ...")
+	}
+
+	var err error
+	switch strings.ToLower(args[1]) {
+	case "record":
+		err = startRecord(args[2:])
+	case "replay":
+		err = startReplay(args[2:])
+	default:
+		panic(fmt.Sprintf("Unknown tool '%s' specified, valid tools are 'record', 'replay'", args[1]))
+	}
+	if err != nil {
+		panic(err)
+	}
+}
+
+func setupLogging(logLevel int) {
+	glogger := log.NewGlogHandler(log.StreamHandler(os.Stderr, log.TerminalFormat(false)))
+	glogger.Verbosity(log.Lvl(logLevel))
+	log.Root().SetHandler(glogger)
+}
+
+// feedtool record
+
+type RecordConfig struct {
+	ChainId    uint64                      `koanf:"chain-id"`
+	Feed       broadcastclient.Config      `koanf:"feed"`
+	Output     broadcaster.RecordingConfig `koanf:"output"`
+	LogLevel   int                         `koanf:"log-level"`
+	ConfConfig genericconf.ConfConfig      `koanf:"conf"`
+}
+
+func parseRecordConfig(args []string) (*RecordConfig, error) {
+	f := flag.NewFlagSet("feedtool record", flag.ContinueOnError)
+	f.Uint64("chain-id", 0, "L2 chain ID of the feed being recorded")
+	broadcastclient.ConfigAddOptions("feed", f)
+	broadcaster.RecordingConfigAddOptions("output", f)
+	f.Int("log-level", int(log.LvlInfo), "log level; 1: ERROR, 2: WARN, 3: INFO, 4: DEBUG, 5: TRACE")
+	genericconf.ConfConfigAddOptions("conf", f)
+
+	k, err := confighelpers.BeginCommonParse(f, args)
+	if err != nil {
+		return nil, err
+	}
+
+	var config RecordConfig
+	if err := confighelpers.EndCommonParse(k, &config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// feedRecorder writes the messages and signer rotations from every feed being
+// recorded to the recording, once each.
+type feedRecorder struct {
+	writer  *broadcaster.RecordingWriter
+	chainId uint64
+
+	// Protects all fields below
+	mutex         sync.Mutex
+	recorded      map[arbutil.MessageIndex]struct{}
+	latest        arbutil.MessageIndex
+	lastConfirmed arbutil.MessageIndex
+	lastRotation  common.Hash
+}
+
+// feedRecorderWindow is how far behind the latest message a feed can deliver
+// messages and still have them recognized as already recorded.
+const feedRecorderWindow = 1024
+
+func newFeedRecorder(writer *broadcaster.RecordingWriter, chainId uint64) *feedRecorder {
+	return &feedRecorder{
+		writer:   writer,
+		chainId:  chainId,
+		recorded: make(map[arbutil.MessageIndex]struct{}),
+	}
+}
+
+func (r *feedRecorder) AddBroadcastMessages(feedMessages []*broadcaster.BroadcastFeedMessage) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	var newMessages []*broadcaster.BroadcastFeedMessage
+	for _, feedMessage := range feedMessages {
+		seqNum := feedMessage.SequenceNumber
+		if _, ok := r.recorded[seqNum]; ok || seqNum+feedRecorderWindow < r.latest {
+			continue
+		}
+		r.recorded[seqNum] = struct{}{}
+		if seqNum > r.latest {
+			r.latest = seqNum
+		}
+		newMessages = append(newMessages, feedMessage)
+	}
+	if len(r.recorded) > 2*feedRecorderWindow {
+		for seqNum := range r.recorded {
+			if seqNum+feedRecorderWindow < r.latest {
+				delete(r.recorded, seqNum)
+			}
+		}
+	}
+	if len(newMessages) == 0 {
+		return nil
+	}
+	return r.writer.Write(time.Now(), &broadcaster.BroadcastMessage{
+		Version:  1,
+		Messages: newMessages,
+	})
+}
+
+func (r *feedRecorder) recordConfirmed(seqNum arbutil.MessageIndex) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if seqNum <= r.lastConfirmed {
+		return nil
+	}
+	r.lastConfirmed = seqNum
+	return r.writer.Write(time.Now(), &broadcaster.BroadcastMessage{
+		Version:                        1,
+		ConfirmedSequenceNumberMessage: &broadcaster.ConfirmedSequenceNumberMessage{SequenceNumber: seqNum},
+	})
+}
+
+// AddKeyRotation records a signer rotation, so that replays of the recording
+// can be verified across it. Every feed sends the same rotation, and sends it
+// again on reconnect, so it's only recorded when it changes.
+func (r *feedRecorder) AddKeyRotation(rotation *broadcaster.FeedKeyRotationMessage) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	hash := rotation.Hash(r.chainId)
+	if hash == r.lastRotation {
+		return nil
+	}
+	r.lastRotation = hash
+	return r.writer.Write(time.Now(), &broadcaster.BroadcastMessage{
+		Version:            1,
+		KeyRotationMessage: rotation,
+	})
+}
+
+func startRecord(args []string) error {
+	config, err := parseRecordConfig(args)
+	if err != nil {
+		return err
+	}
+	if !config.Feed.Enable() {
+		return errors.New("--feed.url must be specified")
+	}
+	if config.ChainId == 0 {
+		return errors.New("--chain-id must be specified")
+	}
+	setupLogging(config.LogLevel)
+	// The recorder has no L1 connection to look up the sequencer, so unless
+	// signers are configured it records the feed as received without verifying it
+	config.Feed.Verifier.AcceptSequencer = false
+
+	writer, err := broadcaster.NewRecordingWriter(config.Output)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := writer.Close(); err != nil {
+			log.Error("error closing recording", "err", err)
+		}
+	}()
+	recorder := newFeedRecorder(writer, config.ChainId)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	feedErrChan := make(chan error, 10)
+	confirmedChan := make(chan arbutil.MessageIndex, 128)
+	var clients []*broadcastclient.BroadcastClient
+	for _, url := range config.Feed.URLs {
+		client, err := broadcastclient.NewBroadcastClient(config.Feed, url, config.ChainId, 0, recorder, feedErrChan, nil)
+		if err != nil {
+			return err
+		}
+		client.ConfirmedSequenceNumberListener = confirmedChan
+		client.Start(ctx)
+		clients = append(clients, client)
+	}
+	defer func() {
+		for _, client := range clients {
+			client.StopAndWait()
+		}
+	}()
+
+	log.Info("recording sequencer feed", "urls", config.Feed.URLs, "dir", config.Output.Dir)
+
+	sigint := make(chan os.Signal, 1)
+	signal.Notify(sigint, os.Interrupt, syscall.SIGTERM)
+	for {
+		select {
+		case <-sigint:
+			log.Info("shutting down because of sigint")
+			return nil
+		case err := <-feedErrChan:
+			return err
+		case seqNum := <-confirmedChan:
+			if err := recorder.recordConfirmed(seqNum); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// feedtool replay
+
+type ReplayConfig struct {
+	ChainId        uint64                              `koanf:"chain-id"`
+	Input          string                              `koanf:"input"`
+	Speed          float64                             `koanf:"speed"`
+	WaitForClients int                                 `koanf:"wait-for-clients"`
+	Output         wsbroadcastserver.BroadcasterConfig `koanf:"output"`
+	LogLevel       int                                 `koanf:"log-level"`
+	ConfConfig     genericconf.ConfConfig              `koanf:"conf"`
+}
+
+func parseReplayConfig(args []string) (*ReplayConfig, error) {
+	f := flag.NewFlagSet("feedtool replay", flag.ContinueOnError)
+	f.Uint64("chain-id", 0, "L2 chain ID to advertise to connecting clients")
+	f.String("input", broadcaster.DefaultRecordingConfig.Dir, "recording file or directory of recording files to replay")
+	f.Float64("speed", 1, "replay speed relative to the original timing, 0 to replay as fast as possible")
+	f.Int("wait-for-clients", 0, "number of clients to wait for before starting the replay")
+	wsbroadcastserver.BroadcasterConfigAddOptions("output", f)
+	f.Int("log-level", int(log.LvlInfo), "log level; 1: ERROR, 2: WARN, 3: INFO, 4: DEBUG, 5: TRACE")
+	genericconf.ConfConfigAddOptions("conf", f)
+
+	k, err := confighelpers.BeginCommonParse(f, args)
+	if err != nil {
+		return nil, err
+	}
+
+	var config ReplayConfig
+	if err := confighelpers.EndCommonParse(k, &config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+func recordingFiles(input string) ([]string, error) {
+	info, err := os.Stat(input)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{input}, nil
+	}
+	files, err := broadcaster.ListRecordingFiles(input)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no recording files found in %v", input)
+	}
+	return files, nil
+}
+
+func startReplay(args []string) error {
+	config, err := parseReplayConfig(args)
+	if err != nil {
+		return err
+	}
+	if config.ChainId == 0 {
+		return errors.New("--chain-id must be specified")
+	}
+	setupLogging(config.LogLevel)
+
+	files, err := recordingFiles(config.Input)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	feedErrChan := make(chan error, 10)
+	// Recorded messages are already signed, so nothing is signed during replay
+	b := broadcaster.NewBroadcaster(func() *wsbroadcastserver.BroadcasterConfig { return &config.Output }, config.ChainId, feedErrChan, nil)
+	if err := b.Initialize(); err != nil {
+		return err
+	}
+	if err := b.Start(ctx); err != nil {
+		return err
+	}
+	defer b.StopAndWait()
+
+	sigint := make(chan os.Signal, 1)
+	signal.Notify(sigint, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case <-sigint:
+			log.Info("shutting down because of sigint")
+		case err := <-feedErrChan:
+			log.Error("feed error, shutting down", "err", err)
+		}
+		cancel()
+	}()
+
+	log.Info("replaying sequencer feed", "address", b.ListenerAddr(), "files", len(files), "speed", config.Speed)
+	for int(b.ClientCount()) < config.WaitForClients {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+
+	reader := broadcaster.NewRecordingReader(files)
+	defer reader.Close()
+	return replay(ctx, reader, b, config.Speed)
+}
+
+func replay(ctx context.Context, reader *broadcaster.RecordingReader, b *broadcaster.Broadcaster, speed float64) error {
+	var firstRecorded time.Time
+	replayStart := time.Now()
+	count := 0
+	for {
+		recorded, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			log.Info("replay complete", "messages", count)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			firstRecorded = recorded.Timestamp
+		}
+		if speed > 0 {
+			offset := time.Duration(float64(recorded.Timestamp.Sub(firstRecorded)) / speed)
+			wait := time.Until(replayStart.Add(offset))
+			if wait > 0 {
+				select {
+				case <-ctx.Done():
+					return nil
+				case <-time.After(wait):
+				}
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+		}
+
+		b.BroadcastRecorded(&recorded.Message)
+		count++
+	}
+}
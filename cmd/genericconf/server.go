@@ -71,10 +71,15 @@ func HTTPConfigAddOptions(prefix string, f *flag.FlagSet) {
 }
 
 func HTTPServerTimeoutConfigAddOptions(prefix string, f *flag.FlagSet) {
-	f.Duration(prefix+".read-timeout", HTTPServerTimeoutConfigDefault.ReadTimeout, "the maximum duration for reading the entire request (http.Server.ReadTimeout)")
-	f.Duration(prefix+".read-header-timeout", HTTPServerTimeoutConfigDefault.ReadHeaderTimeout, "the amount of time allowed to read the request headers (http.Server.ReadHeaderTimeout)")
-	f.Duration(prefix+".write-timeout", HTTPServerTimeoutConfigDefault.WriteTimeout, "the maximum duration before timing out writes of the response (http.Server.WriteTimeout)")
-	f.Duration(prefix+".idle-timeout", HTTPServerTimeoutConfigDefault.IdleTimeout, "the maximum amount of time to wait for the next request when keep-alives are enabled (http.Server.IdleTimeout)")
+	HTTPServerTimeoutConfigAddOptionsWithDefault(prefix, f, HTTPServerTimeoutConfigDefault)
+}
+
+// HTTPServerTimeoutConfigAddOptionsWithDefault is HTTPServerTimeoutConfigAddOptions for servers with their own defaults
+func HTTPServerTimeoutConfigAddOptionsWithDefault(prefix string, f *flag.FlagSet, defaultConfig HTTPServerTimeoutConfig) {
+	f.Duration(prefix+".read-timeout", defaultConfig.ReadTimeout, "the maximum duration for reading the entire request (http.Server.ReadTimeout)")
+	f.Duration(prefix+".read-header-timeout", defaultConfig.ReadHeaderTimeout, "the amount of time allowed to read the request headers (http.Server.ReadHeaderTimeout)")
+	f.Duration(prefix+".write-timeout", defaultConfig.WriteTimeout, "the maximum duration before timing out writes of the response (http.Server.WriteTimeout)")
+	f.Duration(prefix+".idle-timeout", defaultConfig.IdleTimeout, "the maximum amount of time to wait for the next request when keep-alives are enabled (http.Server.IdleTimeout)")
 }
 
 type WSConfig struct {
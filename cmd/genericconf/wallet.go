@@ -6,6 +6,7 @@ package genericconf
 import (
 	"path"
 	"path/filepath"
+	"time"
 
 	flag "github.com/spf13/pflag"
 )
@@ -13,11 +14,12 @@ import (
 const PASSWORD_NOT_SET = "PASSWORD_NOT_SET"
 
 type WalletConfig struct {
-	Pathname      string `koanf:"pathname"`
-	PasswordImpl  string `koanf:"password"`
-	PrivateKey    string `koanf:"private-key"`
-	Account       string `koanf:"account"`
-	OnlyCreateKey bool   `koanf:"only-create-key"`
+	Pathname       string               `koanf:"pathname"`
+	PasswordImpl   string               `koanf:"password"`
+	PrivateKey     string               `koanf:"private-key"`
+	Account        string               `koanf:"account"`
+	OnlyCreateKey  bool                 `koanf:"only-create-key"`
+	ExternalSigner ExternalSignerConfig `koanf:"external-signer"`
 }
 
 func (w *WalletConfig) Password() *string {
@@ -28,11 +30,12 @@ func (w *WalletConfig) Password() *string {
 }
 
 var WalletConfigDefault = WalletConfig{
-	Pathname:      "",
-	PasswordImpl:  PASSWORD_NOT_SET,
-	PrivateKey:    "",
-	Account:       "",
-	OnlyCreateKey: false,
+	Pathname:       "",
+	PasswordImpl:   PASSWORD_NOT_SET,
+	PrivateKey:     "",
+	Account:        "",
+	OnlyCreateKey:  false,
+	ExternalSigner: ExternalSignerConfigDefault,
 }
 
 func WalletConfigAddOptions(prefix string, f *flag.FlagSet, defaultPathname string) {
@@ -41,6 +44,36 @@ func WalletConfigAddOptions(prefix string, f *flag.FlagSet, defaultPathname stri
 	f.String(prefix+".private-key", WalletConfigDefault.PrivateKey, "private key for wallet")
 	f.String(prefix+".account", WalletConfigDefault.Account, "account to use (default is first account in keystore)")
 	f.Bool(prefix+".only-create-key", WalletConfigDefault.OnlyCreateKey, "if true, creates new key then exits")
+	ExternalSignerConfigAddOptions(prefix+".external-signer", f)
+}
+
+// ExternalSignerConfig configures signing with a remote signer speaking the
+// Clef or Web3Signer JSON-RPC protocol, instead of a local key. Neither of
+// their standard methods signs a raw hash, as eth_sign and account_signData
+// both hash the data with the EIP-191 message prefix first, so signing data
+// such as feed messages needs DataMethod set to a signer method that doesn't.
+type ExternalSignerConfig struct {
+	URL        string        `koanf:"url"`
+	Address    string        `koanf:"address"`
+	Method     string        `koanf:"method"`
+	DataMethod string        `koanf:"data-method"`
+	Timeout    time.Duration `koanf:"timeout"`
+}
+
+var ExternalSignerConfigDefault = ExternalSignerConfig{
+	URL:        "",
+	Address:    "",
+	Method:     "eth_signTransaction",
+	DataMethod: "",
+	Timeout:    10 * time.Second,
+}
+
+func ExternalSignerConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.String(prefix+".url", ExternalSignerConfigDefault.URL, "url of the external signer to use instead of a local key (disabled if empty)")
+	f.String(prefix+".address", ExternalSignerConfigDefault.Address, "address of the account to sign with")
+	f.String(prefix+".method", ExternalSignerConfigDefault.Method, "rpc method to sign transactions with (account_signTransaction for clef)")
+	f.String(prefix+".data-method", ExternalSignerConfigDefault.DataMethod, "rpc method taking an address and a 32 byte hash that signs the hash as is, used to sign data such as feed messages (disabled if empty); eth_sign and clef's account_signData add a message prefix so can't be used")
+	f.Duration(prefix+".timeout", ExternalSignerConfigDefault.Timeout, "timeout for requests to the external signer")
 }
 
 func (w *WalletConfig) ResolveDirectoryNames(chain string) {
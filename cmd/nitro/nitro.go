@@ -173,6 +173,31 @@ func main() {
 		}
 	}
 
+	var retryableKeeperTxOpts *bind.TransactOpts
+	if nodeConfig.Node.RetryableKeeper.Enable {
+		retryableKeeperTxOpts, _, err = util.OpenWallet("node.retryable-keeper", &nodeConfig.Node.RetryableKeeper.Wallet, new(big.Int).SetUint64(nodeConfig.L2.ChainID))
+		if err != nil {
+			fmt.Printf("%v\n", err.Error())
+			return
+		}
+		// Don't pass around wallet contents with normal configuration
+		nodeConfig.Node.RetryableKeeper.Wallet = genericconf.WalletConfigDefault
+	}
+
+	var feedNextSigner signature.DataSignerFunc
+	var feedNextSignerAddress common.Address
+	if nodeConfig.Node.FeedSignerRotation.Enable {
+		var nextSignerOpts *bind.TransactOpts
+		nextSignerOpts, feedNextSigner, err = util.OpenWallet("node.feed-signer-rotation", &nodeConfig.Node.FeedSignerRotation.Wallet, new(big.Int).SetUint64(nodeConfig.L1.ChainID))
+		if err != nil {
+			fmt.Printf("%v\n", err.Error())
+			return
+		}
+		feedNextSignerAddress = nextSignerOpts.From
+		// Don't pass around wallet contents with normal configuration
+		nodeConfig.Node.FeedSignerRotation.Wallet = genericconf.WalletConfigDefault
+	}
+
 	var rollupAddrs arbnode.RollupAddresses
 	if nodeConfig.Node.L1Reader.Enable {
 		log.Info("connected to l1 chain", "l1url", nodeConfig.L1.URL, "l1chainid", l1ChainId)
@@ -307,6 +332,21 @@ func main() {
 	if err != nil {
 		panic(err)
 	}
+	if retryableKeeperTxOpts != nil {
+		currentNode.RetryableKeeper, err = arbnode.NewRetryableKeeper(
+			stack,
+			l2BlockChain,
+			arbDb,
+			retryableKeeperTxOpts,
+			func() *arbnode.RetryableKeeperConfig { return &liveNodeConfig.get().Node.RetryableKeeper },
+		)
+		if err != nil {
+			panic(err)
+		}
+	}
+	if feedNextSigner != nil {
+		currentNode.BroadcastServer.SetNextSigner(feedNextSigner, feedNextSignerAddress)
+	}
 	liveNodeConfig.setOnReloadHook(func(old *NodeConfig, new *NodeConfig) error {
 		return currentNode.OnConfigReload(&old.Node, &new.Node)
 	})
@@ -410,6 +450,7 @@ func (c *NodeConfig) ResolveDirectoryNames() error {
 	}
 	c.L1.ResolveDirectoryNames(c.Persistent.Chain)
 	c.L2.ResolveDirectoryNames(c.Persistent.Chain)
+	c.Node.RetryableKeeper.Wallet.ResolveDirectoryNames(c.Persistent.Chain)
 
 	return nil
 }
@@ -449,6 +490,9 @@ func (c *NodeConfig) CanReload(new *NodeConfig) error {
 }
 
 func (c *NodeConfig) Validate() error {
+	if c.Node.Feed.Output.Enable && c.Node.Feed.Output.Signed && c.L1.Wallet.ExternalSigner.URL != "" && c.L1.Wallet.ExternalSigner.DataMethod == "" {
+		return errors.New("signing the feed with an external signer requires --l1.wallet.external-signer.data-method")
+	}
 	return c.Node.Validate()
 }
 
@@ -598,16 +642,17 @@ func ParseNode(ctx context.Context, args []string) (*NodeConfig, *genericconf.Wa
 		return nil, nil, nil, nil, nil, err
 	}
 
+	err = nodeConfig.Validate()
+	if err != nil {
+		return nil, nil, nil, nil, nil, err
+	}
+
 	// Don't pass around wallet contents with normal configuration
 	l1Wallet := nodeConfig.L1.Wallet
 	l2DevWallet := nodeConfig.L2.DevWallet
 	nodeConfig.L1.Wallet = genericconf.WalletConfigDefault
 	nodeConfig.L2.DevWallet = genericconf.WalletConfigDefault
 
-	err = nodeConfig.Validate()
-	if err != nil {
-		return nil, nil, nil, nil, nil, err
-	}
 	return &nodeConfig, &l1Wallet, &l2DevWallet, l1Client, l1ChainId, nil
 }
 
@@ -0,0 +1,316 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"strconv"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/offchainlabs/nitro/cmd/genericconf"
+	"github.com/offchainlabs/nitro/cmd/util/confighelpers"
+)
+
+// PricingSimConfig configures simulating ArbOS's L1 and L2 pricing models with a set of
+// parameters, over either recorded or synthetic traffic.
+type PricingSimConfig struct {
+	Parameters     PricingParameters      `koanf:"params"`
+	Traffic        string                 `koanf:"traffic"`
+	Reports        string                 `koanf:"reports"`
+	Synthetic      SyntheticTrafficConfig `koanf:"synthetic"`
+	SampleInterval time.Duration          `koanf:"sample-interval"`
+	Output         string                 `koanf:"output"`
+	LogLevel       int                    `koanf:"log-level"`
+	ConfConfig     genericconf.ConfConfig `koanf:"conf"`
+}
+
+var DefaultPricingSimConfig = PricingSimConfig{
+	Parameters:     DefaultPricingParameters,
+	Traffic:        "",
+	Reports:        "",
+	Synthetic:      DefaultSyntheticTrafficConfig,
+	SampleInterval: time.Minute,
+	Output:         "",
+	LogLevel:       int(log.LvlWarn),
+	ConfConfig:     genericconf.ConfConfigDefault,
+}
+
+func main() {
+	if err := simulate(os.Args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+}
+
+func printSampleUsage(progname string) {
+	fmt.Printf("\n")
+	fmt.Printf("Sample usage:                  %s [--traffic <blocks.csv>] [--reports <reports.csv>] [--params.l1-pricing-inertia 10] --output <trajectory.csv>\n", progname)
+}
+
+func pricingParametersAddOptions(prefix string, f *flag.FlagSet) {
+	f.Uint64(prefix+".l1-pricing-inertia", DefaultPricingParameters.L1PricingInertia, "L1 pricing inertia, as set by SetL1PricingInertia")
+	f.Uint64(prefix+".l1-equilibration-units", DefaultPricingParameters.L1EquilibrationUnits, "L1 pricing equilibration units, as set by SetL1PricingEquilibrationUnits")
+	f.Uint64(prefix+".l1-per-unit-reward", DefaultPricingParameters.L1PerUnitReward, "L1 pricing reward per unit, as set by SetL1PricingRewardRate")
+	f.Uint64(prefix+".l1-price-per-unit", DefaultPricingParameters.L1PricePerUnit, "initial L1 price per unit in wei, as set by SetL1PricePerUnit")
+	f.Int64(prefix+".per-batch-gas-cost", DefaultPricingParameters.PerBatchGasCost, "L1 gas charged per batch, as set by SetPerBatchGasCharge")
+	f.Uint64(prefix+".amortized-cost-cap-bips", DefaultPricingParameters.AmortizedCostCapBips, "cap on the amortized cost of a batch in basis points (0 for no cap), as set by SetAmortizedCostCapBips")
+	f.Uint64(prefix+".l2-pricing-inertia", DefaultPricingParameters.L2PricingInertia, "L2 gas pricing inertia, as set by SetL2GasPricingInertia")
+	f.Uint64(prefix+".speed-limit", DefaultPricingParameters.SpeedLimit, "L2 gas per second speed limit, as set by SetSpeedLimit")
+	f.Uint64(prefix+".min-base-fee", DefaultPricingParameters.MinBaseFee, "minimum L2 base fee in wei, as set by SetMinimumL2BaseFee")
+}
+
+func syntheticTrafficConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Duration(prefix+".duration", DefaultSyntheticTrafficConfig.Duration, "length of the generated traffic")
+	f.Duration(prefix+".block-time", DefaultSyntheticTrafficConfig.BlockTime, "time between generated blocks")
+	f.Uint64(prefix+".gas-per-second", DefaultSyntheticTrafficConfig.GasPerSecond, "average L2 gas used per second")
+	f.Uint64(prefix+".l1-units-per-second", DefaultSyntheticTrafficConfig.L1UnitsPerSecond, "average L1 calldata units charged per second")
+	f.Float64(prefix+".variation", DefaultSyntheticTrafficConfig.Variation, "fraction traffic varies from the average by over each period")
+	f.Duration(prefix+".variation-period", DefaultSyntheticTrafficConfig.VariationPeriod, "period of the traffic and L1 base fee variation")
+	f.Duration(prefix+".report-interval", DefaultSyntheticTrafficConfig.ReportInterval, "time between generated batches, when reports aren't read from a file")
+	f.Duration(prefix+".report-delay", DefaultSyntheticTrafficConfig.ReportDelay, "time between a generated batch and its posting report")
+	f.Float64(prefix+".l1-base-fee-gwei", DefaultSyntheticTrafficConfig.L1BaseFeeGwei, "average L1 base fee of generated batches")
+	f.Float64(prefix+".l1-base-fee-variation", DefaultSyntheticTrafficConfig.L1BaseFeeVariation, "fraction the L1 base fee varies from the average by over each period")
+}
+
+func parsePricingSimConfig(args []string) (*PricingSimConfig, error) {
+	f := flag.NewFlagSet("pricing-sim", flag.ContinueOnError)
+	pricingParametersAddOptions("params", f)
+	f.String("traffic", DefaultPricingSimConfig.Traffic, "csv file of recorded blocks with timestamp, gas-used and l1-units columns (generates synthetic traffic if unset)")
+	f.String("reports", DefaultPricingSimConfig.Reports, "csv file of batch posting reports with batch-timestamp, report-timestamp, batch-data-gas and l1-base-fee columns (generates reports from the traffic if unset)")
+	syntheticTrafficConfigAddOptions("synthetic", f)
+	f.Duration("sample-interval", DefaultPricingSimConfig.SampleInterval, "minimum time between output rows (0 for every block)")
+	f.String("output", DefaultPricingSimConfig.Output, "csv file to write the pricing trajectories to (stdout if unset)")
+	f.Int("log-level", DefaultPricingSimConfig.LogLevel, "log level; 1: ERROR, 2: WARN, 3: INFO, 4: DEBUG, 5: TRACE")
+	genericconf.ConfConfigAddOptions("conf", f)
+
+	k, err := confighelpers.BeginCommonParse(f, args)
+	if err != nil {
+		return nil, err
+	}
+
+	var config PricingSimConfig
+	if err := confighelpers.EndCommonParse(k, &config); err != nil {
+		return nil, err
+	}
+	if err := config.Parameters.Validate(); err != nil {
+		return nil, err
+	}
+	if err := config.Synthetic.Validate(); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+func simulate(args []string) error {
+	config, err := parsePricingSimConfig(args)
+	if err != nil {
+		confighelpers.HandleError(err, printSampleUsage)
+		return nil
+	}
+
+	glogger := log.NewGlogHandler(log.StreamHandler(os.Stderr, log.TerminalFormat(false)))
+	glogger.Verbosity(log.Lvl(config.LogLevel))
+	log.Root().SetHandler(glogger)
+
+	var blocks []trafficBlock
+	if config.Traffic != "" {
+		blocks, err = readTraffic(config.Traffic)
+		if err != nil {
+			return err
+		}
+	} else {
+		blocks = config.Synthetic.blocks()
+	}
+	var reports []batchPostingReport
+	if config.Reports != "" {
+		reports, err = readReports(config.Reports)
+		if err != nil {
+			return err
+		}
+	} else {
+		reports = config.Synthetic.reports(blocks)
+	}
+	log.Info("simulating pricing", "blocks", len(blocks), "reports", len(reports))
+
+	samples, err := simulatePricing(&config.Parameters, blocks, reports)
+	if err != nil {
+		return err
+	}
+	samples = thinSamples(samples, uint64(config.SampleInterval/time.Second))
+
+	output := os.Stdout
+	if config.Output != "" {
+		output, err = os.Create(config.Output)
+		if err != nil {
+			return err
+		}
+		defer output.Close()
+	}
+	return writeSamples(output, samples)
+}
+
+// thinSamples keeps samples at least interval seconds apart, along with the last one.
+func thinSamples(samples []pricingSample, interval uint64) []pricingSample {
+	if interval == 0 || len(samples) == 0 {
+		return samples
+	}
+	var thinned []pricingSample
+	for i, sample := range samples {
+		if len(thinned) == 0 || sample.Timestamp >= thinned[len(thinned)-1].Timestamp+interval || i == len(samples)-1 {
+			thinned = append(thinned, sample)
+		}
+	}
+	return thinned
+}
+
+var sampleColumns = []string{
+	"timestamp",
+	"base-fee",
+	"gas-backlog",
+	"l1-price-per-unit",
+	"l1-units-since-update",
+	"l1-surplus",
+	"l1-fees-collected",
+	"poster-spent",
+	"poster-reimbursed",
+	"poster-funds-due",
+	"rewards-paid",
+}
+
+func writeSamples(w io.Writer, samples []pricingSample) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(sampleColumns); err != nil {
+		return err
+	}
+	for _, sample := range samples {
+		record := []string{
+			strconv.FormatUint(sample.Timestamp, 10),
+			sample.BaseFee.String(),
+			strconv.FormatUint(sample.GasBacklog, 10),
+			sample.L1PricePerUnit.String(),
+			strconv.FormatUint(sample.L1UnitsSinceUpdate, 10),
+			sample.L1Surplus.String(),
+			sample.L1FeesCollected.String(),
+			sample.PosterSpent.String(),
+			sample.PosterReimbursed.String(),
+			sample.PosterFundsDue.String(),
+			sample.RewardsPaid.String(),
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// readColumns reads a csv file with a header row, returning the named columns of each record.
+func readColumns(path string, columns []string) ([][]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	reader := csv.NewReader(file)
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header of %v: %w", path, err)
+	}
+	indices := make([]int, len(columns))
+	for i, column := range columns {
+		indices[i] = -1
+		for j, name := range header {
+			if name == column {
+				indices[i] = j
+			}
+		}
+		if indices[i] < 0 {
+			return nil, fmt.Errorf("%v has no %v column", path, column)
+		}
+	}
+	var rows [][]string
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		row := make([]string, len(columns))
+		for i, index := range indices {
+			row[i] = record[index]
+		}
+		rows = append(rows, row)
+	}
+}
+
+func parseUints(row []string) ([]uint64, error) {
+	values := make([]uint64, len(row))
+	for i, field := range row {
+		value, err := strconv.ParseUint(field, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+func readTraffic(path string) ([]trafficBlock, error) {
+	rows, err := readColumns(path, []string{"timestamp", "gas-used", "l1-units"})
+	if err != nil {
+		return nil, err
+	}
+	blocks := make([]trafficBlock, 0, len(rows))
+	for i, row := range rows {
+		values, err := parseUints(row)
+		if err != nil {
+			return nil, fmt.Errorf("invalid traffic in row %v: %w", i+1, err)
+		}
+		blocks = append(blocks, trafficBlock{
+			Timestamp: values[0],
+			GasUsed:   values[1],
+			L1Units:   values[2],
+		})
+	}
+	return blocks, nil
+}
+
+func readReports(path string) ([]batchPostingReport, error) {
+	rows, err := readColumns(path, []string{"batch-timestamp", "report-timestamp", "batch-data-gas", "l1-base-fee"})
+	if err != nil {
+		return nil, err
+	}
+	reports := make([]batchPostingReport, 0, len(rows))
+	for i, row := range rows {
+		values, err := parseUints(row[:3])
+		if err != nil {
+			return nil, fmt.Errorf("invalid report in row %v: %w", i+1, err)
+		}
+		l1BaseFee, ok := new(big.Int).SetString(row[3], 10)
+		if !ok || l1BaseFee.Sign() < 0 {
+			return nil, fmt.Errorf("invalid l1 base fee in row %v: %v", i+1, row[3])
+		}
+		if i > 0 && values[1] < reports[i-1].ReportTimestamp {
+			return nil, fmt.Errorf("reports aren't ordered by report timestamp at row %v", i+1)
+		}
+		reports = append(reports, batchPostingReport{
+			BatchTimestamp:  values[0],
+			ReportTimestamp: values[1],
+			BatchDataGas:    values[2],
+			L1BaseFee:       l1BaseFee,
+		})
+	}
+	return reports, nil
+}
@@ -0,0 +1,374 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+
+	"github.com/offchainlabs/nitro/arbos/arbosState"
+	"github.com/offchainlabs/nitro/arbos/l1pricing"
+	"github.com/offchainlabs/nitro/arbos/l2pricing"
+	"github.com/offchainlabs/nitro/arbos/util"
+	"github.com/offchainlabs/nitro/util/arbmath"
+)
+
+// PricingParameters are the chain owner settable parameters the simulation is run with.
+type PricingParameters struct {
+	L1PricingInertia     uint64 `koanf:"l1-pricing-inertia"`
+	L1EquilibrationUnits uint64 `koanf:"l1-equilibration-units"`
+	L1PerUnitReward      uint64 `koanf:"l1-per-unit-reward"`
+	L1PricePerUnit       uint64 `koanf:"l1-price-per-unit"`
+	PerBatchGasCost      int64  `koanf:"per-batch-gas-cost"`
+	AmortizedCostCapBips uint64 `koanf:"amortized-cost-cap-bips"`
+	L2PricingInertia     uint64 `koanf:"l2-pricing-inertia"`
+	SpeedLimit           uint64 `koanf:"speed-limit"`
+	MinBaseFee           uint64 `koanf:"min-base-fee"`
+}
+
+var DefaultPricingParameters = PricingParameters{
+	L1PricingInertia:     l1pricing.InitialInertia,
+	L1EquilibrationUnits: l1pricing.InitialEquilibrationUnitsV6.Uint64(),
+	L1PerUnitReward:      l1pricing.InitialPerUnitReward,
+	L1PricePerUnit:       l1pricing.InitialPricePerUnitWei,
+	PerBatchGasCost:      l1pricing.InitialPerBatchGasCostV6,
+	AmortizedCostCapBips: 0,
+	L2PricingInertia:     l2pricing.InitialPricingInertia,
+	SpeedLimit:           l2pricing.InitialSpeedLimitPerSecondV6,
+	MinBaseFee:           l2pricing.InitialMinimumBaseFeeWei,
+}
+
+func (p *PricingParameters) Validate() error {
+	if p.L1PricingInertia == 0 || p.L2PricingInertia == 0 {
+		return errors.New("pricing inertia must be non-zero")
+	}
+	if p.L1EquilibrationUnits == 0 {
+		return errors.New("l1 equilibration units must be non-zero")
+	}
+	if p.SpeedLimit == 0 {
+		return errors.New("speed limit must be non-zero")
+	}
+	return nil
+}
+
+func (p *PricingParameters) apply(l1p *l1pricing.L1PricingState, l2p *l2pricing.L2PricingState) error {
+	if err := l1p.SetInertia(p.L1PricingInertia); err != nil {
+		return err
+	}
+	if err := l1p.SetEquilibrationUnits(arbmath.UintToBig(p.L1EquilibrationUnits)); err != nil {
+		return err
+	}
+	if err := l1p.SetPerUnitReward(p.L1PerUnitReward); err != nil {
+		return err
+	}
+	if err := l1p.SetPricePerUnit(arbmath.UintToBig(p.L1PricePerUnit)); err != nil {
+		return err
+	}
+	if err := l1p.SetPerBatchGasCost(p.PerBatchGasCost); err != nil {
+		return err
+	}
+	if err := l1p.SetAmortizedCostCapBips(p.AmortizedCostCapBips); err != nil {
+		return err
+	}
+	if err := l2p.SetPricingInertia(p.L2PricingInertia); err != nil {
+		return err
+	}
+	if err := l2p.SetSpeedLimitPerSecond(p.SpeedLimit); err != nil {
+		return err
+	}
+	if err := l2p.SetMinBaseFeeWei(arbmath.UintToBig(p.MinBaseFee)); err != nil {
+		return err
+	}
+	return l2p.SetBaseFeeWei(arbmath.UintToBig(p.MinBaseFee))
+}
+
+// The L2 traffic in a block: the gas it used and the L1 calldata units it was charged for.
+type trafficBlock struct {
+	Timestamp uint64
+	GasUsed   uint64
+	L1Units   uint64
+}
+
+// A batch posting report, which is processed in the first block at or after its report timestamp.
+type batchPostingReport struct {
+	BatchTimestamp  uint64
+	ReportTimestamp uint64
+	BatchDataGas    uint64
+	L1BaseFee       *big.Int
+}
+
+// The pricing state after a block.
+type pricingSample struct {
+	Timestamp          uint64
+	BaseFee            *big.Int
+	GasBacklog         uint64
+	L1PricePerUnit     *big.Int
+	L1UnitsSinceUpdate uint64
+	L1Surplus          *big.Int
+	L1FeesCollected    *big.Int // cumulative
+	PosterSpent        *big.Int // cumulative
+	PosterReimbursed   *big.Int // cumulative
+	PosterFundsDue     *big.Int
+	RewardsPaid        *big.Int // cumulative
+}
+
+var (
+	simPosterPayTo       = common.HexToAddress("0x0000000000000000000000000000000000001001")
+	simRewardsRecipient  = common.HexToAddress("0x0000000000000000000000000000000000001002")
+	errTrafficNotOrdered = errors.New("traffic isn't ordered by timestamp")
+)
+
+// simulatePricing drives ArbOS's L1 and L2 pricing models over memory-backed storage
+// with the given traffic and batch posting reports, returning the state after each block.
+func simulatePricing(parameters *PricingParameters, blocks []trafficBlock, reports []batchPostingReport) ([]pricingSample, error) {
+	if err := parameters.Validate(); err != nil {
+		return nil, err
+	}
+	arbState, statedb := arbosState.NewArbosMemoryBackedArbOSState()
+	l1p := arbState.L1PricingState()
+	l2p := arbState.L2PricingState()
+	if err := parameters.apply(l1p, l2p); err != nil {
+		return nil, err
+	}
+	poster, err := l1p.BatchPosterTable().OpenPoster(l1pricing.BatchPosterAddress, true)
+	if err != nil {
+		return nil, err
+	}
+	if err := poster.SetPayTo(simPosterPayTo); err != nil {
+		return nil, err
+	}
+	if err := l1p.SetPayRewardsTo(simRewardsRecipient); err != nil {
+		return nil, err
+	}
+	version := arbState.FormatVersion()
+	blockContext := vm.BlockContext{
+		BlockNumber: new(big.Int),
+		GasLimit:    math.MaxUint64,
+		Time:        new(big.Int),
+	}
+	evm := vm.NewEVM(blockContext, vm.TxContext{}, statedb, params.ArbitrumDevTestChainConfig(), vm.Config{})
+
+	samples := make([]pricingSample, 0, len(blocks))
+	feesCollected := new(big.Int)
+	posterSpent := new(big.Int)
+	nextReport := 0
+	for i, block := range blocks {
+		timePassed := uint64(0)
+		if i > 0 {
+			if block.Timestamp < blocks[i-1].Timestamp {
+				return nil, fmt.Errorf("%w: block %v at %v", errTrafficNotOrdered, i, block.Timestamp)
+			}
+			timePassed = block.Timestamp - blocks[i-1].Timestamp
+		}
+		evm.Context.BlockNumber = arbmath.UintToBig(uint64(i))
+		evm.Context.Time = arbmath.UintToBig(block.Timestamp)
+
+		// the start of the block updates the L2 base fee, then processes any batch posting reports
+		l2BaseFee, err := l2p.BaseFeeWei()
+		if err != nil {
+			return nil, err
+		}
+		l2p.UpdatePricingModel(l2BaseFee, timePassed, false)
+		baseFee, err := l2p.BaseFeeWei()
+		if err != nil {
+			return nil, err
+		}
+		for ; nextReport < len(reports) && reports[nextReport].ReportTimestamp <= block.Timestamp; nextReport++ {
+			report := reports[nextReport]
+			perBatchGas, err := l1p.PerBatchGasCost()
+			if err != nil {
+				return nil, err
+			}
+			gasSpent := arbmath.SaturatingAdd(perBatchGas, arbmath.SaturatingCast(report.BatchDataGas))
+			weiSpent := arbmath.BigMulByUint(report.L1BaseFee, arbmath.SaturatingUCast(gasSpent))
+			posterSpent = arbmath.BigAdd(posterSpent, weiSpent)
+			err = l1p.UpdateForBatchPosterSpending(
+				statedb,
+				evm,
+				version,
+				report.BatchTimestamp,
+				block.Timestamp,
+				l1pricing.BatchPosterAddress,
+				weiSpent,
+				report.L1BaseFee,
+				util.TracingBeforeEVM,
+			)
+			if err != nil {
+				log.Warn("L1Pricing UpdateForSequencerSpending failed", "batchTimestamp", report.BatchTimestamp, "err", err)
+			}
+		}
+
+		// the block's transactions pay for their L1 calldata and use up L2 gas
+		pricePerUnit, err := l1p.PricePerUnit()
+		if err != nil {
+			return nil, err
+		}
+		fee := arbmath.BigMulByUint(pricePerUnit, block.L1Units)
+		statedb.AddBalance(l1pricing.L1PricerFundsPoolAddress, fee)
+		feesCollected = arbmath.BigAdd(feesCollected, fee)
+		if err := l1p.AddToUnitsSinceUpdate(block.L1Units); err != nil {
+			return nil, err
+		}
+		if err := l2p.AddToGasPool(-arbmath.SaturatingCast(block.GasUsed)); err != nil {
+			return nil, err
+		}
+
+		sample, err := samplePricing(statedb, l1p, l2p, poster)
+		if err != nil {
+			return nil, err
+		}
+		sample.Timestamp = block.Timestamp
+		sample.BaseFee = baseFee
+		sample.L1FeesCollected = feesCollected
+		sample.PosterSpent = posterSpent
+		samples = append(samples, sample)
+	}
+	if nextReport < len(reports) {
+		log.Warn("batch posting reports after the last block weren't processed", "count", len(reports)-nextReport)
+	}
+	return samples, nil
+}
+
+func samplePricing(
+	statedb *state.StateDB, l1p *l1pricing.L1PricingState, l2p *l2pricing.L2PricingState, poster *l1pricing.BatchPosterState,
+) (pricingSample, error) {
+	gasBacklog, err := l2p.GasBacklog()
+	if err != nil {
+		return pricingSample{}, err
+	}
+	pricePerUnit, err := l1p.PricePerUnit()
+	if err != nil {
+		return pricingSample{}, err
+	}
+	unitsSinceUpdate, err := l1p.UnitsSinceUpdate()
+	if err != nil {
+		return pricingSample{}, err
+	}
+	totalFundsDue, err := l1p.BatchPosterTable().TotalFundsDue()
+	if err != nil {
+		return pricingSample{}, err
+	}
+	fundsDueForRewards, err := l1p.FundsDueForRewards()
+	if err != nil {
+		return pricingSample{}, err
+	}
+	posterFundsDue, err := poster.FundsDue()
+	if err != nil {
+		return pricingSample{}, err
+	}
+	// the surplus as computed by UpdateForBatchPosterSpending
+	poolBalance := statedb.GetBalance(l1pricing.L1PricerFundsPoolAddress)
+	surplus := arbmath.BigSub(poolBalance, arbmath.BigAdd(totalFundsDue, fundsDueForRewards))
+	return pricingSample{
+		GasBacklog:         gasBacklog,
+		L1PricePerUnit:     pricePerUnit,
+		L1UnitsSinceUpdate: unitsSinceUpdate,
+		L1Surplus:          surplus,
+		PosterReimbursed:   new(big.Int).Set(statedb.GetBalance(simPosterPayTo)),
+		PosterFundsDue:     posterFundsDue,
+		RewardsPaid:        new(big.Int).Set(statedb.GetBalance(simRewardsRecipient)),
+	}, nil
+}
+
+// SyntheticTrafficConfig describes generated traffic, which follows a sine wave around its average,
+// and how batch posting reports are generated when they aren't read from a file.
+type SyntheticTrafficConfig struct {
+	Duration           time.Duration `koanf:"duration"`
+	BlockTime          time.Duration `koanf:"block-time"`
+	GasPerSecond       uint64        `koanf:"gas-per-second"`
+	L1UnitsPerSecond   uint64        `koanf:"l1-units-per-second"`
+	Variation          float64       `koanf:"variation"`
+	VariationPeriod    time.Duration `koanf:"variation-period"`
+	ReportInterval     time.Duration `koanf:"report-interval"`
+	ReportDelay        time.Duration `koanf:"report-delay"`
+	L1BaseFeeGwei      float64       `koanf:"l1-base-fee-gwei"`
+	L1BaseFeeVariation float64       `koanf:"l1-base-fee-variation"`
+}
+
+var DefaultSyntheticTrafficConfig = SyntheticTrafficConfig{
+	Duration:           24 * time.Hour,
+	BlockTime:          time.Second,
+	GasPerSecond:       5_000_000,
+	L1UnitsPerSecond:   20_000,
+	Variation:          0.5,
+	VariationPeriod:    24 * time.Hour,
+	ReportInterval:     10 * time.Minute,
+	ReportDelay:        time.Minute,
+	L1BaseFeeGwei:      30,
+	L1BaseFeeVariation: 0,
+}
+
+func (c *SyntheticTrafficConfig) Validate() error {
+	if c.BlockTime < time.Second || c.ReportInterval < time.Second {
+		return errors.New("synthetic block time and report interval must be at least a second")
+	}
+	if c.Variation < 0 || c.Variation > 1 || c.L1BaseFeeVariation < 0 || c.L1BaseFeeVariation > 1 {
+		return errors.New("synthetic variations must be between 0 and 1")
+	}
+	return nil
+}
+
+// The factor traffic is scaled by at a given time.
+func (c *SyntheticTrafficConfig) scale(variation float64, timestamp uint64) float64 {
+	if c.VariationPeriod <= 0 {
+		return 1
+	}
+	return 1 + variation*math.Sin(2*math.Pi*float64(timestamp)/c.VariationPeriod.Seconds())
+}
+
+// The first block's timestamp, as the L1 pricer treats a last update time of 0 as there not being one.
+const syntheticStartTime = 1
+
+func (c *SyntheticTrafficConfig) blocks() []trafficBlock {
+	blockTime := uint64(c.BlockTime / time.Second)
+	count := uint64(c.Duration / c.BlockTime)
+	blocks := make([]trafficBlock, 0, count)
+	for i := uint64(0); i < count; i++ {
+		timestamp := syntheticStartTime + i*blockTime
+		scale := c.scale(c.Variation, timestamp)
+		blocks = append(blocks, trafficBlock{
+			Timestamp: timestamp,
+			GasUsed:   uint64(float64(c.GasPerSecond*blockTime) * scale),
+			L1Units:   uint64(float64(c.L1UnitsPerSecond*blockTime) * scale),
+		})
+	}
+	return blocks
+}
+
+// reports posts a batch every report interval with the L1 units of the blocks since the last one.
+func (c *SyntheticTrafficConfig) reports(blocks []trafficBlock) []batchPostingReport {
+	if len(blocks) == 0 {
+		return nil
+	}
+	interval := uint64(c.ReportInterval / time.Second)
+	delay := uint64(c.ReportDelay / time.Second)
+	var reports []batchPostingReport
+	nextBatch := blocks[0].Timestamp + interval
+	units := uint64(0)
+	for _, block := range blocks {
+		if block.Timestamp >= nextBatch {
+			baseFee := c.L1BaseFeeGwei * c.scale(c.L1BaseFeeVariation, block.Timestamp) * params.GWei
+			l1BaseFee, _ := big.NewFloat(baseFee).Int(nil)
+			reports = append(reports, batchPostingReport{
+				BatchTimestamp:  block.Timestamp,
+				ReportTimestamp: block.Timestamp + delay,
+				BatchDataGas:    units,
+				L1BaseFee:       l1BaseFee,
+			})
+			units = 0
+			nextBatch = block.Timestamp + interval
+		}
+		units = arbmath.SaturatingUAdd(units, block.L1Units)
+	}
+	return reports
+}
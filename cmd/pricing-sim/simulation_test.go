@@ -0,0 +1,125 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/params"
+
+	"github.com/offchainlabs/nitro/util/arbmath"
+)
+
+func TestL1PriceFollowsL1BaseFee(t *testing.T) {
+	for _, l1BaseFeeGwei := range []float64{5, 200} {
+		synthetic := DefaultSyntheticTrafficConfig
+		synthetic.Duration = 6 * time.Hour
+		synthetic.Variation = 0
+		synthetic.L1BaseFeeGwei = l1BaseFeeGwei
+		blocks := synthetic.blocks()
+		reports := synthetic.reports(blocks)
+		if len(reports) == 0 {
+			t.Fatal("no reports generated")
+		}
+		samples, err := simulatePricing(&DefaultPricingParameters, blocks, reports)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(samples) != len(blocks) {
+			t.Fatal("expected a sample per block, got", len(samples))
+		}
+		initialPrice := arbmath.UintToBig(DefaultPricingParameters.L1PricePerUnit)
+		l1BaseFee := arbmath.UintToBig(uint64(l1BaseFeeGwei * params.GWei))
+		final := samples[len(samples)-1]
+		expectedMovement := arbmath.BigSub(l1BaseFee, initialPrice).Sign()
+		if arbmath.BigSub(final.L1PricePerUnit, initialPrice).Sign() != expectedMovement {
+			t.Fatal("L1 price moved the wrong way", l1BaseFeeGwei, final.L1PricePerUnit)
+		}
+		if final.PosterSpent.Sign() <= 0 || final.PosterReimbursed.Sign() <= 0 || final.L1FeesCollected.Sign() <= 0 {
+			t.Fatal("poster wasn't reimbursed", final.PosterSpent, final.PosterReimbursed, final.L1FeesCollected)
+		}
+		if arbmath.BigGreaterThan(final.PosterReimbursed, final.PosterSpent) {
+			t.Fatal("poster reimbursed more than it spent", final.PosterReimbursed, final.PosterSpent)
+		}
+	}
+}
+
+func TestL2BaseFeeRisesAboveSpeedLimit(t *testing.T) {
+	parameters := DefaultPricingParameters
+	minBaseFee := arbmath.UintToBig(parameters.MinBaseFee)
+	for _, overSpeedLimit := range []bool{false, true} {
+		synthetic := DefaultSyntheticTrafficConfig
+		synthetic.Duration = time.Hour
+		synthetic.Variation = 0
+		synthetic.GasPerSecond = parameters.SpeedLimit / 2
+		if overSpeedLimit {
+			synthetic.GasPerSecond = parameters.SpeedLimit * 2
+		}
+		blocks := synthetic.blocks()
+		samples, err := simulatePricing(&parameters, blocks, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		final := samples[len(samples)-1]
+		if arbmath.BigGreaterThan(final.BaseFee, minBaseFee) != overSpeedLimit {
+			t.Fatal("unexpected base fee", overSpeedLimit, final.BaseFee, final.GasBacklog)
+		}
+	}
+
+	// a higher inertia should slow the base fee's rise
+	synthetic := DefaultSyntheticTrafficConfig
+	synthetic.Duration = time.Hour
+	synthetic.Variation = 0
+	synthetic.GasPerSecond = parameters.SpeedLimit * 2
+	blocks := synthetic.blocks()
+	samples, err := simulatePricing(&parameters, blocks, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parameters.L2PricingInertia *= 4
+	slowSamples, err := simulatePricing(&parameters, blocks, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fast, slow := samples[len(samples)-1].BaseFee, slowSamples[len(slowSamples)-1].BaseFee
+	if !arbmath.BigLessThan(slow, fast) {
+		t.Fatal("higher inertia didn't slow the base fee", slow, fast)
+	}
+}
+
+func TestWriteSamples(t *testing.T) {
+	synthetic := DefaultSyntheticTrafficConfig
+	synthetic.Duration = 30 * time.Minute
+	blocks := synthetic.blocks()
+	samples, err := simulatePricing(&DefaultPricingParameters, blocks, synthetic.reports(blocks))
+	if err != nil {
+		t.Fatal(err)
+	}
+	thinned := thinSamples(samples, 60)
+	if len(thinned) != 31 {
+		t.Fatal("unexpected number of thinned samples", len(thinned))
+	}
+	var output bytes.Buffer
+	if err := writeSamples(&output, thinned); err != nil {
+		t.Fatal(err)
+	}
+	records, err := csv.NewReader(&output).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != len(thinned)+1 || len(records[0]) != len(sampleColumns) {
+		t.Fatal("unexpected csv shape", len(records), len(records[0]))
+	}
+	last := records[len(records)-1]
+	if last[1] != thinned[len(thinned)-1].BaseFee.String() {
+		t.Fatal("unexpected base fee in csv", last[1])
+	}
+	if _, ok := new(big.Int).SetString(last[5], 10); !ok {
+		t.Fatal("invalid surplus in csv", last[5])
+	}
+}
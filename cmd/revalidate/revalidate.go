@@ -0,0 +1,133 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/offchainlabs/nitro/arbnode"
+	"github.com/offchainlabs/nitro/cmd/genericconf"
+	"github.com/offchainlabs/nitro/cmd/util/confighelpers"
+	"github.com/offchainlabs/nitro/validator"
+)
+
+// RevalidateConfig configures re-executing validation bundles written by a node,
+// checking the end state of each against the one the node expected.
+type RevalidateConfig struct {
+	Bundles      []string               `koanf:"bundle"`
+	Jit          bool                   `koanf:"jit"`
+	JitCranelift bool                   `koanf:"jit-cranelift"`
+	Wasm         arbnode.WasmConfig     `koanf:"wasm"`
+	LogLevel     int                    `koanf:"log-level"`
+	ConfConfig   genericconf.ConfConfig `koanf:"conf"`
+}
+
+var DefaultRevalidateConfig = RevalidateConfig{
+	Bundles:      []string{},
+	Jit:          true,
+	JitCranelift: validator.DefaultBlockValidatorConfig.JitValidatorCranelift,
+	Wasm:         arbnode.DefaultWasmConfig,
+	LogLevel:     int(log.LvlInfo),
+	ConfConfig:   genericconf.ConfConfigDefault,
+}
+
+func main() {
+	if err := revalidate(os.Args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+}
+
+func printSampleUsage(progname string) {
+	fmt.Printf("\n")
+	fmt.Printf("Sample usage:                  %s --bundle <bundle file> [--jit=false] \n", progname)
+}
+
+func parseRevalidateConfig(args []string) (*RevalidateConfig, error) {
+	f := flag.NewFlagSet("revalidate", flag.ContinueOnError)
+	f.StringSlice("bundle", DefaultRevalidateConfig.Bundles, "validation bundle files to re-validate")
+	f.Bool("jit", DefaultRevalidateConfig.Jit, "use the jit-accelerated machine, instead of the complete arbitrator machine")
+	f.Bool("jit-cranelift", DefaultRevalidateConfig.JitCranelift, "use Cranelift instead of LLVM when validating using the jit-accelerated machine")
+	arbnode.WasmConfigAddOptions("wasm", f)
+	f.Int("log-level", DefaultRevalidateConfig.LogLevel, "log level; 1: ERROR, 2: WARN, 3: INFO, 4: DEBUG, 5: TRACE")
+	genericconf.ConfConfigAddOptions("conf", f)
+
+	k, err := confighelpers.BeginCommonParse(f, args)
+	if err != nil {
+		return nil, err
+	}
+
+	var config RevalidateConfig
+	if err := confighelpers.EndCommonParse(k, &config); err != nil {
+		return nil, err
+	}
+	if len(config.Bundles) == 0 {
+		return nil, errors.New("--bundle must be specified")
+	}
+	return &config, nil
+}
+
+func revalidate(args []string) error {
+	config, err := parseRevalidateConfig(args)
+	if err != nil {
+		confighelpers.HandleError(err, printSampleUsage)
+		return nil
+	}
+
+	glogger := log.NewGlogHandler(log.StreamHandler(os.Stderr, log.TerminalFormat(false)))
+	glogger.Verbosity(log.Lvl(config.LogLevel))
+	log.Root().SetHandler(glogger)
+
+	machinesPath, foundMachines := config.Wasm.FindMachineDir()
+	if !foundMachines {
+		return fmt.Errorf("failed to find machines %v", machinesPath)
+	}
+	fatalErrChan := make(chan error, 10)
+	machineConfig := validator.DefaultNitroMachineConfig
+	machineConfig.RootPath = machinesPath
+	machineConfig.JitCranelift = config.JitCranelift
+	loader := validator.NewNitroMachineLoader(machineConfig, fatalErrChan)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case err := <-fatalErrChan:
+			log.Error("machine failed", "err", err)
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	mismatches := 0
+	for _, path := range config.Bundles {
+		bundle, err := validator.ReadValidationBundle(path)
+		if err != nil {
+			return err
+		}
+		before := time.Now()
+		gsEnd, err := bundle.Execute(ctx, loader, config.Jit)
+		if err != nil {
+			return fmt.Errorf("failed to execute bundle %v: %w", path, err)
+		}
+		if gsEnd != bundle.ExpectedEnd {
+			mismatches++
+			fmt.Printf("MISMATCH %v: block %v module root %v got %v expected %v\n", path, bundle.BlockNumber, bundle.ModuleRoot, gsEnd, bundle.ExpectedEnd)
+			continue
+		}
+		fmt.Printf("OK %v: block %v module root %v end state %v (%v)\n", path, bundle.BlockNumber, bundle.ModuleRoot, gsEnd, time.Since(before))
+	}
+	if mismatches > 0 {
+		return fmt.Errorf("%v of %v bundles did not match the expected end state", mismatches, len(config.Bundles))
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package util
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/offchainlabs/nitro/cmd/genericconf"
+	"github.com/offchainlabs/nitro/util/signature"
+)
+
+// externalSignerTxArgs is the transaction object accepted by both Clef's
+// account_signTransaction and eth_signTransaction.
+type externalSignerTxArgs struct {
+	From                 common.Address    `json:"from"`
+	To                   *common.Address   `json:"to"`
+	Gas                  hexutil.Uint64    `json:"gas"`
+	GasPrice             *hexutil.Big      `json:"gasPrice,omitempty"`
+	MaxFeePerGas         *hexutil.Big      `json:"maxFeePerGas,omitempty"`
+	MaxPriorityFeePerGas *hexutil.Big      `json:"maxPriorityFeePerGas,omitempty"`
+	Value                *hexutil.Big      `json:"value"`
+	Nonce                hexutil.Uint64    `json:"nonce"`
+	Data                 hexutil.Bytes     `json:"data"`
+	AccessList           *types.AccessList `json:"accessList,omitempty"`
+	ChainID              *hexutil.Big      `json:"chainId,omitempty"`
+}
+
+func newExternalSignerTxArgs(from common.Address, tx *types.Transaction) *externalSignerTxArgs {
+	args := &externalSignerTxArgs{
+		From:    from,
+		To:      tx.To(),
+		Gas:     hexutil.Uint64(tx.Gas()),
+		Value:   (*hexutil.Big)(tx.Value()),
+		Nonce:   hexutil.Uint64(tx.Nonce()),
+		Data:    tx.Data(),
+		ChainID: (*hexutil.Big)(tx.ChainId()),
+	}
+	if tx.Type() == types.LegacyTxType {
+		args.GasPrice = (*hexutil.Big)(tx.GasPrice())
+	} else {
+		args.MaxFeePerGas = (*hexutil.Big)(tx.GasFeeCap())
+		args.MaxPriorityFeePerGas = (*hexutil.Big)(tx.GasTipCap())
+		accessList := tx.AccessList()
+		args.AccessList = &accessList
+	}
+	return args
+}
+
+// externalSigner signs with a remote Clef or Web3Signer compatible service.
+type externalSigner struct {
+	client  *rpc.Client
+	address common.Address
+	config  *genericconf.ExternalSignerConfig
+}
+
+func newExternalSigner(ctx context.Context, config *genericconf.ExternalSignerConfig) (*externalSigner, error) {
+	if !common.IsHexAddress(config.Address) {
+		return nil, fmt.Errorf("external signer address is invalid: \"%s\"", config.Address)
+	}
+	client, err := rpc.DialContext(ctx, config.URL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to external signer: %w", err)
+	}
+	return &externalSigner{
+		client:  client,
+		address: common.HexToAddress(config.Address),
+		config:  config,
+	}, nil
+}
+
+// signTransaction has the remote signer sign tx, and checks it signed exactly tx.
+func (s *externalSigner) signTransaction(ctx context.Context, signer types.Signer, tx *types.Transaction) (*types.Transaction, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.config.Timeout)
+	defer cancel()
+	var result json.RawMessage
+	if err := s.client.CallContext(ctx, &result, s.config.Method, newExternalSignerTxArgs(s.address, tx)); err != nil {
+		return nil, fmt.Errorf("external signer failed to sign transaction: %w", err)
+	}
+	// eth_signTransaction returns the raw transaction, Clef an object containing it
+	var raw hexutil.Bytes
+	if err := json.Unmarshal(result, &raw); err != nil {
+		var object struct {
+			Raw hexutil.Bytes `json:"raw"`
+		}
+		if err := json.Unmarshal(result, &object); err != nil {
+			return nil, fmt.Errorf("unexpected external signer response: %w", err)
+		}
+		raw = object.Raw
+	}
+	signedTx := new(types.Transaction)
+	if err := signedTx.UnmarshalBinary(raw); err != nil {
+		return nil, fmt.Errorf("external signer returned an invalid transaction: %w", err)
+	}
+	if signer.Hash(signedTx) != signer.Hash(tx) {
+		return nil, errors.New("external signer signed a different transaction than requested")
+	}
+	sender, err := types.Sender(signer, signedTx)
+	if err != nil {
+		return nil, err
+	}
+	if sender != s.address {
+		return nil, fmt.Errorf("external signer signed transaction with %v instead of %v", sender, s.address)
+	}
+	return signedTx, nil
+}
+
+// signData has the remote signer sign the hash data, and checks it signed
+// without a message prefix, as expected by signature verifiers.
+func (s *externalSigner) signData(ctx context.Context, data []byte) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.config.Timeout)
+	defer cancel()
+	var sig hexutil.Bytes
+	if err := s.client.CallContext(ctx, &sig, s.config.DataMethod, s.address, hexutil.Bytes(data)); err != nil {
+		return nil, fmt.Errorf("external signer failed to sign data: %w", err)
+	}
+	if len(sig) != crypto.SignatureLength {
+		return nil, fmt.Errorf("external signer returned a signature of length %v", len(sig))
+	}
+	sig = append([]byte{}, sig...)
+	// Signers return the recovery id as 27 or 28, but crypto.Sign gives 0 or 1
+	if sig[crypto.RecoveryIDOffset] >= 27 {
+		sig[crypto.RecoveryIDOffset] -= 27
+	}
+	pubkey, err := crypto.SigToPub(data, sig)
+	if err != nil {
+		return nil, err
+	}
+	if crypto.PubkeyToAddress(*pubkey) != s.address {
+		return nil, errors.New("external signer signature doesn't match address; it must sign the hash without a message prefix")
+	}
+	return sig, nil
+}
+
+func (s *externalSigner) transactOpts(chainId *big.Int) *bind.TransactOpts {
+	signer := types.LatestSignerForChainID(chainId)
+	return &bind.TransactOpts{
+		From: s.address,
+		Signer: func(address common.Address, tx *types.Transaction) (*types.Transaction, error) {
+			if address != s.address {
+				return nil, bind.ErrNotAuthorized
+			}
+			return s.signTransaction(context.Background(), signer, tx)
+		},
+		Context: context.Background(),
+	}
+}
+
+func (s *externalSigner) dataSigner() signature.DataSignerFunc {
+	return func(data []byte) ([]byte, error) {
+		return s.signData(context.Background(), data)
+	}
+}
+
+// openExternalSigner returns a nil data signer unless the config sets a method
+// to sign raw hashes with.
+func openExternalSigner(config *genericconf.ExternalSignerConfig, chainId *big.Int) (*bind.TransactOpts, signature.DataSignerFunc, error) {
+	s, err := newExternalSigner(context.Background(), config)
+	if err != nil {
+		return nil, nil, err
+	}
+	var txOpts *bind.TransactOpts
+	if chainId != nil {
+		txOpts = s.transactOpts(chainId)
+	}
+	var dataSigner signature.DataSignerFunc
+	if config.DataMethod != "" {
+		dataSigner = s.dataSigner()
+	}
+	return txOpts, dataSigner, nil
+}
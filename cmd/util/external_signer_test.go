@@ -0,0 +1,160 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package util
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/offchainlabs/nitro/cmd/genericconf"
+)
+
+// mockSigner serves eth_signTransaction and eth_sign like Web3Signer.
+type mockSigner struct {
+	key     *ecdsa.PrivateKey
+	chainId *big.Int
+	// if set, signs data with the personal message prefix, which is rejected
+	prefixData bool
+}
+
+func (s *mockSigner) SignTransaction(args externalSignerTxArgs) (hexutil.Bytes, error) {
+	var inner types.TxData
+	if args.MaxFeePerGas != nil {
+		inner = &types.DynamicFeeTx{
+			ChainID:   s.chainId,
+			Nonce:     uint64(args.Nonce),
+			GasTipCap: args.MaxPriorityFeePerGas.ToInt(),
+			GasFeeCap: args.MaxFeePerGas.ToInt(),
+			Gas:       uint64(args.Gas),
+			To:        args.To,
+			Value:     args.Value.ToInt(),
+			Data:      args.Data,
+		}
+	} else {
+		inner = &types.LegacyTx{
+			Nonce:    uint64(args.Nonce),
+			GasPrice: args.GasPrice.ToInt(),
+			Gas:      uint64(args.Gas),
+			To:       args.To,
+			Value:    args.Value.ToInt(),
+			Data:     args.Data,
+		}
+	}
+	tx, err := types.SignNewTx(s.key, types.LatestSignerForChainID(s.chainId), inner)
+	if err != nil {
+		return nil, err
+	}
+	return tx.MarshalBinary()
+}
+
+func (s *mockSigner) Sign(address common.Address, data hexutil.Bytes) (hexutil.Bytes, error) {
+	if s.prefixData {
+		data = accounts.TextHash(data)
+	}
+	sig, err := crypto.Sign(data, s.key)
+	if err != nil {
+		return nil, err
+	}
+	sig[crypto.RecoveryIDOffset] += 27
+	return sig, nil
+}
+
+func startMockSigner(t *testing.T, signer *mockSigner) string {
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", signer); err != nil {
+		t.Fatal(err)
+	}
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(httpServer.Close)
+	t.Cleanup(server.Stop)
+	return httpServer.URL
+}
+
+func TestExternalSigner(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := crypto.PubkeyToAddress(key.PublicKey)
+	chainId := big.NewInt(1337)
+	mock := &mockSigner{key: key, chainId: chainId}
+
+	walletConfig := genericconf.WalletConfigDefault
+	walletConfig.ExternalSigner.URL = startMockSigner(t, mock)
+	walletConfig.ExternalSigner.Address = address.Hex()
+	_, dataSigner, err := OpenWallet("test", &walletConfig, chainId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dataSigner != nil {
+		t.Fatal("got a data signer without a data method")
+	}
+	walletConfig.ExternalSigner.DataMethod = "eth_sign"
+	txOpts, dataSigner, err := OpenWallet("test", &walletConfig, chainId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if txOpts.From != address {
+		t.Fatalf("transact opts from %v, expected %v", txOpts.From, address)
+	}
+
+	to := common.HexToAddress("0x1234")
+	tx := types.NewTx(&types.DynamicFeeTx{
+		ChainID:   chainId,
+		Nonce:     3,
+		GasTipCap: big.NewInt(1e9),
+		GasFeeCap: big.NewInt(2e9),
+		Gas:       100_000,
+		To:        &to,
+		Value:     big.NewInt(5),
+		Data:      []byte{1, 2, 3},
+	})
+	signedTx, err := txOpts.Signer(address, tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer := types.LatestSignerForChainID(chainId)
+	sender, err := types.Sender(signer, signedTx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sender != address || signedTx.Nonce() != 3 || signedTx.Value().Cmp(big.NewInt(5)) != 0 {
+		t.Fatal("unexpected signed transaction", sender, signedTx.Nonce(), signedTx.Value())
+	}
+	if _, err := txOpts.Signer(to, tx); err == nil {
+		t.Fatal("signed transaction for another address")
+	}
+
+	hash := crypto.Keccak256([]byte("some data"))
+	sig, err := dataSigner(hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubkey, err := crypto.SigToPub(hash, sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if crypto.PubkeyToAddress(*pubkey) != address {
+		t.Fatal("data signature doesn't recover to signer address")
+	}
+
+	mock.prefixData = true
+	if _, err := dataSigner(hash); err == nil {
+		t.Fatal("accepted signature over prefixed message")
+	}
+
+	walletConfig.PrivateKey = "0x1234"
+	if _, _, err := OpenWallet("test", &walletConfig, chainId); err == nil {
+		t.Fatal("accepted both a private key and an external signer")
+	}
+}
@@ -22,6 +22,12 @@ import (
 )
 
 func OpenWallet(description string, walletConfig *genericconf.WalletConfig, chainId *big.Int) (*bind.TransactOpts, signature.DataSignerFunc, error) {
+	if walletConfig.ExternalSigner.URL != "" {
+		if walletConfig.PrivateKey != "" {
+			return nil, nil, fmt.Errorf("--%s.wallet.private-key and --%s.wallet.external-signer.url are mutually exclusive", description, description)
+		}
+		return openExternalSigner(&walletConfig.ExternalSigner, chainId)
+	}
 	if walletConfig.PrivateKey != "" {
 		privateKey, err := crypto.HexToECDSA(walletConfig.PrivateKey)
 		if err != nil {
@@ -0,0 +1,156 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/metrics/exp"
+
+	"github.com/offchainlabs/nitro/arbnode"
+	"github.com/offchainlabs/nitro/cmd/genericconf"
+	"github.com/offchainlabs/nitro/cmd/util/confighelpers"
+	"github.com/offchainlabs/nitro/validator"
+)
+
+type WorkerConfig struct {
+	Addr                string                              `koanf:"addr"`
+	Port                uint64                              `koanf:"port"`
+	ServerTimeouts      genericconf.HTTPServerTimeoutConfig `koanf:"server-timeouts"`
+	ConcurrentRunsLimit int                                 `koanf:"concurrent-runs-limit"`
+	JitCranelift        bool                                `koanf:"jit-cranelift"`
+	Wasm                arbnode.WasmConfig                  `koanf:"wasm"`
+
+	ConfConfig genericconf.ConfConfig `koanf:"conf"`
+	LogLevel   int                    `koanf:"log-level"`
+
+	Metrics       bool                            `koanf:"metrics"`
+	MetricsServer genericconf.MetricsServerConfig `koanf:"metrics-server"`
+}
+
+// Validations take much longer than a typical RPC request
+var workerServerTimeoutsDefault = func() genericconf.HTTPServerTimeoutConfig {
+	timeouts := genericconf.HTTPServerTimeoutConfigDefault
+	timeouts.WriteTimeout = 20 * time.Minute
+	return timeouts
+}()
+
+var DefaultWorkerConfig = WorkerConfig{
+	Addr:                "localhost",
+	Port:                9645,
+	ServerTimeouts:      workerServerTimeoutsDefault,
+	ConcurrentRunsLimit: 0,
+	JitCranelift:        validator.DefaultBlockValidatorConfig.JitValidatorCranelift,
+	Wasm:                arbnode.DefaultWasmConfig,
+	ConfConfig:          genericconf.ConfConfigDefault,
+	LogLevel:            3,
+	Metrics:             false,
+	MetricsServer:       genericconf.MetricsServerConfigDefault,
+}
+
+func main() {
+	if err := startup(); err != nil {
+		log.Error("Error running validation worker", "err", err)
+	}
+}
+
+func printSampleUsage(progname string) {
+	fmt.Printf("\n")
+	fmt.Printf("Sample usage:                  %s --help \n", progname)
+}
+
+func parseWorkerConfig(args []string) (*WorkerConfig, error) {
+	f := flag.NewFlagSet("validation-worker", flag.ContinueOnError)
+	f.String("addr", DefaultWorkerConfig.Addr, "HTTP-RPC server listening interface")
+	f.Uint64("port", DefaultWorkerConfig.Port, "HTTP-RPC server listening port")
+	genericconf.HTTPServerTimeoutConfigAddOptionsWithDefault("server-timeouts", f, DefaultWorkerConfig.ServerTimeouts)
+	f.Int("concurrent-runs-limit", DefaultWorkerConfig.ConcurrentRunsLimit, "maximum number of validations to run at once, 0 for the number of CPUs")
+	f.Bool("jit-cranelift", DefaultWorkerConfig.JitCranelift, "use Cranelift instead of LLVM when validating blocks using the jit-accelerated validator")
+	arbnode.WasmConfigAddOptions("wasm", f)
+
+	f.Bool("metrics", DefaultWorkerConfig.Metrics, "enable metrics")
+	genericconf.MetricsServerAddOptions("metrics-server", f)
+
+	f.Int("log-level", int(log.LvlInfo), "log level; 1: ERROR, 2: WARN, 3: INFO, 4: DEBUG, 5: TRACE")
+	genericconf.ConfConfigAddOptions("conf", f)
+
+	k, err := confighelpers.BeginCommonParse(f, args)
+	if err != nil {
+		return nil, err
+	}
+
+	var workerConfig WorkerConfig
+	if err := confighelpers.EndCommonParse(k, &workerConfig); err != nil {
+		return nil, err
+	}
+	return &workerConfig, nil
+}
+
+func startup() error {
+	workerConfig, err := parseWorkerConfig(os.Args[1:])
+	if err != nil {
+		confighelpers.HandleError(err, printSampleUsage)
+		return nil
+	}
+
+	glogger := log.NewGlogHandler(log.StreamHandler(os.Stderr, log.TerminalFormat(false)))
+	glogger.Verbosity(log.Lvl(workerConfig.LogLevel))
+	log.Root().SetHandler(glogger)
+
+	if workerConfig.Metrics {
+		if len(workerConfig.MetricsServer.Addr) == 0 {
+			fmt.Printf("Metrics is enabled, but missing --metrics-server.addr")
+			return nil
+		}
+
+		go metrics.CollectProcessMetrics(workerConfig.MetricsServer.UpdateInterval)
+
+		address := fmt.Sprintf("%v:%v", workerConfig.MetricsServer.Addr, workerConfig.MetricsServer.Port)
+		exp.Setup(address)
+	}
+
+	machinesPath, foundMachines := workerConfig.Wasm.FindMachineDir()
+	if !foundMachines {
+		return fmt.Errorf("failed to find machines %v", machinesPath)
+	}
+	fatalErrChan := make(chan error, 10)
+	nitroMachineConfig := validator.DefaultNitroMachineConfig
+	nitroMachineConfig.RootPath = machinesPath
+	nitroMachineConfig.JitCranelift = workerConfig.JitCranelift
+	nitroMachineLoader := validator.NewNitroMachineLoader(nitroMachineConfig, fatalErrChan)
+
+	sigint := make(chan os.Signal, 1)
+	signal.Notify(sigint, os.Interrupt, syscall.SIGTERM)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	vcsRevision, vcsTime := confighelpers.GetVersion()
+	log.Info("Starting validation worker", "addr", workerConfig.Addr, "port", workerConfig.Port, "machines", machinesPath, "revision", vcsRevision, "vcs.time", vcsTime)
+	api := validator.NewValidationServerAPI(nitroMachineLoader, workerConfig.ConcurrentRunsLimit)
+	server, err := validator.StartValidationServer(ctx, workerConfig.Addr, workerConfig.Port, workerConfig.ServerTimeouts, api)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case <-sigint:
+		log.Info("shutting down because of sigint")
+	case err = <-fatalErrChan:
+		log.Error("shutting down because of fatal error", "err", err)
+	}
+	if shutdownErr := server.Shutdown(ctx); shutdownErr != nil {
+		return shutdownErr
+	}
+	return err
+}
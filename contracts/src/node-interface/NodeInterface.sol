@@ -32,6 +32,43 @@ interface NodeInterface {
         bytes calldata data
     ) external;
 
+    /**
+     * @notice Estimates the parameters and total cost of a deposit made via Inbox.createRetryableTicket
+     * @dev Use eth_call to call.
+     *      The submission fee is based on ArbOS's estimate of the L1 base fee, which the L1 inbox checks against
+     *      the actual L1 base fee, so callers expecting L1 prices to move should choose the buffer accordingly.
+     * @param sender unaliased sender of the L1 and L2 transaction
+     * @param to destination L2 contract address
+     * @param l2CallValue call value for retryable L2 message
+     * @param excessFeeRefundAddress gasLimit x maxFeePerGas - execution cost gets credited here on L2 balance
+     * @param callValueRefundAddress l2Callvalue gets credited here on L2 if retryable txn times out or gets cancelled
+     * @param data ABI encoded data of L2 message
+     * @param feeBufferBips how much to pad the submission fee and maxFeePerGas by, in basis points
+     * @return maxSubmissionCost the submission fee to pass to createRetryableTicket, including the buffer
+     * @return maxFeePerGas the L2 gas price bid to pass to createRetryableTicket, including the buffer
+     * @return gasLimit the L2 gas needed to auto-redeem the retryable
+     * @return deposit the total ETH to send on L1, covering the submission fee, call value and L2 gas
+     * @return l1BaseFeeEstimate ArbOS's l1 estimate of the l1 base fee
+     */
+    function estimateRetryableDeposit(
+        address sender,
+        address to,
+        uint256 l2CallValue,
+        address excessFeeRefundAddress,
+        address callValueRefundAddress,
+        bytes calldata data,
+        uint64 feeBufferBips
+    )
+        external
+        view
+        returns (
+            uint256 maxSubmissionCost,
+            uint256 maxFeePerGas,
+            uint64 gasLimit,
+            uint256 deposit,
+            uint256 l1BaseFeeEstimate
+        );
+
     /**
      * @notice Constructs an outbox proof of an l2->l1 send's existence in the outbox accumulator.
      * @dev Use eth_call to call.
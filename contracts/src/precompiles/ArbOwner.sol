@@ -81,6 +81,31 @@ interface ArbOwner {
     /// @notice Sets the cost amortization cap in basis points
     function setAmortizedCostCapBips(uint64 cap) external;
 
+    /// @notice Sets how long, in seconds, calls to this precompile are delayed before taking effect.
+    /// While the delay is nonzero, state-changing calls are scheduled rather than executed, and execute
+    /// automatically at the first block after the delay has passed. Setting a delay of 0 disables this.
+    /// The delay can be at most 30 days.
+    /// Available in ArbOS version 8
+    function setOwnerActionDelay(uint64 delay) external;
+
+    /// @notice Cancels a scheduled owner action before it executes. This call is never delayed.
+    /// Available in ArbOS version 8
+    function cancelOwnerAction(uint64 id) external;
+
     // Emitted when a successful call is made to this precompile
     event OwnerActs(bytes4 indexed method, address indexed owner, bytes data);
+
+    // Emitted when a call to this precompile is delayed, to execute at the given timestamp
+    event OwnerActionScheduled(
+        uint64 indexed id,
+        address indexed owner,
+        uint64 timestamp,
+        bytes data
+    );
+
+    // Emitted when a scheduled owner action is canceled
+    event OwnerActionCanceled(uint64 indexed id, address indexed canceler);
+
+    // Emitted when a scheduled owner action is executed, whether or not it succeeded
+    event OwnerActionExecuted(uint64 indexed id, bool success);
 }
@@ -18,4 +18,26 @@ interface ArbOwnerPublic {
 
     /// @notice Get the infrastructure fee collector
     function getInfraFeeAccount() external view returns (address);
+
+    /// @notice Gets how long, in seconds, chain owner actions are delayed before taking effect
+    /// Available in ArbOS version 8
+    function getOwnerActionDelay() external view returns (uint64);
+
+    /// @notice Retrieves the ids of the scheduled chain owner actions, in the order they'll execute
+    /// Available in ArbOS version 8
+    function getScheduledOwnerActions() external view returns (uint64[] memory);
+
+    /// @notice Gets a scheduled chain owner action, reverting if it has executed or been canceled
+    /// Available in ArbOS version 8
+    /// @return owner the chain owner that scheduled the action
+    /// @return timestamp when the action will execute
+    /// @return data the calldata of the delayed call to ArbOwner
+    function getScheduledOwnerAction(uint64 id)
+        external
+        view
+        returns (
+            address owner,
+            uint64 timestamp,
+            bytes memory data
+        );
 }
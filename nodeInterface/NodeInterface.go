@@ -10,6 +10,7 @@ import (
 	"math/big"
 	"sort"
 
+	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/arbitrum"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
@@ -47,6 +48,7 @@ type NodeInterface struct {
 }
 
 var merkleTopic common.Hash
+var nodeInterfaceAbi *abi.ABI
 var l2ToL1TxTopic common.Hash
 var l2ToL1TransactionTopic common.Hash
 
@@ -160,6 +162,72 @@ func (n NodeInterface) EstimateRetryableTicket(
 	return err
 }
 
+func (n NodeInterface) EstimateRetryableDeposit(
+	c ctx,
+	evm mech,
+	sender addr,
+	to addr,
+	l2CallValue huge,
+	excessFeeRefundAddress addr,
+	callValueRefundAddress addr,
+	data []byte,
+	feeBufferBips uint64,
+) (huge, huge, uint64, huge, huge, error) {
+	node, err := arbNodeFromNodeInterfaceBackend(n.backend)
+	if err != nil {
+		return nil, nil, 0, nil, nil, err
+	}
+	l1BaseFeeEstimate, err := c.State.L1PricingState().PricePerUnit()
+	if err != nil {
+		return nil, nil, 0, nil, nil, err
+	}
+	baseFee, err := c.State.L2PricingState().BaseFeeWei()
+	if err != nil {
+		return nil, nil, 0, nil, nil, err
+	}
+
+	// The submission fee is charged at the L1 base fee when the deposit lands, and the auto-redeem
+	// at the L2 base fee when it executes, so both are padded to allow for prices rising meanwhile
+	buffer := arbmath.Bips(arbmath.SaturatingAdd(int64(arbmath.SaturatingCastToBips(feeBufferBips)), int64(arbmath.OneInBips)))
+	submissionFee := retryables.RetryableSubmissionFee(len(data), l1BaseFeeEstimate)
+	maxSubmissionFee := arbmath.BigMulByBips(submissionFee, buffer)
+	maxFeePerGas := arbmath.BigMulByBips(baseFee, buffer)
+
+	// Estimate the auto-redeem the same way eth_estimateGas does for estimateRetryableTicket.
+	// A zero fee cap is permitted in gas estimation mode, so the deposit need only cover the call value.
+	estimateData, err := nodeInterfaceAbi.Pack(
+		"estimateRetryableTicket",
+		sender,
+		arbmath.BigAdd(submissionFee, l2CallValue),
+		to,
+		l2CallValue,
+		excessFeeRefundAddress,
+		callValueRefundAddress,
+		data,
+	)
+	if err != nil {
+		return nil, nil, 0, nil, nil, err
+	}
+	from := n.sourceMessage.From()
+	nodeInterfaceAddress := types.NodeInterfaceAddress
+	args := arbitrum.TransactionArgs{
+		ChainID: (*hexutil.Big)(evm.ChainConfig().ChainID),
+		From:    &from,
+		To:      &nodeInterfaceAddress,
+		Data:    (*hexutil.Bytes)(&estimateData),
+	}
+	backend := node.Backend.APIBackend()
+	block := rpc.BlockNumberOrHashWithHash(n.header.Hash(), false)
+	gasLimit, err := arbitrum.EstimateGas(n.context, backend, args, block, backend.RPCGasCap())
+	if err != nil {
+		return nil, nil, 0, nil, nil, err
+	}
+
+	deposit := arbmath.BigAdd(maxSubmissionFee, l2CallValue)
+	deposit.Add(deposit, arbmath.BigMulByUint(maxFeePerGas, uint64(gasLimit)))
+	return maxSubmissionFee, maxFeePerGas, uint64(gasLimit), deposit, l1BaseFeeEstimate, nil
+}
+
 func (n NodeInterface) ConstructOutboxProof(c ctx, evm mech, size, leaf uint64) (bytes32, bytes32, []bytes32, error) {
 
 	hash0 := bytes32{}
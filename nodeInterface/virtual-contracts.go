@@ -167,6 +167,11 @@ func init() {
 	l2ToL1TxTopic = arbSys.Events["L2ToL1Tx"].ID
 	l2ToL1TransactionTopic = arbSys.Events["L2ToL1Transaction"].ID
 	merkleTopic = arbSys.Events["SendMerkleUpdate"].ID
+
+	nodeInterfaceAbi, err = nodeInterfaceMeta.GetAbi()
+	if err != nil {
+		panic(err)
+	}
 }
 
 func arbNodeFromNodeInterfaceBackend(backend BackendAPI) (*arbnode.Node, error) {
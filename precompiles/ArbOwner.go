@@ -18,6 +18,13 @@ type ArbOwner struct {
 	Address          addr // 0x70
 	OwnerActs        func(ctx, mech, bytes4, addr, []byte) error
 	OwnerActsGasCost func(bytes4, addr, []byte) (uint64, error)
+
+	OwnerActionScheduled        func(ctx, mech, uint64, addr, uint64, []byte) error
+	OwnerActionScheduledGasCost func(uint64, addr, uint64, []byte) (uint64, error)
+	OwnerActionCanceled         func(ctx, mech, uint64, addr) error
+	OwnerActionCanceledGasCost  func(uint64, addr) (uint64, error)
+	OwnerActionExecuted         func(ctx, mech, uint64, bool) error
+	OwnerActionExecutedGasCost  func(uint64, bool) (uint64, error)
 }
 
 var (
@@ -135,3 +142,21 @@ func (con ArbOwner) SetPerBatchGasCharge(c ctx, evm mech, cost int64) error {
 func (con ArbOwner) SetAmortizedCostCapBips(c ctx, evm mech, cap uint64) error {
 	return c.State.L1PricingState().SetAmortizedCostCapBips(cap)
 }
+
+// Sets how long calls to this precompile are delayed before taking effect, or 0 to not delay them.
+// The delay can be at most 30 days.
+func (con ArbOwner) SetOwnerActionDelay(c ctx, evm mech, delay uint64) error {
+	return c.State.OwnerTimelock().SetDelay(delay)
+}
+
+// Cancels a scheduled owner action before it executes
+func (con ArbOwner) CancelOwnerAction(c ctx, evm mech, id uint64) error {
+	canceled, err := c.State.OwnerTimelock().Cancel(id)
+	if err != nil {
+		return err
+	}
+	if !canceled {
+		return errors.New("no scheduled owner action with that id")
+	}
+	return con.OwnerActionCanceled(c, evm, id, c.caller)
+}
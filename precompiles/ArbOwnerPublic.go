@@ -4,6 +4,8 @@
 package precompiles
 
 import (
+	"errors"
+
 	"github.com/ethereum/go-ethereum/common"
 )
 
@@ -36,3 +38,33 @@ func (con ArbOwnerPublic) GetInfraFeeAccount(c ctx, evm mech) (addr, error) {
 	}
 	return c.State.InfraFeeAccount()
 }
+
+// Gets how long chain owner actions are delayed before taking effect
+func (con ArbOwnerPublic) GetOwnerActionDelay(c ctx, evm mech) (uint64, error) {
+	return c.State.OwnerTimelock().Delay()
+}
+
+// Retrieves the ids of the scheduled chain owner actions, in the order they'll execute
+func (con ArbOwnerPublic) GetScheduledOwnerActions(c ctx, evm mech) ([]uint64, error) {
+	pending, err := c.State.OwnerTimelock().Pending()
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uint64, len(pending))
+	for i, action := range pending {
+		ids[i] = action.Id
+	}
+	return ids, nil
+}
+
+// Gets a scheduled chain owner action
+func (con ArbOwnerPublic) GetScheduledOwnerAction(c ctx, evm mech, id uint64) (addr, uint64, []byte, error) {
+	action, err := c.State.OwnerTimelock().Action(id)
+	if err != nil {
+		return addr{}, 0, nil, err
+	}
+	if action == nil {
+		return addr{}, 0, nil, errors.New("no scheduled owner action with that id")
+	}
+	return action.Owner, action.Timestamp, action.Data, nil
+}
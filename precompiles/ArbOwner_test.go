@@ -4,12 +4,19 @@
 package precompiles
 
 import (
+	"math/big"
 	"testing"
 
 	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/params"
 
+	"github.com/offchainlabs/nitro/arbos"
 	"github.com/offchainlabs/nitro/arbos/arbosState"
 	"github.com/offchainlabs/nitro/arbos/burn"
+	"github.com/offchainlabs/nitro/solgen/go/precompilesgen"
 	"github.com/offchainlabs/nitro/util/testhelpers"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -145,3 +152,135 @@ func TestArbInfraFeeAccount(t *testing.T) {
 		t.Fatal()
 	}
 }
+
+func TestArbOwnerTimelock(t *testing.T) {
+	// start on a v7 chain, which predates owner action delays
+	chainConfig := params.ArbitrumDevTestChainConfig()
+	chainConfig.ArbitrumChainParams.InitialArbOSVersion = 7
+	statedb, err := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	Require(t, err)
+	arbState, err := arbosState.InitializeArbosState(statedb, burn.NewSystemBurner(nil, false), chainConfig)
+	Require(t, err)
+	blockContext := vm.BlockContext{
+		BlockNumber: big.NewInt(0),
+		GasLimit:    ^uint64(0),
+		Time:        big.NewInt(1000),
+	}
+	evm := vm.NewEVM(blockContext, vm.TxContext{}, statedb, chainConfig, vm.Config{})
+	evm.ProcessingHook = &arbos.TxProcessor{}
+
+	owner := testhelpers.RandomAddress()
+	removedOwner := testhelpers.RandomAddress()
+	Require(t, arbState.ChainOwners().Add(owner))
+	Require(t, arbState.ChainOwners().Add(removedOwner))
+
+	abi, err := precompilesgen.ArbOwnerMetaData.GetAbi()
+	Require(t, err)
+	arbOwner := Precompiles()[common.HexToAddress("70")] // also sets the hook that executes scheduled actions
+	call := func(caller common.Address, method string, args ...interface{}) error {
+		t.Helper()
+		input, err := abi.Pack(method, args...)
+		Require(t, err)
+		address := common.HexToAddress("70")
+		_, _, err = arbOwner.Call(input, address, address, caller, common.Big0, false, ^uint64(0), evm)
+		return err
+	}
+	speedLimit := func() uint64 {
+		t.Helper()
+		limit, err := arbState.L2PricingState().SpeedLimitPerSecond()
+		Require(t, err)
+		return limit
+	}
+	startBlock := func(time uint64) {
+		t.Helper()
+		evm.Context.Time = new(big.Int).SetUint64(time)
+		header := &types.Header{Number: common.Big1, Time: time}
+		lastHeader := &types.Header{Number: common.Big0, Time: time}
+		tx := arbos.InternalTxStartBlock(chainConfig.ChainID, common.Big0, 0, header, lastHeader)
+		Require(t, arbos.ApplyInternalTxUpdate(tx, arbState, evm))
+	}
+
+	// pre-v8, delays can't be set and owner calls take effect immediately
+	if call(owner, "setOwnerActionDelay", uint64(100)) == nil {
+		Fail(t, "set an owner action delay before ArbOS 8")
+	}
+	Require(t, call(owner, "setSpeedLimit", uint64(1111)))
+	if speedLimit() != 1111 {
+		Fail(t, "owner call wasn't executed immediately before ArbOS 8", speedLimit())
+	}
+	startBlock(1000)
+
+	Require(t, arbState.UpgradeArbosVersion(8, false))
+	arbState, err = arbosState.OpenArbosState(statedb, burn.NewSystemBurner(nil, false))
+	Require(t, err)
+	if arbState.FormatVersion() != 8 {
+		Fail(t, "failed to upgrade to ArbOS 8", arbState.FormatVersion())
+	}
+
+	// without a delay, owner calls still take effect immediately
+	Require(t, call(owner, "setSpeedLimit", uint64(2222)))
+	if speedLimit() != 2222 {
+		Fail(t, "owner call wasn't executed immediately without a delay", speedLimit())
+	}
+
+	Require(t, call(owner, "setOwnerActionDelay", uint64(100)))
+	delay, err := arbState.OwnerTimelock().Delay()
+	Require(t, err)
+	if delay != 100 {
+		Fail(t, "unexpected delay", delay)
+	}
+
+	// with a delay, owner calls are scheduled rather than executed
+	Require(t, call(owner, "setSpeedLimit", uint64(3333)))
+	if speedLimit() != 2222 {
+		Fail(t, "delayed owner call was executed immediately", speedLimit())
+	}
+	pending, err := arbState.OwnerTimelock().Pending()
+	Require(t, err)
+	if len(pending) != 1 || pending[0].Owner != owner || pending[0].Timestamp != 1100 {
+		Fail(t, "unexpected pending actions", pending)
+	}
+	scheduledId := pending[0].Id
+
+	// cancellations take effect immediately
+	Require(t, call(owner, "setSpeedLimit", uint64(4444)))
+	pending, err = arbState.OwnerTimelock().Pending()
+	Require(t, err)
+	if len(pending) != 2 {
+		Fail(t, "unexpected pending actions", pending)
+	}
+	Require(t, call(owner, "cancelOwnerAction", pending[1].Id))
+	if call(owner, "cancelOwnerAction", pending[1].Id) == nil {
+		Fail(t, "canceled an owner action twice")
+	}
+	action, err := arbState.OwnerTimelock().Action(pending[1].Id)
+	Require(t, err)
+	if action != nil {
+		Fail(t, "canceled action is still pending", action)
+	}
+
+	// actions scheduled by owners removed during the delay are skipped
+	Require(t, call(removedOwner, "setSpeedLimit", uint64(5555)))
+	Require(t, arbState.ChainOwners().Remove(removedOwner))
+
+	// nothing executes until the delay has passed
+	startBlock(1099)
+	if speedLimit() != 2222 {
+		Fail(t, "owner action executed early", speedLimit())
+	}
+	action, err = arbState.OwnerTimelock().Action(scheduledId)
+	Require(t, err)
+	if action == nil {
+		Fail(t, "owner action is no longer pending")
+	}
+
+	startBlock(1100)
+	if speedLimit() != 3333 {
+		Fail(t, "due owner action wasn't executed", speedLimit())
+	}
+	pending, err = arbState.OwnerTimelock().Pending()
+	Require(t, err)
+	if len(pending) != 0 {
+		Fail(t, "owner actions still pending", pending)
+	}
+}
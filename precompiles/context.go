@@ -32,6 +32,7 @@ type Context struct {
 	txProcessor *arbos.TxProcessor
 	State       *arbosState.ArbosState
 	tracingInfo *util.TracingInfo
+	traceCall   *util.PrecompileCall // the call being traced, if the tracer decodes precompile calls
 	readOnly    bool
 }
 
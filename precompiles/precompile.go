@@ -4,6 +4,7 @@
 package precompiles
 
 import (
+	"encoding"
 	"errors"
 	"fmt"
 	"math/big"
@@ -21,6 +22,7 @@ import (
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -429,6 +431,17 @@ func MakePrecompile(metadata *bind.MetaData, implementer interface{}) (addr, Pre
 			}
 
 			state.AddLog(event)
+
+			if callerCtx.traceCall != nil {
+				values := make([]interface{}, len(args))
+				for i, arg := range args {
+					values[i] = arg.Interface()
+				}
+				callerCtx.traceCall.Events = append(callerCtx.traceCall.Events, util.PrecompileEvent{
+					Name: name,
+					Args: tracingArgs(capturedEvent.Inputs, values),
+				})
+			}
 			return []reflect.Value{nilError}
 		}
 
@@ -545,6 +558,9 @@ func Precompiles() map[addr]ArbosPrecompile {
 
 	ArbOwnerPublic := insert(MakePrecompile(templates.ArbOwnerPublicMetaData, &ArbOwnerPublic{Address: hex("6b")}))
 	ArbOwnerPublic.methodsByName["GetInfraFeeAccount"].arbosVersion = 5
+	ArbOwnerPublic.methodsByName["GetOwnerActionDelay"].arbosVersion = 8
+	ArbOwnerPublic.methodsByName["GetScheduledOwnerActions"].arbosVersion = 8
+	ArbOwnerPublic.methodsByName["GetScheduledOwnerAction"].arbosVersion = 8
 
 	ArbRetryableImpl := &ArbRetryableTx{Address: types.ArbRetryableTxAddress}
 	ArbRetryable := insert(MakePrecompile(templates.ArbRetryableTxMetaData, ArbRetryableImpl))
@@ -570,11 +586,35 @@ func Precompiles() map[addr]ArbosPrecompile {
 		context := eventCtx(ArbOwnerImpl.OwnerActsGasCost(method, owner, data))
 		return ArbOwnerImpl.OwnerActs(context, evm, method, owner, data)
 	}
+	emitOwnerActionScheduled := func(evm mech, id uint64, owner addr, timestamp uint64, data []byte) error {
+		context := eventCtx(ArbOwnerImpl.OwnerActionScheduledGasCost(id, owner, timestamp, data))
+		return ArbOwnerImpl.OwnerActionScheduled(context, evm, id, owner, timestamp, data)
+	}
 	_, ArbOwner := MakePrecompile(templates.ArbOwnerMetaData, ArbOwnerImpl)
 	ArbOwner.methodsByName["GetInfraFeeAccount"].arbosVersion = 5
 	ArbOwner.methodsByName["SetInfraFeeAccount"].arbosVersion = 5
-
-	insert(ownerOnly(ArbOwnerImpl.Address, ArbOwner, emitOwnerActs))
+	ArbOwner.methodsByName["SetOwnerActionDelay"].arbosVersion = 8
+	ArbOwner.methodsByName["CancelOwnerAction"].arbosVersion = 8
+
+	insert(ownerOnly(ArbOwnerImpl.Address, ArbOwner, emitOwnerActs, emitOwnerActionScheduled))
+	arbos.ExecuteOwnerAction = func(evm mech, id uint64, owner addr, data []byte) error {
+		// the caller has already checked the owner is still a chain owner, so call the precompile directly
+		snapshot := evm.StateDB.Snapshot()
+		_, _, err := ArbOwner.Call(data, ArbOwnerImpl.Address, ArbOwnerImpl.Address, owner, common.Big0, false, ^uint64(0), evm)
+		if err != nil {
+			evm.StateDB.RevertToSnapshot(snapshot)
+		}
+		context := eventCtx(ArbOwnerImpl.OwnerActionExecutedGasCost(id, err == nil))
+		if emitErr := ArbOwnerImpl.OwnerActionExecuted(context, evm, id, err == nil); emitErr != nil {
+			log.Error("failed to emit OwnerActionExecuted event", "err", emitErr)
+		}
+		if err == nil {
+			if emitErr := emitOwnerActs(evm, *(*[4]byte)(data[:4]), owner, data); emitErr != nil {
+				log.Error("failed to emit OwnerActs event", "err", emitErr)
+			}
+		}
+		return err
+	}
 	insert(debugOnly(MakePrecompile(templates.ArbDebugMetaData, &ArbDebug{Address: hex("ff")})))
 
 	ArbosActs := insert(MakePrecompile(templates.ArbosActsMetaData, &ArbosActs{Address: types.ArbosAddress}))
@@ -703,6 +743,25 @@ func (p Precompile) Call(
 		reflectArgs = append(reflectArgs, converted)
 	}
 
+	if tracer := callerCtx.tracingInfo.PrecompileTracer(); tracer != nil {
+		traceCall := &util.PrecompileCall{
+			Address:  precompileAddress,
+			Contract: p.name,
+			Method:   method.template.RawName,
+			Args:     tracingArgs(method.template.Inputs, args),
+			Input:    common.CopyBytes(input), // the EVM may reuse the memory
+			Depth:    callerCtx.tracingInfo.Depth,
+		}
+		callerCtx.traceCall = traceCall
+		tracer.CapturePrecompileEnter(traceCall)
+		defer func() {
+			if err != nil && traceCall.Error == "" {
+				traceCall.Error = err.Error()
+			}
+			tracer.CapturePrecompileExit(traceCall)
+		}()
+	}
+
 	reflectResult := method.handler.Func.Call(reflectArgs)
 	resultCount := len(reflectResult) - 1
 	if !reflectResult[resultCount].IsNil() {
@@ -715,6 +774,9 @@ func (p Precompile) Call(
 		var solErr *SolError
 		isSolErr := errors.As(errRet, &solErr)
 		if isSolErr {
+			if callerCtx.traceCall != nil {
+				callerCtx.traceCall.Error = solErr.Error()
+			}
 			resultCost := params.CopyGas * arbmath.WordsForBytes(uint64(len(solErr.data)))
 			if err := callerCtx.Burn(resultCost); err != nil {
 				// user cannot afford the result data returned
@@ -734,6 +796,9 @@ func (p Precompile) Call(
 		log.Error("could not encode precompile result", "err", err)
 		return nil, callerCtx.gasLeft, vm.ErrExecutionReverted
 	}
+	if callerCtx.traceCall != nil {
+		callerCtx.traceCall.Results = tracingArgs(method.template.Outputs, result)
+	}
 
 	resultCost := params.CopyGas * arbmath.WordsForBytes(uint64(len(encoded)))
 	if err := callerCtx.Burn(resultCost); err != nil {
@@ -744,6 +809,50 @@ func (p Precompile) Call(
 	return encoded, callerCtx.gasLeft, nil
 }
 
+// tracingArgs names a method or event's values for tracers, hex encoding them as geth's tracers do
+func tracingArgs(arguments abi.Arguments, values []interface{}) map[string]interface{} {
+	named := make(map[string]interface{}, len(values))
+	for i, value := range values {
+		name := ""
+		if i < len(arguments) {
+			name = arguments[i].Name
+		}
+		if name == "" {
+			name = fmt.Sprintf("arg%v", i)
+		}
+		named[name] = tracingValue(value)
+	}
+	return named
+}
+
+func tracingValue(value interface{}) interface{} {
+	switch value := value.(type) {
+	case *big.Int:
+		return (*hexutil.Big)(value)
+	case []byte:
+		return hexutil.Bytes(value)
+	case [32]byte:
+		return common.Hash(value)
+	}
+	if _, ok := value.(encoding.TextMarshaler); ok {
+		return value
+	}
+	list := reflect.ValueOf(value)
+	if list.Kind() != reflect.Slice && list.Kind() != reflect.Array {
+		return value
+	}
+	if list.Kind() == reflect.Array && list.Type().Elem().Kind() == reflect.Uint8 {
+		bytes := make(hexutil.Bytes, list.Len())
+		reflect.Copy(reflect.ValueOf(bytes), list)
+		return bytes
+	}
+	converted := make([]interface{}, list.Len())
+	for i := range converted {
+		converted[i] = tracingValue(list.Index(i).Interface())
+	}
+	return converted
+}
+
 func (p Precompile) Precompile() Precompile {
 	return p
 }
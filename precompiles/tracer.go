@@ -0,0 +1,87 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package precompiles
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+
+	"github.com/offchainlabs/nitro/arbos/util"
+)
+
+const PrecompileTracerName = "precompileTracer"
+
+func init() {
+	tracers.RegisterLookup(false, func(name string, ctx *tracers.Context, cfg json.RawMessage) (tracers.Tracer, error) {
+		if name != PrecompileTracerName {
+			return nil, errors.New("no tracer found")
+		}
+		inner, err := tracers.New("callTracer", ctx, cfg)
+		if err != nil {
+			return nil, err
+		}
+		return &precompileTracer{
+			Tracer: inner,
+			calls:  make(map[precompileFrame][]*util.PrecompileCall),
+		}, nil
+	})
+}
+
+// identifies the call frames a precompile call may belong to
+type precompileFrame struct {
+	address common.Address
+	depth   int
+}
+
+// precompileTracer is geth's callTracer, with each call to a precompile annotated with its decoded
+// method, arguments, return values, events and custom error.
+type precompileTracer struct {
+	tracers.Tracer
+	calls map[precompileFrame][]*util.PrecompileCall // in the order they were made
+}
+
+func (t *precompileTracer) CapturePrecompileEnter(call *util.PrecompileCall) {
+	frame := precompileFrame{call.Address, call.Depth}
+	t.calls[frame] = append(t.calls[frame], call)
+}
+
+func (t *precompileTracer) CapturePrecompileExit(call *util.PrecompileCall) {}
+
+func (t *precompileTracer) GetResult() (json.RawMessage, error) {
+	result, err := t.Tracer.GetResult()
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(result))
+	decoder.UseNumber()
+	var root map[string]interface{}
+	if err := decoder.Decode(&root); err != nil {
+		return nil, err
+	}
+	t.annotate(root, 0)
+	return json.Marshal(root)
+}
+
+// annotate walks the call frames in the order they were made, matching precompile calls to them.
+// Precompile calls ArbOS makes outside the EVM have no frame, and so are left out.
+func (t *precompileTracer) annotate(frame map[string]interface{}, depth int) {
+	to, _ := frame["to"].(string)
+	input, _ := frame["input"].(string)
+	key := precompileFrame{common.HexToAddress(to), depth}
+	if calls := t.calls[key]; len(calls) > 0 && bytes.Equal(calls[0].Input, common.FromHex(input)) {
+		// calls rejected before being decoded, such as those with invalid calldata, have frames but aren't recorded
+		frame["precompile"] = calls[0]
+		t.calls[key] = calls[1:]
+	}
+	children, _ := frame["calls"].([]interface{})
+	for _, child := range children {
+		if childFrame, ok := child.(map[string]interface{}); ok {
+			t.annotate(childFrame, depth+1)
+		}
+	}
+}
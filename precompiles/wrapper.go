@@ -53,17 +53,29 @@ func (wrapper *DebugPrecompile) Precompile() Precompile {
 
 // A precompile wrapper for those only chain owners may use
 type OwnerPrecompile struct {
-	precompile  ArbosPrecompile
-	emitSuccess func(mech, bytes4, addr, []byte) error
+	precompile    ArbosPrecompile
+	emitSuccess   func(mech, bytes4, addr, []byte) error
+	emitScheduled func(mech, uint64, addr, uint64, []byte) error
 }
 
-func ownerOnly(address addr, impl ArbosPrecompile, emit func(mech, bytes4, addr, []byte) error) (addr, ArbosPrecompile) {
+func ownerOnly(
+	address addr,
+	impl ArbosPrecompile,
+	emit func(mech, bytes4, addr, []byte) error,
+	emitScheduled func(mech, uint64, addr, uint64, []byte) error,
+) (addr, ArbosPrecompile) {
 	return address, &OwnerPrecompile{
-		precompile:  impl,
-		emitSuccess: emit,
+		precompile:    impl,
+		emitSuccess:   emit,
+		emitScheduled: emitScheduled,
 	}
 }
 
+// Owner methods that always take effect immediately, even when owner actions are delayed
+var undelayedOwnerMethods = map[string]bool{
+	"CancelOwnerAction": true,
+}
+
 func (wrapper *OwnerPrecompile) Call(
 	input []byte,
 	precompileAddress common.Address,
@@ -96,6 +108,11 @@ func (wrapper *OwnerPrecompile) Call(
 		return nil, burner.gasLeft, errors.New("unauthorized caller to access-controlled method")
 	}
 
+	scheduled, err := wrapper.scheduleIfDelayed(state, input, caller, readOnly, evm)
+	if scheduled || err != nil {
+		return []byte{}, gasSupplied, err // we don't deduct gas since we don't want to charge the owner
+	}
+
 	output, _, err := con.Call(input, precompileAddress, actingAsAddress, caller, value, readOnly, gasSupplied, evm)
 
 	if err != nil {
@@ -110,6 +127,44 @@ func (wrapper *OwnerPrecompile) Call(
 	return output, gasSupplied, err // we don't deduct gas since we don't want to charge the owner
 }
 
+// When owner actions are delayed, queues a state-changing call to execute once the delay passes.
+// Returns whether the call was scheduled, in which case it shouldn't be executed now.
+func (wrapper *OwnerPrecompile) scheduleIfDelayed(
+	state *arbosState.ArbosState,
+	input []byte,
+	caller common.Address,
+	readOnly bool,
+	evm *vm.EVM,
+) (bool, error) {
+	version := state.FormatVersion()
+	if version < 8 || len(input) < 4 {
+		return false, nil
+	}
+	method, ok := wrapper.precompile.Precompile().methods[*(*[4]byte)(input)]
+	if !ok || version < method.arbosVersion || method.purity < write || undelayedOwnerMethods[method.name] {
+		return false, nil
+	}
+	delay, err := state.OwnerTimelock().Delay()
+	if err != nil || delay == 0 {
+		return false, err
+	}
+	if readOnly {
+		return true, vm.ErrExecutionReverted
+	}
+	if _, err := method.template.Inputs.Unpack(input[4:]); err != nil {
+		// reject malformed calls now rather than when they execute
+		return true, vm.ErrExecutionReverted
+	}
+	id, timestamp, err := state.OwnerTimelock().Schedule(caller, input, evm.Context.Time.Uint64())
+	if err != nil {
+		return true, err
+	}
+	if err := wrapper.emitScheduled(evm, id, caller, timestamp, input); err != nil {
+		log.Error("failed to emit OwnerActionScheduled event", "err", err)
+	}
+	return true, nil
+}
+
 func (wrapper *OwnerPrecompile) Precompile() Precompile {
 	con := wrapper.precompile
 	return con.Precompile()
@@ -0,0 +1,92 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package relay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/offchainlabs/nitro/cmd/genericconf"
+)
+
+const healthRequestPath = "/health"
+
+type HealthConfig struct {
+	Enable         bool                                `koanf:"enable"`
+	Addr           string                              `koanf:"addr"`
+	Port           uint64                              `koanf:"port"`
+	ServerTimeouts genericconf.HTTPServerTimeoutConfig `koanf:"server-timeouts"`
+}
+
+var HealthConfigDefault = HealthConfig{
+	Enable:         false,
+	Addr:           "0.0.0.0",
+	Port:           9644,
+	ServerTimeouts: genericconf.HTTPServerTimeoutConfigDefault,
+}
+
+func HealthConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", HealthConfigDefault.Enable, "enable the HTTP health endpoint reporting the latest sequence number and upstream status")
+	f.String(prefix+".addr", HealthConfigDefault.Addr, "health server listening interface")
+	f.Uint64(prefix+".port", HealthConfigDefault.Port, "health server listening port")
+	genericconf.HTTPServerTimeoutConfigAddOptions(prefix+".server-timeouts", f)
+}
+
+func (r *Relay) startHealthServer() (*http.Server, error) {
+	listener, err := net.Listen("tcp", fmt.Sprintf("%s:%d", r.healthConfig.Addr, r.healthConfig.Port))
+	if err != nil {
+		return nil, err
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(healthRequestPath, r.serveHealth)
+	srv := &http.Server{
+		Handler:           mux,
+		ReadTimeout:       r.healthConfig.ServerTimeouts.ReadTimeout,
+		ReadHeaderTimeout: r.healthConfig.ServerTimeouts.ReadHeaderTimeout,
+		WriteTimeout:      r.healthConfig.ServerTimeouts.WriteTimeout,
+		IdleTimeout:       r.healthConfig.ServerTimeouts.IdleTimeout,
+	}
+	go func() {
+		err := srv.Serve(listener)
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error("relay health server exited", "err", err)
+		}
+	}()
+	log.Info("relay health server is listening", "address", listener.Addr().String())
+	return srv, nil
+}
+
+// serveHealth responds with the relay status as JSON, using status code 503
+// when no upstream feed is healthy so that load balancers can route around the relay.
+func (r *Relay) serveHealth(w http.ResponseWriter, req *http.Request) {
+	status := r.Status()
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache")
+	if status.Healthy {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	err := json.NewEncoder(w).Encode(status)
+	if err != nil {
+		log.Warn("failed writing relay health response", "err", err)
+	}
+}
+
+// RelayAPI exposes the relay status over the admin RPC server.
+type RelayAPI struct {
+	relay *Relay
+}
+
+func (a *RelayAPI) Status(ctx context.Context) (RelayStatus, error) {
+	return a.relay.Status(), nil
+}
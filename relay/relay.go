@@ -8,11 +8,15 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"net/http"
+	"sync"
 	"time"
 
 	flag "github.com/spf13/pflag"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
 
 	"github.com/offchainlabs/nitro/arbutil"
 	"github.com/offchainlabs/nitro/broadcastclient"
@@ -26,43 +30,49 @@ import (
 
 type Relay struct {
 	stopwaiter.StopWaiter
-	broadcastClients            []*broadcastclient.BroadcastClient
+	upstreams                   []*upstream
+	chainId                     uint64
 	broadcaster                 *broadcaster.Broadcaster
 	confirmedSequenceNumberChan chan arbutil.MessageIndex
-	messageChan                 chan broadcaster.BroadcastFeedMessage
-}
-
-type MessageQueue struct {
-	queue chan broadcaster.BroadcastFeedMessage
-}
-
-func (q *MessageQueue) AddBroadcastMessages(feedMessages []*broadcaster.BroadcastFeedMessage) error {
-	for _, feedMessage := range feedMessages {
-		q.queue <- *feedMessage
-	}
-
-	return nil
+	messageChan                 chan upstreamMessage
+	clientErrChan               chan error
+	feedErrChan                 chan error
+	upstreamConfig              UpstreamConfig
+	adminRPCConfig              AdminRPCConfig
+	adminRPCServer              *http.Server
+	healthConfig                HealthConfig
+	healthServer                *http.Server
+
+	// Protects all fields below
+	statusMutex     sync.Mutex
+	latestSeqNum    arbutil.MessageIndex
+	confirmedSeqNum arbutil.MessageIndex
+	lastMessageTime time.Time
+	preferred       *upstream
 }
 
 func NewRelay(config *Config, feedErrChan chan error) (*Relay, error) {
-	var broadcastClients []*broadcastclient.BroadcastClient
+	var upstreams []*upstream
 
-	q := MessageQueue{make(chan broadcaster.BroadcastFeedMessage, config.Queue)}
+	messageChan := make(chan upstreamMessage, config.Queue)
+	clientErrChan := make(chan error, 10)
 
 	confirmedSequenceNumberListener := make(chan arbutil.MessageIndex, config.Queue)
 
 	var lastClientError error
 	for _, address := range config.Node.Feed.Input.URLs {
-		client, err := broadcastclient.NewBroadcastClient(config.Node.Feed.Input, address, config.L2.ChainId, 0, &q, feedErrChan, nil)
+		u := newUpstream(len(upstreams), messageChan)
+		client, err := broadcastclient.NewBroadcastClient(config.Node.Feed.Input, address, config.L2.ChainId, 0, u, clientErrChan, nil)
 		if err != nil {
 			lastClientError = err
 			log.Warn("init broadcast client failed", "address", address, "err", err)
 			continue
 		}
 		client.ConfirmedSequenceNumberListener = confirmedSequenceNumberListener
-		broadcastClients = append(broadcastClients, client)
+		u.client = client
+		upstreams = append(upstreams, u)
 	}
-	if len(broadcastClients) == 0 && len(config.Node.Feed.Input.URLs) > 0 {
+	if len(upstreams) == 0 && len(config.Node.Feed.Input.URLs) > 0 {
 		return nil, fmt.Errorf("no broadcast clients initialized. Last error: %w", lastClientError)
 	}
 
@@ -71,9 +81,15 @@ func NewRelay(config *Config, feedErrChan chan error) (*Relay, error) {
 	}
 	return &Relay{
 		broadcaster:                 broadcaster.NewBroadcaster(func() *wsbroadcastserver.BroadcasterConfig { return &config.Node.Feed.Output }, config.L2.ChainId, feedErrChan, dataSignerErr),
-		broadcastClients:            broadcastClients,
+		upstreams:                   upstreams,
+		chainId:                     config.L2.ChainId,
 		confirmedSequenceNumberChan: confirmedSequenceNumberListener,
-		messageChan:                 q.queue,
+		messageChan:                 messageChan,
+		clientErrChan:               clientErrChan,
+		feedErrChan:                 feedErrChan,
+		upstreamConfig:              config.Upstream,
+		adminRPCConfig:              config.AdminRPC,
+		healthConfig:                config.Health,
 	}, nil
 }
 
@@ -91,21 +107,71 @@ func (r *Relay) Start(ctx context.Context) error {
 		return errors.New("broadcast unable to start")
 	}
 
-	for _, client := range r.broadcastClients {
-		client.Start(ctx)
+	for _, u := range r.upstreams {
+		u.client.Start(ctx)
 	}
 
+	if r.adminRPCConfig.Enable {
+		r.adminRPCServer, err = r.startAdminRPCServer()
+		if err != nil {
+			return err
+		}
+	}
+	if r.healthConfig.Enable {
+		r.healthServer, err = r.startHealthServer()
+		if err != nil {
+			return err
+		}
+	}
+
+	r.LaunchThread(func(ctx context.Context) {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case err := <-r.clientErrChan:
+				if isFatalClientError(err) {
+					r.feedErrChan <- err
+				} else {
+					// Errors such as invalid signatures only affect the health of the upstream
+					log.Warn("error from upstream feed", "err", err)
+				}
+			}
+		}
+	})
+
 	var lastConfirmed arbutil.MessageIndex
+	var lastKeyRotation common.Hash
 	recentFeedItemsNew := make(map[arbutil.MessageIndex]time.Time, RECENT_FEED_INITIAL_MAP_SIZE)
 	recentFeedItemsOld := make(map[arbutil.MessageIndex]time.Time, RECENT_FEED_INITIAL_MAP_SIZE)
 	r.LaunchThread(func(ctx context.Context) {
 		recentFeedItemsCleanup := time.NewTicker(RECENT_FEED_ITEM_TTL)
 		defer recentFeedItemsCleanup.Stop()
+		upstreamEvaluation := time.NewTicker(r.upstreamConfig.EvaluationInterval)
+		defer upstreamEvaluation.Stop()
+		anyHealthy := true
 		for {
 			select {
 			case <-ctx.Done():
 				return
-			case msg := <-r.messageChan:
+			case upstreamMsg := <-r.messageChan:
+				if rotation := upstreamMsg.keyRotation; rotation != nil {
+					// Every upstream sends the same announcement, and resends it on reconnect.
+					// The upstream's client only passes on announcements it verified, unless
+					// it has no trusted signers, in which case clients verify them themselves.
+					if hash := rotation.Hash(r.chainId); hash != lastKeyRotation {
+						lastKeyRotation = hash
+						r.broadcaster.BroadcastKeyRotation(rotation)
+					}
+					continue
+				}
+				if anyHealthy && !upstreamMsg.upstream.isHealthy() {
+					// Only fall back to unhealthy upstreams when there is no healthy one
+					continue
+				}
+				// Relay the first copy of each message from any healthy upstream,
+				// so a slow preferred upstream doesn't hold back the others
+				msg := upstreamMsg.message
 				if _, ok := recentFeedItemsNew[msg.SequenceNumber]; ok {
 					continue
 				}
@@ -114,16 +180,21 @@ func (r *Relay) Start(ctx context.Context) error {
 				}
 				recentFeedItemsNew[msg.SequenceNumber] = time.Now()
 				sharedmetrics.UpdateSequenceNumberGauge(msg.SequenceNumber)
+				r.recordMessage(msg.SequenceNumber)
 				r.broadcaster.BroadcastSingleFeedMessage(&msg)
 			case cs := <-r.confirmedSequenceNumberChan:
 				if lastConfirmed == cs {
 					continue
 				}
+				lastConfirmed = cs
+				r.recordConfirmed(cs)
 				r.broadcaster.Confirm(cs)
 			case <-recentFeedItemsCleanup.C:
 				// Cycle buckets to get rid of old entries
 				recentFeedItemsOld = recentFeedItemsNew
 				recentFeedItemsNew = make(map[arbutil.MessageIndex]time.Time, RECENT_FEED_INITIAL_MAP_SIZE)
+			case <-upstreamEvaluation.C:
+				anyHealthy = r.evaluateUpstreams()
 			}
 		}
 	})
@@ -131,14 +202,136 @@ func (r *Relay) Start(ctx context.Context) error {
 	return nil
 }
 
+func isFatalClientError(err error) bool {
+	return errors.Is(err, broadcastclient.ErrMissingChainId) ||
+		errors.Is(err, broadcastclient.ErrIncorrectChainId) ||
+		errors.Is(err, broadcastclient.ErrMissingFeedServerVersion) ||
+		errors.Is(err, broadcastclient.ErrIncorrectFeedServerVersion)
+}
+
+func (r *Relay) recordMessage(seqNum arbutil.MessageIndex) {
+	r.statusMutex.Lock()
+	defer r.statusMutex.Unlock()
+	if seqNum > r.latestSeqNum {
+		r.latestSeqNum = seqNum
+	}
+	r.lastMessageTime = time.Now()
+}
+
+func (r *Relay) recordConfirmed(seqNum arbutil.MessageIndex) {
+	r.statusMutex.Lock()
+	defer r.statusMutex.Unlock()
+	r.confirmedSeqNum = seqNum
+}
+
+// evaluateUpstreams updates the health of every upstream and picks the
+// healthy upstream with the lowest lag as preferred.
+// Returns true if at least one upstream is healthy.
+func (r *Relay) evaluateUpstreams() bool {
+	r.statusMutex.Lock()
+	latestSeqNum := r.latestSeqNum
+	r.statusMutex.Unlock()
+
+	var preferred *upstream
+	for _, u := range r.upstreams {
+		if !u.evaluate(&r.upstreamConfig, latestSeqNum) {
+			continue
+		}
+		if preferred == nil || u.getLag() < preferred.getLag() {
+			preferred = u
+		}
+	}
+
+	r.statusMutex.Lock()
+	if preferred != r.preferred {
+		if preferred == nil {
+			log.Warn("no healthy upstream feeds")
+		} else {
+			log.Info("preferred upstream feed changed", "url", preferred.client.URL())
+		}
+	}
+	r.preferred = preferred
+	r.statusMutex.Unlock()
+
+	return preferred != nil
+}
+
+// RelayStatus is a snapshot of the health of the relay and its upstream feeds.
+type RelayStatus struct {
+	Healthy                 bool                 `json:"healthy"`
+	LatestSequenceNumber    arbutil.MessageIndex `json:"latestSequenceNumber"`
+	ConfirmedSequenceNumber arbutil.MessageIndex `json:"confirmedSequenceNumber"`
+	LastMessageTime         time.Time            `json:"lastMessageTime"`
+	ClientCount             int32                `json:"clientCount"`
+	Upstreams               []UpstreamStatus     `json:"upstreams"`
+}
+
+func (r *Relay) Status() RelayStatus {
+	r.statusMutex.Lock()
+	status := RelayStatus{
+		LatestSequenceNumber:    r.latestSeqNum,
+		ConfirmedSequenceNumber: r.confirmedSeqNum,
+		LastMessageTime:         r.lastMessageTime,
+		ClientCount:             r.broadcaster.ClientCount(),
+	}
+	preferred := r.preferred
+	r.statusMutex.Unlock()
+
+	for _, u := range r.upstreams {
+		upstreamStatus := u.status(u == preferred)
+		if upstreamStatus.Healthy {
+			status.Healthy = true
+		}
+		status.Upstreams = append(status.Upstreams, upstreamStatus)
+	}
+	return status
+}
+
 func (r *Relay) GetListenerAddr() net.Addr {
 	return r.broadcaster.ListenerAddr()
 }
 
+func (r *Relay) startAdminRPCServer() (*http.Server, error) {
+	rpcServer := rpc.NewServer()
+	err := rpcServer.RegisterName("arbfeed", broadcaster.NewBroadcasterAdminAPI(r.broadcaster))
+	if err != nil {
+		return nil, err
+	}
+	err = rpcServer.RegisterName("arbrelay", &RelayAPI{r})
+	if err != nil {
+		return nil, err
+	}
+	listener, err := net.Listen("tcp", fmt.Sprintf("%s:%d", r.adminRPCConfig.Addr, r.adminRPCConfig.Port))
+	if err != nil {
+		return nil, err
+	}
+	srv := &http.Server{
+		Handler:           rpcServer,
+		ReadTimeout:       r.adminRPCConfig.ServerTimeouts.ReadTimeout,
+		ReadHeaderTimeout: r.adminRPCConfig.ServerTimeouts.ReadHeaderTimeout,
+		WriteTimeout:      r.adminRPCConfig.ServerTimeouts.WriteTimeout,
+		IdleTimeout:       r.adminRPCConfig.ServerTimeouts.IdleTimeout,
+	}
+	go func() {
+		err := srv.Serve(listener)
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error("relay admin rpc server exited", "err", err)
+		}
+	}()
+	log.Info("relay admin rpc server is listening", "address", listener.Addr().String())
+	return srv, nil
+}
+
 func (r *Relay) StopAndWait() {
 	r.StopWaiter.StopAndWait()
-	for _, client := range r.broadcastClients {
-		client.StopAndWait()
+	if r.adminRPCServer != nil {
+		_ = r.adminRPCServer.Shutdown(context.Background())
+	}
+	if r.healthServer != nil {
+		_ = r.healthServer.Shutdown(context.Background())
+	}
+	for _, u := range r.upstreams {
+		u.client.StopAndWait()
 	}
 	r.broadcaster.StopAndWait()
 }
@@ -152,6 +345,9 @@ type Config struct {
 	MetricsServer genericconf.MetricsServerConfig `koanf:"metrics-server"`
 	Node          NodeConfig                      `koanf:"node"`
 	Queue         int                             `koanf:"queue"`
+	Upstream      UpstreamConfig                  `koanf:"upstream"`
+	AdminRPC      AdminRPCConfig                  `koanf:"admin-rpc"`
+	Health        HealthConfig                    `koanf:"health"`
 }
 
 var ConfigDefault = Config{
@@ -163,6 +359,9 @@ var ConfigDefault = Config{
 	MetricsServer: genericconf.MetricsServerConfigDefault,
 	Node:          NodeConfigDefault,
 	Queue:         1024,
+	Upstream:      UpstreamConfigDefault,
+	AdminRPC:      AdminRPCConfigDefault,
+	Health:        HealthConfigDefault,
 }
 
 func ConfigAddOptions(f *flag.FlagSet) {
@@ -174,6 +373,9 @@ func ConfigAddOptions(f *flag.FlagSet) {
 	genericconf.MetricsServerAddOptions("metrics-server", f)
 	NodeConfigAddOptions("node", f)
 	f.Int("queue", ConfigDefault.Queue, "size of relay queue")
+	UpstreamConfigAddOptions("upstream", f)
+	AdminRPCConfigAddOptions("admin-rpc", f)
+	HealthConfigAddOptions("health", f)
 }
 
 type NodeConfig struct {
@@ -188,6 +390,27 @@ func NodeConfigAddOptions(prefix string, f *flag.FlagSet) {
 	broadcastclient.FeedConfigAddOptions(prefix+".feed", f, true, true)
 }
 
+type AdminRPCConfig struct {
+	Enable         bool                                `koanf:"enable"`
+	Addr           string                              `koanf:"addr"`
+	Port           uint64                              `koanf:"port"`
+	ServerTimeouts genericconf.HTTPServerTimeoutConfig `koanf:"server-timeouts"`
+}
+
+var AdminRPCConfigDefault = AdminRPCConfig{
+	Enable:         false,
+	Addr:           "127.0.0.1",
+	Port:           9643,
+	ServerTimeouts: genericconf.HTTPServerTimeoutConfigDefault,
+}
+
+func AdminRPCConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", AdminRPCConfigDefault.Enable, "enable the admin RPC server used to list and disconnect feed clients")
+	f.String(prefix+".addr", AdminRPCConfigDefault.Addr, "admin RPC server listening interface")
+	f.Uint64(prefix+".port", AdminRPCConfigDefault.Port, "admin RPC server listening port")
+	genericconf.HTTPServerTimeoutConfigAddOptions(prefix+".server-timeouts", f)
+}
+
 type L2Config struct {
 	ChainId uint64 `koanf:"chain-id"`
 }
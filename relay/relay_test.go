@@ -0,0 +1,243 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package relay
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/offchainlabs/nitro/arbstate"
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/broadcastclient"
+	"github.com/offchainlabs/nitro/broadcaster"
+	"github.com/offchainlabs/nitro/util/signature"
+	"github.com/offchainlabs/nitro/util/testhelpers"
+	"github.com/offchainlabs/nitro/wsbroadcastserver"
+)
+
+const testChainId = uint64(8746)
+
+func feedUrl(addr net.Addr) string {
+	return fmt.Sprintf("ws://127.0.0.1:%d/", addr.(*net.TCPAddr).Port)
+}
+
+func newTestBroadcaster(t *testing.T, ctx context.Context, key *ecdsa.PrivateKey) *broadcaster.Broadcaster {
+	t.Helper()
+	config := wsbroadcastserver.DefaultTestBroadcasterConfig
+	var dataSigner signature.DataSignerFunc
+	if key != nil {
+		dataSigner = signature.DataSignerFromPrivateKey(key)
+	}
+	b := broadcaster.NewBroadcaster(func() *wsbroadcastserver.BroadcasterConfig { return &config }, testChainId, make(chan error, 10), dataSigner)
+	testhelpers.RequireImpl(t, b.Initialize())
+	testhelpers.RequireImpl(t, b.Start(ctx))
+	return b
+}
+
+func newTestRelay(t *testing.T, ctx context.Context, upstreamConfig UpstreamConfig, upstreams ...net.Addr) *Relay {
+	t.Helper()
+	config := ConfigDefault
+	config.L2.ChainId = testChainId
+	config.Upstream = upstreamConfig
+	config.Node.Feed.Output = wsbroadcastserver.DefaultTestBroadcasterConfig
+	config.Node.Feed.Input = broadcastclient.DefaultTestConfig
+	// relays don't verify the feed, their clients do
+	config.Node.Feed.Input.Verifier = signature.TestingFeedVerifierConfig
+	config.Node.Feed.Input.Verifier.Dangerous.AcceptMissing = true
+	config.Node.Feed.Input.URLs = nil
+	for _, addr := range upstreams {
+		config.Node.Feed.Input.URLs = append(config.Node.Feed.Input.URLs, feedUrl(addr))
+	}
+	relay, err := NewRelay(&config, make(chan error, 10))
+	testhelpers.RequireImpl(t, err)
+	testhelpers.RequireImpl(t, relay.Start(ctx))
+	return relay
+}
+
+// messageRecorder receives the messages of a BroadcastClient
+type messageRecorder struct {
+	messages chan arbutil.MessageIndex
+}
+
+func newMessageRecorder() *messageRecorder {
+	return &messageRecorder{messages: make(chan arbutil.MessageIndex, 100)}
+}
+
+func (r *messageRecorder) AddBroadcastMessages(feedMessages []*broadcaster.BroadcastFeedMessage) error {
+	for _, message := range feedMessages {
+		r.messages <- message.SequenceNumber
+	}
+	return nil
+}
+
+func (r *messageRecorder) expect(t *testing.T, expected arbutil.MessageIndex) {
+	t.Helper()
+	timer := time.NewTimer(5 * time.Second)
+	defer timer.Stop()
+	select {
+	case seqNum := <-r.messages:
+		if seqNum != expected {
+			t.Fatal("expected sequence number", expected, "got", seqNum)
+		}
+	case <-timer.C:
+		t.Fatal("didn't receive sequence number", expected)
+	}
+}
+
+func (r *messageRecorder) expectNone(t *testing.T) {
+	t.Helper()
+	select {
+	case seqNum := <-r.messages:
+		t.Fatal("unexpected sequence number", seqNum)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func newTestClient(t *testing.T, ctx context.Context, relay *Relay) *messageRecorder {
+	t.Helper()
+	clientConfig := broadcastclient.DefaultTestConfig
+	clientConfig.Verifier = signature.TestingFeedVerifierConfig
+	clientConfig.Verifier.Dangerous.AcceptMissing = true
+	recorder := newMessageRecorder()
+	client, err := broadcastclient.NewBroadcastClient(clientConfig, feedUrl(relay.GetListenerAddr()), testChainId, 0, recorder, make(chan error, 10), nil)
+	testhelpers.RequireImpl(t, err)
+	client.Start(ctx)
+	t.Cleanup(client.StopAndWait)
+	return recorder
+}
+
+func waitForPreferred(t *testing.T, relay *Relay, index int) {
+	t.Helper()
+	for i := 0; i < 500; i++ {
+		if relay.Status().Upstreams[index].Preferred {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("upstream", index, "never became preferred")
+}
+
+func testUpstreamConfig() UpstreamConfig {
+	config := UpstreamConfigDefault
+	config.MaxLag = 1
+	config.EvaluationInterval = 20 * time.Millisecond
+	return config
+}
+
+func TestRelayFailsOverToOtherUpstream(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	first := newTestBroadcaster(t, ctx, nil)
+	second := newTestBroadcaster(t, ctx, nil)
+	defer second.StopAndWait()
+	relay := newTestRelay(t, ctx, testUpstreamConfig(), first.ListenerAddr(), second.ListenerAddr())
+	defer relay.StopAndWait()
+	recorder := newTestClient(t, ctx, relay)
+
+	testhelpers.RequireImpl(t, first.BroadcastSingle(arbstate.EmptyTestMessageWithMetadata, 0))
+	testhelpers.RequireImpl(t, second.BroadcastSingle(arbstate.EmptyTestMessageWithMetadata, 0))
+	recorder.expect(t, 0)
+	waitForPreferred(t, relay, 0)
+
+	first.StopAndWait()
+	waitForPreferred(t, relay, 1)
+	for i := arbutil.MessageIndex(1); i < 4; i++ {
+		testhelpers.RequireImpl(t, second.BroadcastSingle(arbstate.EmptyTestMessageWithMetadata, i))
+		recorder.expect(t, i)
+	}
+	recorder.expectNone(t)
+}
+
+func TestRelaySwitchesBackToPreferredUpstream(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	first := newTestBroadcaster(t, ctx, nil)
+	defer first.StopAndWait()
+	second := newTestBroadcaster(t, ctx, nil)
+	defer second.StopAndWait()
+	relay := newTestRelay(t, ctx, testUpstreamConfig(), first.ListenerAddr(), second.ListenerAddr())
+	defer relay.StopAndWait()
+	recorder := newTestClient(t, ctx, relay)
+
+	testhelpers.RequireImpl(t, first.BroadcastSingle(arbstate.EmptyTestMessageWithMetadata, 0))
+	testhelpers.RequireImpl(t, second.BroadcastSingle(arbstate.EmptyTestMessageWithMetadata, 0))
+	recorder.expect(t, 0)
+	waitForPreferred(t, relay, 0)
+
+	// while the preferred upstream falls behind, messages are relayed from the other without waiting for it
+	for i := arbutil.MessageIndex(1); i < 3; i++ {
+		testhelpers.RequireImpl(t, second.BroadcastSingle(arbstate.EmptyTestMessageWithMetadata, i))
+		recorder.expect(t, i)
+	}
+	waitForPreferred(t, relay, 1)
+
+	// once it catches up it's preferred again, and each message is still relayed once
+	for i := arbutil.MessageIndex(1); i < 4; i++ {
+		testhelpers.RequireImpl(t, first.BroadcastSingle(arbstate.EmptyTestMessageWithMetadata, i))
+	}
+	testhelpers.RequireImpl(t, second.BroadcastSingle(arbstate.EmptyTestMessageWithMetadata, 3))
+	recorder.expect(t, 3)
+	waitForPreferred(t, relay, 0)
+	testhelpers.RequireImpl(t, first.BroadcastSingle(arbstate.EmptyTestMessageWithMetadata, 4))
+	recorder.expect(t, 4)
+	recorder.expectNone(t)
+}
+
+func TestRelayPassesOnKeyRotation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	oldKey, err := crypto.GenerateKey()
+	testhelpers.RequireImpl(t, err)
+	newKey, err := crypto.GenerateKey()
+	testhelpers.RequireImpl(t, err)
+	b := newTestBroadcaster(t, ctx, oldKey)
+	defer b.StopAndWait()
+	relay := newTestRelay(t, ctx, UpstreamConfigDefault, b.ListenerAddr())
+	defer relay.StopAndWait()
+
+	// the relay's client only trusts the old key
+	clientConfig := broadcastclient.DefaultTestConfig
+	clientConfig.Verifier = signature.TestingFeedVerifierConfig
+	clientConfig.Verifier.AllowedAddresses = []string{crypto.PubkeyToAddress(oldKey.PublicKey).Hex()}
+	recorder := newMessageRecorder()
+	clientErrChan := make(chan error, 10)
+	client, err := broadcastclient.NewBroadcastClient(clientConfig, feedUrl(relay.GetListenerAddr()), testChainId, 0, recorder, clientErrChan, nil)
+	testhelpers.RequireImpl(t, err)
+	client.Start(ctx)
+	defer client.StopAndWait()
+
+	testhelpers.RequireImpl(t, b.BroadcastSingle(arbstate.EmptyTestMessageWithMetadata, 0))
+	recorder.expect(t, 0)
+
+	b.SetNextSigner(signature.DataSignerFromPrivateKey(newKey), crypto.PubkeyToAddress(newKey.PublicKey))
+	_, err = b.RotateSigner(2, 3)
+	testhelpers.RequireImpl(t, err)
+	for i := arbutil.MessageIndex(1); i < 5; i++ {
+		testhelpers.RequireImpl(t, b.BroadcastSingle(arbstate.EmptyTestMessageWithMetadata, i))
+		recorder.expect(t, i)
+	}
+
+	// the relay doesn't verify the feed itself, so it has no signature failures
+	if failures := relay.upstreams[0].client.GetSignatureFailureCount(); failures != 0 {
+		t.Fatal("relay failed to verify", failures, "messages")
+	}
+	// the messages signed by the new key were verified through the relay
+	if client.GetSignatureFailureCount() != 0 {
+		t.Fatal("client failed to verify", client.GetSignatureFailureCount(), "relayed messages")
+	}
+	select {
+	case err := <-clientErrChan:
+		t.Fatal("client error", err)
+	default:
+	}
+}
@@ -0,0 +1,189 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package relay
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/metrics"
+
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/broadcastclient"
+	"github.com/offchainlabs/nitro/broadcaster"
+)
+
+type UpstreamConfig struct {
+	MaxLag               uint64        `koanf:"max-lag"`
+	MaxGaps              uint64        `koanf:"max-gaps"`
+	MaxSignatureFailures uint64        `koanf:"max-signature-failures"`
+	EvaluationInterval   time.Duration `koanf:"evaluation-interval"`
+}
+
+var UpstreamConfigDefault = UpstreamConfig{
+	MaxLag:               100,
+	MaxGaps:              5,
+	MaxSignatureFailures: 0,
+	EvaluationInterval:   5 * time.Second,
+}
+
+func UpstreamConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Uint64(prefix+".max-lag", UpstreamConfigDefault.MaxLag, "maximum number of sequence numbers an upstream feed can be behind the most recent message before it is considered unhealthy")
+	f.Uint64(prefix+".max-gaps", UpstreamConfigDefault.MaxGaps, "maximum number of sequence number gaps an upstream feed can have per evaluation interval before it is considered unhealthy")
+	f.Uint64(prefix+".max-signature-failures", UpstreamConfigDefault.MaxSignatureFailures, "maximum number of signature failures an upstream feed can have per evaluation interval before it is considered unhealthy")
+	f.Duration(prefix+".evaluation-interval", UpstreamConfigDefault.EvaluationInterval, "how often to re-evaluate the health of upstream feeds")
+}
+
+// UpstreamStatus is a snapshot of the health of a single upstream feed.
+type UpstreamStatus struct {
+	URL                  string               `json:"url"`
+	Connected            bool                 `json:"connected"`
+	Healthy              bool                 `json:"healthy"`
+	Preferred            bool                 `json:"preferred"`
+	LatestSequenceNumber arbutil.MessageIndex `json:"latestSequenceNumber"`
+	Lag                  uint64               `json:"lag"`
+	LastMessageTime      time.Time            `json:"lastMessageTime"`
+	Messages             uint64               `json:"messages"`
+	Gaps                 uint64               `json:"gaps"`
+	SignatureFailures    int64                `json:"signatureFailures"`
+	Retries              int64                `json:"retries"`
+}
+
+// upstreamMessage is either a feed message or, if keyRotation is set,
+// a signer rotation announced by the upstream.
+type upstreamMessage struct {
+	upstream    *upstream
+	message     broadcaster.BroadcastFeedMessage
+	keyRotation *broadcaster.FeedKeyRotationMessage
+}
+
+// upstream wraps the BroadcastClient of a single upstream feed, recording
+// statistics about the messages it delivers before queueing them for the relay.
+type upstream struct {
+	index  int
+	client *broadcastclient.BroadcastClient
+	queue  chan upstreamMessage
+
+	lagGauge     metrics.Gauge
+	gapsCounter  metrics.Counter
+	healthyGauge metrics.Gauge
+
+	// Protects all fields below
+	mutex                   sync.Mutex
+	latestSeqNum            arbutil.MessageIndex
+	hasMessages             bool
+	lastMessageTime         time.Time
+	messages                uint64
+	gaps                    uint64
+	gapsAtEvaluation        uint64
+	sigFailuresAtEvaluation int64
+	lag                     uint64
+	healthy                 bool
+}
+
+func newUpstream(index int, queue chan upstreamMessage) *upstream {
+	return &upstream{
+		index:        index,
+		queue:        queue,
+		lagGauge:     metrics.NewRegisteredGauge(fmt.Sprintf("arb/relay/upstream/%d/lag", index), nil),
+		gapsCounter:  metrics.NewRegisteredCounter(fmt.Sprintf("arb/relay/upstream/%d/gaps", index), nil),
+		healthyGauge: metrics.NewRegisteredGauge(fmt.Sprintf("arb/relay/upstream/%d/healthy", index), nil),
+		// Upstreams are considered healthy until first evaluated
+		healthy: true,
+	}
+}
+
+func (u *upstream) AddBroadcastMessages(feedMessages []*broadcaster.BroadcastFeedMessage) error {
+	u.mutex.Lock()
+	for _, feedMessage := range feedMessages {
+		if u.hasMessages && feedMessage.SequenceNumber > u.latestSeqNum+1 {
+			u.gaps++
+			u.gapsCounter.Inc(1)
+		}
+		if !u.hasMessages || feedMessage.SequenceNumber > u.latestSeqNum {
+			u.latestSeqNum = feedMessage.SequenceNumber
+			u.hasMessages = true
+		}
+		u.messages++
+	}
+	u.lastMessageTime = time.Now()
+	u.mutex.Unlock()
+
+	for _, feedMessage := range feedMessages {
+		u.queue <- upstreamMessage{upstream: u, message: *feedMessage}
+	}
+	return nil
+}
+
+// AddKeyRotation queues signer rotations to be passed on to the relay's
+// clients, in order with the messages around them.
+func (u *upstream) AddKeyRotation(rotation *broadcaster.FeedKeyRotationMessage) error {
+	u.queue <- upstreamMessage{upstream: u, keyRotation: rotation}
+	return nil
+}
+
+// evaluate updates the health of the upstream relative to the latest sequence
+// number seen from any upstream, and returns whether it is healthy.
+func (u *upstream) evaluate(config *UpstreamConfig, latestSeqNum arbutil.MessageIndex) bool {
+	sigFailures := u.client.GetSignatureFailureCount()
+	connected := u.client.IsConnected()
+
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+
+	u.lag = 0
+	if u.hasMessages && latestSeqNum > u.latestSeqNum {
+		u.lag = uint64(latestSeqNum - u.latestSeqNum)
+	}
+	recentGaps := u.gaps - u.gapsAtEvaluation
+	recentSigFailures := uint64(sigFailures - u.sigFailuresAtEvaluation)
+	u.gapsAtEvaluation = u.gaps
+	u.sigFailuresAtEvaluation = sigFailures
+
+	u.healthy = connected &&
+		u.lag <= config.MaxLag &&
+		recentGaps <= config.MaxGaps &&
+		recentSigFailures <= config.MaxSignatureFailures
+
+	u.lagGauge.Update(int64(u.lag))
+	if u.healthy {
+		u.healthyGauge.Update(1)
+	} else {
+		u.healthyGauge.Update(0)
+	}
+	return u.healthy
+}
+
+func (u *upstream) isHealthy() bool {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+	return u.healthy
+}
+
+func (u *upstream) getLag() uint64 {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+	return u.lag
+}
+
+func (u *upstream) status(preferred bool) UpstreamStatus {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+	return UpstreamStatus{
+		URL:                  u.client.URL(),
+		Connected:            u.client.IsConnected(),
+		Healthy:              u.healthy,
+		Preferred:            preferred,
+		LatestSequenceNumber: u.latestSeqNum,
+		Lag:                  u.lag,
+		LastMessageTime:      u.lastMessageTime,
+		Messages:             u.messages,
+		Gaps:                 u.gaps,
+		SignatureFailures:    u.client.GetSignatureFailureCount(),
+		Retries:              u.client.GetRetryCount(),
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package relay
+
+import (
+	"testing"
+
+	"github.com/offchainlabs/nitro/arbstate"
+	"github.com/offchainlabs/nitro/arbutil"
+	"github.com/offchainlabs/nitro/broadcastclient"
+	"github.com/offchainlabs/nitro/broadcaster"
+	"github.com/offchainlabs/nitro/util/testhelpers"
+)
+
+func feedMessages(seqNums ...arbutil.MessageIndex) []*broadcaster.BroadcastFeedMessage {
+	var messages []*broadcaster.BroadcastFeedMessage
+	for _, seqNum := range seqNums {
+		messages = append(messages, &broadcaster.BroadcastFeedMessage{
+			SequenceNumber: seqNum,
+			Message:        arbstate.EmptyTestMessageWithMetadata,
+		})
+	}
+	return messages
+}
+
+func TestUpstreamTracksGapsAndLag(t *testing.T) {
+	queue := make(chan upstreamMessage, 100)
+	u := newUpstream(0, queue)
+	clientConfig := broadcastclient.DefaultTestConfig
+	clientConfig.Verifier.AcceptSequencer = false
+	client, err := broadcastclient.NewBroadcastClient(clientConfig, "", 1234, 0, u, make(chan error, 1), nil)
+	testhelpers.RequireImpl(t, err)
+	u.client = client
+
+	testhelpers.RequireImpl(t, u.AddBroadcastMessages(feedMessages(1, 2, 3)))
+	testhelpers.RequireImpl(t, u.AddBroadcastMessages(feedMessages(7, 8)))
+	if len(queue) != 5 {
+		t.Fatal("expected 5 queued messages, got", len(queue))
+	}
+
+	config := UpstreamConfigDefault
+	config.MaxLag = 10
+	config.MaxGaps = 1
+	u.evaluate(&config, 15)
+	status := u.status(false)
+	if status.Gaps != 1 {
+		t.Error("expected 1 gap, got", status.Gaps)
+	}
+	if status.LatestSequenceNumber != 8 {
+		t.Error("expected latest sequence number 8, got", status.LatestSequenceNumber)
+	}
+	if status.Lag != 7 {
+		t.Error("expected lag of 7, got", status.Lag)
+	}
+	if status.Healthy {
+		t.Error("upstream which never connected should not be healthy")
+	}
+}
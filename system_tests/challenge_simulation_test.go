@@ -0,0 +1,187 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+// race detection makes things slow and miss timeouts
+//go:build fullchallengetest
+// +build fullchallengetest
+
+package arbtest
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/params"
+
+	"github.com/offchainlabs/nitro/arbnode"
+	"github.com/offchainlabs/nitro/solgen/go/rollupgen"
+	"github.com/offchainlabs/nitro/validator"
+	"github.com/offchainlabs/nitro/validator/challengesim"
+)
+
+func TestRollupChallengeSimulation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	l2info, l2nodeA, l2clientA, l1info, _, l1client, l1stack := createTestNodeOnL1(t, ctx, true)
+	defer requireClose(t, l1stack)
+	defer l2nodeA.StopAndWait()
+
+	// node B starts from a different genesis, so its staker makes incorrect assertions
+	l2info.GenerateGenesysAccount("FaultyAddr", common.Big1)
+	l2clientB, l2nodeB := Create2ndNodeWithConfig(t, ctx, l2nodeA, l1stack, l1info, &l2info.ArbInitData, arbnode.ConfigDefaultL1Test())
+	defer l2nodeB.StopAndWait()
+
+	balance := new(big.Int).Mul(big.NewInt(params.Ether), big.NewInt(100))
+	l1info.GenerateAccount("ValidatorA")
+	TransferBalance(t, "Faucet", "ValidatorA", balance, l1info, l1client, ctx)
+	l1authA := l1info.GetDefaultTransactOpts("ValidatorA", ctx)
+	l1info.GenerateAccount("ValidatorB")
+	TransferBalance(t, "Faucet", "ValidatorB", balance, l1info, l1client, ctx)
+	l1authB := l1info.GetDefaultTransactOpts("ValidatorB", ctx)
+
+	deployAuth := l1info.GetDefaultTransactOpts("RollupOwner", ctx)
+	rollup, err := rollupgen.NewRollupAdminLogic(l2nodeA.DeployInfo.Rollup, l1client)
+	Require(t, err)
+	tx, err := rollup.SetValidator(&deployAuth, []common.Address{l1authA.From, l1authB.From}, []bool{true, true})
+	Require(t, err)
+	_, err = EnsureTxSucceeded(ctx, l1client, tx)
+	Require(t, err)
+	tx, err = rollup.SetMinimumAssertionPeriod(&deployAuth, big.NewInt(1))
+	Require(t, err)
+	_, err = EnsureTxSucceeded(ctx, l1client, tx)
+	Require(t, err)
+
+	machineLoader := validator.NewNitroMachineLoader(validator.DefaultNitroMachineConfig, nil)
+	valConfig := validator.L1ValidatorConfig{
+		Strategy:           "MakeNodes",
+		TargetMachineCount: 4,
+	}
+	newStaker := func(node *arbnode.Node, auth *bind.TransactOpts) *validator.Staker {
+		wallet, err := validator.NewEoaValidatorWallet(node.DeployInfo.Rollup, node.L1Reader.Client(), auth)
+		Require(t, err)
+		staker, err := validator.NewStaker(
+			node.L1Reader,
+			wallet,
+			bind.CallOpts{},
+			valConfig,
+			node.ArbInterface.BlockChain(),
+			nil,
+			node.InboxReader,
+			node.InboxTracker,
+			node.TxStreamer,
+			node.BlockValidator,
+			machineLoader,
+			node.DeployInfo.ValidatorUtils,
+		)
+		Require(t, err)
+		Require(t, staker.Initialize(ctx))
+		return staker
+	}
+	stakers := []*validator.Staker{newStaker(l2nodeA, &l1authA), newStaker(l2nodeB, &l1authB)}
+
+	l2info.GenerateAccount("BackgroundUser")
+	tx = l2info.PrepareTx("Faucet", "BackgroundUser", l2info.TransferGas, balance, nil)
+	for _, client := range []*ethclient.Client{l2clientA, l2clientB} {
+		Require(t, client.SendTransaction(ctx, tx))
+		_, err = EnsureTxSucceeded(ctx, client, tx)
+		Require(t, err)
+	}
+	backgroundTxsCtx, cancelBackgroundTxs := context.WithCancel(ctx)
+	backgroundTxsShutdownChan := make(chan struct{})
+	defer (func() {
+		cancelBackgroundTxs()
+		<-backgroundTxsShutdownChan
+	})()
+	go (func() {
+		defer close(backgroundTxsShutdownChan)
+		err := makeBackgroundTxs(backgroundTxsCtx, l2info, l2clientA, l2clientB, true)
+		if !errors.Is(err, context.Canceled) {
+			log.Warn("error making background txs", "err", err)
+		}
+	})()
+
+	advanceTime := func(ctx context.Context, duration time.Duration) error {
+		start, err := l1client.HeaderByNumber(ctx, nil)
+		if err != nil {
+			return err
+		}
+		for {
+			TransferBalance(t, "Faucet", "Faucet", common.Big0, l1info, l1client, ctx)
+			header, err := l1client.HeaderByNumber(ctx, nil)
+			if err != nil {
+				return err
+			}
+			if time.Duration(header.Time-start.Time)*time.Second >= duration {
+				return nil
+			}
+		}
+	}
+	sim, err := challengesim.NewRollupSimulation(ctx, l1client, l2nodeA.DeployInfo.Rollup, &l1authA, &l1authB, advanceTime)
+	Require(t, err)
+
+	// let the stakers make conflicting nodes until one of them challenges the other
+	var challenge *challengesim.Challenge
+	for i := 0; challenge == nil; i++ {
+		if i >= 100 {
+			Fail(t, "stakers never started a challenge")
+		}
+		tx, err := stakers[i%2].Act(ctx)
+		if err != nil && strings.Contains(err.Error(), "waiting") {
+			time.Sleep(20 * time.Millisecond)
+			continue
+		}
+		Require(t, err, "staker", i%2, "failed to act")
+		if tx != nil {
+			_, err = EnsureTxSucceeded(ctx, l1client, tx)
+			Require(t, err)
+		}
+		challenge, err = sim.StakerChallenge(ctx, l1authA.From)
+		Require(t, err)
+		for j := 0; j < 5; j++ {
+			TransferBalance(t, "Faucet", "Faucet", common.Big0, l1info, l1client, ctx)
+		}
+	}
+	cancelBackgroundTxs()
+
+	nodeOf := func(node *arbnode.Node) *challengesim.Node {
+		return &challengesim.Node{
+			Blockchain:    node.ArbInterface.BlockChain(),
+			InboxReader:   node.InboxReader,
+			InboxTracker:  node.InboxTracker,
+			TxStreamer:    node.TxStreamer,
+			MachineLoader: machineLoader,
+		}
+	}
+	managerA, managerB, err := sim.NewBlockChallengeManagers(ctx, challenge, nodeOf(l2nodeA), nodeOf(l2nodeB), 4)
+	Require(t, err)
+	result, err := sim.Play(ctx, challenge, managerA, managerB, 200)
+	Require(t, err)
+
+	if result.Winner != l1authA.From || result.Loser != l1authB.From {
+		Fail(t, "faulty staker won the challenge", result.Winner)
+	}
+	modes := make(map[string]bool)
+	enteredExecution := false
+	for _, move := range result.Moves {
+		modes[move.Mode] = true
+		if move.Method == "challengeExecution" && move.Error == "" {
+			enteredExecution = true
+		}
+	}
+	if !modes["block"] || !modes["execution"] || !enteredExecution {
+		Fail(t, "challenge didn't move on from blocks to execution", modes)
+	}
+	isZombie, err := rollup.IsZombie(&bind.CallOpts{Context: ctx}, l1authB.From)
+	Require(t, err)
+	if !isZombie {
+		Fail(t, "rollup didn't receive the challenge result")
+	}
+}
@@ -261,3 +261,74 @@ func compressedTxSize(t *testing.T, tx *types.Transaction) uint64 {
 	Require(t, err)
 	return uint64(len(compressed))
 }
+
+func TestTransactionFeeBreakdown(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	l2info, l2node, l2client, _, _, _, l1stack := createTestNodeOnL1(t, ctx, true)
+	defer requireClose(t, l1stack)
+	defer l2node.StopAndWait()
+
+	ownerTxOpts := l2info.GetDefaultTransactOpts("Owner", ctx)
+	callOpts := l2info.GetDefaultCallOpts("Owner", ctx)
+	arbOwner, err := precompilesgen.NewArbOwner(common.HexToAddress("0x70"), l2client)
+	Require(t, err)
+	arbOwnerPublic, err := precompilesgen.NewArbOwnerPublic(common.HexToAddress("0x6b"), l2client)
+	Require(t, err)
+	arbGasInfo, err := precompilesgen.NewArbGasInfo(common.HexToAddress("0x6c"), l2client)
+	Require(t, err)
+	networkFeeAccount, err := arbOwnerPublic.GetNetworkFeeAccount(callOpts)
+	Require(t, err)
+	infraFeeAccount := common.HexToAddress("0x1f2e3d4c")
+	tx, err := arbOwner.SetInfraFeeAccount(&ownerTxOpts, infraFeeAccount)
+	Require(t, err)
+	_, err = EnsureTxSucceeded(ctx, l2client, tx)
+	Require(t, err)
+
+	l1Estimate, err := arbGasInfo.GetL1BaseFeeEstimate(callOpts)
+	Require(t, err)
+	networkBefore := GetBalance(t, ctx, l2client, networkFeeAccount)
+	infraBefore := GetBalance(t, ctx, l2client, infraFeeAccount)
+
+	l2info.GasPrice = GetBaseFee(t, l2client, ctx)
+	tx, receipt := TransferBalance(t, "Faucet", "Owner", big.NewInt(1), l2info, l2client, ctx)
+	networkRevenue := arbmath.BigSub(GetBalance(t, ctx, l2client, networkFeeAccount), networkBefore)
+	infraRevenue := arbmath.BigSub(GetBalance(t, ctx, l2client, infraFeeAccount), infraBefore)
+
+	l2rpc, err := l2node.Stack.Attach()
+	Require(t, err)
+	var breakdown arbnode.TransactionFeeBreakdown
+	err = l2rpc.CallContext(ctx, &breakdown, "arb_getTransactionFeeBreakdown", tx.Hash())
+	Require(t, err)
+
+	header, err := l2client.HeaderByHash(ctx, receipt.BlockHash)
+	Require(t, err)
+	if breakdown.GasUsed != receipt.GasUsed || breakdown.GasUsedForL1 != receipt.GasUsedForL1 {
+		Fail(t, "unexpected gas used", breakdown.GasUsed, breakdown.GasUsedForL1)
+	}
+	if !arbmath.BigEquals(breakdown.BaseFee, header.BaseFee) || !arbmath.BigEquals(breakdown.L1PricePerUnit, l1Estimate) {
+		Fail(t, "unexpected prices", breakdown.BaseFee, breakdown.L1PricePerUnit)
+	}
+	if breakdown.PosterUnits != compressedTxSize(t, tx)*params.TxDataNonZeroGasEIP2028 {
+		Fail(t, "unexpected poster units", breakdown.PosterUnits)
+	}
+	if !arbmath.BigEquals(breakdown.TotalFee, arbmath.BigMulByUint(header.BaseFee, receipt.GasUsed)) {
+		Fail(t, "unexpected total fee", breakdown.TotalFee)
+	}
+	if !arbmath.BigEquals(arbmath.BigAdd(breakdown.L1Fee, breakdown.L2Fee), breakdown.TotalFee) {
+		Fail(t, "fees don't add up", breakdown.L1Fee, breakdown.L2Fee, breakdown.TotalFee)
+	}
+	if breakdown.L1FeeRecipient != l1pricing.L1PricerFundsPoolAddress {
+		Fail(t, "unexpected L1 fee recipient", breakdown.L1FeeRecipient)
+	}
+	if breakdown.NetworkFeeAccount != networkFeeAccount || !arbmath.BigEquals(breakdown.NetworkFee, networkRevenue) {
+		Fail(t, "unexpected network fee", breakdown.NetworkFee, networkRevenue)
+	}
+	if breakdown.InfraFeeAccount != infraFeeAccount || !arbmath.BigEquals(breakdown.InfraFee, infraRevenue) {
+		Fail(t, "unexpected infra fee", breakdown.InfraFee, infraRevenue)
+	}
+	if breakdown.InfraFee.Sign() <= 0 {
+		Fail(t, "expected an infra fee")
+	}
+}
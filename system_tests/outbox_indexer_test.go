@@ -0,0 +1,98 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbtest
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+
+	"github.com/offchainlabs/nitro/arbnode"
+	"github.com/offchainlabs/nitro/solgen/go/precompilesgen"
+)
+
+func TestOutboxIndexer(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	config := arbnode.ConfigDefaultL1Test()
+	config.OutboxIndexer.Enable = true
+	config.OutboxIndexer.PollInterval = 10 * time.Millisecond
+	l2info, l2node, l2client, _, _, _, l1stack := createTestNodeOnL1WithConfig(t, ctx, true, config, nil, nil)
+	defer requireClose(t, l1stack)
+	defer l2node.StopAndWait()
+
+	arbSys, err := precompilesgen.NewArbSys(types.ArbSysAddress, l2client)
+	Require(t, err)
+	auth := l2info.GetDefaultTransactOpts("Owner", ctx)
+	destinations := []common.Address{common.HexToAddress("0xd1"), common.HexToAddress("0xd2")}
+	calldata := []byte{1, 2, 3, 4}
+	var receipts []*types.Receipt
+	for i, destination := range destinations {
+		auth.Value = big.NewInt(int64(i+1) * 1000)
+		tx, err := arbSys.SendTxToL1(&auth, destination, calldata)
+		Require(t, err)
+		receipt, err := EnsureTxSucceeded(ctx, l2client, tx)
+		Require(t, err)
+		receipts = append(receipts, receipt)
+	}
+	auth.Value = nil
+
+	l2rpc, err := l2node.Stack.Attach()
+	Require(t, err)
+	query := arbnode.OutboxMessageQuery{Sender: &auth.From}
+	var messages []*arbnode.OutboxMessageStatus
+	for {
+		Require(t, l2rpc.CallContext(ctx, &messages, "arb_outboxMessages", query))
+		if len(messages) == len(destinations) {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			Fail(t, "messages never indexed")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	for i, message := range messages {
+		if message.Destination != destinations[i] || message.CallValue.Int64() != int64(i+1)*1000 {
+			Fail(t, "unexpected message", i, message.Destination, message.CallValue)
+		}
+		if message.CalldataHash != crypto.Keccak256Hash(calldata) || message.TxHash != receipts[i].TxHash {
+			Fail(t, "unexpected message", i, message.CalldataHash, message.TxHash)
+		}
+		if message.BlockNumber != receipts[i].BlockNumber.Uint64() {
+			Fail(t, "unexpected block", i, message.BlockNumber)
+		}
+		if message.Confirmable {
+			Fail(t, "message confirmable without a confirmed rollup node", i)
+		}
+	}
+	if messages[1].Position != messages[0].Position+1 {
+		Fail(t, "unexpected positions", messages[0].Position, messages[1].Position)
+	}
+
+	query = arbnode.OutboxMessageQuery{Destination: &destinations[1]}
+	Require(t, l2rpc.CallContext(ctx, &messages, "arb_outboxMessages", query))
+	if len(messages) != 1 || messages[0].TxHash != receipts[1].TxHash {
+		Fail(t, "unexpected messages by destination", len(messages))
+	}
+
+	var message arbnode.OutboxMessageStatus
+	Require(t, l2rpc.CallContext(ctx, &message, "arb_outboxMessage", hexutil.Uint64(messages[0].Position)))
+	if message.Hash != messages[0].Hash {
+		Fail(t, "unexpected message by position", message.Hash)
+	}
+	var proof arbnode.OutboxProof
+	err = l2rpc.CallContext(ctx, &proof, "arb_outboxProof", hexutil.Uint64(messages[0].Position))
+	if err == nil {
+		Fail(t, "served a proof for an unconfirmed message")
+	}
+}
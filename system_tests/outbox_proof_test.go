@@ -0,0 +1,156 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+// race detection makes things slow and miss timeouts
+//go:build !race
+// +build !race
+
+package arbtest
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+
+	"github.com/offchainlabs/nitro/arbnode"
+	"github.com/offchainlabs/nitro/solgen/go/bridgegen"
+	"github.com/offchainlabs/nitro/solgen/go/precompilesgen"
+	"github.com/offchainlabs/nitro/solgen/go/rollupgen"
+	"github.com/offchainlabs/nitro/validator"
+)
+
+func TestOutboxIndexerProof(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	config := arbnode.ConfigDefaultL1Test()
+	config.OutboxIndexer.Enable = true
+	config.OutboxIndexer.PollInterval = 10 * time.Millisecond
+	l2info, l2node, l2client, l1info, _, l1client, l1stack := createTestNodeOnL1WithConfig(t, ctx, true, config, nil, nil)
+	defer requireClose(t, l1stack)
+	defer l2node.StopAndWait()
+
+	arbSys, err := precompilesgen.NewArbSys(types.ArbSysAddress, l2client)
+	Require(t, err)
+	auth := l2info.GetDefaultTransactOpts("Owner", ctx)
+	destination := common.HexToAddress("0xd1")
+	calldata := []byte{1, 2, 3, 4}
+	tx, err := arbSys.SendTxToL1(&auth, destination, calldata)
+	Require(t, err)
+	_, err = EnsureTxSucceeded(ctx, l2client, tx)
+	Require(t, err)
+	l2rpc, err := l2node.Stack.Attach()
+	Require(t, err)
+	var messages []*arbnode.OutboxMessageStatus
+	for len(messages) == 0 {
+		Require(t, l2rpc.CallContext(ctx, &messages, "arb_outboxMessages", arbnode.OutboxMessageQuery{Sender: &auth.From}))
+		select {
+		case <-ctx.Done():
+			Fail(t, "message never indexed")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	position := messages[0].Position
+
+	deployAuth := l1info.GetDefaultTransactOpts("RollupOwner", ctx)
+	l1info.GenerateAccount("Validator")
+	TransferBalance(t, "Faucet", "Validator", new(big.Int).Mul(big.NewInt(params.Ether), big.NewInt(100)), l1info, l1client, ctx)
+	l1auth := l1info.GetDefaultTransactOpts("Validator", ctx)
+	rollup, err := rollupgen.NewRollupAdminLogic(l2node.DeployInfo.Rollup, l1client)
+	Require(t, err)
+	tx, err = rollup.SetValidator(&deployAuth, []common.Address{l1auth.From}, []bool{true})
+	Require(t, err)
+	_, err = EnsureTxSucceeded(ctx, l1client, tx)
+	Require(t, err)
+	tx, err = rollup.SetMinimumAssertionPeriod(&deployAuth, big.NewInt(1))
+	Require(t, err)
+	_, err = EnsureTxSucceeded(ctx, l1client, tx)
+	Require(t, err)
+
+	wallet, err := validator.NewEoaValidatorWallet(l2node.DeployInfo.Rollup, l2node.L1Reader.Client(), &l1auth)
+	Require(t, err)
+	staker, err := validator.NewStaker(
+		l2node.L1Reader,
+		wallet,
+		bind.CallOpts{},
+		validator.L1ValidatorConfig{Strategy: "MakeNodes", TargetMachineCount: 4},
+		l2node.ArbInterface.BlockChain(),
+		nil,
+		l2node.InboxReader,
+		l2node.InboxTracker,
+		l2node.TxStreamer,
+		l2node.BlockValidator,
+		validator.NewNitroMachineLoader(validator.DefaultNitroMachineConfig, nil),
+		l2node.DeployInfo.ValidatorUtils,
+	)
+	Require(t, err)
+	Require(t, staker.Initialize(ctx))
+
+	// assert and confirm a rollup node including the message
+	var proof arbnode.OutboxProof
+	for i := 0; ; i++ {
+		if i >= 100 {
+			Fail(t, "message never confirmed")
+		}
+		tx, err := staker.Act(ctx)
+		if err != nil && strings.Contains(err.Error(), "waiting") {
+			time.Sleep(20 * time.Millisecond)
+			continue
+		}
+		Require(t, err, "staker failed to act")
+		if tx != nil {
+			_, err = EnsureTxSucceeded(ctx, l1client, tx)
+			Require(t, err)
+		}
+		if l2rpc.CallContext(ctx, &proof, "arb_outboxProof", hexutil.Uint64(position)) == nil {
+			break
+		}
+		for j := 0; j < 5; j++ {
+			TransferBalance(t, "Faucet", "Faucet", common.Big0, l1info, l1client, ctx)
+		}
+	}
+
+	rollupUser, err := rollupgen.NewRollupUserLogic(l2node.DeployInfo.Rollup, l1client)
+	Require(t, err)
+	outboxAddr, err := rollupUser.Outbox(&bind.CallOpts{Context: ctx})
+	Require(t, err)
+	outbox, err := bridgegen.NewOutbox(outboxAddr, l1client)
+	Require(t, err)
+	callOpts := &bind.CallOpts{Context: ctx}
+	item, err := outbox.CalculateItemHash(callOpts, proof.L2Sender, proof.To, new(big.Int).SetUint64(proof.L2Block), new(big.Int).SetUint64(proof.L1Block), new(big.Int).SetUint64(proof.L2Timestamp), proof.Value, proof.Data)
+	Require(t, err)
+	path := make([][32]byte, len(proof.Proof))
+	for i, hash := range proof.Proof {
+		path[i] = hash
+	}
+	root, err := outbox.CalculateMerkleRoot(callOpts, path, new(big.Int).SetUint64(proof.Index), item)
+	Require(t, err)
+	if root != proof.Root {
+		Fail(t, "proof leads to root", common.Hash(root), "instead of", proof.Root)
+	}
+	blockHash, err := outbox.Roots(callOpts, proof.Root)
+	Require(t, err)
+	if blockHash == (common.Hash{}) {
+		Fail(t, "proof root", proof.Root, "isn't a confirmed send root")
+	}
+	if proof.Index != position || proof.To != destination || proof.L2Sender != auth.From || proof.Size <= position {
+		Fail(t, "unexpected proof", proof.Index, proof.To, proof.L2Sender, proof.Size)
+	}
+
+	l1info.GenerateAccount("Executor")
+	TransferBalance(t, "Faucet", "Executor", big.NewInt(params.Ether), l1info, l1client, ctx)
+	executorAuth := l1info.GetDefaultTransactOpts("Executor", ctx)
+	tx, err = outbox.ExecuteTransaction(&executorAuth, path, new(big.Int).SetUint64(proof.Index), proof.L2Sender, proof.To, new(big.Int).SetUint64(proof.L2Block), new(big.Int).SetUint64(proof.L1Block), new(big.Int).SetUint64(proof.L2Timestamp), proof.Value, proof.Data)
+	Require(t, err)
+	_, err = EnsureTxSucceeded(ctx, l1client, tx)
+	Require(t, err)
+}
@@ -0,0 +1,77 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbtest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/offchainlabs/nitro/arbnode"
+	"github.com/offchainlabs/nitro/solgen/go/precompilesgen"
+)
+
+func TestParameterHistory(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	l2info, l2node, l2client, _, _, _, l1stack := createTestNodeOnL1(t, ctx, true)
+	defer requireClose(t, l1stack)
+	defer l2node.StopAndWait()
+
+	ownerTxOpts := l2info.GetDefaultTransactOpts("Owner", ctx)
+	arbOwner, err := precompilesgen.NewArbOwner(common.HexToAddress("0x70"), l2client)
+	Require(t, err)
+
+	start, err := l2client.BlockNumber(ctx)
+	Require(t, err)
+	tx, err := arbOwner.SetSpeedLimit(&ownerTxOpts, 12345)
+	Require(t, err)
+	speedLimitReceipt, err := EnsureTxSucceeded(ctx, l2client, tx)
+	Require(t, err)
+
+	// an unrelated transaction shouldn't show up in the history
+	TransferBalance(t, "Faucet", "Owner", common.Big1, l2info, l2client, ctx)
+
+	newOwner := common.HexToAddress("0x5eed")
+	tx, err = arbOwner.AddChainOwner(&ownerTxOpts, newOwner)
+	Require(t, err)
+	ownerReceipt, err := EnsureTxSucceeded(ctx, l2client, tx)
+	Require(t, err)
+
+	l2rpc, err := l2node.Stack.Attach()
+	Require(t, err)
+	var history arbnode.ParameterHistory
+	err = l2rpc.CallContext(ctx, &history, "arb_getParameterHistory", rpc.BlockNumber(start), rpc.LatestBlockNumber)
+	Require(t, err)
+
+	if history.Initial["l2SpeedLimitPerSecond"] == "12345" {
+		Fail(t, "speed limit was already set", history.Initial)
+	}
+	if len(history.Changes) != 2 {
+		Fail(t, "unexpected changes", len(history.Changes))
+	}
+	speedLimitChange := history.Changes[0]
+	if speedLimitChange.Parameter != "l2SpeedLimitPerSecond" || speedLimitChange.NewValue != "12345" {
+		Fail(t, "unexpected change", speedLimitChange.Parameter, speedLimitChange.NewValue)
+	}
+	if speedLimitChange.OldValue != history.Initial["l2SpeedLimitPerSecond"] {
+		Fail(t, "unexpected old value", speedLimitChange.OldValue)
+	}
+	if speedLimitChange.BlockNumber != speedLimitReceipt.BlockNumber.Uint64() {
+		Fail(t, "unexpected block", speedLimitChange.BlockNumber)
+	}
+	if len(speedLimitChange.Transactions) != 1 || speedLimitChange.Transactions[0] != speedLimitReceipt.TxHash {
+		Fail(t, "unexpected transactions", speedLimitChange.Transactions)
+	}
+	ownerChange := history.Changes[1]
+	if ownerChange.Parameter != "chainOwner" || ownerChange.NewValue != newOwner.Hex() || ownerChange.OldValue != "" {
+		Fail(t, "unexpected owner change", ownerChange.Parameter, ownerChange.OldValue, ownerChange.NewValue)
+	}
+	if len(ownerChange.Transactions) != 1 || ownerChange.Transactions[0] != ownerReceipt.TxHash {
+		Fail(t, "unexpected transactions", ownerChange.Transactions)
+	}
+}
@@ -5,11 +5,16 @@ package arbtest
 
 import (
 	"context"
+	"math/big"
 	"testing"
+	"time"
 
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	_ "github.com/ethereum/go-ethereum/eth/tracers/native"
 	"github.com/ethereum/go-ethereum/params"
+	"github.com/offchainlabs/nitro/precompiles"
 	"github.com/offchainlabs/nitro/solgen/go/precompilesgen"
 )
 
@@ -48,3 +53,82 @@ func TestCustomSolidityErrors(t *testing.T) {
 		Fail(t, observedMessage)
 	}
 }
+
+type precompileTraceFrame struct {
+	To         common.Address          `json:"to"`
+	Error      string                  `json:"error"`
+	Precompile *precompileTraceCall    `json:"precompile"`
+	Calls      []*precompileTraceFrame `json:"calls"`
+}
+
+type precompileTraceCall struct {
+	Contract string                 `json:"contract"`
+	Method   string                 `json:"method"`
+	Args     map[string]interface{} `json:"args"`
+	Results  map[string]interface{} `json:"results"`
+	Events   []struct {
+		Name string                 `json:"name"`
+		Args map[string]interface{} `json:"args"`
+	} `json:"events"`
+	Error string `json:"error"`
+}
+
+func TestPrecompileTracer(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	l2info, node, client := CreateTestL2(t, ctx)
+	defer node.StopAndWait()
+	l2rpc, err := node.Stack.Attach()
+	Require(t, err)
+	traceConfig := map[string]interface{}{"tracer": precompiles.PrecompileTracerName}
+
+	auth := l2info.GetDefaultTransactOpts("Owner", ctx)
+	arbSys, err := precompilesgen.NewArbSys(types.ArbSysAddress, client)
+	Require(t, err)
+	destination := common.HexToAddress("0xde57")
+	auth.Value = big.NewInt(1000)
+	tx, err := arbSys.WithdrawEth(&auth, destination)
+	Require(t, err)
+	_, err = EnsureTxSucceeded(ctx, client, tx)
+	Require(t, err)
+	auth.Value = nil
+
+	var frame precompileTraceFrame
+	Require(t, l2rpc.CallContext(ctx, &frame, "debug_traceTransaction", tx.Hash(), traceConfig))
+	call := frame.Precompile
+	if call == nil || call.Contract != "ArbSys" || call.Method != "withdrawEth" {
+		Fail(t, "withdrawal not decoded", call)
+	}
+	if arg, _ := call.Args["destination"].(string); common.HexToAddress(arg) != destination {
+		Fail(t, "unexpected destination", call.Args)
+	}
+	if _, ok := call.Results["arg0"]; !ok {
+		Fail(t, "missing result", call.Results)
+	}
+	withdrawn := false
+	for _, event := range call.Events {
+		withdrawn = withdrawn || event.Name == "L2ToL1Tx"
+	}
+	if !withdrawn {
+		Fail(t, "missing event", call.Events)
+	}
+
+	arbRetryableTx, err := precompilesgen.NewArbRetryableTx(types.ArbRetryableTxAddress, client)
+	Require(t, err)
+	auth.GasLimit = 10000000 // the call reverts, so can't be estimated
+	tx, err = arbRetryableTx.Keepalive(&auth, common.Hash{})
+	Require(t, err)
+	receipt, err := WaitForTx(ctx, client, tx.Hash(), time.Second*5)
+	Require(t, err)
+	if receipt.Status != types.ReceiptStatusFailed {
+		Fail(t, "keepalive of a nonexistent ticket succeeded")
+	}
+
+	var revertFrame precompileTraceFrame
+	Require(t, l2rpc.CallContext(ctx, &revertFrame, "debug_traceTransaction", tx.Hash(), traceConfig))
+	call = revertFrame.Precompile
+	if call == nil || call.Method != "keepalive" || call.Error != "error NoTicketWithID()" {
+		Fail(t, "revert not decoded", call)
+	}
+}
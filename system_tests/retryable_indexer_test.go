@@ -0,0 +1,154 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbtest
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/offchainlabs/nitro/arbnode"
+	"github.com/offchainlabs/nitro/arbos/l2pricing"
+	"github.com/offchainlabs/nitro/arbos/util"
+	"github.com/offchainlabs/nitro/solgen/go/bridgegen"
+	"github.com/offchainlabs/nitro/solgen/go/mocksgen"
+	"github.com/offchainlabs/nitro/solgen/go/precompilesgen"
+	"github.com/offchainlabs/nitro/util/arbmath"
+)
+
+func waitForRetryableTicket(
+	t *testing.T, ctx context.Context, indexer *arbnode.RetryableIndexer, query *arbnode.RetryableTicketQuery, done func(*arbnode.RetryableTicket) bool,
+) *arbnode.RetryableTicket {
+	for i := 0; i < 500; i++ {
+		tickets, err := indexer.Tickets(query)
+		Require(t, err)
+		if len(tickets) > 1 {
+			Fail(t, "expected one ticket, found", len(tickets))
+		}
+		if len(tickets) == 1 && done(tickets[0]) {
+			return tickets[0]
+		}
+		select {
+		case <-ctx.Done():
+			Fail(t, ctx.Err())
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+	Fail(t, "timed out waiting for the retryable indexer")
+	return nil
+}
+
+// submitFailingRetryable submits a retryable from L1 whose auto-redeem fails,
+// and returns its destination and the aliased sender.
+func submitFailingRetryable(
+	t *testing.T, ctx context.Context, l2info info, l1info info, l2client *ethclient.Client, l1client *ethclient.Client,
+) (common.Address, common.Address) {
+	l2info.GenerateAccount("Beneficiary")
+	l2info.GenerateAccount("Burn")
+	// burn some gas so that the faucet's Callvalue + Balance never exceeds a uint256
+	discard := arbmath.BigMul(big.NewInt(1e12), big.NewInt(1e12))
+	TransferBalance(t, "Faucet", "Burn", discard, l2info, l2client, ctx)
+
+	delayedInbox, err := bridgegen.NewInbox(l1info.GetAddress("Inbox"), l1client)
+	Require(t, err)
+	ownerTxOpts := l2info.GetDefaultTransactOpts("Owner", ctx)
+	usertxopts := l1info.GetDefaultTransactOpts("Faucet", ctx)
+	usertxopts.Value = arbmath.BigMul(big.NewInt(1e12), big.NewInt(1e12))
+
+	simpleAddr, _ := deploySimple(t, ctx, ownerTxOpts, l2client)
+	simpleABI, err := mocksgen.SimpleMetaData.GetAbi()
+	Require(t, err)
+
+	beneficiaryAddress := l2info.GetAddress("Beneficiary")
+	l1tx, err := delayedInbox.CreateRetryableTicket(
+		&usertxopts,
+		simpleAddr,
+		common.Big0,
+		big.NewInt(1e16),
+		beneficiaryAddress,
+		beneficiaryAddress,
+		// send enough L2 gas for intrinsic but not compute, so the auto-redeem fails
+		big.NewInt(int64(params.TxGas+params.TxDataNonZeroGasEIP2028*4)),
+		big.NewInt(l2pricing.InitialBaseFeeWei*2),
+		simpleABI.Methods["incrementRedeem"].ID,
+	)
+	Require(t, err)
+	_, err = EnsureTxSucceeded(ctx, l1client, l1tx)
+	Require(t, err)
+	waitForL1DelayBlocks(t, ctx, l1client, l1info)
+	return simpleAddr, util.RemapL1Address(usertxopts.From)
+}
+
+func TestRetryableIndexer(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	config := arbnode.ConfigDefaultL1Test()
+	config.RetryableIndexer.Enable = true
+	config.RetryableIndexer.PollInterval = 10 * time.Millisecond
+	l2info, l2node, l2client, l1info, _, l1client, l1stack := createTestNodeOnL1WithConfig(t, ctx, true, config, nil, nil)
+	defer requireClose(t, l1stack)
+	defer l2node.StopAndWait()
+
+	simpleAddr, sender := submitFailingRetryable(t, ctx, l2info, l1info, l2client, l1client)
+	beneficiaryAddress := l2info.GetAddress("Beneficiary")
+	ownerTxOpts := l2info.GetDefaultTransactOpts("Owner", ctx)
+	indexer := l2node.RetryableIndexer
+	bySender := &arbnode.RetryableTicketQuery{Sender: &sender}
+	ticket := waitForRetryableTicket(t, ctx, indexer, bySender, func(ticket *arbnode.RetryableTicket) bool {
+		return len(ticket.Redeems) == 1 && ticket.Redeems[0].Status != arbnode.RedeemPending
+	})
+	if ticket.Status != arbnode.RetryableOpen || ticket.Redeems[0].Status != arbnode.RedeemFailed {
+		Fail(t, "unexpected ticket after failed auto-redeem", ticket.Status, ticket.Redeems[0].Status)
+	}
+	if ticket.Beneficiary != beneficiaryAddress || ticket.To == nil || *ticket.To != simpleAddr {
+		Fail(t, "unexpected ticket", ticket)
+	}
+	open := arbnode.RetryableOpen
+	expiresBefore := ticket.Expiry + 1
+	tickets, err := indexer.Tickets(&arbnode.RetryableTicketQuery{Status: &open, ExpiresBefore: &expiresBefore})
+	Require(t, err)
+	if len(tickets) != 1 || tickets[0].TicketId != ticket.TicketId {
+		Fail(t, "open ticket not found by expiry", len(tickets))
+	}
+	tickets, err = indexer.Tickets(&arbnode.RetryableTicketQuery{Status: &open, ExpiresBefore: &ticket.Expiry})
+	Require(t, err)
+	if len(tickets) != 0 {
+		Fail(t, "ticket found after expiry window", len(tickets))
+	}
+
+	arbRetryableTx, err := precompilesgen.NewArbRetryableTx(types.ArbRetryableTxAddress, l2client)
+	Require(t, err)
+	tx, err := arbRetryableTx.Redeem(&ownerTxOpts, ticket.TicketId)
+	Require(t, err)
+	_, err = EnsureTxSucceeded(ctx, l2client, tx)
+	Require(t, err)
+
+	byDestination := &arbnode.RetryableTicketQuery{Destination: &simpleAddr}
+	ticket = waitForRetryableTicket(t, ctx, indexer, byDestination, func(ticket *arbnode.RetryableTicket) bool {
+		return ticket.Status == arbnode.RetryableRedeemed
+	})
+	if len(ticket.Redeems) != 2 || ticket.Redeems[1].Status != arbnode.RedeemSucceeded || ticket.Redeems[1].GasDonor != ownerTxOpts.From {
+		Fail(t, "unexpected redeems", ticket.Redeems)
+	}
+	if ticket.ClosedBlock == 0 {
+		Fail(t, "redeemed ticket not closed")
+	}
+	tickets, err = indexer.Tickets(&arbnode.RetryableTicketQuery{Status: &open})
+	Require(t, err)
+	if len(tickets) != 0 {
+		Fail(t, "redeemed ticket still indexed as open")
+	}
+	indexed, err := indexer.Ticket(ticket.TicketId)
+	Require(t, err)
+	if indexed == nil || indexed.Status != arbnode.RetryableRedeemed {
+		Fail(t, "ticket lookup by id failed")
+	}
+}
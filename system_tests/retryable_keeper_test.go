@@ -0,0 +1,62 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package arbtest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/rawdb"
+
+	"github.com/offchainlabs/nitro/arbnode"
+	"github.com/offchainlabs/nitro/arbos/retryables"
+)
+
+func TestRetryableKeeper(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	config := arbnode.ConfigDefaultL1Test()
+	config.RetryableIndexer.Enable = true
+	config.RetryableIndexer.PollInterval = 10 * time.Millisecond
+	l2info, l2node, l2client, l1info, _, l1client, l1stack := createTestNodeOnL1WithConfig(t, ctx, true, config, nil, nil)
+	defer requireClose(t, l1stack)
+	defer l2node.StopAndWait()
+
+	simpleAddr, sender := submitFailingRetryable(t, ctx, l2info, l1info, l2client, l1client)
+	indexer := l2node.RetryableIndexer
+	bySender := &arbnode.RetryableTicketQuery{Sender: &sender}
+	ticket := waitForRetryableTicket(t, ctx, indexer, bySender, func(ticket *arbnode.RetryableTicket) bool {
+		return len(ticket.Redeems) == 1 && ticket.Redeems[0].Status != arbnode.RedeemPending
+	})
+	createdExpiry := ticket.Expiry
+
+	keeperConfig := arbnode.DefaultRetryableKeeperConfig
+	keeperConfig.Destinations = []string{simpleAddr.Hex()}
+	// the new ticket is within a lifetime of expiring, so it's kept alive right away
+	keeperConfig.KeepaliveMargin = 8 * 24 * time.Hour
+	keeperConfig.PollInterval = 10 * time.Millisecond
+	keeperConfig.DailySpendCap = 0
+	ownerTxOpts := l2info.GetDefaultTransactOpts("Owner", ctx)
+	keeper, err := arbnode.NewRetryableKeeper(l2node.Stack, l2node.ArbInterface.BlockChain(), rawdb.NewMemoryDatabase(), &ownerTxOpts, func() *arbnode.RetryableKeeperConfig { return &keeperConfig })
+	Require(t, err)
+	keeper.Start(ctx)
+	defer keeper.StopAndWait()
+
+	ticket = waitForRetryableTicket(t, ctx, indexer, bySender, func(ticket *arbnode.RetryableTicket) bool {
+		return ticket.Keepalives > 0
+	})
+	if ticket.Expiry != createdExpiry+retryables.RetryableLifetimeSeconds {
+		Fail(t, "unexpected expiry after keepalive", ticket.Expiry, createdExpiry)
+	}
+	// once kept alive, the ticket is no longer within the margin
+	time.Sleep(200 * time.Millisecond)
+	ticket, err = indexer.Ticket(ticket.TicketId)
+	Require(t, err)
+	if ticket.Keepalives != 1 || ticket.Status != arbnode.RetryableOpen {
+		Fail(t, "unexpected ticket after keepalive", ticket.Keepalives, ticket.Status)
+	}
+}
@@ -394,3 +394,73 @@ func waitForL1DelayBlocks(t *testing.T, ctx context.Context, l1client *ethclient
 		})
 	}
 }
+
+func TestEstimateRetryableDeposit(t *testing.T) {
+	t.Parallel()
+	l2info, l1info, l2client, l1client, delayedInbox, lookupSubmitRetryableL2TxHash, ctx, teardown := retryableSetup(t)
+	defer teardown()
+
+	user2Address := l2info.GetAddress("User2")
+	beneficiaryAddress := l2info.GetAddress("Beneficiary")
+	callValue := big.NewInt(1e6)
+	calldata := []byte{0x32, 0x42, 0x32, 0x88}
+
+	nodeInterface, err := node_interfacegen.NewNodeInterface(types.NodeInterfaceAddress, l2client)
+	Require(t, err)
+	usertxoptsL1 := l1info.GetDefaultTransactOpts("Faucet", ctx)
+	estimate, err := nodeInterface.EstimateRetryableDeposit(
+		&bind.CallOpts{},
+		usertxoptsL1.From,
+		user2Address,
+		callValue,
+		beneficiaryAddress,
+		beneficiaryAddress,
+		calldata,
+		5000,
+	)
+	Require(t, err, "failed to estimate deposit")
+	colors.PrintBlue("deposit estimate: ", estimate.Deposit, " gas: ", estimate.GasLimit)
+
+	baseFee := GetBaseFee(t, l2client, ctx)
+	if !arbmath.BigEquals(estimate.MaxFeePerGas, arbmath.BigMulByBips(baseFee, 15000)) {
+		Fail(t, "unexpected max fee per gas", estimate.MaxFeePerGas, baseFee)
+	}
+	if estimate.GasLimit < params.TxGas {
+		Fail(t, "gas limit too low", estimate.GasLimit)
+	}
+	expectedDeposit := arbmath.BigAdd(estimate.MaxSubmissionCost, callValue)
+	expectedDeposit.Add(expectedDeposit, arbmath.BigMulByUint(estimate.MaxFeePerGas, estimate.GasLimit))
+	if !arbmath.BigEquals(estimate.Deposit, expectedDeposit) {
+		Fail(t, "unexpected deposit", estimate.Deposit, expectedDeposit)
+	}
+
+	// submit & auto-redeem the retryable using exactly the estimated parameters
+	usertxoptsL1.Value = estimate.Deposit
+	l1tx, err := delayedInbox.CreateRetryableTicket(
+		&usertxoptsL1,
+		user2Address,
+		callValue,
+		estimate.MaxSubmissionCost,
+		beneficiaryAddress,
+		beneficiaryAddress,
+		arbmath.UintToBig(estimate.GasLimit),
+		estimate.MaxFeePerGas,
+		calldata,
+	)
+	Require(t, err)
+	l1receipt, err := EnsureTxSucceeded(ctx, l1client, l1tx)
+	Require(t, err)
+
+	waitForL1DelayBlocks(t, ctx, l1client, l1info)
+
+	receipt, err := WaitForTx(ctx, l2client, lookupSubmitRetryableL2TxHash(l1receipt), time.Second*5)
+	Require(t, err)
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		Fail(t)
+	}
+	l2balance, err := l2client.BalanceAt(ctx, user2Address, nil)
+	Require(t, err)
+	if !arbmath.BigEquals(l2balance, callValue) {
+		Fail(t, "auto-redeem didn't succeed, unexpected balance:", l2balance)
+	}
+}
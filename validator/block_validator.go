@@ -54,6 +54,11 @@ type BlockValidator struct {
 	sendValidationsChan chan struct{}
 	checkProgressChan   chan struct{}
 	progressChan        chan uint64
+
+	history        *validationHistory
+	requiredBlocks requiredBlocks
+
+	validationResultsPrunedBefore uint64 // only accessed with the reorgMutex
 }
 
 type BlockValidatorConfig struct {
@@ -66,6 +71,10 @@ type BlockValidatorConfig struct {
 	CurrentModuleRoot        string                        `koanf:"current-module-root"`          // TODO(magic) requires reinitialization on hot reload
 	PendingUpgradeModuleRoot string                        `koanf:"pending-upgrade-module-root"`  // TODO(magic) requires StatelessBlockValidator recreation on hot reload
 	StorePreimages           bool                          `koanf:"store-preimages" reload:"hot"` // TODO verify if hot reloading is safe
+	HistorySize              int                           `koanf:"history-size"`
+	Sampling                 SamplingConfig                `koanf:"sampling"`
+	ValidationCache          ValidationCacheConfig         `koanf:"validation-cache"`
+	RemoteValidation         RemoteValidationConfig        `koanf:"remote-validation"`
 	Dangerous                BlockValidatorDangerousConfig `koanf:"dangerous"`
 }
 
@@ -85,6 +94,10 @@ func BlockValidatorConfigAddOptions(prefix string, f *flag.FlagSet) {
 	f.String(prefix+".current-module-root", DefaultBlockValidatorConfig.CurrentModuleRoot, "current wasm module root ('current' read from chain, 'latest' from machines/latest dir, or provide hash)")
 	f.String(prefix+".pending-upgrade-module-root", DefaultBlockValidatorConfig.PendingUpgradeModuleRoot, "pending upgrade wasm module root to additionally validate (hash, 'latest' or empty)")
 	f.Bool(prefix+".store-preimages", DefaultBlockValidatorConfig.StorePreimages, "store preimages of running machines (higher memory cost, better debugging, potentially better performance)")
+	f.Int(prefix+".history-size", DefaultBlockValidatorConfig.HistorySize, "number of recently finished block validations to report in the validation progress")
+	SamplingConfigAddOptions(prefix+".sampling", f)
+	ValidationCacheConfigAddOptions(prefix+".validation-cache", f)
+	RemoteValidationConfigAddOptions(prefix+".remote-validation", f)
 	BlockValidatorDangerousConfigAddOptions(prefix+".dangerous", f)
 }
 
@@ -102,6 +115,10 @@ var DefaultBlockValidatorConfig = BlockValidatorConfig{
 	CurrentModuleRoot:        "current",
 	PendingUpgradeModuleRoot: "latest",
 	StorePreimages:           false,
+	HistorySize:              64,
+	Sampling:                 DefaultSamplingConfig,
+	ValidationCache:          DefaultValidationCacheConfig,
+	RemoteValidation:         DefaultRemoteValidationConfig,
 	Dangerous:                DefaultBlockValidatorDangerousConfig,
 }
 
@@ -115,6 +132,10 @@ var TestBlockValidatorConfig = BlockValidatorConfig{
 	CurrentModuleRoot:        "latest",
 	PendingUpgradeModuleRoot: "latest",
 	StorePreimages:           false,
+	HistorySize:              64,
+	Sampling:                 DefaultSamplingConfig,
+	ValidationCache:          DefaultValidationCacheConfig,
+	RemoteValidation:         DefaultRemoteValidationConfig,
 	Dangerous:                DefaultBlockValidatorDangerousConfig,
 }
 
@@ -132,6 +153,9 @@ type validationStatus struct {
 	Cancel      func()           // non-atomic: only read/written to with reorg mutex
 	Entry       *validationEntry // non-atomic: only read if Status >= validationStatusPrepared
 	ModuleRoots []common.Hash    // non-atomic: present from the start
+	Started     int64            // atomic: unix nanoseconds the validation started at, or 0
+	Failure     string           // non-atomic: only read if Status == validationStatusFailed
+	MessageKind uint8            // non-atomic: present from the start
 }
 
 func NewBlockValidator(
@@ -148,6 +172,7 @@ func NewBlockValidator(
 		checkProgressChan:       make(chan struct{}, 1),
 		progressChan:            make(chan uint64, 1),
 		config:                  config,
+		history:                 newValidationHistory(config().HistorySize),
 	}
 	err := validator.readLastBlockValidatedDbInfo(reorgingToBlock)
 	if err != nil {
@@ -223,7 +248,9 @@ func (v *BlockValidator) readLastBlockValidatedDbInfo(reorgingToBlock *types.Blo
 }
 
 func (v *BlockValidator) prepareBlock(ctx context.Context, header *types.Header, prevHeader *types.Header, msg arbstate.MessageWithMetadata, validationStatus *validationStatus) {
-	preimages, readBatchInfo, hasDelayedMessage, delayedMsgToRead, err := BlockDataForValidation(ctx, v.blockchain, v.inboxReader, header, prevHeader, msg, v.config().StorePreimages)
+	// Remote workers have no access to our database, so need every preimage recorded
+	producePreimages := v.config().StorePreimages || v.remoteValidator != nil
+	preimages, readBatchInfo, hasDelayedMessage, delayedMsgToRead, err := BlockDataForValidation(ctx, v.blockchain, v.inboxReader, header, prevHeader, msg, producePreimages)
 	if err != nil {
 		log.Error("failed to set up validation", "err", err, "header", header, "prevHeader", prevHeader)
 		return
@@ -263,6 +290,9 @@ func (v *BlockValidator) NewBlock(block *types.Block, prevHeader *types.Header,
 		Entry:       nil,
 		ModuleRoots: v.GetModuleRootsToValidate(),
 	}
+	if msg.Message != nil && msg.Message.Header != nil {
+		status.MessageKind = msg.Message.Header.Kind
+	}
 	// It's fine to separately load and then store as we have the blockMutex acquired
 	_, present := v.validationEntries.Load(blockNum)
 	if present {
@@ -373,6 +403,26 @@ func (v *BlockValidator) writeToFile(validationEntry *validationEntry, moduleRoo
 	return nil
 }
 
+// writeValidationBundle writes a portable bundle of the validation alongside the
+// files written by writeToFile.
+func (v *BlockValidator) writeValidationBundle(ctx context.Context, entry *validationEntry, moduleRoot common.Hash) error {
+	if entry.Preimages == nil {
+		// Bundles need every preimage, which weren't recorded when preparing the block
+		var err error
+		entry, err = v.validationEntryForBlock(ctx, entry.BlockHeader, true)
+		if err != nil {
+			return err
+		}
+	}
+	bundle, err := v.validationBundleFor(ctx, entry, moduleRoot)
+	if err != nil {
+		return err
+	}
+	machConf := v.MachineLoader.GetConfig()
+	outDirPath := filepath.Join(machConf.RootPath, v.config().OutputPath, launchTime, fmt.Sprintf("block_%d", entry.BlockNumber))
+	return WriteValidationBundle(filepath.Join(outDirPath, ValidationBundleFileName(entry.BlockNumber, moduleRoot)), bundle)
+}
+
 func (v *BlockValidator) SetCurrentWasmModuleRoot(hash common.Hash) error {
 	v.blockMutex.Lock()
 	v.moduleMutex.Lock()
@@ -416,6 +466,18 @@ func (v *BlockValidator) validate(ctx context.Context, validationStatus *validat
 		default:
 		}
 	}()
+	if !v.shouldValidate(&v.config().Sampling, entry.BlockNumber, validationStatus) {
+		log.Debug("skipping validation of block not sampled", "blockNr", entry.BlockNumber)
+		samplingSkippedCounter.Inc(1)
+		atomic.StoreUint32(&validationStatus.Status, validationStatusValid)
+		select {
+		case v.checkProgressChan <- struct{}{}:
+		default:
+		}
+		return
+	}
+	started := time.Now()
+	atomic.StoreInt64(&validationStatus.Started, started.UnixNano())
 	entry.BatchInfo = append(entry.BatchInfo, BatchInfo{
 		Number: entry.StartPosition.BatchNumber,
 		Data:   seqMsg,
@@ -427,6 +489,7 @@ func (v *BlockValidator) validate(ctx context.Context, validationStatus *validat
 
 		type replay = func(context.Context, *validationEntry, common.Hash) (GoGlobalState, []byte, error)
 		var delayedMsg []byte
+		var failure string
 
 		execValidation := func(replay replay, name string) (bool, bool) {
 			gsEnd, delayed, err := replay(ctx, entry, moduleRoot)
@@ -434,6 +497,9 @@ func (v *BlockValidator) validate(ctx context.Context, validationStatus *validat
 
 			if err != nil {
 				canceled := ctx.Err() != nil
+				if failure == "" {
+					failure = fmt.Sprintf("%v validation on module root %v failed: %v", name, moduleRoot, err)
+				}
 				if canceled {
 					log.Info(
 						"Validation of block canceled", "blockNr", entry.BlockNumber,
@@ -453,6 +519,9 @@ func (v *BlockValidator) validate(ctx context.Context, validationStatus *validat
 			resultValid := gsEnd == gsExpected
 
 			if !resultValid {
+				if failure == "" {
+					failure = fmt.Sprintf("%v validation on module root %v got %v expected %v", name, moduleRoot, gsEnd, gsExpected)
+				}
 				log.Error(
 					"validation failed", "moduleRoot", moduleRoot, "got", gsEnd,
 					"expected", gsExpected, "expHeader", entry.BlockHeader, "name", name,
@@ -461,18 +530,32 @@ func (v *BlockValidator) validate(ctx context.Context, validationStatus *validat
 			return resultValid, !resultValid
 		}
 
+		cacheKey, cached := v.cachedValidationResult(entry, moduleRoot)
+		if cached {
+			log.Info(
+				"validation result cached", "blockNr", entry.BlockNumber,
+				"blockHash", entry.BlockHash, "moduleRoot", moduleRoot,
+			)
+			v.history.recordModuleRoot(moduleRoot, true)
+			continue
+		}
+
 		before := time.Now()
 		writeBlock := false // we write the block if either fail
 
 		config := v.config()
 		valid := true
+		executeBlock, jitBlock := v.executeBlock, v.jitBlock
+		if v.remoteValidator != nil {
+			executeBlock, jitBlock = v.remoteExecuteBlock, v.remoteJitBlock
+		}
 		if config.ArbitratorValidator {
-			thisValid, thisWriteBlock := execValidation(v.executeBlock, "arbitrator")
+			thisValid, thisWriteBlock := execValidation(executeBlock, "arbitrator")
 			valid = valid && thisValid
 			writeBlock = writeBlock || thisWriteBlock
 		}
 		if config.JitValidator {
-			thisValid, thisWriteBlock := execValidation(v.jitBlock, "jit")
+			thisValid, thisWriteBlock := execValidation(jitBlock, "jit")
 			valid = valid && thisValid
 			writeBlock = writeBlock || thisWriteBlock
 		}
@@ -485,13 +568,24 @@ func (v *BlockValidator) validate(ctx context.Context, validationStatus *validat
 			if err != nil {
 				log.Error("failed to write file", "err", err)
 			}
+			err = v.writeValidationBundle(ctx, entry, moduleRoot)
+			if err != nil {
+				log.Error("failed to write validation bundle", "err", err)
+			}
 		}
 
+		v.history.recordModuleRoot(moduleRoot, valid)
 		if !valid {
+			validationStatus.Failure = failure
 			atomic.StoreUint32(&validationStatus.Status, validationStatusFailed)
+			v.history.recordBlock(entry, validationStatus.ModuleRoots, false, failure, time.Since(started))
 			return
 		}
 
+		if config.ArbitratorValidator || config.JitValidator {
+			v.storeValidationResult(cacheKey, entry.expectedEnd())
+		}
+
 		log.Info(
 			"validation succeeded", "blockNr", entry.BlockNumber,
 			"blockDate", common.PrettyAge(time.Unix(int64(entry.BlockHeader.Time), 0)),
@@ -499,6 +593,8 @@ func (v *BlockValidator) validate(ctx context.Context, validationStatus *validat
 		)
 	}
 
+	v.history.recordBlock(entry, validationStatus.ModuleRoots, true, "", time.Since(started))
+	v.requiredBlocks.setStatus(entry.BlockNumber, requiredBlockValid)
 	atomic.StoreUint32(&validationStatus.Status, validationStatusValid) // after that - validation entry could be deleted from map
 	select {
 	case v.checkProgressChan <- struct{}{}:
@@ -511,7 +607,15 @@ func (v *BlockValidator) sendValidations(ctx context.Context) {
 	defer v.reorgMutex.Unlock()
 	concurrentRunsLimit := (int32)(v.config().ConcurrentRunsLimit)
 	if concurrentRunsLimit == 0 {
-		concurrentRunsLimit = (int32)(runtime.NumCPU())
+		if v.remoteValidator != nil {
+			concurrentRunsLimit = (int32)(v.remoteValidator.Capacity())
+			if concurrentRunsLimit == 0 {
+				// Keep trying a single validation until a worker becomes healthy
+				concurrentRunsLimit = 1
+			}
+		} else {
+			concurrentRunsLimit = (int32)(runtime.NumCPU())
+		}
 	}
 	var batchCount uint64
 	for atomic.LoadInt32(&v.reorgsPending) == 0 {
@@ -692,6 +796,7 @@ func (v *BlockValidator) progressValidated() {
 		if err != nil {
 			log.Error("failed to write validated entry to database", "err", err)
 		}
+		v.pruneValidationResults(validationEntry.BlockNumber)
 	}
 }
 
@@ -904,12 +1009,12 @@ func (v *BlockValidator) Initialize() error {
 			return errors.New("current-module-root config value illegal")
 		}
 	}
-	if config.ArbitratorValidator {
+	if config.ArbitratorValidator && v.remoteValidator == nil {
 		if err := v.MachineLoader.CreateMachine(v.currentWasmModuleRoot, true, false); err != nil {
 			return err
 		}
 	}
-	if config.JitValidator {
+	if config.JitValidator && v.remoteValidator == nil {
 		if err := v.MachineLoader.CreateMachine(v.currentWasmModuleRoot, true, true); err != nil {
 			return err
 		}
@@ -921,6 +1026,9 @@ func (v *BlockValidator) Initialize() error {
 
 func (v *BlockValidator) Start(ctxIn context.Context) error {
 	v.StopWaiter.Start(ctxIn, v)
+	if v.remoteValidator != nil {
+		v.CallIteratively(v.remoteValidator.checkWorkers)
+	}
 	v.LaunchThread(func(ctx context.Context) {
 		// `progressValidated` and `sendValidations` should both only do `concurrentRunsLimit` iterations of work,
 		// so they won't stomp on each other and prevent the other from running.
@@ -13,4 +13,5 @@ type lastBlockValidatedDbInfo struct {
 
 var (
 	lastBlockValidatedInfoKey []byte = []byte("_lastBlockValidatedInfo") // contains a rlp encoded lastBlockValidatedDbInfo
+	validationResultPrefix    []byte = []byte("r")                       // maps a block number and validation inputs hash to a rlp encoded GoGlobalState
 )
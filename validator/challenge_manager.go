@@ -7,7 +7,9 @@ import (
 	"context"
 	"encoding/binary"
 	"fmt"
+	"math"
 	"math/big"
+	"time"
 
 	"github.com/offchainlabs/nitro/arbstate"
 
@@ -17,6 +19,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/offchainlabs/nitro/arbutil"
 	"github.com/offchainlabs/nitro/solgen/go/challengegen"
@@ -25,7 +28,10 @@ import (
 
 const maxBisectionDegree uint64 = 40
 
-const challengeModeExecution = 2
+const (
+	challengeModeNone      = 0
+	challengeModeExecution = 2
+)
 
 var initiatedChallengeID common.Hash
 var challengeBisectedID common.Hash
@@ -73,6 +79,7 @@ type ChallengeManager struct {
 	machineLoader     *NitroMachineLoader
 	targetNumMachines int
 	wasmModuleRoot    common.Hash
+	checkpointConfig  *MachineCheckpointConfig
 
 	initialMachine        *ArbitratorMachine
 	initialMachineBlockNr int64
@@ -232,6 +239,11 @@ func (m *ChallengeManager) ChallengeIndex() uint64 {
 	return m.challengeIndex
 }
 
+// ActingAs returns the address of the participant the manager moves for.
+func (m *ChallengeManager) ActingAs() common.Address {
+	return m.actingAs
+}
+
 func uint64ToIndex(val uint64) common.Hash {
 	var challengeIndex common.Hash
 	binary.BigEndian.PutUint64(challengeIndex[(32-8):], val)
@@ -352,6 +364,29 @@ func (m *ChallengeManager) IsMyTurn(ctx context.Context) (bool, error) {
 	return true, nil
 }
 
+// ResponderTimeLeft returns the participant whose turn it is in the challenge,
+// and how long they have left to act before timing out.
+func (m *ChallengeManager) ResponderTimeLeft(ctx context.Context) (common.Address, time.Duration, error) {
+	callOpts := &bind.CallOpts{Context: ctx}
+	challenge, err := m.con.Challenges(callOpts, new(big.Int).SetUint64(m.challengeIndex))
+	if err != nil {
+		return common.Address{}, 0, err
+	}
+	header, err := m.client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return common.Address{}, 0, err
+	}
+	used := new(big.Int).Sub(new(big.Int).SetUint64(header.Time), challenge.LastMoveTimestamp)
+	left := new(big.Int).Sub(challenge.Current.TimeLeft, used)
+	if left.Sign() < 0 {
+		left.SetInt64(0)
+	}
+	if !left.IsInt64() || left.Int64() > int64(math.MaxInt64/time.Second) {
+		return challenge.Current.Addr, time.Duration(math.MaxInt64), nil
+	}
+	return challenge.Current.Addr, time.Duration(left.Int64()) * time.Second, nil
+}
+
 func (m *ChallengeManager) GetChallengeState(ctx context.Context) (*ChallengeState, error) {
 	callOpts := &bind.CallOpts{Context: ctx}
 	var err error
@@ -535,10 +570,21 @@ func (m *ChallengeManager) LoadExecChallengeIfExists(ctx context.Context) error
 	if err != nil {
 		return err
 	}
+	// The challenge and the L1 block it began in pin down the inputs of the execution
+	challengeId := crypto.Keccak256Hash(m.challengeManagerAddr.Bytes(), uint64ToIndex(m.challengeIndex).Bytes(), logs[0].BlockHash.Bytes())
+	execBackend.checkpoints = newMachineCheckpoints(m.checkpointConfig, m.wasmModuleRoot, challengeId, m.initialMachine)
 	m.executionChallengeBackend = execBackend
 	return nil
 }
 
+// RemoveMachineCheckpoints deletes any machine checkpoints kept for the
+// execution challenge, once it's over.
+func (m *ChallengeManager) RemoveMachineCheckpoints() {
+	if m.executionChallengeBackend != nil {
+		m.executionChallengeBackend.checkpoints.remove()
+	}
+}
+
 func (m *ChallengeManager) Act(ctx context.Context) (*types.Transaction, error) {
 	err := m.LoadExecChallengeIfExists(ctx)
 	if err != nil {
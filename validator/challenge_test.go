@@ -1,14 +1,12 @@
 // Copyright 2021-2022, Offchain Labs, Inc.
 // For license information, see https://github.com/nitro/blob/master/LICENSE
 
-package validator
+package validator_test
 
 import (
 	"context"
 	"math/big"
 	"os"
-	"path"
-	"runtime"
 	"strings"
 	"testing"
 	"time"
@@ -21,23 +19,25 @@ import (
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/offchainlabs/nitro/solgen/go/mocksgen"
 	"github.com/offchainlabs/nitro/solgen/go/ospgen"
+	"github.com/offchainlabs/nitro/validator"
+	"github.com/offchainlabs/nitro/validator/challengesim"
 )
 
 func DeployOneStepProofEntry(t *testing.T, auth *bind.TransactOpts, client bind.ContractBackend) common.Address {
 	osp0, _, _, err := ospgen.DeployOneStepProver0(auth, client)
-	Require(t, err)
+	validator.Require(t, err)
 
 	ospMem, _, _, err := ospgen.DeployOneStepProverMemory(auth, client)
-	Require(t, err)
+	validator.Require(t, err)
 
 	ospMath, _, _, err := ospgen.DeployOneStepProverMath(auth, client)
-	Require(t, err)
+	validator.Require(t, err)
 
 	ospHostIo, _, _, err := ospgen.DeployOneStepProverHostIo(auth, client)
-	Require(t, err)
+	validator.Require(t, err)
 
 	ospEntry, _, _, err := ospgen.DeployOneStepProofEntry(auth, client, osp0, ospMem, ospMath, ospHostIo)
-	Require(t, err)
+	validator.Require(t, err)
 	return ospEntry
 }
 
@@ -47,18 +47,18 @@ func CreateChallenge(
 	auth *bind.TransactOpts,
 	client bind.ContractBackend,
 	ospEntry common.Address,
-	inputMachine MachineInterface,
+	inputMachine validator.MachineInterface,
 	maxInboxMessage uint64,
 	asserter common.Address,
 	challenger common.Address,
 ) (*mocksgen.MockResultReceiver, common.Address) {
 	resultReceiverAddr, _, resultReceiver, err := mocksgen.DeployMockResultReceiver(auth, client, common.Address{})
-	Require(t, err)
+	validator.Require(t, err)
 
 	machine := inputMachine.CloneMachineInterface()
 	startMachineHash := machine.Hash()
 
-	Require(t, machine.Step(ctx, ^uint64(0)))
+	validator.Require(t, machine.Step(ctx, ^uint64(0)))
 
 	endMachineHash := machine.Hash()
 	endMachineSteps := machine.GetStepCount()
@@ -80,17 +80,17 @@ func CreateChallenge(
 		big.NewInt(100),
 		big.NewInt(100),
 	)
-	Require(t, err)
+	validator.Require(t, err)
 
 	return resultReceiver, challenge
 }
 
 func createTransactOpts(t *testing.T) *bind.TransactOpts {
 	key, err := crypto.GenerateKey()
-	Require(t, err)
+	validator.Require(t, err)
 
 	opts, err := bind.NewKeyedTransactorWithChainID(key, big.NewInt(1337))
-	Require(t, err)
+	validator.Require(t, err)
 	return opts
 }
 
@@ -108,8 +108,8 @@ func createGenesisAlloc(accts ...*bind.TransactOpts) core.GenesisAlloc {
 
 func runChallengeTest(
 	t *testing.T,
-	baseMachine *ArbitratorMachine,
-	incorrectMachine MachineInterface,
+	baseMachine *validator.ArbitratorMachine,
+	incorrectMachine validator.MachineInterface,
 	asserterIsCorrect bool,
 	testTimeout bool,
 	maxInboxMessage uint64,
@@ -130,7 +130,7 @@ func runChallengeTest(
 	ospEntry := DeployOneStepProofEntry(t, deployer, backend)
 	backend.Commit()
 
-	var asserterMachine, challengerMachine MachineInterface
+	var asserterMachine, challengerMachine validator.MachineInterface
 	var expectedWinner common.Address
 	if asserterIsCorrect {
 		expectedWinner = asserter.From
@@ -156,7 +156,7 @@ func runChallengeTest(
 
 	backend.Commit()
 
-	asserterManager, err := NewExecutionChallengeManager(
+	asserterManager, err := validator.NewExecutionChallengeManager(
 		backend,
 		asserter,
 		challengeManager,
@@ -166,9 +166,9 @@ func runChallengeTest(
 		4,
 		12,
 	)
-	Require(t, err)
+	validator.Require(t, err)
 
-	challengerManager, err := NewExecutionChallengeManager(
+	challengerManager, err := validator.NewExecutionChallengeManager(
 		backend,
 		challenger,
 		challengeManager,
@@ -178,13 +178,13 @@ func runChallengeTest(
 		4,
 		12,
 	)
-	Require(t, err)
+	validator.Require(t, err)
 
 	for i := 0; i < 100; i++ {
 		if testTimeout {
 			err = backend.AdjustTime(time.Second * 40)
 		}
-		Require(t, err)
+		validator.Require(t, err)
 		backend.Commit()
 
 		var currentCorrect bool
@@ -214,7 +214,7 @@ func runChallengeTest(
 		backend.Commit()
 
 		winner, err := resultReceiver.Winner(&bind.CallOpts{})
-		Require(t, err)
+		validator.Require(t, err)
 
 		if winner == (common.Address{}) {
 			continue
@@ -227,65 +227,48 @@ func runChallengeTest(
 	t.Fatal("challenge timed out without winner")
 }
 
-func createBaseMachine(t *testing.T, wasmname string, wasmModules []string) *ArbitratorMachine {
-	_, filename, _, _ := runtime.Caller(0)
-	wasmDir := path.Join(path.Dir(filename), "../arbitrator/prover/test-cases/")
-
-	wasmPath := path.Join(wasmDir, wasmname)
-
-	var modulePaths []string
-	for _, moduleName := range wasmModules {
-		modulePaths = append(modulePaths, path.Join(wasmDir, moduleName))
-	}
-
-	machine, err := LoadSimpleMachine(wasmPath, modulePaths)
-	Require(t, err)
-
-	return machine
-}
-
 func TestChallengeToOSP(t *testing.T) {
-	machine := createBaseMachine(t, "global-state.wasm", []string{"global-state-wrapper.wasm"})
-	IncorrectMachine := NewIncorrectMachine(machine, 200)
+	machine := validator.CreateBaseMachine(t, "global-state.wasm", []string{"global-state-wrapper.wasm"})
+	IncorrectMachine := challengesim.NewIncorrectMachine(machine, 200)
 	runChallengeTest(t, machine, IncorrectMachine, false, false, 0)
 }
 
 func TestChallengeToFailedOSP(t *testing.T) {
-	machine := createBaseMachine(t, "global-state.wasm", []string{"global-state-wrapper.wasm"})
-	IncorrectMachine := NewIncorrectMachine(machine, 200)
+	machine := validator.CreateBaseMachine(t, "global-state.wasm", []string{"global-state-wrapper.wasm"})
+	IncorrectMachine := challengesim.NewIncorrectMachine(machine, 200)
 	runChallengeTest(t, machine, IncorrectMachine, true, false, 0)
 }
 
 func TestChallengeToErroredOSP(t *testing.T) {
-	machine := createBaseMachine(t, "const.wasm", nil)
-	IncorrectMachine := NewIncorrectMachine(machine, 10)
+	machine := validator.CreateBaseMachine(t, "const.wasm", nil)
+	IncorrectMachine := challengesim.NewIncorrectMachine(machine, 10)
 	runChallengeTest(t, machine, IncorrectMachine, false, false, 0)
 }
 
 func TestChallengeToFailedErroredOSP(t *testing.T) {
-	machine := createBaseMachine(t, "const.wasm", nil)
-	IncorrectMachine := NewIncorrectMachine(machine, 10)
+	machine := validator.CreateBaseMachine(t, "const.wasm", nil)
+	IncorrectMachine := challengesim.NewIncorrectMachine(machine, 10)
 	runChallengeTest(t, machine, IncorrectMachine, true, false, 0)
 }
 
 func TestChallengeToTimeout(t *testing.T) {
-	machine := createBaseMachine(t, "global-state.wasm", []string{"global-state-wrapper.wasm"})
-	IncorrectMachine := NewIncorrectMachine(machine, 200)
+	machine := validator.CreateBaseMachine(t, "global-state.wasm", []string{"global-state-wrapper.wasm"})
+	IncorrectMachine := challengesim.NewIncorrectMachine(machine, 200)
 	runChallengeTest(t, machine, IncorrectMachine, false, true, 0)
 }
 
 func TestChallengeToTooFar(t *testing.T) {
-	machine := createBaseMachine(t, "read-inboxmsg-10.wasm", []string{"global-state-wrapper.wasm"})
-	Require(t, machine.SetGlobalState(GoGlobalState{PosInBatch: 10}))
+	machine := validator.CreateBaseMachine(t, "read-inboxmsg-10.wasm", []string{"global-state-wrapper.wasm"})
+	validator.Require(t, machine.SetGlobalState(validator.GoGlobalState{PosInBatch: 10}))
 	incorrectMachine := machine.Clone()
-	Require(t, incorrectMachine.AddSequencerInboxMessage(10, []byte{0, 1, 2, 3}))
+	validator.Require(t, incorrectMachine.AddSequencerInboxMessage(10, []byte{0, 1, 2, 3}))
 	runChallengeTest(t, machine, incorrectMachine, false, false, 9)
 }
 
 func TestChallengeToFailedTooFar(t *testing.T) {
-	machine := createBaseMachine(t, "read-inboxmsg-10.wasm", []string{"global-state-wrapper.wasm"})
-	Require(t, machine.SetGlobalState(GoGlobalState{PosInBatch: 10}))
+	machine := validator.CreateBaseMachine(t, "read-inboxmsg-10.wasm", []string{"global-state-wrapper.wasm"})
+	validator.Require(t, machine.SetGlobalState(validator.GoGlobalState{PosInBatch: 10}))
 	incorrectMachine := machine.Clone()
-	Require(t, machine.AddSequencerInboxMessage(10, []byte{0, 1, 2, 3}))
+	validator.Require(t, machine.AddSequencerInboxMessage(10, []byte{0, 1, 2, 3}))
 	runChallengeTest(t, machine, incorrectMachine, true, false, 11)
 }
@@ -1,32 +1,36 @@
 // Copyright 2021-2022, Offchain Labs, Inc.
 // For license information, see https://github.com/nitro/blob/master/LICENSE
 
-package validator
+package challengesim
 
 import (
 	"context"
 
 	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/offchainlabs/nitro/validator"
 )
 
+// IncorrectMachine wraps a machine, corrupting its global state from
+// incorrectStep on, to play the faulty side of a challenge.
 type IncorrectMachine struct {
-	inner         *ArbitratorMachine
+	inner         *validator.ArbitratorMachine
 	incorrectStep uint64
 	stepCount     uint64
 }
 
-var badGlobalState = GoGlobalState{Batch: 0xbadbadbadbad, PosInBatch: 0xbadbadbadbad}
+var badGlobalState = validator.GoGlobalState{Batch: 0xbadbadbadbad, PosInBatch: 0xbadbadbadbad}
 
-var _ MachineInterface = (*IncorrectMachine)(nil)
+var _ validator.MachineInterface = (*IncorrectMachine)(nil)
 
-func NewIncorrectMachine(inner *ArbitratorMachine, incorrectStep uint64) *IncorrectMachine {
+func NewIncorrectMachine(inner *validator.ArbitratorMachine, incorrectStep uint64) *IncorrectMachine {
 	return &IncorrectMachine{
 		inner:         inner.Clone(),
 		incorrectStep: incorrectStep,
 	}
 }
 
-func (m *IncorrectMachine) CloneMachineInterface() MachineInterface {
+func (m *IncorrectMachine) CloneMachineInterface() validator.MachineInterface {
 	return &IncorrectMachine{
 		inner:         m.inner.Clone(),
 		incorrectStep: m.incorrectStep,
@@ -34,7 +38,7 @@ func (m *IncorrectMachine) CloneMachineInterface() MachineInterface {
 	}
 }
 
-func (m *IncorrectMachine) GetGlobalState() GoGlobalState {
+func (m *IncorrectMachine) GetGlobalState() validator.GoGlobalState {
 	if m.GetStepCount() >= m.incorrectStep {
 		return badGlobalState
 	}
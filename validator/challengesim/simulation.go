@@ -0,0 +1,480 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+// Package challengesim plays challenges between two validator.ChallengeManagers
+// on an L1, for tests and the challenge-sim command. It isn't used by nodes.
+package challengesim
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/backends"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/offchainlabs/nitro/solgen/go/challengegen"
+	"github.com/offchainlabs/nitro/solgen/go/mocksgen"
+	"github.com/offchainlabs/nitro/solgen/go/ospgen"
+	"github.com/offchainlabs/nitro/solgen/go/rollupgen"
+	"github.com/offchainlabs/nitro/validator"
+	"github.com/pkg/errors"
+)
+
+// Simulation plays challenges between two ChallengeManagers to completion,
+// recording every move and its gas cost. It either deploys the one step provers
+// to a simulated L1 to play execution challenges, or attaches to a rollup to
+// play its block challenges through to the execution challenges they lead to,
+// with the rollup receiving their results.
+type Simulation struct {
+	L1         L1
+	Deployer   *bind.TransactOpts
+	Asserter   *bind.TransactOpts
+	Challenger *bind.TransactOpts
+
+	OneStepProofEntry common.Address
+	Rollup            common.Address
+	ChallengeManager  common.Address
+
+	advanceTime  func(ctx context.Context, duration time.Duration) error
+	challengeABI *abi.ABI
+}
+
+// Modes of a challenge in the ChallengeManager contract
+const (
+	challengeModeNone      = 0
+	challengeModeExecution = 2
+)
+
+// L1 is the L1 a Simulation plays challenges on. Backends that mine blocks on
+// demand, like the simulated backend, are committed after every transaction.
+type L1 interface {
+	bind.ContractBackend
+	bind.DeployBackend
+}
+
+type committingL1 interface {
+	Commit()
+}
+
+// Node is the L2 node a participant in a simulated block challenge validates
+// the challenged blocks with.
+type Node struct {
+	Blockchain    *core.BlockChain
+	InboxReader   validator.InboxReaderInterface
+	InboxTracker  validator.InboxTrackerInterface
+	TxStreamer    validator.TransactionStreamerInterface
+	MachineLoader *validator.NitroMachineLoader
+}
+
+// Challenge is a challenge played by a Simulation.
+type Challenge struct {
+	Address common.Address
+	Index   uint64
+}
+
+type Move struct {
+	Turn        int            `json:"turn"`
+	Participant common.Address `json:"participant"`
+	Mode        string         `json:"mode"`
+	Method      string         `json:"method"`
+	// The range of steps (of blocks in block challenges) being challenged before the move
+	SegmentStart uint64      `json:"segmentStart"`
+	SegmentEnd   uint64      `json:"segmentEnd"`
+	Degree       int         `json:"degree"`
+	ProofSize    int         `json:"proofSize,omitempty"`
+	TxHash       common.Hash `json:"txHash,omitempty"`
+	GasUsed      uint64      `json:"gasUsed"`
+	Error        string      `json:"error,omitempty"`
+}
+
+type Result struct {
+	Winner common.Address `json:"winner"`
+	Loser  common.Address `json:"loser"`
+	Moves  []Move         `json:"moves"`
+}
+
+func (r *Result) TotalGas() uint64 {
+	var total uint64
+	for _, move := range r.Moves {
+		total += move.GasUsed
+	}
+	return total
+}
+
+func (r *Result) GasByMethod() map[string]uint64 {
+	gas := make(map[string]uint64)
+	for _, move := range r.Moves {
+		gas[move.Method] += move.GasUsed
+	}
+	return gas
+}
+
+func newSimulationTransactOpts() (*bind.TransactOpts, error) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		return nil, err
+	}
+	return bind.NewKeyedTransactorWithChainID(key, big.NewInt(1337))
+}
+
+// NewSimulation creates a simulated L1 with funded deployer, asserter
+// and challenger accounts, and deploys the one step provers for execution
+// challenges to use.
+func NewSimulation(ctx context.Context) (*Simulation, error) {
+	challengeABI, err := challengegen.ChallengeManagerMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	s := &Simulation{challengeABI: challengeABI}
+	alloc := make(core.GenesisAlloc)
+	balance := new(big.Int).Exp(big.NewInt(10), big.NewInt(20), nil)
+	for _, opts := range []**bind.TransactOpts{&s.Deployer, &s.Asserter, &s.Challenger} {
+		*opts, err = newSimulationTransactOpts()
+		if err != nil {
+			return nil, err
+		}
+		alloc[(*opts).From] = core.GenesisAccount{Balance: new(big.Int).Set(balance)}
+	}
+	backend := backends.NewSimulatedBackend(alloc, 1_000_000_000)
+	backend.Commit()
+	s.L1 = backend
+	s.advanceTime = func(_ context.Context, duration time.Duration) error {
+		if err := backend.AdjustTime(duration); err != nil {
+			return err
+		}
+		backend.Commit()
+		return nil
+	}
+
+	osp0, tx, _, err := ospgen.DeployOneStepProver0(s.Deployer, s.L1)
+	if err := s.commit(ctx, tx, err); err != nil {
+		return nil, err
+	}
+	ospMem, tx, _, err := ospgen.DeployOneStepProverMemory(s.Deployer, s.L1)
+	if err := s.commit(ctx, tx, err); err != nil {
+		return nil, err
+	}
+	ospMath, tx, _, err := ospgen.DeployOneStepProverMath(s.Deployer, s.L1)
+	if err := s.commit(ctx, tx, err); err != nil {
+		return nil, err
+	}
+	ospHostIo, tx, _, err := ospgen.DeployOneStepProverHostIo(s.Deployer, s.L1)
+	if err := s.commit(ctx, tx, err); err != nil {
+		return nil, err
+	}
+	s.OneStepProofEntry, tx, _, err = ospgen.DeployOneStepProofEntry(s.Deployer, s.L1, osp0, ospMem, ospMath, ospHostIo)
+	if err := s.commit(ctx, tx, err); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// NewRollupSimulation plays the challenges of a deployed rollup between
+// the stakers asserter and challenger. Which of them the rollup treats as the
+// asserter of a challenge depends on whose node was created first, so the names
+// only label the results. advanceTime must make at least the given duration pass
+// on l1, so that a participant who can't move can be timed out.
+func NewRollupSimulation(
+	ctx context.Context,
+	l1 L1,
+	rollupAddr common.Address,
+	asserter *bind.TransactOpts,
+	challenger *bind.TransactOpts,
+	advanceTime func(ctx context.Context, duration time.Duration) error,
+) (*Simulation, error) {
+	challengeABI, err := challengegen.ChallengeManagerMetaData.GetAbi()
+	if err != nil {
+		return nil, err
+	}
+	rollup, err := rollupgen.NewRollupUserLogic(rollupAddr, l1)
+	if err != nil {
+		return nil, err
+	}
+	challengeManager, err := rollup.ChallengeManager(&bind.CallOpts{Context: ctx})
+	if err != nil {
+		return nil, err
+	}
+	return &Simulation{
+		L1:               l1,
+		Asserter:         asserter,
+		Challenger:       challenger,
+		Rollup:           rollupAddr,
+		ChallengeManager: challengeManager,
+		advanceTime:      advanceTime,
+		challengeABI:     challengeABI,
+	}, nil
+}
+
+// commit mines tx, taking the error from sending it, and checks it succeeded.
+func (s *Simulation) commit(ctx context.Context, tx *types.Transaction, err error) error {
+	_, err = s.commitReceipt(ctx, tx, err)
+	return err
+}
+
+func (s *Simulation) commitReceipt(ctx context.Context, tx *types.Transaction, err error) (*types.Receipt, error) {
+	if err != nil {
+		return nil, err
+	}
+	s.mine()
+	receipt, err := bind.WaitMined(ctx, s.L1, tx)
+	if err != nil {
+		return nil, err
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return receipt, fmt.Errorf("transaction %v reverted", tx.Hash())
+	}
+	return receipt, nil
+}
+
+// mine makes an L1 that mines on demand produce a block.
+func (s *Simulation) mine() {
+	if l1, ok := s.L1.(committingL1); ok {
+		l1.Commit()
+	}
+}
+
+// CreateExecutionChallenge creates a challenge over the execution of machine,
+// skipping the block challenge. The asserter claims the end state machine reaches.
+// Execution challenges on their own aren't created by a rollup, so a mock
+// receives the result.
+func (s *Simulation) CreateExecutionChallenge(ctx context.Context, machine validator.MachineInterface, maxInboxMessage uint64, timeLeft time.Duration) (*Challenge, error) {
+	if s.OneStepProofEntry == (common.Address{}) {
+		return nil, errors.New("execution challenges can only be created on a simulated L1")
+	}
+	resultReceiverAddr, tx, _, err := mocksgen.DeployMockResultReceiver(s.Deployer, s.L1, common.Address{})
+	if err := s.commit(ctx, tx, err); err != nil {
+		return nil, err
+	}
+	machine = machine.CloneMachineInterface()
+	startMachineHash := machine.Hash()
+	if err := machine.Step(ctx, ^uint64(0)); err != nil {
+		return nil, err
+	}
+	endMachineHash := machine.Hash()
+	seconds := big.NewInt(int64(timeLeft / time.Second))
+	challengeAddr, tx, _, err := mocksgen.DeploySingleExecutionChallenge(
+		s.Deployer,
+		s.L1,
+		s.OneStepProofEntry,
+		resultReceiverAddr,
+		maxInboxMessage,
+		[2][32]byte{startMachineHash, endMachineHash},
+		new(big.Int).SetUint64(machine.GetStepCount()),
+		s.Asserter.From,
+		s.Challenger.From,
+		seconds,
+		seconds,
+	)
+	if err := s.commit(ctx, tx, err); err != nil {
+		return nil, err
+	}
+	return &Challenge{
+		Address: challengeAddr,
+		Index:   1,
+	}, nil
+}
+
+// StakerChallenge returns the rollup challenge staker is in, or nil if it isn't in one.
+func (s *Simulation) StakerChallenge(ctx context.Context, staker common.Address) (*Challenge, error) {
+	if s.Rollup == (common.Address{}) {
+		return nil, errors.New("not attached to a rollup")
+	}
+	rollup, err := rollupgen.NewRollupUserLogic(s.Rollup, s.L1)
+	if err != nil {
+		return nil, err
+	}
+	index, err := rollup.CurrentChallenge(&bind.CallOpts{Context: ctx}, staker)
+	if err != nil || index == 0 {
+		return nil, err
+	}
+	return &Challenge{
+		Address: s.ChallengeManager,
+		Index:   index,
+	}, nil
+}
+
+// NewBlockChallengeManagers creates the asserter and challenger of a rollup's
+// block challenge, each validating blocks with its own node. Once the challenge
+// is bisected down to a single block, they move on to its execution challenge.
+func (s *Simulation) NewBlockChallengeManagers(
+	ctx context.Context,
+	challenge *Challenge,
+	asserterNode *Node,
+	challengerNode *Node,
+	targetNumMachines int,
+) (*validator.ChallengeManager, *validator.ChallengeManager, error) {
+	newManager := func(auth *bind.TransactOpts, node *Node) (*validator.ChallengeManager, error) {
+		return validator.NewChallengeManager(
+			ctx,
+			s.L1,
+			auth,
+			auth.From,
+			challenge.Address,
+			challenge.Index,
+			node.Blockchain,
+			nil,
+			node.InboxReader,
+			node.InboxTracker,
+			node.TxStreamer,
+			node.MachineLoader,
+			0,
+			targetNumMachines,
+			0,
+		)
+	}
+	asserter, err := newManager(s.Asserter, asserterNode)
+	if err != nil {
+		return nil, nil, err
+	}
+	challenger, err := newManager(s.Challenger, challengerNode)
+	if err != nil {
+		return nil, nil, err
+	}
+	return asserter, challenger, nil
+}
+
+// NewExecutionChallengeManagers creates the asserter and challenger of an
+// execution challenge, each executing its own machine.
+func (s *Simulation) NewExecutionChallengeManagers(challenge *Challenge, asserterMachine, challengerMachine validator.MachineInterface, targetNumMachines int) (*validator.ChallengeManager, *validator.ChallengeManager, error) {
+	asserter, err := validator.NewExecutionChallengeManager(s.L1, s.Asserter, challenge.Address, challenge.Index, asserterMachine, 0, targetNumMachines, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	challenger, err := validator.NewExecutionChallengeManager(s.L1, s.Challenger, challenge.Address, challenge.Index, challengerMachine, 0, targetNumMachines, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	return asserter, challenger, nil
+}
+
+// Play alternates moves between asserter and challenger until the challenge is
+// over. A participant that can't make a valid move is timed out, as it would be
+// on a real chain. Challenges only end in timeouts, as a one step proof just
+// leaves the loser without a move, so the participant timing out the other wins.
+func (s *Simulation) Play(ctx context.Context, challenge *Challenge, asserter, challenger *validator.ChallengeManager, maxMoves int) (*Result, error) {
+	result := &Result{}
+	for turn := 0; turn < maxMoves; turn++ {
+		// Start every move in a new block, as gas estimation is off if the
+		// previous move was in the same block.
+		s.mine()
+		responder, timeLeft, err := asserter.ResponderTimeLeft(ctx)
+		if err != nil {
+			return nil, err
+		}
+		mover, other := asserter, challenger
+		if responder == challenger.ActingAs() {
+			mover, other = challenger, asserter
+		} else if responder != asserter.ActingAs() {
+			return nil, fmt.Errorf("unexpected responder %v in challenge %v", responder, challenge.Index)
+		}
+		move, err := s.move(ctx, turn, challenge, mover)
+		if err != nil {
+			return nil, err
+		}
+		result.Moves = append(result.Moves, *move)
+		if move.Error == "" {
+			continue
+		}
+
+		log.Info("participant failed to move, timing it out", "challenge", challenge.Index, "participant", responder, "err", move.Error)
+		if err := s.advanceTime(ctx, timeLeft+time.Second); err != nil {
+			return nil, err
+		}
+		auth := s.Asserter
+		if other.ActingAs() == s.Challenger.From {
+			auth = s.Challenger
+		}
+		con, err := challengegen.NewChallengeManager(challenge.Address, s.L1)
+		if err != nil {
+			return nil, err
+		}
+		tx, err := con.Timeout(auth, challenge.Index)
+		receipt, err := s.commitReceipt(ctx, tx, err)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to time out participant")
+		}
+		result.Moves = append(result.Moves, Move{
+			Turn:        turn,
+			Participant: other.ActingAs(),
+			Method:      "timeout",
+			TxHash:      tx.Hash(),
+			GasUsed:     receipt.GasUsed,
+		})
+		info, err := con.Challenges(&bind.CallOpts{Context: ctx}, new(big.Int).SetUint64(challenge.Index))
+		if err != nil {
+			return nil, err
+		}
+		if info.Mode != challengeModeNone {
+			return nil, fmt.Errorf("challenge %v still active after timing out %v", challenge.Index, responder)
+		}
+		result.Winner = other.ActingAs()
+		result.Loser = mover.ActingAs()
+		return result, nil
+	}
+	return nil, fmt.Errorf("challenge %v didn't finish within %v moves", challenge.Index, maxMoves)
+}
+
+// move makes mover act in the challenge and records what it did. Failing to
+// make a valid move is recorded in the move, rather than returned as an error.
+func (s *Simulation) move(ctx context.Context, turn int, challenge *Challenge, mover *validator.ChallengeManager) (*Move, error) {
+	move := &Move{
+		Turn:        turn,
+		Participant: mover.ActingAs(),
+	}
+	con, err := challengegen.NewChallengeManager(challenge.Address, s.L1)
+	if err != nil {
+		return nil, err
+	}
+	info, err := con.Challenges(&bind.CallOpts{Context: ctx}, new(big.Int).SetUint64(challenge.Index))
+	if err != nil {
+		return nil, err
+	}
+	move.Mode = "block"
+	if info.Mode == challengeModeExecution {
+		move.Mode = "execution"
+	}
+	state, err := mover.GetChallengeState(ctx)
+	if err != nil {
+		move.Error = err.Error()
+		return move, nil
+	}
+	move.SegmentStart = state.Start.Uint64()
+	move.SegmentEnd = state.End.Uint64()
+	move.Degree = len(state.Segments) - 1
+
+	tx, err := mover.Act(ctx)
+	if err == nil && tx == nil {
+		err = errors.New("no move made")
+	}
+	if err != nil {
+		move.Error = err.Error()
+		return move, nil
+	}
+	move.TxHash = tx.Hash()
+	if method, err := s.challengeABI.MethodById(tx.Data()); err == nil {
+		move.Method = method.Name
+		if method.Name == "oneStepProveExecution" {
+			args, err := method.Inputs.Unpack(tx.Data()[4:])
+			if err == nil && len(args) == 3 {
+				if proof, ok := args[2].([]byte); ok {
+					move.ProofSize = len(proof)
+				}
+			}
+		}
+	}
+	receipt, err := s.commitReceipt(ctx, tx, nil)
+	if receipt != nil {
+		move.GasUsed = receipt.GasUsed
+	}
+	if err != nil {
+		move.Error = err.Error()
+	}
+	return move, nil
+}
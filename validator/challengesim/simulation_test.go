@@ -0,0 +1,66 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package challengesim
+
+import (
+	"context"
+	"path"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/offchainlabs/nitro/util/testhelpers"
+	"github.com/offchainlabs/nitro/validator"
+)
+
+func testMachine(t *testing.T) *validator.ArbitratorMachine {
+	_, filename, _, _ := runtime.Caller(0)
+	wasmDir := path.Join(path.Dir(filename), "../../arbitrator/prover/test-cases/")
+	machine, err := validator.LoadSimpleMachine(path.Join(wasmDir, "global-state.wasm"), []string{path.Join(wasmDir, "global-state-wrapper.wasm")})
+	testhelpers.RequireImpl(t, err)
+	return machine
+}
+
+func TestSimulation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sim, err := NewSimulation(ctx)
+	testhelpers.RequireImpl(t, err)
+
+	machine := testMachine(t)
+	incorrectMachine := NewIncorrectMachine(machine, 200)
+	challenge, err := sim.CreateExecutionChallenge(ctx, incorrectMachine, 0, time.Hour)
+	testhelpers.RequireImpl(t, err)
+	asserter, challenger, err := sim.NewExecutionChallengeManagers(challenge, incorrectMachine, machine.Clone(), 4)
+	testhelpers.RequireImpl(t, err)
+
+	result, err := sim.Play(ctx, challenge, asserter, challenger, 100)
+	testhelpers.RequireImpl(t, err)
+	if result.Winner != sim.Challenger.From || result.Loser != sim.Asserter.From {
+		testhelpers.FailImpl(t, "wrong party won challenge", result.Winner)
+	}
+	bisections := 0
+	for _, move := range result.Moves {
+		if move.Mode != "execution" && move.Method != "timeout" {
+			testhelpers.FailImpl(t, "unexpected move in mode", move.Mode)
+		}
+		if move.Method == "bisectExecution" {
+			bisections++
+		}
+		if move.Error == "" && move.GasUsed == 0 {
+			testhelpers.FailImpl(t, "no gas recorded for move", move.Turn, move.Method)
+		}
+	}
+	if bisections == 0 {
+		testhelpers.FailImpl(t, "no bisections recorded")
+	}
+	last := result.Moves[len(result.Moves)-1]
+	if last.Method != "oneStepProveExecution" && last.Method != "timeout" {
+		testhelpers.FailImpl(t, "challenge didn't end in a one step proof or timeout", last.Method)
+	}
+	if result.TotalGas() == 0 {
+		testhelpers.FailImpl(t, "no gas used")
+	}
+}
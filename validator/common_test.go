@@ -4,6 +4,8 @@
 package validator
 
 import (
+	"path"
+	"runtime"
 	"testing"
 
 	"github.com/offchainlabs/nitro/util/testhelpers"
@@ -18,3 +20,21 @@ func Fail(t *testing.T, printables ...interface{}) {
 	t.Helper()
 	testhelpers.FailImpl(t, printables...)
 }
+
+// CreateBaseMachine loads a machine from the prover's test cases.
+func CreateBaseMachine(t *testing.T, wasmname string, wasmModules []string) *ArbitratorMachine {
+	_, filename, _, _ := runtime.Caller(0)
+	wasmDir := path.Join(path.Dir(filename), "../arbitrator/prover/test-cases/")
+
+	wasmPath := path.Join(wasmDir, wasmname)
+
+	var modulePaths []string
+	for _, moduleName := range wasmModules {
+		modulePaths = append(modulePaths, path.Join(wasmDir, moduleName))
+	}
+
+	machine, err := LoadSimpleMachine(wasmPath, modulePaths)
+	Require(t, err)
+
+	return machine
+}
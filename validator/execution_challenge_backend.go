@@ -20,6 +20,7 @@ type ExecutionChallengeBackend struct {
 	machineCacheStart uint64
 	machineCacheEnd   uint64
 	targetNumMachines int
+	checkpoints       *machineCheckpoints
 }
 
 // Assert that ExecutionChallengeBackend implements ChallengeBackend
@@ -47,7 +48,11 @@ func (b *ExecutionChallengeBackend) getMachineAt(ctx context.Context, stepCount
 		if b.lastMachine != nil && b.lastMachine.GetStepCount() <= stepCount {
 			mach = b.lastMachine
 		}
-		mach = mach.CloneMachineInterface()
+		if checkpoint := b.checkpoints.load(mach.GetStepCount(), stepCount); checkpoint != nil {
+			mach = checkpoint
+		} else {
+			mach = mach.CloneMachineInterface()
+		}
 		err := mach.Step(ctx, stepCount-mach.GetStepCount())
 		if err != nil {
 			return nil, err
@@ -73,8 +78,14 @@ func (b *ExecutionChallengeBackend) SetRange(ctx context.Context, start uint64,
 		return err
 	}
 	b.machineCache = nil
-	b.machineCache, err = NewMachineCacheWithEndSteps(ctx, startMach, b.targetNumMachines, end)
-	return err
+	b.machineCache, err = newMachineCacheWithEndSteps(ctx, startMach, b.targetNumMachines, end, b.checkpoints)
+	if err != nil {
+		return err
+	}
+	b.machineCacheStart = start
+	b.machineCacheEnd = end
+	b.checkpoints.prune(start, end)
+	return nil
 }
 
 func (b *ExecutionChallengeBackend) GetHashAtStep(ctx context.Context, position uint64) (common.Hash, error) {
@@ -55,6 +55,7 @@ type L1Validator struct {
 	txStreamer         TransactionStreamerInterface
 	blockValidator     *BlockValidator
 	lastWasmModuleRoot common.Hash
+	alerts             *stakerAlerts
 }
 
 func NewL1Validator(
@@ -295,6 +296,12 @@ func (v *L1Validator) generateNodeAction(ctx context.Context, stakerInfo *OurSta
 		}
 	}
 
+	// the first block of any successor assertion
+	firstAssertionBlock := v.genesisBlockNumber + 1
+	if startBlock != nil {
+		firstAssertionBlock = startBlock.NumberU64() + 1
+	}
+
 	var lastBlockValidated uint64
 	if v.blockValidator != nil {
 		var expectedHash common.Hash
@@ -315,6 +322,15 @@ func (v *L1Validator) generateNodeAction(ctx context.Context, stakerInfo *OurSta
 			}
 		}
 		if !wasmRootValid {
+			v.alerts.alert(&StakerAlert{
+				Kind:    UnvalidatedAssertionAlert,
+				Message: "block validator isn't validating the rollup's wasm module root",
+				Node:    stakerInfo.LatestStakedNode,
+				Details: map[string]string{
+					"rollupModuleRoot": v.lastWasmModuleRoot.Hex(),
+					"validatedRoots":   fmt.Sprintf("%v", validRoots),
+				},
+			})
 			return nil, false, fmt.Errorf("wasmroot doesn't match rollup : %v, valid: %v", v.lastWasmModuleRoot, validRoots)
 		}
 	} else {
@@ -370,7 +386,9 @@ func (v *L1Validator) generateNodeAction(ctx context.Context, stakerInfo *OurSta
 			afterGs := nd.AfterState().GlobalState
 			requiredBatches := nd.AfterState().RequiredBatches()
 			if localBatchCount < requiredBatches {
-				return nil, false, fmt.Errorf("waiting for validator to catch up to assertion batches: %v/%v", localBatchCount, requiredBatches)
+				err := fmt.Errorf("waiting for validator to catch up to assertion batches: %v/%v", localBatchCount, requiredBatches)
+				v.alerts.waitingForValidation(nd.NodeNum, nd.NodeHash, err.Error())
+				return nil, false, err
 			}
 			if requiredBatches > 0 {
 				haveAcc, err := v.inboxTracker.GetBatchAcc(requiredBatches - 1)
@@ -378,15 +396,46 @@ func (v *L1Validator) generateNodeAction(ctx context.Context, stakerInfo *OurSta
 					return nil, false, err
 				}
 				if haveAcc != nd.AfterInboxBatchAcc {
-					return nil, false, fmt.Errorf("missed sequencer batches reorg: at seq num %v have acc %v but assertion has acc %v", requiredBatches-1, haveAcc, nd.AfterInboxBatchAcc)
+					err := fmt.Errorf("missed sequencer batches reorg: at seq num %v have acc %v but assertion has acc %v", requiredBatches-1, haveAcc, nd.AfterInboxBatchAcc)
+					v.alerts.alert(&StakerAlert{
+						Kind:     UnvalidatedAssertionAlert,
+						Message:  "assertion's inbox accumulator doesn't match ours",
+						Node:     nd.NodeNum,
+						NodeHash: &nd.NodeHash,
+						Details:  map[string]string{"reason": err.Error()},
+					})
+					return nil, false, err
 				}
 			}
 			lastBlockNum, inboxPositionInvalid, err := v.blockNumberFromGlobalState(afterGs)
 			if err != nil {
 				return nil, false, err
 			}
+			sampledBlocksValid := true
+			if v.blockValidator != nil && lastBlockNum >= 0 && !inboxPositionInvalid {
+				// blocks the block validator didn't sample were treated as valid without being validated,
+				// so make sure a sample of the assertion's blocks is validated, in the background
+				sampledBlocksValid, err = v.blockValidator.RequireValidation(firstAssertionBlock, uint64(lastBlockNum))
+				if err != nil {
+					v.alerts.alert(&StakerAlert{
+						Kind:     UnvalidatedAssertionAlert,
+						Message:  "a block sampled from the assertion failed validation",
+						Node:     nd.NodeNum,
+						NodeHash: &nd.NodeHash,
+						Details:  map[string]string{"reason": err.Error()},
+					})
+					return nil, false, err
+				}
+			}
 			if int64(lastBlockValidated) < lastBlockNum {
-				return nil, false, fmt.Errorf("waiting for validator to catch up to assertion blocks: %v/%v", lastBlockValidated, lastBlockNum)
+				err := fmt.Errorf("waiting for validator to catch up to assertion blocks: %v/%v", lastBlockValidated, lastBlockNum)
+				v.alerts.waitingForValidation(nd.NodeNum, nd.NodeHash, err.Error())
+				return nil, false, err
+			}
+			if !sampledBlocksValid {
+				err := fmt.Errorf("waiting for validation of blocks sampled from assertion ending at block %v", lastBlockNum)
+				v.alerts.waitingForValidation(nd.NodeNum, nd.NodeHash, err.Error())
+				return nil, false, err
 			}
 			var expectedBlockHash common.Hash
 			var expectedSendRoot common.Hash
@@ -426,6 +475,22 @@ func (v *L1Validator) generateNodeAction(ctx context.Context, stakerInfo *OurSta
 				}
 				continue
 			} else {
+				v.alerts.alert(&StakerAlert{
+					Kind:     ConflictingNodeAlert,
+					Message:  "found incorrect assertion",
+					Node:     nd.NodeNum,
+					NodeHash: &nd.NodeHash,
+					Details: map[string]string{
+						"inboxPositionInvalid": fmt.Sprintf("%v", inboxPositionInvalid),
+						"computedBlockNum":     fmt.Sprintf("%v", lastBlockNum),
+						"numBlocks":            fmt.Sprintf("%v", nd.Assertion.NumBlocks),
+						"expectedNumBlocks":    fmt.Sprintf("%v", expectedNumBlocks),
+						"blockHash":            afterGs.BlockHash.Hex(),
+						"expectedBlockHash":    expectedBlockHash.Hex(),
+						"sendRoot":             afterGs.SendRoot.Hex(),
+						"expectedSendRoot":     expectedSendRoot.Hex(),
+					},
+				})
 				log.Error(
 					"found incorrect assertion",
 					"node", nd.NodeNum,
@@ -441,6 +506,12 @@ func (v *L1Validator) generateNodeAction(ctx context.Context, stakerInfo *OurSta
 			}
 		} else {
 			log.Error("found younger sibling to correct assertion (implicitly invalid)", "node", nd.NodeNum)
+			v.alerts.alert(&StakerAlert{
+				Kind:     ConflictingNodeAlert,
+				Message:  "found younger sibling to correct assertion (implicitly invalid)",
+				Node:     nd.NodeNum,
+				NodeHash: &nd.NodeHash,
+			})
 		}
 		// If we've hit this point, the node is "wrong"
 		wrongNodesExist = true
@@ -36,6 +36,11 @@ func NewMachineCache(ctx context.Context, initialMachine MachineInterface, targe
 // `endSteps` should be the *total* step count at which the cache ends, not the number of steps from `initialMachine` to the end.
 // `initialMachine` may be mutated by this function.
 func NewMachineCacheWithEndSteps(ctx context.Context, initialMachine MachineInterface, targetNumMachines int, endSteps uint64) (*MachineCache, error) {
+	return newMachineCacheWithEndSteps(ctx, initialMachine, targetNumMachines, endSteps, nil)
+}
+
+// Like NewMachineCacheWithEndSteps, but resumes from and saves checkpoints, which may be nil.
+func newMachineCacheWithEndSteps(ctx context.Context, initialMachine MachineInterface, targetNumMachines int, endSteps uint64, checkpoints *machineCheckpoints) (*MachineCache, error) {
 	startSteps := initialMachine.GetStepCount()
 	if endSteps < startSteps {
 		return nil, errors.Errorf("endSteps %v before initialMachine step count %v", endSteps, startSteps)
@@ -46,14 +51,20 @@ func NewMachineCacheWithEndSteps(ctx context.Context, initialMachine MachineInte
 		firstMachineStep:    startSteps,
 		machineStepInterval: (endSteps - startSteps) / uint64(targetNumMachines+1),
 	}
+	checkpoints.save(initialMachine)
 	for i := 1; i < targetNumMachines; i++ {
 		if !initialMachine.IsRunning() {
 			break
 		}
-		err := initialMachine.Step(ctx, cache.machineStepInterval)
+		targetSteps := startSteps + uint64(i)*cache.machineStepInterval
+		if checkpoint := checkpoints.load(initialMachine.GetStepCount(), targetSteps); checkpoint != nil {
+			initialMachine = checkpoint
+		}
+		err := initialMachine.Step(ctx, targetSteps-initialMachine.GetStepCount())
 		if err != nil {
 			return nil, err
 		}
+		checkpoints.save(initialMachine)
 		cache.machines = append(cache.machines, initialMachine.CloneMachineInterface())
 	}
 	return cache, nil
@@ -0,0 +1,190 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package validator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// MachineCheckpointConfig configures persisting the machines cached during an
+// execution challenge, so a restarted validator can resume from them instead
+// of re-executing from the start of the challenged block.
+type MachineCheckpointConfig struct {
+	Enable   bool   `koanf:"enable"`
+	Path     string `koanf:"path"`
+	MinSteps uint64 `koanf:"min-steps"`
+}
+
+var DefaultMachineCheckpointConfig = MachineCheckpointConfig{
+	Enable:   false,
+	Path:     "machine-checkpoints",
+	MinSteps: 1 << 24,
+}
+
+func MachineCheckpointConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultMachineCheckpointConfig.Enable, "persist machine checkpoints during execution challenges to resume from after a restart")
+	f.String(prefix+".path", DefaultMachineCheckpointConfig.Path, "directory to store machine checkpoints in (relative to the data directory)")
+	f.Uint64(prefix+".min-steps", DefaultMachineCheckpointConfig.MinSteps, "only checkpoint machines at least this many steps into the execution")
+}
+
+// machineCheckpoints stores serialized states of machines derived from one
+// initial machine, keyed by step count. They're kept under the module root, the
+// challenge and the hash of the initial machine. The machine hash doesn't commit
+// to the inbox contents the machine reads, so the challenge is what ties the
+// checkpoints to the inputs of the execution.
+// A nil *machineCheckpoints stores nothing.
+type machineCheckpoints struct {
+	initialMachine *ArbitratorMachine
+	dir            string
+	minSteps       uint64
+}
+
+// newMachineCheckpoints returns nil if checkpoints are disabled, or can't be
+// stored for initialMachine. challengeId must identify the inputs of the
+// execution being challenged.
+func newMachineCheckpoints(config *MachineCheckpointConfig, moduleRoot common.Hash, challengeId common.Hash, initialMachine MachineInterface) *machineCheckpoints {
+	if config == nil || !config.Enable {
+		return nil
+	}
+	machine, ok := initialMachine.(*ArbitratorMachine)
+	if !ok || machine.GetStepCount() != 0 {
+		return nil
+	}
+	return &machineCheckpoints{
+		initialMachine: machine,
+		dir:            filepath.Join(config.Path, moduleRoot.Hex(), challengeId.Hex(), machine.Hash().Hex()),
+		minSteps:       config.MinSteps,
+	}
+}
+
+func (c *machineCheckpoints) path(stepCount uint64) string {
+	return filepath.Join(c.dir, fmt.Sprintf("%020d", stepCount))
+}
+
+// steps returns the step counts of the stored checkpoints.
+func (c *machineCheckpoints) steps() ([]uint64, error) {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var steps []uint64
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		step, err := strconv.ParseUint(entry.Name(), 10, 64)
+		if err != nil {
+			continue
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+// save stores a checkpoint of machine, unless one exists at its step count.
+func (c *machineCheckpoints) save(machine MachineInterface) {
+	if c == nil {
+		return
+	}
+	arbMachine, ok := machine.(*ArbitratorMachine)
+	if !ok || arbMachine.GetStepCount() < c.minSteps {
+		return
+	}
+	path := c.path(arbMachine.GetStepCount())
+	if _, err := os.Stat(path); err == nil {
+		return
+	}
+	if err := os.MkdirAll(c.dir, 0o755); err != nil {
+		log.Warn("failed to create machine checkpoint directory", "path", c.dir, "err", err)
+		return
+	}
+	tmpPath := path + ".tmp"
+	if err := arbMachine.SerializeState(tmpPath); err != nil {
+		log.Warn("failed to checkpoint machine", "steps", arbMachine.GetStepCount(), "err", err)
+		_ = os.Remove(tmpPath)
+		return
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		log.Warn("failed to checkpoint machine", "steps", arbMachine.GetStepCount(), "err", err)
+		_ = os.Remove(tmpPath)
+		return
+	}
+	log.Debug("checkpointed machine", "steps", arbMachine.GetStepCount(), "path", path)
+}
+
+// load returns the machine at the latest checkpoint after afterStep and at most
+// maxStep, or nil if there isn't one.
+func (c *machineCheckpoints) load(afterStep uint64, maxStep uint64) MachineInterface {
+	if c == nil {
+		return nil
+	}
+	steps, err := c.steps()
+	if err != nil {
+		log.Warn("failed to list machine checkpoints", "path", c.dir, "err", err)
+		return nil
+	}
+	var best uint64
+	for _, step := range steps {
+		if step > afterStep && step <= maxStep && step > best {
+			best = step
+		}
+	}
+	if best == 0 {
+		return nil
+	}
+	machine := c.initialMachine.Clone()
+	path := c.path(best)
+	if err := machine.DeserializeAndReplaceState(path); err != nil {
+		log.Warn("failed to load machine checkpoint; will reexecute", "path", path, "err", err)
+		return nil
+	}
+	if machine.GetStepCount() != best {
+		log.Warn("machine checkpoint has the wrong step count; removing it", "path", path, "steps", machine.GetStepCount())
+		_ = os.Remove(path)
+		return nil
+	}
+	log.Info("resuming from machine checkpoint", "steps", best)
+	return machine
+}
+
+// prune removes checkpoints outside of the challenged range [start, end], as
+// the range only narrows as the challenge goes on.
+func (c *machineCheckpoints) prune(start uint64, end uint64) {
+	if c == nil {
+		return
+	}
+	steps, err := c.steps()
+	if err != nil {
+		log.Warn("failed to list machine checkpoints", "path", c.dir, "err", err)
+		return
+	}
+	for _, step := range steps {
+		if step < start || step > end {
+			if err := os.Remove(c.path(step)); err != nil {
+				log.Warn("failed to remove machine checkpoint", "steps", step, "err", err)
+			}
+		}
+	}
+}
+
+// remove deletes all checkpoints, once the challenge is over.
+func (c *machineCheckpoints) remove() {
+	if c == nil {
+		return
+	}
+	if err := os.RemoveAll(c.dir); err != nil {
+		log.Warn("failed to remove machine checkpoints", "path", c.dir, "err", err)
+	}
+}
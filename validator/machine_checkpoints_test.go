@@ -0,0 +1,76 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package validator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestMachineCheckpoints(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	machine := CreateBaseMachine(t, "global-state.wasm", []string{"global-state-wrapper.wasm"})
+	config := DefaultMachineCheckpointConfig
+	config.Enable = true
+	config.Path = t.TempDir()
+	config.MinSteps = 50
+	checkpoints := newMachineCheckpoints(&config, common.Hash{1}, common.Hash{2}, machine)
+	if checkpoints == nil {
+		Fail(t, "checkpoints not enabled")
+	}
+
+	early := machine.Clone()
+	Require(t, early.Step(ctx, 20))
+	checkpoints.save(early)
+	stepped := machine.Clone()
+	Require(t, stepped.Step(ctx, 100))
+	checkpoints.save(stepped)
+
+	loaded := checkpoints.load(0, 200)
+	if loaded == nil {
+		Fail(t, "failed to load checkpoint")
+	}
+	if loaded.GetStepCount() != 100 || loaded.Hash() != stepped.Hash() {
+		Fail(t, "loaded checkpoint doesn't match saved machine", loaded.GetStepCount())
+	}
+	if checkpoints.load(100, 200) != nil {
+		Fail(t, "loaded checkpoint at or before start step")
+	}
+	if checkpoints.load(0, 99) != nil {
+		Fail(t, "loaded checkpoint after max step")
+	}
+
+	// A cache over the same range should come out the same when resuming.
+	cache, err := newMachineCacheWithEndSteps(ctx, machine.Clone(), 4, 500, checkpoints)
+	Require(t, err)
+	expected, err := NewMachineCacheWithEndSteps(ctx, machine.Clone(), 4, 500)
+	Require(t, err)
+	for _, step := range []uint64{0, 100, 200, 300, 400, 500} {
+		a, err := cache.GetMachineAt(ctx, nil, step)
+		Require(t, err)
+		b, err := expected.GetMachineAt(ctx, nil, step)
+		Require(t, err)
+		if a.Hash() != b.Hash() {
+			Fail(t, "machine cache with checkpoints differs at step", step)
+		}
+	}
+
+	// Another challenge may have different inputs with the same initial machine
+	if newMachineCheckpoints(&config, common.Hash{1}, common.Hash{3}, machine).load(0, 500) != nil {
+		Fail(t, "loaded checkpoint of a different challenge")
+	}
+
+	checkpoints.prune(150, 500)
+	if checkpoints.load(0, 149) != nil {
+		Fail(t, "checkpoint not pruned")
+	}
+	checkpoints.remove()
+	if checkpoints.load(0, 500) != nil {
+		Fail(t, "checkpoints not removed")
+	}
+}
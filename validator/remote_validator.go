@@ -0,0 +1,265 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package validator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+var (
+	remoteValidationRetryCounter   = metrics.NewRegisteredCounter("arb/validator/remote/retries", nil)
+	remoteValidationFailureCounter = metrics.NewRegisteredCounter("arb/validator/remote/failures", nil)
+	remoteWorkersHealthyGauge      = metrics.NewRegisteredGauge("arb/validator/remote/workers/healthy", nil)
+)
+
+type RemoteValidationConfig struct {
+	Enable              bool          `koanf:"enable"`
+	URLs                []string      `koanf:"url"`
+	Timeout             time.Duration `koanf:"timeout"`
+	Retries             int           `koanf:"retries"`
+	RetryDelay          time.Duration `koanf:"retry-delay"`
+	HealthCheckInterval time.Duration `koanf:"health-check-interval"`
+}
+
+func RemoteValidationConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultRemoteValidationConfig.Enable, "validate blocks on remote validation workers instead of in-process")
+	f.StringSlice(prefix+".url", DefaultRemoteValidationConfig.URLs, "URLs of validation workers")
+	f.Duration(prefix+".timeout", DefaultRemoteValidationConfig.Timeout, "maximum time to wait for a validation worker to validate a block")
+	f.Int(prefix+".retries", DefaultRemoteValidationConfig.Retries, "number of times to retry a validation on another worker after a failure")
+	f.Duration(prefix+".retry-delay", DefaultRemoteValidationConfig.RetryDelay, "time to wait before retrying a validation when no worker is available")
+	f.Duration(prefix+".health-check-interval", DefaultRemoteValidationConfig.HealthCheckInterval, "how often to check the status of validation workers")
+}
+
+var DefaultRemoteValidationConfig = RemoteValidationConfig{
+	Enable:              false,
+	URLs:                []string{},
+	Timeout:             15 * time.Minute,
+	Retries:             3,
+	RetryDelay:          time.Second,
+	HealthCheckInterval: 10 * time.Second,
+}
+
+var errNoHealthyWorkers = errors.New("no healthy validation workers")
+
+type validationWorker struct {
+	url    string
+	client *rpc.Client
+
+	healthy int32 // atomic
+	running int32 // atomic: validations sent by this node
+	limit   int32 // atomic: as last reported by the worker
+}
+
+func (w *validationWorker) isHealthy() bool {
+	return atomic.LoadInt32(&w.healthy) == 1
+}
+
+func (w *validationWorker) setHealthy(healthy bool) {
+	var value int32
+	if healthy {
+		value = 1
+	}
+	if atomic.SwapInt32(&w.healthy, value) != value {
+		log.Info("validation worker health changed", "url", w.url, "healthy", healthy)
+	}
+}
+
+// load is the fraction of the worker's capacity used by this node.
+func (w *validationWorker) load() float64 {
+	limit := atomic.LoadInt32(&w.limit)
+	if limit <= 0 {
+		limit = 1
+	}
+	return float64(atomic.LoadInt32(&w.running)) / float64(limit)
+}
+
+// RemoteValidator dispatches validations to a pool of validation workers,
+// sending each to the least loaded healthy worker and retrying failures on
+// other workers.
+type RemoteValidator struct {
+	config  *RemoteValidationConfig
+	workers []*validationWorker
+}
+
+func NewRemoteValidator(config *RemoteValidationConfig) (*RemoteValidator, error) {
+	if len(config.URLs) == 0 {
+		return nil, errors.New("remote validation enabled but no validation worker URLs configured")
+	}
+	var workers []*validationWorker
+	for _, url := range config.URLs {
+		client, err := rpc.Dial(url)
+		if err != nil {
+			return nil, fmt.Errorf("error connecting to validation worker %v: %w", url, err)
+		}
+		workers = append(workers, &validationWorker{
+			url:     url,
+			client:  client,
+			healthy: 1,
+			limit:   1,
+		})
+	}
+	return &RemoteValidator{
+		config:  config,
+		workers: workers,
+	}, nil
+}
+
+// Capacity returns the number of validations the healthy workers can run at once.
+func (r *RemoteValidator) Capacity() int {
+	capacity := 0
+	for _, worker := range r.workers {
+		if worker.isHealthy() {
+			capacity += int(atomic.LoadInt32(&worker.limit))
+		}
+	}
+	return capacity
+}
+
+// checkWorkers refreshes the status of every worker, and is meant to be called
+// iteratively.
+func (r *RemoteValidator) checkWorkers(ctx context.Context) time.Duration {
+	healthy := 0
+	for _, worker := range r.workers {
+		checkCtx, cancel := context.WithTimeout(ctx, r.config.HealthCheckInterval)
+		var status ValidationWorkerStatus
+		err := worker.client.CallContext(checkCtx, &status, "validation_status")
+		cancel()
+		if err != nil {
+			if ctx.Err() != nil {
+				return 0
+			}
+			log.Warn("validation worker status check failed", "url", worker.url, "err", err)
+			worker.setHealthy(false)
+			continue
+		}
+		atomic.StoreInt32(&worker.limit, status.Limit)
+		worker.setHealthy(true)
+		healthy++
+	}
+	remoteWorkersHealthyGauge.Update(int64(healthy))
+	return r.config.HealthCheckInterval
+}
+
+func (r *RemoteValidator) pickWorker(exclude *validationWorker) *validationWorker {
+	var best *validationWorker
+	for _, worker := range r.workers {
+		if !worker.isHealthy() || (worker == exclude && len(r.workers) > 1) {
+			continue
+		}
+		if best == nil || worker.load() < best.load() {
+			best = worker
+		}
+	}
+	return best
+}
+
+func (r *RemoteValidator) Validate(ctx context.Context, input *ValidationInput) (GoGlobalState, error) {
+	var lastWorker *validationWorker
+	lastErr := errNoHealthyWorkers
+	for attempt := 0; attempt <= r.config.Retries; attempt++ {
+		if attempt > 0 {
+			remoteValidationRetryCounter.Inc(1)
+		}
+		worker := r.pickWorker(lastWorker)
+		if worker == nil {
+			select {
+			case <-ctx.Done():
+				return GoGlobalState{}, ctx.Err()
+			case <-time.After(r.config.RetryDelay):
+			}
+			continue
+		}
+		lastWorker = worker
+
+		gsEnd, err := r.validateOn(ctx, worker, input)
+		if err == nil {
+			return gsEnd, nil
+		}
+		if ctx.Err() != nil {
+			return GoGlobalState{}, ctx.Err()
+		}
+		var rpcErr rpc.Error
+		if !errors.As(err, &rpcErr) {
+			// The worker couldn't be reached, rather than failing the validation itself
+			worker.setHealthy(false)
+		}
+		log.Warn("remote validation failed", "url", worker.url, "blockNr", input.BlockNumber, "attempt", attempt, "err", err)
+		lastErr = err
+	}
+	remoteValidationFailureCounter.Inc(1)
+	return GoGlobalState{}, fmt.Errorf("remote validation of block %v failed after %v attempts: %w", input.BlockNumber, r.config.Retries+1, lastErr)
+}
+
+func (r *RemoteValidator) validateOn(ctx context.Context, worker *validationWorker, input *ValidationInput) (GoGlobalState, error) {
+	atomic.AddInt32(&worker.running, 1)
+	defer atomic.AddInt32(&worker.running, -1)
+
+	ctx, cancel := context.WithTimeout(ctx, r.config.Timeout)
+	defer cancel()
+	var gsEnd GoGlobalState
+	err := worker.client.CallContext(ctx, &gsEnd, "validation_validate", input)
+	return gsEnd, err
+}
+
+// validationInput gathers everything a remote worker needs to validate entry.
+func (v *StatelessBlockValidator) validationInput(
+	ctx context.Context, entry *validationEntry, moduleRoot common.Hash, jit bool,
+) (*ValidationInput, []byte, error) {
+	if entry.Preimages == nil {
+		return nil, nil, errors.New("remote validation requires recorded preimages")
+	}
+	delayedMsg, err := v.readDelayedMsg(entry)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Adds the preimages of any data availability batches to entry.Preimages
+	if _, err := NewMachinePreimageResolver(ctx, entry.Preimages, entry.BatchInfo, v.blockchain, v.daService); err != nil {
+		return nil, nil, err
+	}
+	return &ValidationInput{
+		BlockNumber:   entry.BlockNumber,
+		ModuleRoot:    moduleRoot,
+		Jit:           jit,
+		StartState:    entry.start(),
+		Preimages:     entry.Preimages,
+		BatchInfo:     entry.BatchInfo,
+		HasDelayedMsg: entry.HasDelayedMsg,
+		DelayedMsgNr:  entry.DelayedMsgNr,
+		DelayedMsg:    delayedMsg,
+	}, delayedMsg, nil
+}
+
+func (v *StatelessBlockValidator) remoteBlock(
+	ctx context.Context, entry *validationEntry, moduleRoot common.Hash, jit bool,
+) (GoGlobalState, []byte, error) {
+	input, delayedMsg, err := v.validationInput(ctx, entry, moduleRoot, jit)
+	if err != nil {
+		return GoGlobalState{}, nil, err
+	}
+	gsEnd, err := v.remoteValidator.Validate(ctx, input)
+	return gsEnd, delayedMsg, err
+}
+
+func (v *StatelessBlockValidator) remoteExecuteBlock(
+	ctx context.Context, entry *validationEntry, moduleRoot common.Hash,
+) (GoGlobalState, []byte, error) {
+	return v.remoteBlock(ctx, entry, moduleRoot, false)
+}
+
+func (v *StatelessBlockValidator) remoteJitBlock(
+	ctx context.Context, entry *validationEntry, moduleRoot common.Hash,
+) (GoGlobalState, []byte, error) {
+	return v.remoteBlock(ctx, entry, moduleRoot, true)
+}
@@ -0,0 +1,93 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package validator
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+type stubValidationService struct {
+	limit int32
+	fail  bool
+	calls int
+}
+
+func (s *stubValidationService) Validate(ctx context.Context, input *ValidationInput) (*GoGlobalState, error) {
+	s.calls++
+	if s.fail {
+		return nil, errors.New("stub validation failure")
+	}
+	return &GoGlobalState{
+		BlockHash:  common.BigToHash(new(big.Int).SetUint64(input.BlockNumber)),
+		Batch:      input.StartState.Batch,
+		PosInBatch: input.StartState.PosInBatch + 1,
+	}, nil
+}
+
+func (s *stubValidationService) Status(ctx context.Context) (*ValidationWorkerStatus, error) {
+	return &ValidationWorkerStatus{Limit: s.limit}, nil
+}
+
+func newStubWorker(t *testing.T, url string, service *stubValidationService) *validationWorker {
+	t.Helper()
+	server := rpc.NewServer()
+	Require(t, server.RegisterName("validation", service))
+	t.Cleanup(server.Stop)
+	return &validationWorker{
+		url:     url,
+		client:  rpc.DialInProc(server),
+		healthy: 1,
+		limit:   1,
+	}
+}
+
+func TestRemoteValidatorRetriesOnAnotherWorker(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	failing := &stubValidationService{limit: 4, fail: true}
+	working := &stubValidationService{limit: 2}
+	config := DefaultRemoteValidationConfig
+	config.RetryDelay = time.Millisecond
+	remote := &RemoteValidator{
+		config: &config,
+		workers: []*validationWorker{
+			newStubWorker(t, "failing", failing),
+			newStubWorker(t, "working", working),
+		},
+	}
+
+	remote.checkWorkers(ctx)
+	if remote.Capacity() != 6 {
+		Fail(t, "unexpected capacity", remote.Capacity())
+	}
+
+	input := &ValidationInput{BlockNumber: 7, StartState: GoGlobalState{Batch: 2, PosInBatch: 3}}
+	for i := 0; i < 3; i++ {
+		gsEnd, err := remote.Validate(ctx, input)
+		Require(t, err)
+		if gsEnd.Batch != 2 || gsEnd.PosInBatch != 4 || gsEnd.BlockHash != common.BigToHash(new(big.Int).SetUint64(7)) {
+			Fail(t, "unexpected end state", gsEnd)
+		}
+	}
+	if working.calls != 3 {
+		Fail(t, "expected every validation to succeed on the working worker, got", working.calls)
+	}
+	// Failures returned by the worker itself don't mark it unhealthy
+	if !remote.workers[0].isHealthy() {
+		Fail(t, "worker marked unhealthy after a validation error")
+	}
+
+	working.fail = true
+	if _, err := remote.Validate(ctx, input); err == nil {
+		Fail(t, "expected validation to fail when every worker fails")
+	}
+}
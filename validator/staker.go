@@ -37,34 +37,45 @@ const (
 )
 
 type L1PostingStrategy struct {
-	HighGasThreshold   float64 `koanf:"high-gas-threshold"`
-	HighGasDelayBlocks int64   `koanf:"high-gas-delay-blocks"`
+	HighGasThreshold    float64             `koanf:"high-gas-threshold"`
+	HighGasDelayBlocks  int64               `koanf:"high-gas-delay-blocks"`
+	ActionThresholds    ActionGasThresholds `koanf:"action-threshold"`
+	DailyBudget         float64             `koanf:"daily-budget"`
+	ChallengeMoveMargin time.Duration       `koanf:"challenge-move-margin"`
 }
 
 var DefaultL1PostingStrategy = L1PostingStrategy{
-	HighGasThreshold:   0,
-	HighGasDelayBlocks: 0,
+	HighGasThreshold:    0,
+	HighGasDelayBlocks:  0,
+	ActionThresholds:    DefaultActionGasThresholds,
+	DailyBudget:         0,
+	ChallengeMoveMargin: 4 * time.Hour,
 }
 
 func L1PostingStrategyAddOptions(prefix string, f *flag.FlagSet) {
 	f.Float64(prefix+".high-gas-threshold", DefaultL1PostingStrategy.HighGasThreshold, "high gas threshold")
 	f.Int64(prefix+".high-gas-delay-blocks", DefaultL1PostingStrategy.HighGasDelayBlocks, "high gas delay blocks")
+	ActionGasThresholdsAddOptions(prefix+".action-threshold", f)
+	f.Float64(prefix+".daily-budget", DefaultL1PostingStrategy.DailyBudget, "maximum ETH to spend on staker transactions in any 24 hours, except on challenge moves (0 for no limit)")
+	f.Duration(prefix+".challenge-move-margin", DefaultL1PostingStrategy.ChallengeMoveMargin, "never delay a challenge move once there's less than this time left to make it")
 }
 
 type L1ValidatorConfig struct {
-	Enable                   bool              `koanf:"enable"`
-	Strategy                 string            `koanf:"strategy"`
-	StakerInterval           time.Duration     `koanf:"staker-interval"`
-	MakeAssertionInterval    time.Duration     `koanf:"make-assertion-interval"`
-	L1PostingStrategy        L1PostingStrategy `koanf:"posting-strategy"`
-	DisableChallenge         bool              `koanf:"disable-challenge"`
-	TargetMachineCount       int               `koanf:"target-machine-count"`
-	ConfirmationBlocks       int64             `koanf:"confirmation-blocks"`
-	UseSmartContractWallet   bool              `koanf:"use-smart-contract-wallet"`
-	OnlyCreateWalletContract bool              `koanf:"only-create-wallet-contract"`
-	ContractWalletAddress    string            `koanf:"contract-wallet-address"`
-	GasRefunderAddress       string            `koanf:"gas-refunder-address"`
-	Dangerous                DangerousConfig   `koanf:"dangerous"`
+	Enable                   bool                    `koanf:"enable"`
+	Strategy                 string                  `koanf:"strategy"`
+	StakerInterval           time.Duration           `koanf:"staker-interval"`
+	MakeAssertionInterval    time.Duration           `koanf:"make-assertion-interval"`
+	L1PostingStrategy        L1PostingStrategy       `koanf:"posting-strategy"`
+	DisableChallenge         bool                    `koanf:"disable-challenge"`
+	TargetMachineCount       int                     `koanf:"target-machine-count"`
+	ConfirmationBlocks       int64                   `koanf:"confirmation-blocks"`
+	UseSmartContractWallet   bool                    `koanf:"use-smart-contract-wallet"`
+	OnlyCreateWalletContract bool                    `koanf:"only-create-wallet-contract"`
+	ContractWalletAddress    string                  `koanf:"contract-wallet-address"`
+	GasRefunderAddress       string                  `koanf:"gas-refunder-address"`
+	Alerts                   StakerAlertsConfig      `koanf:"alerts"`
+	MachineCheckpoints       MachineCheckpointConfig `koanf:"machine-checkpoints"`
+	Dangerous                DangerousConfig         `koanf:"dangerous"`
 }
 
 var DefaultL1ValidatorConfig = L1ValidatorConfig{
@@ -72,7 +83,7 @@ var DefaultL1ValidatorConfig = L1ValidatorConfig{
 	Strategy:                 "Watchtower",
 	StakerInterval:           time.Minute,
 	MakeAssertionInterval:    time.Hour,
-	L1PostingStrategy:        L1PostingStrategy{},
+	L1PostingStrategy:        DefaultL1PostingStrategy,
 	DisableChallenge:         false,
 	TargetMachineCount:       4,
 	ConfirmationBlocks:       12,
@@ -80,6 +91,8 @@ var DefaultL1ValidatorConfig = L1ValidatorConfig{
 	OnlyCreateWalletContract: false,
 	ContractWalletAddress:    "",
 	GasRefunderAddress:       "",
+	Alerts:                   DefaultStakerAlertsConfig,
+	MachineCheckpoints:       DefaultMachineCheckpointConfig,
 	Dangerous:                DefaultDangerousConfig,
 }
 
@@ -96,6 +109,8 @@ func L1ValidatorConfigAddOptions(prefix string, f *flag.FlagSet) {
 	f.Bool(prefix+".only-create-wallet-contract", DefaultL1ValidatorConfig.OnlyCreateWalletContract, "only create smart wallet contract and exit")
 	f.String(prefix+".contract-wallet-address", DefaultL1ValidatorConfig.ContractWalletAddress, "validator smart contract wallet public address")
 	f.String(prefix+".gas-refunder-address", DefaultL1ValidatorConfig.GasRefunderAddress, "The gas refunder contract address (optional)")
+	StakerAlertsConfigAddOptions(prefix+".alerts", f)
+	MachineCheckpointConfigAddOptions(prefix+".machine-checkpoints", f)
 	DangerousConfigAddOptions(prefix+".dangerous", f)
 }
 
@@ -124,14 +139,24 @@ type Staker struct {
 	strategy                StakerStrategy
 	baseCallOpts            bind.CallOpts
 	config                  L1ValidatorConfig
-	highGasBlocksBuffer     *big.Int
+	highGasBlocksBuffers    map[StakerActionKind]*big.Int
+	gasPriceHigh            map[StakerActionKind]bool
+	gasPriceGwei            float64
 	lastActCalledBlock      *big.Int
+	pendingActions          map[StakerActionKind]bool
+	postedActions           []StakerActionKind
+	spending                *stakerSpending
 	inactiveLastCheckedNode *nodeAndHash
 	bringActiveUntilNode    uint64
 	inboxReader             InboxReaderInterface
 	nitroMachineLoader      *NitroMachineLoader
 }
 
+// ParseStrategy returns the staker strategy the config names.
+func (c *L1ValidatorConfig) ParseStrategy() (StakerStrategy, error) {
+	return stakerStrategyFromString(c.Strategy)
+}
+
 func stakerStrategyFromString(s string) (StakerStrategy, error) {
 	if strings.ToLower(s) == "watchtower" {
 		return WatchtowerStrategy, nil
@@ -172,16 +197,23 @@ func NewStaker(
 	if err != nil {
 		return nil, err
 	}
+	val.alerts = newStakerAlerts(config.Alerts)
+	highGasBlocksBuffers := make(map[StakerActionKind]*big.Int)
+	for _, kind := range stakerActionKinds {
+		highGasBlocksBuffers[kind] = big.NewInt(config.L1PostingStrategy.HighGasDelayBlocks)
+	}
 	return &Staker{
-		L1Validator:         val,
-		l1Reader:            l1Reader,
-		strategy:            strategy,
-		baseCallOpts:        callOpts,
-		config:              config,
-		highGasBlocksBuffer: big.NewInt(config.L1PostingStrategy.HighGasDelayBlocks),
-		lastActCalledBlock:  nil,
-		inboxReader:         inboxReader,
-		nitroMachineLoader:  nitroMachineLoader,
+		L1Validator:          val,
+		l1Reader:             l1Reader,
+		strategy:             strategy,
+		baseCallOpts:         callOpts,
+		config:               config,
+		highGasBlocksBuffers: highGasBlocksBuffers,
+		lastActCalledBlock:   nil,
+		pendingActions:       make(map[StakerActionKind]bool),
+		spending:             newStakerSpending(),
+		inboxReader:          inboxReader,
+		nitroMachineLoader:   nitroMachineLoader,
 	}, nil
 }
 
@@ -211,6 +243,7 @@ func (s *Staker) Initialize(ctx context.Context) error {
 
 func (s *Staker) Start(ctxIn context.Context) {
 	s.StopWaiter.Start(ctxIn, s)
+	s.LaunchThread(s.alerts.deliver)
 	backoff := time.Second
 	s.CallIteratively(func(ctx context.Context) time.Duration {
 		err := s.updateBlockValidatorModuleRoot(ctx)
@@ -219,8 +252,12 @@ func (s *Staker) Start(ctxIn context.Context) {
 		}
 		arbTx, err := s.Act(ctx)
 		if err == nil && arbTx != nil {
-			_, err = s.l1Reader.WaitForTxApproval(ctx, arbTx)
+			var receipt *types.Receipt
+			receipt, err = s.l1Reader.WaitForTxApproval(ctx, arbTx)
 			err = errors.Wrap(err, "error waiting for tx receipt")
+			if receipt != nil {
+				s.spending.record(arbTx, receipt, s.postedActions)
+			}
 			if err == nil {
 				log.Info("successfully executed staker transaction", "hash", arbTx.Hash())
 			}
@@ -260,55 +297,6 @@ func (s *Staker) IsWhitelisted(ctx context.Context) (bool, error) {
 	return false, nil
 }
 
-func (s *Staker) shouldAct(ctx context.Context) bool {
-	var gasPriceHigh = false
-	var gasPriceFloat float64
-	gasPrice, err := s.client.SuggestGasPrice(ctx)
-	if err != nil {
-		log.Warn("error getting gas price", "err", err)
-	} else {
-		gasPriceFloat = float64(gasPrice.Int64()) / 1e9
-		if gasPriceFloat >= s.config.L1PostingStrategy.HighGasThreshold {
-			gasPriceHigh = true
-		}
-	}
-	latestBlockInfo, err := s.client.HeaderByNumber(ctx, nil)
-	if err != nil {
-		log.Warn("error getting latest block", "err", err)
-		return true
-	}
-	latestBlockNum := latestBlockInfo.Number
-	if s.lastActCalledBlock == nil {
-		s.lastActCalledBlock = latestBlockNum
-	}
-	blocksSinceActCalled := new(big.Int).Sub(latestBlockNum, s.lastActCalledBlock)
-	s.lastActCalledBlock = latestBlockNum
-	if gasPriceHigh {
-		// We're eating into the high gas buffer to delay our tx
-		s.highGasBlocksBuffer.Sub(s.highGasBlocksBuffer, blocksSinceActCalled)
-	} else {
-		// We'll try to make a tx if necessary, so we can add to the buffer for future high gas
-		s.highGasBlocksBuffer.Add(s.highGasBlocksBuffer, blocksSinceActCalled)
-	}
-	// Clamp `s.highGasBlocksBuffer` to between 0 and HighGasDelayBlocks
-	if s.highGasBlocksBuffer.Sign() < 0 {
-		s.highGasBlocksBuffer.SetInt64(0)
-	} else if s.highGasBlocksBuffer.Cmp(big.NewInt(s.config.L1PostingStrategy.HighGasDelayBlocks)) > 0 {
-		s.highGasBlocksBuffer.SetInt64(s.config.L1PostingStrategy.HighGasDelayBlocks)
-	}
-	if gasPriceHigh && s.highGasBlocksBuffer.Sign() > 0 {
-		log.Warn(
-			"not acting yet as gas price is high",
-			"gasPrice", gasPriceFloat,
-			"highGasPriceConfig", s.config.L1PostingStrategy.HighGasThreshold,
-			"highGasBuffer", s.highGasBlocksBuffer,
-		)
-		return false
-	} else {
-		return true
-	}
-}
-
 func (s *Staker) Act(ctx context.Context) (*types.Transaction, error) {
 	if s.strategy != WatchtowerStrategy {
 		whitelisted, err := s.IsWhitelisted(ctx)
@@ -319,12 +307,11 @@ func (s *Staker) Act(ctx context.Context) (*types.Transaction, error) {
 			log.Warn("validator address isn't whitelisted", "address", s.wallet.Address(), "txSender", s.wallet.TxSenderAddress())
 		}
 	}
-	if !s.shouldAct(ctx) {
-		// The fact that we're delaying acting is alreay logged in `shouldAct`
-		return nil, nil
-	}
+	s.updateHighGasBuffers(ctx)
 	callOpts := s.getCallOpts(ctx)
 	s.builder.ClearTransactions()
+	s.pendingActions = make(map[StakerActionKind]bool)
+	s.postedActions = nil
 	var rawInfo *StakerInfo
 	walletAddressOrZero := s.wallet.AddressOrZero()
 	if walletAddressOrZero != (common.Address{}) {
@@ -397,14 +384,22 @@ func (s *Staker) Act(ctx context.Context) (*types.Transaction, error) {
 		(effectiveStrategy >= StakeLatestStrategy && rawInfo == nil && requiredStakeElevated)
 	resolvingNode := false
 	if shouldResolveNodes {
-		arbTx, err := s.resolveTimedOutChallenges(ctx)
-		if err != nil || arbTx != nil {
-			return arbTx, err
+		if s.shouldPost(ctx, map[StakerActionKind]bool{ConfirmAction: true}) {
+			arbTx, err := s.resolveTimedOutChallenges(ctx)
+			if arbTx != nil {
+				s.postedActions = []StakerActionKind{ConfirmAction}
+			}
+			if err != nil || arbTx != nil {
+				return arbTx, err
+			}
 		}
 		resolvingNode, err = s.resolveNextNode(ctx, rawInfo, &latestConfirmedNode)
 		if err != nil {
 			return nil, err
 		}
+		if resolvingNode {
+			s.recordAction(ConfirmAction)
+		}
 		if resolvingNode && rawInfo == nil && latestConfirmedNode > info.LatestStakedNode {
 			// If we hit this condition, we've resolved what was previously the latest confirmed node,
 			// and we don't have a stake yet. That means we were planning to enter the rollup on
@@ -438,8 +433,9 @@ func (s *Staker) Act(ctx context.Context) (*types.Transaction, error) {
 			if err != nil {
 				return nil, err
 			}
+			s.recordAction(WithdrawAction)
 			log.Info("removing old stake and withdrawing funds")
-			return s.wallet.ExecuteTransactions(ctx, s.builder, common.HexToAddress(s.config.GasRefunderAddress))
+			return s.executeTransactions(ctx)
 		}
 	}
 
@@ -453,6 +449,7 @@ func (s *Staker) Act(ctx context.Context) (*types.Transaction, error) {
 			if err != nil {
 				return nil, err
 			}
+			s.recordAction(WithdrawAction)
 		}
 	}
 
@@ -489,17 +486,26 @@ func (s *Staker) Act(ctx context.Context) (*types.Transaction, error) {
 	if info.StakerInfo == nil && info.StakeExists {
 		log.Info("staking to execute transactions")
 	}
-	return s.wallet.ExecuteTransactions(ctx, s.builder, common.HexToAddress(s.config.GasRefunderAddress))
+	return s.executeTransactions(ctx)
 }
 
 func (s *Staker) handleConflict(ctx context.Context, info *StakerInfo) error {
 	if info.CurrentChallenge == nil {
+		if s.activeChallenge != nil {
+			s.activeChallenge.RemoveMachineCheckpoints()
+		}
 		s.activeChallenge = nil
 		return nil
 	}
 
 	if s.activeChallenge == nil || s.activeChallenge.ChallengeIndex() != *info.CurrentChallenge {
 		log.Error("entered challenge", "challenge", info.CurrentChallenge)
+		s.alerts.alert(&StakerAlert{
+			Kind:      ChallengeOpenedAlert,
+			Message:   "our wallet is in a challenge",
+			Node:      info.LatestStakedNode,
+			Challenge: info.CurrentChallenge,
+		})
 
 		latestConfirmedCreated, err := s.rollup.LatestConfirmedCreationBlock(ctx)
 		if err != nil {
@@ -526,12 +532,46 @@ func (s *Staker) handleConflict(ctx context.Context, info *StakerInfo) error {
 		if err != nil {
 			return err
 		}
+		newChallengeManager.checkpointConfig = &s.config.MachineCheckpoints
 
+		if s.activeChallenge != nil {
+			s.activeChallenge.RemoveMachineCheckpoints()
+		}
 		s.activeChallenge = newChallengeManager
 	}
 
-	_, err := s.activeChallenge.Act(ctx)
-	return err
+	// check even if acting fails, as that's when we're most at risk of timing out
+	defer s.checkChallengeTimeLeft(ctx)
+	tx, err := s.activeChallenge.Act(ctx)
+	if err != nil {
+		return err
+	}
+	if tx != nil {
+		s.recordAction(ChallengeMoveAction)
+	}
+	return nil
+}
+
+// checkChallengeTimeLeft alerts if it's our turn in the active challenge and
+// we're close to timing out, which would lose our stake.
+func (s *Staker) checkChallengeTimeLeft(ctx context.Context) {
+	responder, timeLeft, err := s.activeChallenge.ResponderTimeLeft(ctx)
+	if err != nil {
+		log.Warn("error checking challenge time left", "err", err)
+		return
+	}
+	if responder != s.activeChallenge.actingAs || timeLeft >= s.config.Alerts.ChallengeTimeLeftThreshold {
+		return
+	}
+	challenge := s.activeChallenge.ChallengeIndex()
+	s.alerts.alert(&StakerAlert{
+		Kind:      StakeAtRiskAlert,
+		Message:   "our turn in a challenge is about to time out",
+		Challenge: &challenge,
+		Details: map[string]string{
+			"timeLeft": timeLeft.String(),
+		},
+	})
 }
 
 func (s *Staker) advanceStake(ctx context.Context, info *OurStakerInfo, effectiveStrategy StakerStrategy) error {
@@ -572,6 +612,9 @@ func (s *Staker) advanceStake(ctx context.Context, info *OurStakerInfo, effectiv
 		// We'll return early if we already havea stake
 		if info.StakeExists {
 			_, err = s.rollup.StakeOnNewNode(s.builder.Auth(ctx), action.assertion.AsSolidityStruct(), action.hash, action.prevInboxMaxCount)
+			if err == nil {
+				s.recordAction(NewAssertionAction)
+			}
 			return err
 		}
 
@@ -589,6 +632,7 @@ func (s *Staker) advanceStake(ctx context.Context, info *OurStakerInfo, effectiv
 		if err != nil {
 			return err
 		}
+		s.recordAction(NewAssertionAction)
 		info.StakeExists = true
 		return nil
 	case existingNodeAction:
@@ -611,6 +655,9 @@ func (s *Staker) advanceStake(ctx context.Context, info *OurStakerInfo, effectiv
 		// We'll return early if we already havea stake
 		if info.StakeExists {
 			_, err = s.rollup.StakeOnExistingNode(s.builder.Auth(ctx), action.number, action.hash)
+			if err == nil {
+				s.recordAction(StakeAction)
+			}
 			return err
 		}
 
@@ -627,6 +674,7 @@ func (s *Staker) advanceStake(ctx context.Context, info *OurStakerInfo, effectiv
 		if err != nil {
 			return err
 		}
+		s.recordAction(StakeAction)
 		info.StakeExists = true
 		return nil
 	default:
@@ -707,6 +755,7 @@ func (s *Staker) createConflict(ctx context.Context, info *StakerInfo) error {
 		if err != nil {
 			return err
 		}
+		s.recordAction(ChallengeMoveAction)
 	}
 	// No conflicts exist
 	return nil
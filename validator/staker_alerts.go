@@ -0,0 +1,345 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package validator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var (
+	stakerAlertCounter       = metrics.NewRegisteredCounter("arb/validator/staker/alerts", nil)
+	stakerAlertFailedCounter = metrics.NewRegisteredCounter("arb/validator/staker/alerts/failed", nil)
+)
+
+type StakerAlertKind string
+
+const (
+	// Someone asserted a node that disagrees with our local chain
+	ConflictingNodeAlert StakerAlertKind = "conflicting-node"
+	// An assertion can't be checked against our local chain
+	UnvalidatedAssertionAlert StakerAlertKind = "unvalidated-assertion"
+	// Our wallet is a participant in a challenge
+	ChallengeOpenedAlert StakerAlertKind = "challenge-opened"
+	// Our wallet is about to time out in a challenge and lose its stake
+	StakeAtRiskAlert StakerAlertKind = "stake-at-risk"
+)
+
+// StakerAlert is sent to the configured sinks when the staker sees something
+// that needs the attention of an operator.
+type StakerAlert struct {
+	Kind      StakerAlertKind   `json:"kind"`
+	Message   string            `json:"message"`
+	Node      uint64            `json:"node,omitempty"`
+	NodeHash  *common.Hash      `json:"nodeHash,omitempty"`
+	Challenge *uint64           `json:"challenge,omitempty"`
+	Details   map[string]string `json:"details,omitempty"`
+	Time      time.Time         `json:"time"`
+}
+
+// key identifies alerts about the same problem, for deduplication.
+func (a *StakerAlert) key() string {
+	key := fmt.Sprintf("%v/%v", a.Kind, a.Node)
+	if a.NodeHash != nil {
+		key += "/" + a.NodeHash.Hex()
+	}
+	if a.Challenge != nil {
+		key += fmt.Sprintf("/challenge%v", *a.Challenge)
+	}
+	return key
+}
+
+type StakerAlertsConfig struct {
+	Log                        bool          `koanf:"log"`
+	File                       string        `koanf:"file"`
+	Webhooks                   []string      `koanf:"webhook"`
+	WebhookTimeout             time.Duration `koanf:"webhook-timeout"`
+	WebhookRetries             int           `koanf:"webhook-retries"`
+	WebhookRetryDelay          time.Duration `koanf:"webhook-retry-delay"`
+	DedupWindow                time.Duration `koanf:"dedup-window"`
+	StalledAssertionTimeout    time.Duration `koanf:"stalled-assertion-timeout"`
+	ChallengeTimeLeftThreshold time.Duration `koanf:"challenge-time-left-threshold"`
+}
+
+var DefaultStakerAlertsConfig = StakerAlertsConfig{
+	Log:                        true,
+	File:                       "",
+	Webhooks:                   []string{},
+	WebhookTimeout:             10 * time.Second,
+	WebhookRetries:             3,
+	WebhookRetryDelay:          time.Second,
+	DedupWindow:                time.Hour,
+	StalledAssertionTimeout:    time.Hour,
+	ChallengeTimeLeftThreshold: 24 * time.Hour,
+}
+
+func StakerAlertsConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".log", DefaultStakerAlertsConfig.Log, "log alerts")
+	f.String(prefix+".file", DefaultStakerAlertsConfig.File, "file to append alerts to as json lines")
+	f.StringSlice(prefix+".webhook", DefaultStakerAlertsConfig.Webhooks, "URLs to post alerts to as json")
+	f.Duration(prefix+".webhook-timeout", DefaultStakerAlertsConfig.WebhookTimeout, "timeout for posting an alert to a webhook")
+	f.Int(prefix+".webhook-retries", DefaultStakerAlertsConfig.WebhookRetries, "number of times to retry posting an alert to a webhook")
+	f.Duration(prefix+".webhook-retry-delay", DefaultStakerAlertsConfig.WebhookRetryDelay, "delay before retrying to post an alert to a webhook, doubled after each retry")
+	f.Duration(prefix+".dedup-window", DefaultStakerAlertsConfig.DedupWindow, "minimum time before an alert about the same problem is sent again")
+	f.Duration(prefix+".stalled-assertion-timeout", DefaultStakerAlertsConfig.StalledAssertionTimeout, "alert if an assertion can't be checked as the block validator hasn't caught up for this long")
+	f.Duration(prefix+".challenge-time-left-threshold", DefaultStakerAlertsConfig.ChallengeTimeLeftThreshold, "alert if it's our turn in a challenge and we have less than this time left to act")
+}
+
+type stakerAlertSink interface {
+	name() string
+	send(ctx context.Context, alert *StakerAlert) error
+}
+
+type logAlertSink struct{}
+
+func (s logAlertSink) name() string { return "log" }
+
+func (s logAlertSink) send(ctx context.Context, alert *StakerAlert) error {
+	args := []interface{}{"kind", alert.Kind, "node", alert.Node}
+	if alert.NodeHash != nil {
+		args = append(args, "nodeHash", *alert.NodeHash)
+	}
+	if alert.Challenge != nil {
+		args = append(args, "challenge", *alert.Challenge)
+	}
+	for key, value := range alert.Details {
+		args = append(args, key, value)
+	}
+	log.Error("staker alert: "+alert.Message, args...)
+	return nil
+}
+
+type fileAlertSink struct {
+	path string
+}
+
+func (s fileAlertSink) name() string { return "file" }
+
+//nolint:gosec
+func (s fileAlertSink) send(ctx context.Context, alert *StakerAlert) error {
+	data, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(s.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = file.Write(append(data, '\n'))
+	return err
+}
+
+type webhookAlertSink struct {
+	url        string
+	client     *http.Client
+	retries    int
+	retryDelay time.Duration
+}
+
+func (s webhookAlertSink) name() string { return "webhook " + s.url }
+
+func (s webhookAlertSink) send(ctx context.Context, alert *StakerAlert) error {
+	data, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+	delay := s.retryDelay
+	for attempt := 0; ; attempt++ {
+		err = s.post(ctx, data)
+		if err == nil || attempt >= s.retries {
+			return err
+		}
+		log.Warn("failed to post staker alert to webhook, retrying", "url", s.url, "err", err, "delay", delay)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+		delay *= 2
+	}
+}
+
+func (s webhookAlertSink) post(ctx context.Context, data []byte) error {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	response, err := s.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %v", response.Status)
+	}
+	return nil
+}
+
+// stakerAlerts deduplicates alerts and delivers them to the configured sinks.
+// Delivery happens on a separate thread so slow sinks don't hold up the staker.
+// Alerts only count as sent to a sink once delivered, so one that's dropped or
+// fails is sent again the next time the staker raises it.
+// A nil *stakerAlerts drops all alerts.
+type stakerAlerts struct {
+	config StakerAlertsConfig
+	sinks  []stakerAlertSink
+	queue  chan *StakerAlert
+
+	mutex        sync.Mutex
+	lastSent     map[string]time.Time // by sink and alert key
+	queued       map[string]bool      // keys of the alerts waiting for delivery
+	waitingSince map[uint64]time.Time
+}
+
+func newStakerAlerts(config StakerAlertsConfig) *stakerAlerts {
+	var sinks []stakerAlertSink
+	if config.Log {
+		sinks = append(sinks, logAlertSink{})
+	}
+	if config.File != "" {
+		sinks = append(sinks, fileAlertSink{path: config.File})
+	}
+	for _, url := range config.Webhooks {
+		sinks = append(sinks, webhookAlertSink{
+			url:        url,
+			client:     &http.Client{Timeout: config.WebhookTimeout},
+			retries:    config.WebhookRetries,
+			retryDelay: config.WebhookRetryDelay,
+		})
+	}
+	return &stakerAlerts{
+		config:       config,
+		sinks:        sinks,
+		queue:        make(chan *StakerAlert, 64),
+		lastSent:     make(map[string]time.Time),
+		queued:       make(map[string]bool),
+		waitingSince: make(map[uint64]time.Time),
+	}
+}
+
+func sentKey(sink stakerAlertSink, key string) string {
+	return sink.name() + " " + key
+}
+
+// recentlySent returns whether the alert with key was delivered to sink within
+// the deduplication window. It must be called with the mutex held.
+func (a *stakerAlerts) recentlySent(sink stakerAlertSink, key string, now time.Time) bool {
+	lastSent, sent := a.lastSent[sentKey(sink, key)]
+	return sent && now.Sub(lastSent) < a.config.DedupWindow
+}
+
+// alert queues alert for delivery, unless it's already queued or an alert
+// about the same problem was delivered to every sink within the deduplication
+// window.
+func (a *stakerAlerts) alert(alert *StakerAlert) {
+	if a == nil || len(a.sinks) == 0 {
+		return
+	}
+	alert.Time = time.Now()
+	key := alert.key()
+	a.mutex.Lock()
+	due := false
+	for _, sink := range a.sinks {
+		if !a.recentlySent(sink, key, alert.Time) {
+			due = true
+		}
+	}
+	if a.queued[key] || !due {
+		a.mutex.Unlock()
+		return
+	}
+	for key, lastSent := range a.lastSent {
+		if alert.Time.Sub(lastSent) >= a.config.DedupWindow {
+			delete(a.lastSent, key)
+		}
+	}
+	a.queued[key] = true
+	a.mutex.Unlock()
+
+	select {
+	case a.queue <- alert:
+	default:
+		a.mutex.Lock()
+		delete(a.queued, key)
+		a.mutex.Unlock()
+		stakerAlertFailedCounter.Inc(1)
+		log.Error("staker alert queue full, dropping alert", "kind", alert.Kind, "message", alert.Message)
+	}
+}
+
+// waitingForValidation records that the assertion of node can't be checked yet,
+// and alerts once that has been the case for too long.
+func (a *stakerAlerts) waitingForValidation(node uint64, nodeHash common.Hash, reason string) {
+	if a == nil {
+		return
+	}
+	a.mutex.Lock()
+	since, ok := a.waitingSince[node]
+	if !ok {
+		since = time.Now()
+		// Only the latest node is waited on at a time
+		a.waitingSince = map[uint64]time.Time{node: since}
+	}
+	a.mutex.Unlock()
+	if time.Since(since) < a.config.StalledAssertionTimeout {
+		return
+	}
+	a.alert(&StakerAlert{
+		Kind:     UnvalidatedAssertionAlert,
+		Message:  "assertion has not been checked for too long",
+		Node:     node,
+		NodeHash: &nodeHash,
+		Details: map[string]string{
+			"reason":       reason,
+			"waitingSince": since.String(),
+		},
+	})
+}
+
+func (a *stakerAlerts) deliver(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case alert := <-a.queue:
+			stakerAlertCounter.Inc(1)
+			key := alert.key()
+			for _, sink := range a.sinks {
+				a.mutex.Lock()
+				recentlySent := a.recentlySent(sink, key, alert.Time)
+				a.mutex.Unlock()
+				if recentlySent {
+					continue
+				}
+				if err := sink.send(ctx, alert); err != nil {
+					stakerAlertFailedCounter.Inc(1)
+					log.Error("failed to send staker alert", "sink", sink.name(), "kind", alert.Kind, "err", err)
+					continue
+				}
+				a.mutex.Lock()
+				a.lastSent[sentKey(sink, key)] = alert.Time
+				a.mutex.Unlock()
+			}
+			a.mutex.Lock()
+			delete(a.queued, key)
+			a.mutex.Unlock()
+		}
+	}
+}
@@ -0,0 +1,186 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package validator
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestStakerAlerts(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	received := make(chan StakerAlert, 8)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var alert StakerAlert
+		if err := json.NewDecoder(r.Body).Decode(&alert); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		received <- alert
+	}))
+	defer server.Close()
+
+	config := DefaultStakerAlertsConfig
+	config.Log = false
+	config.File = filepath.Join(t.TempDir(), "alerts.jsonl")
+	config.Webhooks = []string{server.URL}
+	alerts := newStakerAlerts(config)
+	go alerts.deliver(ctx)
+
+	nodeHash := common.HexToHash("0x1234")
+	alerts.alert(&StakerAlert{Kind: ConflictingNodeAlert, Message: "first", Node: 5, NodeHash: &nodeHash})
+	alerts.alert(&StakerAlert{Kind: ConflictingNodeAlert, Message: "duplicate", Node: 5, NodeHash: &nodeHash})
+	alerts.alert(&StakerAlert{Kind: ConflictingNodeAlert, Message: "other node", Node: 6})
+
+	var messages []string
+	for len(messages) < 2 {
+		select {
+		case alert := <-received:
+			messages = append(messages, alert.Message)
+		case <-time.After(5 * time.Second):
+			Fail(t, "timed out waiting for webhook, received", messages)
+		}
+	}
+	select {
+	case alert := <-received:
+		Fail(t, "duplicate alert delivered", alert.Message)
+	case <-time.After(100 * time.Millisecond):
+	}
+	if messages[0] != "first" || messages[1] != "other node" {
+		Fail(t, "unexpected alerts", messages)
+	}
+
+	file, err := os.Open(config.File)
+	Require(t, err)
+	defer file.Close()
+	lines := 0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var alert StakerAlert
+		Require(t, json.Unmarshal(scanner.Bytes(), &alert))
+		lines++
+	}
+	Require(t, scanner.Err())
+	if lines != 2 {
+		Fail(t, "unexpected number of alerts in file", lines)
+	}
+}
+
+func TestStakerAlertsRetried(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var attempts int32
+	failing := int32(2)
+	received := make(chan StakerAlert, 8)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) <= atomic.LoadInt32(&failing) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var alert StakerAlert
+		if err := json.NewDecoder(r.Body).Decode(&alert); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		received <- alert
+	}))
+	defer server.Close()
+
+	config := DefaultStakerAlertsConfig
+	config.Log = false
+	config.Webhooks = []string{server.URL}
+	config.WebhookRetries = 2
+	config.WebhookRetryDelay = time.Millisecond
+	alerts := newStakerAlerts(config)
+	go alerts.deliver(ctx)
+
+	expectAlert := func(message string) {
+		t.Helper()
+		select {
+		case alert := <-received:
+			if alert.Message != message {
+				Fail(t, "unexpected alert", alert.Message)
+			}
+		case <-time.After(5 * time.Second):
+			Fail(t, "timed out waiting for alert", message)
+		}
+	}
+	waitForDelivery := func(key string) {
+		t.Helper()
+		for i := 0; ; i++ {
+			alerts.mutex.Lock()
+			queued := alerts.queued[key]
+			alerts.mutex.Unlock()
+			if !queued {
+				return
+			}
+			if i >= 500 {
+				Fail(t, "alert never delivered")
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	// the webhook fails twice, then succeeds on the last retry
+	alert := &StakerAlert{Kind: StakeAtRiskAlert, Message: "retried", Node: 1}
+	alerts.alert(alert)
+	expectAlert("retried")
+
+	// an alert that couldn't be delivered isn't deduplicated
+	atomic.StoreInt32(&attempts, 0)
+	atomic.StoreInt32(&failing, 3)
+	failed := &StakerAlert{Kind: StakeAtRiskAlert, Message: "failed", Node: 2}
+	alerts.alert(failed)
+	waitForDelivery(failed.key())
+	if atomic.LoadInt32(&attempts) != 3 {
+		Fail(t, "expected 3 attempts, got", atomic.LoadInt32(&attempts))
+	}
+	alerts.alert(&StakerAlert{Kind: StakeAtRiskAlert, Message: "failed", Node: 2})
+	expectAlert("failed")
+
+	// while one that was delivered is
+	alerts.alert(&StakerAlert{Kind: StakeAtRiskAlert, Message: "retried", Node: 1})
+	select {
+	case alert := <-received:
+		Fail(t, "duplicate alert delivered", alert.Message)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestStakerAlertsWaitingForValidation(t *testing.T) {
+	config := DefaultStakerAlertsConfig
+	config.StalledAssertionTimeout = time.Hour
+	alerts := newStakerAlerts(config)
+
+	alerts.waitingForValidation(3, common.Hash{}, "catching up")
+	if len(alerts.queue) != 0 {
+		Fail(t, "alerted before the assertion stalled")
+	}
+	alerts.waitingSince[3] = time.Now().Add(-2 * time.Hour)
+	alerts.waitingForValidation(3, common.Hash{}, "catching up")
+	if len(alerts.queue) != 1 {
+		Fail(t, "no alert for stalled assertion")
+	}
+	alerts.waitingForValidation(4, common.Hash{}, "catching up")
+	if _, ok := alerts.waitingSince[3]; ok {
+		Fail(t, "previous node still tracked after moving on")
+	}
+
+	var nilAlerts *stakerAlerts
+	nilAlerts.alert(&StakerAlert{Kind: StakeAtRiskAlert})
+	nilAlerts.waitingForValidation(1, common.Hash{}, "")
+}
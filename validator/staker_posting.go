@@ -0,0 +1,478 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package validator
+
+import (
+	"context"
+	"encoding/binary"
+	"math/big"
+	"sync"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// StakerActionKind is the kind of action a staker transaction takes on L1.
+type StakerActionKind string
+
+const (
+	// Creating a new assertion, staking on it
+	NewAssertionAction StakerActionKind = "new-assertion"
+	// Staking on an existing assertion
+	StakeAction StakerActionKind = "stake"
+	// Confirming or rejecting assertions, and timing out challenges
+	ConfirmAction StakerActionKind = "confirm"
+	// Creating a challenge, or moving in one we're part of
+	ChallengeMoveAction StakerActionKind = "challenge-move"
+	// Withdrawing our stake or funds
+	WithdrawAction StakerActionKind = "withdraw"
+)
+
+var stakerActionKinds = []StakerActionKind{
+	NewAssertionAction,
+	StakeAction,
+	ConfirmAction,
+	ChallengeMoveAction,
+	WithdrawAction,
+}
+
+var (
+	stakerDelayedCounter = metrics.NewRegisteredCounter("arb/validator/staker/delayed", nil)
+	stakerSpendCounters  = make(map[StakerActionKind]metrics.Counter)
+)
+
+func init() {
+	for _, kind := range stakerActionKinds {
+		stakerSpendCounters[kind] = metrics.NewRegisteredCounter("arb/validator/staker/spend/"+string(kind)+"/gwei", nil)
+	}
+}
+
+// ActionGasThresholds are the gas prices, in gwei, above which each kind of action
+// is delayed. A threshold of 0 uses the posting strategy's high gas threshold.
+type ActionGasThresholds struct {
+	NewAssertion  float64 `koanf:"new-assertion"`
+	Stake         float64 `koanf:"stake"`
+	Confirm       float64 `koanf:"confirm"`
+	ChallengeMove float64 `koanf:"challenge-move"`
+	Withdraw      float64 `koanf:"withdraw"`
+}
+
+var DefaultActionGasThresholds = ActionGasThresholds{
+	NewAssertion:  0,
+	Stake:         0,
+	Confirm:       0,
+	ChallengeMove: 0,
+	Withdraw:      0,
+}
+
+func ActionGasThresholdsAddOptions(prefix string, f *flag.FlagSet) {
+	f.Float64(prefix+".new-assertion", DefaultActionGasThresholds.NewAssertion, "high gas threshold for creating new assertions (0 to use high-gas-threshold)")
+	f.Float64(prefix+".stake", DefaultActionGasThresholds.Stake, "high gas threshold for staking on existing assertions (0 to use high-gas-threshold)")
+	f.Float64(prefix+".confirm", DefaultActionGasThresholds.Confirm, "high gas threshold for confirming and rejecting assertions (0 to use high-gas-threshold)")
+	f.Float64(prefix+".challenge-move", DefaultActionGasThresholds.ChallengeMove, "high gas threshold for challenge moves, which are never delayed past challenge-move-margin before their deadline (0 to use high-gas-threshold)")
+	f.Float64(prefix+".withdraw", DefaultActionGasThresholds.Withdraw, "high gas threshold for withdrawing stakes and funds (0 to use high-gas-threshold)")
+}
+
+func (p *L1PostingStrategy) highGasThreshold(kind StakerActionKind) float64 {
+	var threshold float64
+	switch kind {
+	case NewAssertionAction:
+		threshold = p.ActionThresholds.NewAssertion
+	case StakeAction:
+		threshold = p.ActionThresholds.Stake
+	case ConfirmAction:
+		threshold = p.ActionThresholds.Confirm
+	case ChallengeMoveAction:
+		threshold = p.ActionThresholds.ChallengeMove
+	case WithdrawAction:
+		threshold = p.ActionThresholds.Withdraw
+	}
+	if threshold == 0 {
+		return p.HighGasThreshold
+	}
+	return threshold
+}
+
+func (p *L1PostingStrategy) dailyBudgetWei() *big.Int {
+	if p.DailyBudget <= 0 {
+		return nil
+	}
+	budget, _ := new(big.Float).Mul(big.NewFloat(p.DailyBudget), big.NewFloat(1e18)).Int(nil)
+	return budget
+}
+
+type StakerActionSpend struct {
+	Transactions uint64   `json:"transactions"`
+	GasUsed      uint64   `json:"gasUsed"`
+	Spent        *big.Int `json:"spent"`
+}
+
+// StakerSpendReport is what the staker spent on L1 transactions, in wei, per kind
+// of action. Transactions taking several actions are split evenly between them.
+type StakerSpendReport struct {
+	DailyBudget  *big.Int                                `json:"dailyBudget,omitempty"`
+	SpentLastDay *big.Int                                `json:"spentLastDay"`
+	LastDay      map[StakerActionKind]*StakerActionSpend `json:"lastDay"`
+	Total        map[StakerActionKind]*StakerActionSpend `json:"total"`
+}
+
+type stakerSpend struct {
+	time    time.Time
+	actions []StakerActionKind
+	gasUsed uint64
+	cost    *big.Int
+}
+
+func (s *stakerSpend) addTo(report map[StakerActionKind]*StakerActionSpend) {
+	actions := uint64(len(s.actions))
+	for _, kind := range s.actions {
+		spend, ok := report[kind]
+		if !ok {
+			spend = &StakerActionSpend{Spent: new(big.Int)}
+			report[kind] = spend
+		}
+		spend.Transactions++
+		spend.GasUsed += s.gasUsed / actions
+		spend.Spent.Add(spend.Spent, new(big.Int).Div(s.cost, new(big.Int).SetUint64(actions)))
+	}
+}
+
+var (
+	stakerSpendPrefix   []byte = []byte("s")      // maps the time of a spend in the last day to a rlp encoded storedStakerSpend
+	stakerSpendTotalKey []byte = []byte("_total") // contains the rlp encoded storedStakerActionSpends of all time
+)
+
+type storedStakerSpend struct {
+	Time    uint64 // in unix nanoseconds
+	Actions []StakerActionKind
+	GasUsed uint64
+	Cost    *big.Int
+}
+
+type storedStakerActionSpend struct {
+	Kind         StakerActionKind
+	Transactions uint64
+	GasUsed      uint64
+	Spent        *big.Int
+}
+
+func stakerSpendKey(at time.Time) []byte {
+	key := make([]byte, len(stakerSpendPrefix)+8)
+	copy(key, stakerSpendPrefix)
+	binary.BigEndian.PutUint64(key[len(stakerSpendPrefix):], uint64(at.UnixNano()))
+	return key
+}
+
+// stakerSpending keeps what the staker spent in the last day, and in total,
+// in the database if it has one so the daily budget holds across restarts.
+type stakerSpending struct {
+	mutex  sync.Mutex
+	db     ethdb.KeyValueStore
+	recent []stakerSpend
+	total  map[StakerActionKind]*StakerActionSpend
+}
+
+func newStakerSpending() *stakerSpending {
+	return &stakerSpending{total: make(map[StakerActionKind]*StakerActionSpend)}
+}
+
+// load reads back what was spent from db, and stores future spends in it.
+func (s *stakerSpending) load(db ethdb.KeyValueStore) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	var recent []stakerSpend
+	iter := db.NewIterator(stakerSpendPrefix, nil)
+	defer iter.Release()
+	for iter.Next() {
+		var stored storedStakerSpend
+		if err := rlp.DecodeBytes(iter.Value(), &stored); err != nil {
+			return err
+		}
+		recent = append(recent, stakerSpend{
+			time:    time.Unix(0, int64(stored.Time)),
+			actions: stored.Actions,
+			gasUsed: stored.GasUsed,
+			cost:    stored.Cost,
+		})
+	}
+	if err := iter.Error(); err != nil {
+		return err
+	}
+	total := make(map[StakerActionKind]*StakerActionSpend)
+	hasTotal, err := db.Has(stakerSpendTotalKey)
+	if err != nil {
+		return err
+	}
+	if hasTotal {
+		data, err := db.Get(stakerSpendTotalKey)
+		if err != nil {
+			return err
+		}
+		var stored []storedStakerActionSpend
+		if err := rlp.DecodeBytes(data, &stored); err != nil {
+			return err
+		}
+		for _, spend := range stored {
+			total[spend.Kind] = &StakerActionSpend{
+				Transactions: spend.Transactions,
+				GasUsed:      spend.GasUsed,
+				Spent:        spend.Spent,
+			}
+		}
+	}
+	s.db = db
+	s.recent = recent
+	s.total = total
+	s.prune(time.Now())
+	return nil
+}
+
+func (s *stakerSpending) prune(now time.Time) {
+	for len(s.recent) > 0 && now.Sub(s.recent[0].time) >= 24*time.Hour {
+		if s.db != nil {
+			if err := s.db.Delete(stakerSpendKey(s.recent[0].time)); err != nil {
+				log.Warn("error deleting old staker spend", "err", err)
+			}
+		}
+		s.recent = s.recent[1:]
+	}
+}
+
+// store writes a new spend and the updated totals to the database.
+func (s *stakerSpending) store(spend *stakerSpend) error {
+	data, err := rlp.EncodeToBytes(storedStakerSpend{
+		Time:    uint64(spend.time.UnixNano()),
+		Actions: spend.actions,
+		GasUsed: spend.gasUsed,
+		Cost:    spend.cost,
+	})
+	if err != nil {
+		return err
+	}
+	var total []storedStakerActionSpend
+	for _, kind := range stakerActionKinds {
+		if actionSpend, ok := s.total[kind]; ok {
+			total = append(total, storedStakerActionSpend{
+				Kind:         kind,
+				Transactions: actionSpend.Transactions,
+				GasUsed:      actionSpend.GasUsed,
+				Spent:        actionSpend.Spent,
+			})
+		}
+	}
+	totalData, err := rlp.EncodeToBytes(total)
+	if err != nil {
+		return err
+	}
+	batch := s.db.NewBatch()
+	if err := batch.Put(stakerSpendKey(spend.time), data); err != nil {
+		return err
+	}
+	if err := batch.Put(stakerSpendTotalKey, totalData); err != nil {
+		return err
+	}
+	return batch.Write()
+}
+
+func (s *stakerSpending) record(tx *types.Transaction, receipt *types.Receipt, actions []StakerActionKind) {
+	if len(actions) == 0 {
+		return
+	}
+	gasPrice := receipt.EffectiveGasPrice
+	if gasPrice == nil {
+		gasPrice = tx.GasPrice()
+	}
+	spend := stakerSpend{
+		time:    time.Now(),
+		actions: actions,
+		gasUsed: receipt.GasUsed,
+		cost:    new(big.Int).Mul(gasPrice, new(big.Int).SetUint64(receipt.GasUsed)),
+	}
+	gwei := new(big.Int).Div(spend.cost, big.NewInt(1e9)).Int64() / int64(len(actions))
+	for _, kind := range actions {
+		stakerSpendCounters[kind].Inc(gwei)
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.prune(spend.time)
+	s.recent = append(s.recent, spend)
+	spend.addTo(s.total)
+	if s.db != nil {
+		if err := s.store(&spend); err != nil {
+			log.Error("error storing staker spend", "err", err)
+		}
+	}
+}
+
+func (s *stakerSpending) spentLastDay() *big.Int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.prune(time.Now())
+	spent := new(big.Int)
+	for _, spend := range s.recent {
+		spent.Add(spent, spend.cost)
+	}
+	return spent
+}
+
+func (s *stakerSpending) report() StakerSpendReport {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.prune(time.Now())
+	report := StakerSpendReport{
+		SpentLastDay: new(big.Int),
+		LastDay:      make(map[StakerActionKind]*StakerActionSpend),
+		Total:        make(map[StakerActionKind]*StakerActionSpend),
+	}
+	for _, spend := range s.recent {
+		report.SpentLastDay.Add(report.SpentLastDay, spend.cost)
+		spend.addTo(report.LastDay)
+	}
+	for kind, spend := range s.total {
+		report.Total[kind] = &StakerActionSpend{
+			Transactions: spend.Transactions,
+			GasUsed:      spend.GasUsed,
+			Spent:        new(big.Int).Set(spend.Spent),
+		}
+	}
+	return report
+}
+
+// LoadSpending has the staker keep what it spends in db, and reads back what
+// it spent before, so the daily budget holds across restarts. It must be
+// called before the staker starts.
+func (s *Staker) LoadSpending(db ethdb.KeyValueStore) error {
+	return s.spending.load(db)
+}
+
+// SpendReport returns what the staker spent on L1 transactions per kind of action.
+func (s *Staker) SpendReport() StakerSpendReport {
+	report := s.spending.report()
+	report.DailyBudget = s.config.L1PostingStrategy.dailyBudgetWei()
+	return report
+}
+
+// recordAction notes the transactions being built take an action of kind.
+func (s *Staker) recordAction(kind StakerActionKind) {
+	s.pendingActions[kind] = true
+}
+
+// updateHighGasBuffers fetches the L1 gas price and, for each kind of action,
+// updates the number of blocks it can still be delayed for while gas is high.
+func (s *Staker) updateHighGasBuffers(ctx context.Context) {
+	s.gasPriceHigh = make(map[StakerActionKind]bool)
+	var gasPriceFloat float64
+	gasPrice, err := s.client.SuggestGasPrice(ctx)
+	if err != nil {
+		log.Warn("error getting gas price", "err", err)
+	} else {
+		gasPriceFloat = float64(gasPrice.Int64()) / 1e9
+	}
+	latestBlockInfo, err := s.client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		log.Warn("error getting latest block", "err", err)
+		return
+	}
+	latestBlockNum := latestBlockInfo.Number
+	if s.lastActCalledBlock == nil {
+		s.lastActCalledBlock = latestBlockNum
+	}
+	blocksSinceActCalled := new(big.Int).Sub(latestBlockNum, s.lastActCalledBlock)
+	s.lastActCalledBlock = latestBlockNum
+	maxBuffer := big.NewInt(s.config.L1PostingStrategy.HighGasDelayBlocks)
+	for _, kind := range stakerActionKinds {
+		highGas := gasPrice != nil && gasPriceFloat >= s.config.L1PostingStrategy.highGasThreshold(kind)
+		buffer := s.highGasBlocksBuffers[kind]
+		if highGas {
+			// We're eating into the high gas buffer to delay our tx
+			buffer.Sub(buffer, blocksSinceActCalled)
+		} else {
+			// We'll try to make a tx if necessary, so we can add to the buffer for future high gas
+			buffer.Add(buffer, blocksSinceActCalled)
+		}
+		// Clamp the buffer to between 0 and HighGasDelayBlocks
+		if buffer.Sign() < 0 {
+			buffer.SetInt64(0)
+		} else if buffer.Cmp(maxBuffer) > 0 {
+			buffer.Set(maxBuffer)
+		}
+		if highGas && buffer.Sign() > 0 {
+			s.gasPriceHigh[kind] = true
+		}
+	}
+	s.gasPriceGwei = gasPriceFloat
+}
+
+// challengeDeadlineNear returns whether a challenge move can't be delayed any
+// longer. Creating a challenge, or any doubt, counts as the deadline being near.
+func (s *Staker) challengeDeadlineNear(ctx context.Context) bool {
+	if s.activeChallenge == nil {
+		return true
+	}
+	responder, timeLeft, err := s.activeChallenge.ResponderTimeLeft(ctx)
+	if err != nil {
+		log.Warn("error checking challenge time left", "err", err)
+		return true
+	}
+	return responder != s.activeChallenge.actingAs || timeLeft <= s.config.L1PostingStrategy.ChallengeMoveMargin
+}
+
+// shouldPost returns whether to post a transaction taking the given actions now,
+// rather than delaying it as gas is high or the daily budget has been spent.
+// The transaction is posted if any of its actions shouldn't be delayed, and
+// challenge moves are never delayed past the challenge move margin.
+func (s *Staker) shouldPost(ctx context.Context, actions map[StakerActionKind]bool) bool {
+	var overBudget bool
+	budget := s.config.L1PostingStrategy.dailyBudgetWei()
+	spent := s.spending.spentLastDay()
+	if budget != nil && spent.Cmp(budget) >= 0 {
+		overBudget = true
+	}
+	var delayed []StakerActionKind
+	for kind := range actions {
+		if kind == ChallengeMoveAction {
+			if !s.gasPriceHigh[kind] || s.challengeDeadlineNear(ctx) {
+				return true
+			}
+		} else if !overBudget && !s.gasPriceHigh[kind] {
+			return true
+		}
+		delayed = append(delayed, kind)
+	}
+	if len(delayed) == 0 {
+		return true
+	}
+	stakerDelayedCounter.Inc(1)
+	log.Warn(
+		"not acting yet as gas price is high or daily budget is spent",
+		"actions", delayed,
+		"gasPrice", s.gasPriceGwei,
+		"highGasPriceConfig", s.config.L1PostingStrategy.HighGasThreshold,
+		"spentLastDay", spent,
+		"dailyBudget", budget,
+	)
+	return false
+}
+
+// executeTransactions posts the transactions built so far, unless they're
+// being delayed.
+func (s *Staker) executeTransactions(ctx context.Context) (*types.Transaction, error) {
+	if !s.shouldPost(ctx, s.pendingActions) {
+		return nil, nil
+	}
+	s.postedActions = nil
+	for _, kind := range stakerActionKinds {
+		if s.pendingActions[kind] {
+			s.postedActions = append(s.postedActions, kind)
+		}
+	}
+	return s.wallet.ExecuteTransactions(ctx, s.builder, common.HexToAddress(s.config.GasRefunderAddress))
+}
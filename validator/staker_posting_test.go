@@ -0,0 +1,94 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package validator
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestStakerSpending(t *testing.T) {
+	spending := newStakerSpending()
+	tx := types.NewTx(&types.LegacyTx{GasPrice: big.NewInt(1e9)})
+	spending.record(tx, &types.Receipt{GasUsed: 100_000, EffectiveGasPrice: big.NewInt(2e9)}, []StakerActionKind{NewAssertionAction, ConfirmAction})
+	spending.record(tx, &types.Receipt{GasUsed: 50_000}, []StakerActionKind{ConfirmAction})
+
+	report := spending.report()
+	if report.SpentLastDay.Cmp(big.NewInt(250_000e9)) != 0 {
+		Fail(t, "unexpected spend in the last day", report.SpentLastDay)
+	}
+	confirm := report.Total[ConfirmAction]
+	if confirm == nil || confirm.Transactions != 2 || confirm.GasUsed != 100_000 || confirm.Spent.Cmp(big.NewInt(150_000e9)) != 0 {
+		Fail(t, "unexpected confirm spend", confirm)
+	}
+	assertion := report.LastDay[NewAssertionAction]
+	if assertion == nil || assertion.Transactions != 1 || assertion.Spent.Cmp(big.NewInt(100_000e9)) != 0 {
+		Fail(t, "unexpected new assertion spend", assertion)
+	}
+}
+
+func TestStakerSpendingStored(t *testing.T) {
+	db := rawdb.NewMemoryDatabase()
+	spending := newStakerSpending()
+	Require(t, spending.load(db))
+	tx := types.NewTx(&types.LegacyTx{GasPrice: big.NewInt(1e9)})
+	spending.record(tx, &types.Receipt{GasUsed: 100_000}, []StakerActionKind{StakeAction})
+	spending.record(tx, &types.Receipt{GasUsed: 50_000}, []StakerActionKind{ConfirmAction})
+
+	restarted := newStakerSpending()
+	Require(t, restarted.load(db))
+	if spent := restarted.spentLastDay(); spent.Cmp(big.NewInt(150_000e9)) != 0 {
+		Fail(t, "unexpected spend in the last day after restarting", spent)
+	}
+	confirm := restarted.report().Total[ConfirmAction]
+	if confirm == nil || confirm.Transactions != 1 || confirm.Spent.Cmp(big.NewInt(50_000e9)) != 0 {
+		Fail(t, "unexpected confirm spend after restarting", confirm)
+	}
+
+	restarted.prune(time.Now().Add(25 * time.Hour))
+	if len(restarted.recent) != 0 {
+		Fail(t, "spends older than a day weren't pruned")
+	}
+	iter := db.NewIterator(stakerSpendPrefix, nil)
+	defer iter.Release()
+	if iter.Next() {
+		Fail(t, "spends older than a day weren't deleted")
+	}
+}
+
+func TestStakerShouldPost(t *testing.T) {
+	ctx := context.Background()
+	config := DefaultL1ValidatorConfig
+	config.L1PostingStrategy.DailyBudget = 0.001
+	s := &Staker{
+		config:       config,
+		spending:     newStakerSpending(),
+		gasPriceHigh: map[StakerActionKind]bool{ConfirmAction: true},
+	}
+
+	if !s.shouldPost(ctx, map[StakerActionKind]bool{NewAssertionAction: true}) {
+		Fail(t, "new assertion delayed with low gas and budget left")
+	}
+	if s.shouldPost(ctx, map[StakerActionKind]bool{ConfirmAction: true}) {
+		Fail(t, "confirmation not delayed with high gas")
+	}
+	if !s.shouldPost(ctx, map[StakerActionKind]bool{ConfirmAction: true, StakeAction: true}) {
+		Fail(t, "stake delayed along with confirmation")
+	}
+
+	tx := types.NewTx(&types.LegacyTx{GasPrice: big.NewInt(1e10)})
+	s.spending.record(tx, &types.Receipt{GasUsed: 100_000}, []StakerActionKind{StakeAction})
+	if s.shouldPost(ctx, map[StakerActionKind]bool{NewAssertionAction: true}) {
+		Fail(t, "new assertion not delayed with daily budget spent")
+	}
+	s.gasPriceHigh[ChallengeMoveAction] = true
+	if !s.shouldPost(ctx, map[StakerActionKind]bool{ChallengeMoveAction: true}) {
+		Fail(t, "challenge creation delayed")
+	}
+}
@@ -34,6 +34,7 @@ type StatelessBlockValidator struct {
 	db              ethdb.Database
 	daService       arbstate.DataAvailabilityReader
 	genesisBlockNum uint64
+	remoteValidator *RemoteValidator
 
 	moduleMutex           sync.Mutex
 	currentWasmModuleRoot common.Hash
@@ -229,6 +230,12 @@ func NewStatelessBlockValidator(
 		genesisBlockNum: genesisBlockNum,
 		fatalErrChan:    fatalErrChan,
 	}
+	if config.RemoteValidation.Enable {
+		validator.remoteValidator, err = NewRemoteValidator(&config.RemoteValidation)
+		if err != nil {
+			return nil, err
+		}
+	}
 	if config.PendingUpgradeModuleRoot != "" {
 		if config.PendingUpgradeModuleRoot == "latest" {
 			latest, err := machineLoader.GetConfig().ReadLatestWasmModuleRoot()
@@ -244,12 +251,12 @@ func NewStatelessBlockValidator(
 		}
 
 		// the machine will be lazily created if need be later otherwise
-		if config.ArbitratorValidator {
+		if config.ArbitratorValidator && validator.remoteValidator == nil {
 			if err := machineLoader.CreateMachine(validator.pendingWasmModuleRoot, true, false); err != nil {
 				return nil, err
 			}
 		}
-		if config.JitValidator {
+		if config.JitValidator && validator.remoteValidator == nil {
 			if err := machineLoader.CreateMachine(validator.pendingWasmModuleRoot, true, true); err != nil {
 				return nil, err
 			}
@@ -476,25 +483,66 @@ func NewMachinePreimageResolver(
 func (v *StatelessBlockValidator) executeBlock(
 	ctx context.Context, entry *validationEntry, moduleRoot common.Hash,
 ) (GoGlobalState, []byte, error) {
-	start := entry.StartPosition
-	gsStart := entry.start()
+	delayedMsg, err := v.readDelayedMsg(entry)
+	if err != nil {
+		return GoGlobalState{}, nil, err
+	}
+	resolver, err := NewMachinePreimageResolver(ctx, entry.Preimages, entry.BatchInfo, v.blockchain, v.daService)
+	if err != nil {
+		return GoGlobalState{}, nil, err
+	}
+	gsEnd, err := executeArbitratorMachine(ctx, v.MachineLoader, entry, moduleRoot, resolver, delayedMsg)
+	return gsEnd, delayedMsg, err
+}
 
-	basemachine, err := v.MachineLoader.GetMachine(ctx, moduleRoot, true)
+func (v *StatelessBlockValidator) jitBlock(
+	ctx context.Context, entry *validationEntry, moduleRoot common.Hash,
+) (GoGlobalState, []byte, error) {
+	delayed, err := v.readDelayedMsg(entry)
 	if err != nil {
-		return GoGlobalState{}, nil, fmt.Errorf("unabled to get WASM machine: %w", err)
+		return GoGlobalState{}, nil, err
 	}
-	mach := basemachine.Clone()
 	resolver, err := NewMachinePreimageResolver(ctx, entry.Preimages, entry.BatchInfo, v.blockchain, v.daService)
 	if err != nil {
 		return GoGlobalState{}, nil, err
 	}
+	state, err := executeJitMachine(ctx, v.MachineLoader, entry, moduleRoot, resolver, delayed)
+	return state, delayed, err
+}
+
+func (v *StatelessBlockValidator) readDelayedMsg(entry *validationEntry) ([]byte, error) {
+	if !entry.HasDelayedMsg {
+		return nil, nil
+	}
+	delayedMsg, err := v.inboxTracker.GetDelayedMessageBytes(entry.DelayedMsgNr)
+	if err != nil {
+		log.Error(
+			"error while trying to read delayed msg for proving",
+			"err", err, "seq", entry.DelayedMsgNr, "blockNr", entry.BlockNumber,
+		)
+		return nil, errors.New("error while trying to read delayed msg for proving")
+	}
+	return delayedMsg, nil
+}
+
+func executeArbitratorMachine(
+	ctx context.Context, loader *NitroMachineLoader, entry *validationEntry, moduleRoot common.Hash, resolver GoPreimageResolver, delayedMsg []byte,
+) (GoGlobalState, error) {
+	start := entry.StartPosition
+	gsStart := entry.start()
+
+	basemachine, err := loader.GetMachine(ctx, moduleRoot, true)
+	if err != nil {
+		return GoGlobalState{}, fmt.Errorf("unabled to get WASM machine: %w", err)
+	}
+	mach := basemachine.Clone()
 	if err := mach.SetPreimageResolver(resolver); err != nil {
-		return GoGlobalState{}, nil, err
+		return GoGlobalState{}, err
 	}
 	err = mach.SetGlobalState(gsStart)
 	if err != nil {
 		log.Error("error while setting global state for proving", "err", err, "gsStart", gsStart)
-		return GoGlobalState{}, nil, errors.New("error while setting global state for proving")
+		return GoGlobalState{}, errors.New("error while setting global state for proving")
 	}
 	for _, batch := range entry.BatchInfo {
 		err = mach.AddSequencerInboxMessage(batch.Number, batch.Data)
@@ -503,26 +551,17 @@ func (v *StatelessBlockValidator) executeBlock(
 				"error while trying to add sequencer msg for proving",
 				"err", err, "seq", start.BatchNumber, "blockNr", entry.BlockNumber,
 			)
-			return GoGlobalState{}, nil, errors.New("error while trying to add sequencer msg for proving")
+			return GoGlobalState{}, errors.New("error while trying to add sequencer msg for proving")
 		}
 	}
-	var delayedMsg []byte
 	if entry.HasDelayedMsg {
-		delayedMsg, err = v.inboxTracker.GetDelayedMessageBytes(entry.DelayedMsgNr)
-		if err != nil {
-			log.Error(
-				"error while trying to read delayed msg for proving",
-				"err", err, "seq", entry.DelayedMsgNr, "blockNr", entry.BlockNumber,
-			)
-			return GoGlobalState{}, nil, errors.New("error while trying to read delayed msg for proving")
-		}
 		err = mach.AddDelayedInboxMessage(entry.DelayedMsgNr, delayedMsg)
 		if err != nil {
 			log.Error(
 				"error while trying to add delayed msg for proving",
 				"err", err, "seq", entry.DelayedMsgNr, "blockNr", entry.BlockNumber,
 			)
-			return GoGlobalState{}, nil, errors.New("error while trying to add delayed msg for proving")
+			return GoGlobalState{}, errors.New("error while trying to add delayed msg for proving")
 		}
 	}
 
@@ -534,101 +573,93 @@ func (v *StatelessBlockValidator) executeBlock(
 			log.Debug("validation", "moduleRoot", moduleRoot, "block", entry.BlockNumber, "steps", steps)
 		}
 		if err != nil {
-			return GoGlobalState{}, nil, fmt.Errorf("machine execution failed with error: %w", err)
+			return GoGlobalState{}, fmt.Errorf("machine execution failed with error: %w", err)
 		}
 		steps += count
 	}
 	if mach.IsErrored() {
 		log.Error("machine entered errored state during attempted validation", "block", entry.BlockNumber)
-		return GoGlobalState{}, nil, errors.New("machine entered errored state during attempted validation")
+		return GoGlobalState{}, errors.New("machine entered errored state during attempted validation")
 	}
-	return mach.GetGlobalState(), delayedMsg, nil
+	return mach.GetGlobalState(), nil
 }
 
-func (v *StatelessBlockValidator) jitBlock(
-	ctx context.Context, entry *validationEntry, moduleRoot common.Hash,
-) (GoGlobalState, []byte, error) {
-	empty := GoGlobalState{}
-
-	machine, err := v.MachineLoader.GetJitMachine(ctx, moduleRoot, true)
+func executeJitMachine(
+	ctx context.Context, loader *NitroMachineLoader, entry *validationEntry, moduleRoot common.Hash, resolver GoPreimageResolver, delayedMsg []byte,
+) (GoGlobalState, error) {
+	machine, err := loader.GetJitMachine(ctx, moduleRoot, true)
 	if err != nil {
-		return empty, nil, fmt.Errorf("unabled to get WASM machine: %w", err)
+		return GoGlobalState{}, fmt.Errorf("unabled to get WASM machine: %w", err)
 	}
-
-	var delayed []byte
-	if entry.HasDelayedMsg {
-		delayed, err = v.inboxTracker.GetDelayedMessageBytes(entry.DelayedMsgNr)
-		if err != nil {
-			log.Error(
-				"error while trying to read delayed msg for jitting",
-				"err", err, "seq", entry.DelayedMsgNr, "blockNr", entry.BlockNumber,
-			)
-			return empty, nil, errors.New("error while trying to read delayed msg for proving")
-		}
-	}
-
-	resolver, err := NewMachinePreimageResolver(ctx, entry.Preimages, entry.BatchInfo, v.blockchain, v.daService)
-	if err != nil {
-		return empty, nil, err
-	}
-	state, err := machine.prove(ctx, entry, resolver, delayed)
-	return state, delayed, err
+	return machine.prove(ctx, entry, resolver, delayedMsg)
 }
 
-func (v *StatelessBlockValidator) ValidateBlock(
-	ctx context.Context, header *types.Header, full bool, moduleRoot common.Hash,
-) (bool, error) {
+// validationEntryForBlock gathers everything needed to validate header, which
+// must have already been produced by this node.
+func (v *StatelessBlockValidator) validationEntryForBlock(
+	ctx context.Context, header *types.Header, producePreimages bool,
+) (*validationEntry, error) {
 	if header == nil {
-		return false, errors.New("header not found")
+		return nil, errors.New("header not found")
 	}
 	blockNum := header.Number.Uint64()
 	msgIndex := arbutil.BlockNumberToMessageCount(blockNum, v.genesisBlockNum) - 1
 	prevHeader := v.blockchain.GetHeaderByNumber(blockNum - 1)
 	if prevHeader == nil {
-		return false, errors.New("prev header not found")
+		return nil, errors.New("prev header not found")
 	}
 	msg, err := v.streamer.GetMessage(msgIndex)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 	preimages, readBatchInfo, hasDelayedMessage, delayedMsgToRead, err := BlockDataForValidation(
-		ctx, v.blockchain, v.inboxReader, header, prevHeader, *msg, false,
+		ctx, v.blockchain, v.inboxReader, header, prevHeader, *msg, producePreimages,
 	)
 	if err != nil {
-		return false, fmt.Errorf("failed to get block data to validate: %w", err)
+		return nil, fmt.Errorf("failed to get block data to validate: %w", err)
 	}
 
 	batchCount, err := v.inboxTracker.GetBatchCount()
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 	batch, err := FindBatchContainingMessageIndex(v.inboxTracker, msgIndex, batchCount)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 
 	startPos, endPos, err := GlobalStatePositionsFor(v.inboxTracker, msgIndex, batch)
 	if err != nil {
-		return false, fmt.Errorf("failed calculating position for validation: %w", err)
+		return nil, fmt.Errorf("failed calculating position for validation: %w", err)
 	}
 
 	entry, err := newValidationEntry(
 		prevHeader, header, hasDelayedMessage, delayedMsgToRead, preimages, readBatchInfo,
 	)
 	if err != nil {
-		return false, fmt.Errorf("failed to create validation entry %w", err)
+		return nil, fmt.Errorf("failed to create validation entry %w", err)
 	}
 	entry.StartPosition = startPos
 	entry.EndPosition = endPos
 
 	seqMsg, err := v.inboxReader.GetSequencerMessageBytes(ctx, startPos.BatchNumber)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 	entry.BatchInfo = append(entry.BatchInfo, BatchInfo{
 		Number: startPos.BatchNumber,
 		Data:   seqMsg,
 	})
+	return entry, nil
+}
+
+func (v *StatelessBlockValidator) ValidateBlock(
+	ctx context.Context, header *types.Header, full bool, moduleRoot common.Hash,
+) (bool, error) {
+	entry, err := v.validationEntryForBlock(ctx, header, false)
+	if err != nil {
+		return false, err
+	}
 
 	var gsEnd GoGlobalState
 	if full {
@@ -0,0 +1,151 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package validator
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// ValidationBundleVersion is bumped whenever the bundle format changes in a way
+// older tools can't read.
+const ValidationBundleVersion = 1
+
+// ValidationBundle is a record of a block validation: all the inputs needed to
+// re-execute the block on a machine, along with the end state the node expects.
+// Bundles don't depend on the node's database, so they can be shared and
+// re-validated elsewhere. The replay machine itself isn't included, only its
+// module root, so the machine for that root must be installed wherever the
+// bundle is executed.
+type ValidationBundle struct {
+	Version     uint64        `json:"version"`
+	ExpectedEnd GoGlobalState `json:"expectedEnd"`
+	ValidationInput
+}
+
+func (b *ValidationBundle) CheckFormat() error {
+	if b.Version == 0 || b.Version > ValidationBundleVersion {
+		return fmt.Errorf("unsupported validation bundle version %v, expected at most %v", b.Version, ValidationBundleVersion)
+	}
+	if b.Preimages == nil {
+		return fmt.Errorf("validation bundle for block %v has no preimages", b.BlockNumber)
+	}
+	if len(b.BatchInfo) == 0 {
+		return fmt.Errorf("validation bundle for block %v has no sequencer inbox messages", b.BlockNumber)
+	}
+	return nil
+}
+
+// Execute re-runs the block in the bundle on the module root it was recorded
+// for, using either the jit or the arbitrator machine, and returns the end state.
+// The loader must have the machine for the bundle's module root.
+func (b *ValidationBundle) Execute(ctx context.Context, loader *NitroMachineLoader, jit bool) (GoGlobalState, error) {
+	if err := b.CheckFormat(); err != nil {
+		return GoGlobalState{}, err
+	}
+	entry := b.entry()
+	var end GoGlobalState
+	var err error
+	if jit {
+		end, err = executeJitMachine(ctx, loader, entry, b.ModuleRoot, b.resolver(), b.DelayedMsg)
+	} else {
+		end, err = executeArbitratorMachine(ctx, loader, entry, b.ModuleRoot, b.resolver(), b.DelayedMsg)
+	}
+	if err != nil {
+		return GoGlobalState{}, fmt.Errorf("executing validation bundle for block %v on module root %v: %w", b.BlockNumber, b.ModuleRoot, err)
+	}
+	return end, nil
+}
+
+func ValidationBundleFileName(blockNumber uint64, moduleRoot common.Hash) string {
+	return fmt.Sprintf("block_%d_%s.bundle.json.gz", blockNumber, moduleRoot.Hex()[2:10])
+}
+
+// WriteValidationBundle writes bundle as gzipped json to path. The bundle is
+// written to a temporary file first, so path never holds a partial bundle.
+//
+//nolint:gosec
+func WriteValidationBundle(path string, bundle *ValidationBundle) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmpPath := path + ".tmp"
+	file, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	err = writeValidationBundle(file, bundle)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, path)
+	}
+	if err != nil {
+		_ = os.Remove(tmpPath)
+	}
+	return err
+}
+
+func writeValidationBundle(file *os.File, bundle *ValidationBundle) error {
+	writer := gzip.NewWriter(file)
+	if err := json.NewEncoder(writer).Encode(bundle); err != nil {
+		return err
+	}
+	return writer.Close()
+}
+
+func ReadValidationBundle(path string) (*ValidationBundle, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read validation bundle %v: %w", path, err)
+	}
+	defer reader.Close()
+	var bundle ValidationBundle
+	if err := json.NewDecoder(reader).Decode(&bundle); err != nil {
+		return nil, fmt.Errorf("failed to decode validation bundle %v: %w", path, err)
+	}
+	if err := bundle.CheckFormat(); err != nil {
+		return nil, err
+	}
+	return &bundle, nil
+}
+
+func (v *StatelessBlockValidator) validationBundleFor(
+	ctx context.Context, entry *validationEntry, moduleRoot common.Hash,
+) (*ValidationBundle, error) {
+	input, _, err := v.validationInput(ctx, entry, moduleRoot, false)
+	if err != nil {
+		return nil, err
+	}
+	return &ValidationBundle{
+		Version:         ValidationBundleVersion,
+		ExpectedEnd:     entry.expectedEnd(),
+		ValidationInput: *input,
+	}, nil
+}
+
+// CreateValidationBundle records everything needed to validate the block with
+// the given header on moduleRoot into a ValidationBundle.
+func (v *StatelessBlockValidator) CreateValidationBundle(
+	ctx context.Context, header *types.Header, moduleRoot common.Hash,
+) (*ValidationBundle, error) {
+	entry, err := v.validationEntryForBlock(ctx, header, true)
+	if err != nil {
+		return nil, err
+	}
+	return v.validationBundleFor(ctx, entry, moduleRoot)
+}
@@ -0,0 +1,77 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package validator
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestValidationBundleRoundTrip(t *testing.T) {
+	preimage := []byte("some preimage")
+	bundle := &ValidationBundle{
+		Version:     ValidationBundleVersion,
+		ExpectedEnd: GoGlobalState{BlockHash: common.HexToHash("0x1234"), Batch: 3, PosInBatch: 1},
+		ValidationInput: ValidationInput{
+			BlockNumber:   42,
+			ModuleRoot:    common.HexToHash("0xabcdef"),
+			StartState:    GoGlobalState{BlockHash: common.HexToHash("0x1233"), Batch: 3},
+			Preimages:     map[common.Hash][]byte{crypto.Keccak256Hash(preimage): preimage},
+			BatchInfo:     []BatchInfo{{Number: 3, Data: []byte{1, 2, 3}}},
+			HasDelayedMsg: true,
+			DelayedMsgNr:  7,
+			DelayedMsg:    []byte{4, 5, 6},
+		},
+	}
+
+	path := filepath.Join(t.TempDir(), ValidationBundleFileName(bundle.BlockNumber, bundle.ModuleRoot))
+	Require(t, WriteValidationBundle(path, bundle))
+	read, err := ReadValidationBundle(path)
+	Require(t, err)
+
+	if read.BlockNumber != 42 || read.ModuleRoot != bundle.ModuleRoot || read.ExpectedEnd != bundle.ExpectedEnd || read.StartState != bundle.StartState {
+		Fail(t, "unexpected bundle header", read)
+	}
+	if !read.HasDelayedMsg || read.DelayedMsgNr != 7 || !bytes.Equal(read.DelayedMsg, bundle.DelayedMsg) {
+		Fail(t, "unexpected delayed message", read.DelayedMsgNr, read.DelayedMsg)
+	}
+	if len(read.BatchInfo) != 1 || read.BatchInfo[0].Number != 3 || !bytes.Equal(read.BatchInfo[0].Data, []byte{1, 2, 3}) {
+		Fail(t, "unexpected batch info", read.BatchInfo)
+	}
+	resolved, err := read.resolver()(crypto.Keccak256Hash(preimage))
+	Require(t, err)
+	if !bytes.Equal(resolved, preimage) {
+		Fail(t, "unexpected preimage", resolved)
+	}
+
+	bundle.Version = ValidationBundleVersion + 1
+	Require(t, WriteValidationBundle(path, bundle))
+	if _, err := ReadValidationBundle(path); err == nil {
+		Fail(t, "read bundle with unsupported version")
+	}
+}
+
+func TestValidationBundleWriteFailureRemovesTmp(t *testing.T) {
+	bundle := &ValidationBundle{
+		Version: ValidationBundleVersion,
+		ValidationInput: ValidationInput{
+			Preimages: map[common.Hash][]byte{},
+			BatchInfo: []BatchInfo{{Number: 0}},
+		},
+	}
+	// a non-empty directory in the way makes the final rename fail
+	path := filepath.Join(t.TempDir(), "bundle")
+	Require(t, os.MkdirAll(filepath.Join(path, "occupied"), 0755))
+	if err := WriteValidationBundle(path, bundle); err == nil {
+		Fail(t, "wrote bundle over a directory")
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		Fail(t, "temporary bundle file left behind", err)
+	}
+}
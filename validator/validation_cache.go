@@ -0,0 +1,151 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package validator
+
+import (
+	"bytes"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+var (
+	validationCacheHitCounter  = metrics.NewRegisteredCounter("arb/validator/cache/hits", nil)
+	validationCacheMissCounter = metrics.NewRegisteredCounter("arb/validator/cache/misses", nil)
+)
+
+// ValidationCacheConfig configures persisting validation results, so validating
+// the same state transition again after a reorg, restart or reset is instant.
+type ValidationCacheConfig struct {
+	Enable       bool   `koanf:"enable"`
+	RetainBlocks uint64 `koanf:"retain-blocks" reload:"hot"`
+}
+
+func ValidationCacheConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultValidationCacheConfig.Enable, "persist validation results and reuse them when validating identical state transitions")
+	f.Uint64(prefix+".retain-blocks", DefaultValidationCacheConfig.RetainBlocks, "number of blocks behind the last validated block to keep validation results for (0 to keep all)")
+}
+
+var DefaultValidationCacheConfig = ValidationCacheConfig{
+	Enable:       false,
+	RetainBlocks: 0,
+}
+
+// validationCachePruneInterval is how often, in blocks, old results are pruned.
+const validationCachePruneInterval = 1000
+
+func validationResultKey(blockNumber uint64) []byte {
+	key := append([]byte{}, validationResultPrefix...)
+	return append(key, u64ToBe(blockNumber)...)
+}
+
+// validationCacheKey identifies a state transition: the start global state, the
+// module root and every input to the machine. The preimages aren't included as
+// they're committed to by the start block hash.
+func validationCacheKey(entry *validationEntry, moduleRoot common.Hash, delayedMsg []byte) []byte {
+	var inputs bytes.Buffer
+	inputs.Write(entry.start().Hash().Bytes())
+	inputs.Write(moduleRoot.Bytes())
+	for _, batch := range entry.BatchInfo {
+		inputs.Write(u64ToBe(batch.Number))
+		inputs.Write(crypto.Keccak256(batch.Data))
+	}
+	if entry.HasDelayedMsg {
+		inputs.Write(u64ToBe(entry.DelayedMsgNr))
+		inputs.Write(crypto.Keccak256(delayedMsg))
+	}
+	return append(validationResultKey(entry.BlockNumber), crypto.Keccak256(inputs.Bytes())...)
+}
+
+// cachedValidationResult returns the cache key for validating entry on moduleRoot,
+// and whether a result reaching the expected end state is already cached.
+// The returned key is nil if the cache is disabled.
+func (v *BlockValidator) cachedValidationResult(entry *validationEntry, moduleRoot common.Hash) ([]byte, bool) {
+	if !v.config().ValidationCache.Enable {
+		return nil, false
+	}
+	delayedMsg, err := v.readDelayedMsg(entry)
+	if err != nil {
+		return nil, false
+	}
+	key := validationCacheKey(entry, moduleRoot, delayedMsg)
+	encoded, err := v.db.Get(key)
+	if err != nil {
+		validationCacheMissCounter.Inc(1)
+		return key, false
+	}
+	var gsEnd GoGlobalState
+	if err := rlp.DecodeBytes(encoded, &gsEnd); err != nil {
+		log.Warn("failed to decode cached validation result", "blockNr", entry.BlockNumber, "err", err)
+		validationCacheMissCounter.Inc(1)
+		return key, false
+	}
+	if gsEnd != entry.expectedEnd() {
+		// Never trust the cache to fail a block, validate it again instead
+		validationCacheMissCounter.Inc(1)
+		return key, false
+	}
+	validationCacheHitCounter.Inc(1)
+	return key, true
+}
+
+func (v *BlockValidator) storeValidationResult(key []byte, gsEnd GoGlobalState) {
+	if key == nil {
+		return
+	}
+	encoded, err := rlp.EncodeToBytes(gsEnd)
+	if err != nil {
+		log.Error("failed to encode validation result", "err", err)
+		return
+	}
+	if err := v.db.Put(key, encoded); err != nil {
+		log.Error("failed to store validation result", "err", err)
+	}
+}
+
+// pruneValidationResults deletes cached results for blocks more than the
+// configured number of blocks behind lastBlockValidated.
+func (v *BlockValidator) pruneValidationResults(lastBlockValidated uint64) {
+	config := v.config().ValidationCache
+	if !config.Enable || config.RetainBlocks == 0 || lastBlockValidated%validationCachePruneInterval != 0 {
+		return
+	}
+	if lastBlockValidated <= config.RetainBlocks {
+		return
+	}
+	pruneBefore := lastBlockValidated - config.RetainBlocks
+	if pruneBefore <= v.validationResultsPrunedBefore {
+		return
+	}
+	iter := v.db.NewIterator(validationResultPrefix, u64ToBe(v.validationResultsPrunedBefore))
+	defer iter.Release()
+	end := validationResultKey(pruneBefore)
+	batch := v.db.NewBatch()
+	deleted := 0
+	for iter.Next() {
+		if bytes.Compare(iter.Key(), end) >= 0 {
+			break
+		}
+		if err := batch.Delete(iter.Key()); err != nil {
+			log.Error("failed to prune validation results", "err", err)
+			return
+		}
+		deleted++
+	}
+	if err := iter.Error(); err != nil {
+		log.Error("failed to prune validation results", "err", err)
+		return
+	}
+	if err := batch.Write(); err != nil {
+		log.Error("failed to prune validation results", "err", err)
+		return
+	}
+	v.validationResultsPrunedBefore = pruneBefore
+	log.Debug("pruned validation results", "before", pruneBefore, "deleted", deleted)
+}
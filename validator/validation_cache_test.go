@@ -0,0 +1,66 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package validator
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+)
+
+func TestValidationCache(t *testing.T) {
+	config := TestBlockValidatorConfig
+	config.ValidationCache.Enable = true
+	config.ValidationCache.RetainBlocks = 500
+	v := &BlockValidator{
+		StatelessBlockValidator: &StatelessBlockValidator{db: rawdb.NewMemoryDatabase()},
+		config:                  func() *BlockValidatorConfig { return &config },
+	}
+	moduleRoot := common.HexToHash("0x1234")
+	newEntry := func(blockNumber uint64) *validationEntry {
+		return &validationEntry{
+			BlockNumber:   blockNumber,
+			PrevBlockHash: common.BigToHash(common.Big1),
+			BlockHash:     common.BigToHash(common.Big2),
+			StartPosition: GlobalStatePosition{BatchNumber: 1},
+			EndPosition:   GlobalStatePosition{BatchNumber: 1, PosInBatch: 1},
+			BatchInfo:     []BatchInfo{{Number: 1, Data: []byte{1, 2, 3}}},
+		}
+	}
+
+	entry := newEntry(10)
+	key, cached := v.cachedValidationResult(entry, moduleRoot)
+	if cached || key == nil {
+		Fail(t, "unexpected cached result before storing")
+	}
+	v.storeValidationResult(key, entry.expectedEnd())
+	if _, cached := v.cachedValidationResult(entry, moduleRoot); !cached {
+		Fail(t, "validation result not cached")
+	}
+	if _, cached := v.cachedValidationResult(entry, common.HexToHash("0x5678")); cached {
+		Fail(t, "validation result cached for another module root")
+	}
+	changedInputs := newEntry(10)
+	changedInputs.BatchInfo[0].Data = []byte{4, 5, 6}
+	if _, cached := v.cachedValidationResult(changedInputs, moduleRoot); cached {
+		Fail(t, "validation result cached for different inputs")
+	}
+	changedEnd := newEntry(10)
+	changedEnd.BlockHash = common.BigToHash(common.Big3)
+	if _, cached := v.cachedValidationResult(changedEnd, moduleRoot); cached {
+		Fail(t, "cached validation result accepted for a different expected end state")
+	}
+
+	recent := newEntry(600)
+	recentKey, _ := v.cachedValidationResult(recent, moduleRoot)
+	v.storeValidationResult(recentKey, recent.expectedEnd())
+	v.pruneValidationResults(1000)
+	if _, cached := v.cachedValidationResult(entry, moduleRoot); cached {
+		Fail(t, "old validation result not pruned")
+	}
+	if _, cached := v.cachedValidationResult(recent, moduleRoot); !cached {
+		Fail(t, "recent validation result pruned")
+	}
+}
@@ -0,0 +1,219 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package validator
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ValidationRecord describes a finished block validation.
+type ValidationRecord struct {
+	BlockNumber uint64        `json:"blockNumber"`
+	BlockHash   common.Hash   `json:"blockHash"`
+	ModuleRoots []common.Hash `json:"moduleRoots"`
+	Valid       bool          `json:"valid"`
+	Failure     string        `json:"failure,omitempty"`
+	Finished    time.Time     `json:"finished"`
+	Duration    string        `json:"duration"`
+
+	duration time.Duration
+}
+
+// ModuleRootValidations counts the validations run on a module root.
+type ModuleRootValidations struct {
+	Valid  uint64 `json:"valid"`
+	Failed uint64 `json:"failed"`
+}
+
+// BlockValidationStatus describes a block the validator is running or has
+// failed to validate.
+type BlockValidationStatus struct {
+	BlockNumber uint64        `json:"blockNumber"`
+	Status      string        `json:"status"`
+	ModuleRoots []common.Hash `json:"moduleRoots"`
+	Running     string        `json:"running,omitempty"`
+	Failure     string        `json:"failure,omitempty"`
+}
+
+// ValidationProgress is a snapshot of the state of the validation pipeline.
+type ValidationProgress struct {
+	LastBlockValidated     uint64                                 `json:"lastBlockValidated"`
+	LastBlockValidatedHash common.Hash                            `json:"lastBlockValidatedHash"`
+	LatestBlock            uint64                                 `json:"latestBlock"`
+	Lag                    uint64                                 `json:"lag"`
+	LastBlockPrepared      uint64                                 `json:"lastBlockPrepared"`
+	ValidationsRunning     int32                                  `json:"validationsRunning"`
+	StatusCounts           map[string]uint64                      `json:"statusCounts"`
+	Blocks                 []BlockValidationStatus                `json:"blocks"`
+	ModuleRoots            map[common.Hash]*ModuleRootValidations `json:"moduleRoots"`
+	AverageDuration        string                                 `json:"averageDuration"`
+	Recent                 []ValidationRecord                     `json:"recent"`
+}
+
+// validationHistory keeps the most recent finished validations and counts of
+// validations per module root.
+type validationHistory struct {
+	mutex       sync.Mutex
+	size        int
+	records     []ValidationRecord // ring buffer, next is the oldest once full
+	next        int
+	moduleRoots map[common.Hash]*ModuleRootValidations
+}
+
+func newValidationHistory(size int) *validationHistory {
+	return &validationHistory{
+		size:        size,
+		moduleRoots: make(map[common.Hash]*ModuleRootValidations),
+	}
+}
+
+func (h *validationHistory) recordModuleRoot(moduleRoot common.Hash, valid bool) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	counts, ok := h.moduleRoots[moduleRoot]
+	if !ok {
+		counts = &ModuleRootValidations{}
+		h.moduleRoots[moduleRoot] = counts
+	}
+	if valid {
+		counts.Valid++
+	} else {
+		counts.Failed++
+	}
+}
+
+func (h *validationHistory) recordBlock(entry *validationEntry, moduleRoots []common.Hash, valid bool, failure string, duration time.Duration) {
+	if h.size <= 0 {
+		return
+	}
+	record := ValidationRecord{
+		BlockNumber: entry.BlockNumber,
+		BlockHash:   entry.BlockHash,
+		ModuleRoots: moduleRoots,
+		Valid:       valid,
+		Failure:     failure,
+		Finished:    time.Now(),
+		Duration:    duration.String(),
+		duration:    duration,
+	}
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if len(h.records) < h.size {
+		h.records = append(h.records, record)
+		return
+	}
+	h.records[h.next] = record
+	h.next = (h.next + 1) % h.size
+}
+
+// snapshot returns the recorded validations newest first, the average duration
+// of the successful ones, and a copy of the module root counts.
+func (h *validationHistory) snapshot() ([]ValidationRecord, time.Duration, map[common.Hash]*ModuleRootValidations) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	records := make([]ValidationRecord, 0, len(h.records))
+	var total time.Duration
+	var counted int64
+	for i := range h.records {
+		// Walk backwards from the newest record
+		record := h.records[(h.next-1-i+2*len(h.records))%len(h.records)]
+		records = append(records, record)
+		if record.Valid {
+			total += record.duration
+			counted++
+		}
+	}
+	var average time.Duration
+	if counted > 0 {
+		average = total / time.Duration(counted)
+	}
+	moduleRoots := make(map[common.Hash]*ModuleRootValidations, len(h.moduleRoots))
+	for root, counts := range h.moduleRoots {
+		countsCopy := *counts
+		moduleRoots[root] = &countsCopy
+	}
+	return records, average, moduleRoots
+}
+
+func validationStatusName(status uint32) string {
+	switch status {
+	case validationStatusUnprepared:
+		return "unprepared"
+	case validationStatusPrepared:
+		return "prepared"
+	case validationStatusFailed:
+		return "failed"
+	case validationStatusValid:
+		return "valid"
+	default:
+		return "unknown"
+	}
+}
+
+// Progress returns a snapshot of the validation pipeline, including the blocks
+// currently being validated and the reasons for any failures.
+func (v *BlockValidator) Progress() *ValidationProgress {
+	lastValidated, lastValidatedHash, _ := v.LastBlockValidatedAndHash()
+	progress := &ValidationProgress{
+		LastBlockValidated:     lastValidated,
+		LastBlockValidatedHash: lastValidatedHash,
+		ValidationsRunning:     atomic.LoadInt32(&v.atomicValidationsRunning),
+		StatusCounts:           make(map[string]uint64),
+		Blocks:                 []BlockValidationStatus{},
+	}
+	if head := v.blockchain.CurrentBlock(); head != nil {
+		progress.LatestBlock = head.NumberU64()
+		if progress.LatestBlock > lastValidated {
+			progress.Lag = progress.LatestBlock - lastValidated
+		}
+	}
+
+	now := time.Now()
+	v.validationEntries.Range(func(key, value interface{}) bool {
+		status, ok := value.(*validationStatus)
+		if !ok || status == nil {
+			return true
+		}
+		blockNumber, ok := key.(uint64)
+		if !ok {
+			return true
+		}
+		state := atomic.LoadUint32(&status.Status)
+		blockStatus := BlockValidationStatus{
+			BlockNumber: blockNumber,
+			Status:      validationStatusName(state),
+			ModuleRoots: status.ModuleRoots,
+		}
+		if state >= validationStatusPrepared && blockNumber > progress.LastBlockPrepared {
+			progress.LastBlockPrepared = blockNumber
+		}
+		if started := atomic.LoadInt64(&status.Started); started != 0 && state == validationStatusPrepared {
+			blockStatus.Status = "running"
+			blockStatus.Running = now.Sub(time.Unix(0, started)).String()
+		}
+		if state == validationStatusFailed {
+			blockStatus.Failure = status.Failure
+		}
+		progress.StatusCounts[blockStatus.Status]++
+		if blockStatus.Status == "running" || state == validationStatusFailed {
+			// Only list these, as there may be many blocks waiting to be validated
+			progress.Blocks = append(progress.Blocks, blockStatus)
+		}
+		return true
+	})
+	sort.Slice(progress.Blocks, func(i, j int) bool {
+		return progress.Blocks[i].BlockNumber < progress.Blocks[j].BlockNumber
+	})
+
+	recent, average, moduleRoots := v.history.snapshot()
+	progress.Recent = recent
+	progress.AverageDuration = average.String()
+	progress.ModuleRoots = moduleRoots
+	return progress
+}
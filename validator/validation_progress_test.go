@@ -0,0 +1,52 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package validator
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestValidationHistory(t *testing.T) {
+	history := newValidationHistory(3)
+	rootA := common.HexToHash("0xa")
+	rootB := common.HexToHash("0xb")
+	for block := uint64(1); block <= 5; block++ {
+		valid := block != 4
+		history.recordModuleRoot(rootA, valid)
+		history.recordModuleRoot(rootB, true)
+		failure := ""
+		if !valid {
+			failure = "mismatch"
+		}
+		history.recordBlock(&validationEntry{BlockNumber: block}, []common.Hash{rootA, rootB}, valid, failure, time.Duration(block)*time.Second)
+	}
+
+	records, average, moduleRoots := history.snapshot()
+	if len(records) != 3 {
+		Fail(t, "expected 3 records, got", len(records))
+	}
+	for i, expected := range []uint64{5, 4, 3} {
+		if records[i].BlockNumber != expected {
+			Fail(t, "record", i, "is for block", records[i].BlockNumber, "expected", expected)
+		}
+	}
+	if records[1].Valid || records[1].Failure != "mismatch" {
+		Fail(t, "expected block 4 to have failed", records[1])
+	}
+	if average != 4*time.Second {
+		Fail(t, "unexpected average duration", average)
+	}
+	if moduleRoots[rootA].Valid != 4 || moduleRoots[rootA].Failed != 1 || moduleRoots[rootB].Valid != 5 {
+		Fail(t, "unexpected module root counts", moduleRoots[rootA], moduleRoots[rootB])
+	}
+
+	// Snapshots must not change as more validations are recorded
+	history.recordModuleRoot(rootA, true)
+	if moduleRoots[rootA].Valid != 4 {
+		Fail(t, "snapshot modified by later validation")
+	}
+}
@@ -0,0 +1,270 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package validator
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	flag "github.com/spf13/pflag"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var (
+	samplingSkippedCounter           = metrics.NewRegisteredCounter("arb/validator/sampling/skipped", nil)
+	samplingSpotCheckCounter         = metrics.NewRegisteredCounter("arb/validator/sampling/spotcheck", nil)
+	samplingSpotCheckFailuresCounter = metrics.NewRegisteredCounter("arb/validator/sampling/spotcheck/failures", nil)
+)
+
+// SamplingConfig configures validating only a sample of blocks, for watchtowers
+// that don't need every block validated. Blocks ending an assertion seen by the
+// staker, and a random sample of the blocks within it, are always validated.
+type SamplingConfig struct {
+	Enable           bool    `koanf:"enable"`
+	Interval         uint64  `koanf:"interval" reload:"hot"`
+	Probability      float64 `koanf:"probability" reload:"hot"`
+	MessageKinds     []int   `koanf:"message-kinds" reload:"hot"`
+	AssertionSamples uint64  `koanf:"assertion-samples" reload:"hot"`
+}
+
+func SamplingConfigAddOptions(prefix string, f *flag.FlagSet) {
+	f.Bool(prefix+".enable", DefaultSamplingConfig.Enable, "only validate a sample of blocks, along with a sample of each assertion's blocks (blocks not sampled are treated as valid; watchtower strategy only)")
+	f.Uint64(prefix+".interval", DefaultSamplingConfig.Interval, "validate every block whose number is a multiple of this (0 to disable)")
+	f.Float64(prefix+".probability", DefaultSamplingConfig.Probability, "probability of validating any other block")
+	f.IntSlice(prefix+".message-kinds", DefaultSamplingConfig.MessageKinds, "validate every block created from an L1 message of one of these kinds")
+	f.Uint64(prefix+".assertion-samples", DefaultSamplingConfig.AssertionSamples, "number of randomly chosen blocks within each assertion to validate, besides the block it ends at")
+}
+
+var DefaultSamplingConfig = SamplingConfig{
+	Enable:           false,
+	Interval:         100,
+	Probability:      0,
+	MessageKinds:     []int{},
+	AssertionSamples: 4,
+}
+
+// maxRequiredBlocks bounds the number of assertion blocks remembered, to avoid
+// spot-checking the same assertion repeatedly.
+const maxRequiredBlocks = 256
+
+// maxAssertionSamples bounds the blocks sampled per assertion, so that an
+// assertion's sample is never evicted from the required blocks.
+const maxAssertionSamples = maxRequiredBlocks/4 - 1
+
+type requiredBlockStatus uint8
+
+const (
+	requiredBlockPending requiredBlockStatus = iota
+	requiredBlockChecking
+	requiredBlockValid
+	requiredBlockInvalid
+)
+
+type requiredBlocks struct {
+	mutex  sync.Mutex
+	blocks map[uint64]requiredBlockStatus
+	seed   int64
+}
+
+func (r *requiredBlocks) initialize() {
+	if r.blocks == nil {
+		r.blocks = make(map[uint64]requiredBlockStatus)
+		r.seed = time.Now().UnixNano()
+	}
+}
+
+// add remembers blockNum as required, keeping its status if it already is,
+// and returns whether blockNum was newly added.
+func (r *requiredBlocks) add(blockNum uint64) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.initialize()
+	if _, ok := r.blocks[blockNum]; ok {
+		return false
+	}
+	if len(r.blocks) >= maxRequiredBlocks {
+		oldest := blockNum
+		for block := range r.blocks {
+			if block < oldest {
+				oldest = block
+			}
+		}
+		delete(r.blocks, oldest)
+	}
+	r.blocks[blockNum] = requiredBlockPending
+	return true
+}
+
+func (r *requiredBlocks) contains(blockNum uint64) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	_, ok := r.blocks[blockNum]
+	return ok
+}
+
+func (r *requiredBlocks) status(blockNum uint64) requiredBlockStatus {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.blocks[blockNum]
+}
+
+// setStatus records the outcome of validating a required block, doing nothing for other blocks.
+func (r *requiredBlocks) setStatus(blockNum uint64, status requiredBlockStatus) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if _, ok := r.blocks[blockNum]; ok {
+		r.blocks[blockNum] = status
+	}
+}
+
+// startCheck marks a pending required block as being spot-checked,
+// and returns whether it was pending.
+func (r *requiredBlocks) startCheck(blockNum uint64) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if status, ok := r.blocks[blockNum]; !ok || status != requiredBlockPending {
+		return false
+	}
+	r.blocks[blockNum] = requiredBlockChecking
+	return true
+}
+
+// assertionSample returns the blocks of the assertion from firstBlock to lastBlock
+// that must be validated: lastBlock and up to samples other blocks. The other blocks
+// are chosen at random, but the same ones are chosen each time for a given range so
+// that rechecking an assertion doesn't require more of its blocks.
+func (r *requiredBlocks) assertionSample(firstBlock uint64, lastBlock uint64, samples uint64) []uint64 {
+	r.mutex.Lock()
+	r.initialize()
+	seed := r.seed
+	r.mutex.Unlock()
+
+	if samples > maxAssertionSamples {
+		samples = maxAssertionSamples
+	}
+	blocks := []uint64{lastBlock}
+	if firstBlock >= lastBlock {
+		return blocks
+	}
+	others := lastBlock - firstBlock
+	if others <= samples {
+		for block := firstBlock; block < lastBlock; block++ {
+			blocks = append(blocks, block)
+		}
+		return blocks
+	}
+	//nolint:gosec
+	random := rand.New(rand.NewSource(seed ^ int64(firstBlock<<32) ^ int64(lastBlock)))
+	chosen := make(map[uint64]bool)
+	for uint64(len(chosen)) < samples {
+		block := firstBlock + uint64(random.Int63n(int64(others)))
+		if !chosen[block] {
+			chosen[block] = true
+			blocks = append(blocks, block)
+		}
+	}
+	return blocks
+}
+
+// shouldValidate returns whether the block with the given status must be
+// validated, rather than skipped, when sampling is enabled.
+func (v *BlockValidator) shouldValidate(config *SamplingConfig, blockNum uint64, status *validationStatus) bool {
+	if !config.Enable {
+		return true
+	}
+	if v.requiredBlocks.contains(blockNum) {
+		return true
+	}
+	if config.Interval > 0 && blockNum%config.Interval == 0 {
+		return true
+	}
+	for _, kind := range config.MessageKinds {
+		if int(status.MessageKind) == kind {
+			return true
+		}
+	}
+	//nolint:gosec
+	return config.Probability > 0 && rand.Float64() < config.Probability
+}
+
+// RequireValidation ensures a sample of the blocks of the rollup assertion from
+// firstBlock to lastBlock, always including lastBlock, is actually validated even
+// if those blocks aren't otherwise sampled, as validation treats blocks it skips as
+// valid. Sampled blocks validation hasn't yet reached will be validated when it
+// does, while those it has moved past without validating are spot-checked in the
+// background. It doesn't block: it returns whether all the sampled blocks are known
+// to be valid, and an error if any of them failed its spot-check.
+func (v *BlockValidator) RequireValidation(firstBlock uint64, lastBlock uint64) (bool, error) {
+	config := &v.config().Sampling
+	if !config.Enable {
+		return true, nil
+	}
+	lastBlockValidated := v.LastBlockValidated()
+	ready := true
+	for _, blockNum := range v.requiredBlocks.assertionSample(firstBlock, lastBlock, config.AssertionSamples) {
+		v.requiredBlocks.add(blockNum)
+		switch v.requiredBlocks.status(blockNum) {
+		case requiredBlockValid:
+			continue
+		case requiredBlockInvalid:
+			return false, fmt.Errorf("assertion block %v failed its spot-check", blockNum)
+		}
+		ready = false
+		if blockNum > lastBlockValidated || !v.requiredBlocks.startCheck(blockNum) {
+			continue
+		}
+		log.Info("spot-checking assertion block already passed by validation", "blockNr", blockNum)
+		blockNum := blockNum
+		v.LaunchThread(func(ctx context.Context) {
+			valid, err := v.spotCheck(ctx, blockNum)
+			if err != nil {
+				log.Warn("couldn't spot-check assertion block", "blockNr", blockNum, "err", err)
+				v.requiredBlocks.setStatus(blockNum, requiredBlockPending)
+			} else if valid {
+				v.requiredBlocks.setStatus(blockNum, requiredBlockValid)
+			} else {
+				v.requiredBlocks.setStatus(blockNum, requiredBlockInvalid)
+			}
+		})
+	}
+	return ready, nil
+}
+
+// spotCheck validates a block outside of the usual validation order,
+// returning an error only if the check itself couldn't be completed.
+func (v *BlockValidator) spotCheck(ctx context.Context, blockNum uint64) (bool, error) {
+	samplingSpotCheckCounter.Inc(1)
+	header := v.blockchain.GetHeaderByNumber(blockNum)
+	if header == nil {
+		return false, fmt.Errorf("block %v not found", blockNum)
+	}
+	entry := &validationEntry{BlockNumber: blockNum, BlockHash: header.Hash()}
+	moduleRoots := v.GetModuleRootsToValidate()
+	started := time.Now()
+	for _, moduleRoot := range moduleRoots {
+		valid, err := v.ValidateBlock(ctx, header, v.config().ArbitratorValidator, moduleRoot)
+		if ctx.Err() != nil {
+			return false, ctx.Err()
+		}
+		v.history.recordModuleRoot(moduleRoot, valid)
+		if err != nil || !valid {
+			failure := fmt.Sprintf("spot-check on module root %v found block invalid", moduleRoot)
+			if err != nil {
+				failure = fmt.Sprintf("spot-check on module root %v failed: %v", moduleRoot, err)
+			}
+			samplingSpotCheckFailuresCounter.Inc(1)
+			log.Error("spot-check of block failed", "blockNr", blockNum, "blockHash", entry.BlockHash, "moduleRoot", moduleRoot, "err", err)
+			v.history.recordBlock(entry, moduleRoots, false, failure, time.Since(started))
+			return false, nil
+		}
+	}
+	log.Info("spot-check of block succeeded", "blockNr", blockNum, "blockHash", entry.BlockHash, "time", time.Since(started))
+	v.history.recordBlock(entry, moduleRoots, true, "", time.Since(started))
+	return true, nil
+}
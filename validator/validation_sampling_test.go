@@ -0,0 +1,154 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package validator
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/offchainlabs/nitro/arbos"
+)
+
+func TestSamplingShouldValidate(t *testing.T) {
+	v := &BlockValidator{}
+	config := DefaultSamplingConfig
+	config.Enable = true
+	config.Interval = 10
+	config.MessageKinds = []int{arbos.L1MessageType_SubmitRetryable}
+
+	l2Message := &validationStatus{MessageKind: arbos.L1MessageType_L2Message}
+	retryable := &validationStatus{MessageKind: arbos.L1MessageType_SubmitRetryable}
+
+	if !v.shouldValidate(&config, 20, l2Message) {
+		Fail(t, "block on the sampling interval not validated")
+	}
+	if v.shouldValidate(&config, 21, l2Message) {
+		Fail(t, "block off the sampling interval validated")
+	}
+	if !v.shouldValidate(&config, 21, retryable) {
+		Fail(t, "block with sampled message kind not validated")
+	}
+	v.requiredBlocks.add(21)
+	if !v.shouldValidate(&config, 21, l2Message) {
+		Fail(t, "required block not validated")
+	}
+
+	config.Probability = 1
+	if !v.shouldValidate(&config, 23, l2Message) {
+		Fail(t, "block not validated with sampling probability 1")
+	}
+
+	config.Enable = false
+	config.Probability = 0
+	if !v.shouldValidate(&config, 25, l2Message) {
+		Fail(t, "block skipped with sampling disabled")
+	}
+}
+
+func TestRequiredBlocksBounded(t *testing.T) {
+	var required requiredBlocks
+	for block := uint64(0); block < maxRequiredBlocks+10; block++ {
+		if !required.add(block) {
+			Fail(t, "block", block, "not newly added")
+		}
+	}
+	if required.add(maxRequiredBlocks) {
+		Fail(t, "block added twice")
+	}
+	if len(required.blocks) != maxRequiredBlocks {
+		Fail(t, "unexpected number of required blocks", len(required.blocks))
+	}
+	if required.contains(0) || !required.contains(maxRequiredBlocks+9) {
+		Fail(t, "expected the oldest blocks to be forgotten")
+	}
+}
+
+func TestRequiredBlockStatus(t *testing.T) {
+	var required requiredBlocks
+	required.setStatus(5, requiredBlockValid)
+	if required.contains(5) {
+		Fail(t, "status recorded for a block that isn't required")
+	}
+	required.add(5)
+	if required.status(5) != requiredBlockPending {
+		Fail(t, "required block not pending validation")
+	}
+	required.setStatus(5, requiredBlockInvalid)
+	if required.add(5) || required.status(5) != requiredBlockInvalid {
+		Fail(t, "re-adding a required block lost its status")
+	}
+}
+
+func TestAssertionSample(t *testing.T) {
+	var required requiredBlocks
+	blocks := required.assertionSample(100, 200, 4)
+	if len(blocks) != 5 || blocks[0] != 200 {
+		Fail(t, "unexpected assertion sample", blocks)
+	}
+	seen := make(map[uint64]bool)
+	for _, block := range blocks[1:] {
+		if block < 100 || block >= 200 || seen[block] {
+			Fail(t, "bad block sampled from assertion", block)
+		}
+		seen[block] = true
+	}
+	if !reflect.DeepEqual(blocks, required.assertionSample(100, 200, 4)) {
+		Fail(t, "assertion sampled differently when rechecked")
+	}
+
+	blocks = required.assertionSample(100, 102, 4)
+	if !reflect.DeepEqual(blocks, []uint64{102, 100, 101}) {
+		Fail(t, "expected every block of a short assertion to be sampled", blocks)
+	}
+	if len(required.assertionSample(0, 10000, 10000)) != maxAssertionSamples+1 {
+		Fail(t, "assertion sample not bounded")
+	}
+}
+
+func TestRequireValidation(t *testing.T) {
+	config := TestBlockValidatorConfig
+	config.Sampling.Enable = true
+	config.Sampling.AssertionSamples = 2
+	v := &BlockValidator{
+		config: func() *BlockValidatorConfig { return &config },
+	}
+
+	// validation hasn't reached the assertion yet, so nothing is spot-checked
+	ready, err := v.RequireValidation(11, 20)
+	Require(t, err)
+	if ready {
+		Fail(t, "assertion ready before its blocks were validated")
+	}
+	blocks := v.requiredBlocks.assertionSample(11, 20, 2)
+	for _, block := range blocks {
+		if v.requiredBlocks.status(block) != requiredBlockPending {
+			Fail(t, "sampled block", block, "not pending validation")
+		}
+		v.requiredBlocks.setStatus(block, requiredBlockValid)
+	}
+	ready, err = v.RequireValidation(11, 20)
+	Require(t, err)
+	if !ready {
+		Fail(t, "assertion not ready with its sampled blocks validated")
+	}
+
+	v.requiredBlocks.setStatus(blocks[1], requiredBlockInvalid)
+	if _, err := v.RequireValidation(11, 20); err == nil {
+		Fail(t, "assertion with an invalid sampled block accepted")
+	}
+}
+
+func TestRequiredBlockStartCheck(t *testing.T) {
+	var required requiredBlocks
+	if required.startCheck(5) {
+		Fail(t, "started checking a block that isn't required")
+	}
+	required.add(5)
+	if !required.startCheck(5) || required.status(5) != requiredBlockChecking {
+		Fail(t, "didn't start checking a pending block")
+	}
+	if required.startCheck(5) {
+		Fail(t, "started checking a block twice")
+	}
+}
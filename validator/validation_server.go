@@ -0,0 +1,158 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package validator
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"runtime"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/offchainlabs/nitro/cmd/genericconf"
+)
+
+var (
+	validationServerRunningGauge   = metrics.NewRegisteredGauge("arb/validator/server/running", nil)
+	validationServerRequestCounter = metrics.NewRegisteredCounter("arb/validator/server/requests", nil)
+	validationServerFailureCounter = metrics.NewRegisteredCounter("arb/validator/server/failures", nil)
+)
+
+// ValidationInput holds everything needed to validate a single block without
+// access to the database of the node that produced it. The preimages must
+// include all state the block touches, as well as any data availability data.
+type ValidationInput struct {
+	BlockNumber   uint64                 `json:"blockNumber"`
+	ModuleRoot    common.Hash            `json:"moduleRoot"`
+	Jit           bool                   `json:"jit"`
+	StartState    GoGlobalState          `json:"startState"`
+	Preimages     map[common.Hash][]byte `json:"preimages"`
+	BatchInfo     []BatchInfo            `json:"batchInfo"`
+	HasDelayedMsg bool                   `json:"hasDelayedMsg"`
+	DelayedMsgNr  uint64                 `json:"delayedMsgNr"`
+	DelayedMsg    []byte                 `json:"delayedMsg"`
+}
+
+func (i *ValidationInput) entry() *validationEntry {
+	return &validationEntry{
+		BlockNumber:   i.BlockNumber,
+		PrevBlockHash: i.StartState.BlockHash,
+		PrevSendRoot:  i.StartState.SendRoot,
+		HasDelayedMsg: i.HasDelayedMsg,
+		DelayedMsgNr:  i.DelayedMsgNr,
+		StartPosition: GlobalStatePosition{
+			BatchNumber: i.StartState.Batch,
+			PosInBatch:  i.StartState.PosInBatch,
+		},
+		Preimages: i.Preimages,
+		BatchInfo: i.BatchInfo,
+	}
+}
+
+func (i *ValidationInput) resolver() GoPreimageResolver {
+	return func(hash common.Hash) ([]byte, error) {
+		if preimage, ok := i.Preimages[hash]; ok {
+			return preimage, nil
+		}
+		return nil, fmt.Errorf("preimage %v not included in validation input", hash)
+	}
+}
+
+// ValidationWorkerStatus is reported by validation workers so nodes can
+// balance load across them.
+type ValidationWorkerStatus struct {
+	Running int32 `json:"running"`
+	Limit   int32 `json:"limit"`
+}
+
+// ValidationServerAPI runs validations sent by nodes on the machines available
+// locally to the worker.
+type ValidationServerAPI struct {
+	loader  *NitroMachineLoader
+	limit   chan struct{}
+	running int32
+}
+
+func NewValidationServerAPI(loader *NitroMachineLoader, concurrentRunsLimit int) *ValidationServerAPI {
+	if concurrentRunsLimit <= 0 {
+		concurrentRunsLimit = runtime.NumCPU()
+	}
+	return &ValidationServerAPI{
+		loader: loader,
+		limit:  make(chan struct{}, concurrentRunsLimit),
+	}
+}
+
+func (a *ValidationServerAPI) Validate(ctx context.Context, input *ValidationInput) (*GoGlobalState, error) {
+	validationServerRequestCounter.Inc(1)
+	select {
+	case a.limit <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	validationServerRunningGauge.Update(int64(atomic.AddInt32(&a.running, 1)))
+	defer func() {
+		validationServerRunningGauge.Update(int64(atomic.AddInt32(&a.running, -1)))
+		<-a.limit
+	}()
+
+	entry := input.entry()
+	var gsEnd GoGlobalState
+	var err error
+	if input.Jit {
+		gsEnd, err = executeJitMachine(ctx, a.loader, entry, input.ModuleRoot, input.resolver(), input.DelayedMsg)
+	} else {
+		gsEnd, err = executeArbitratorMachine(ctx, a.loader, entry, input.ModuleRoot, input.resolver(), input.DelayedMsg)
+	}
+	if err != nil {
+		validationServerFailureCounter.Inc(1)
+		log.Warn("validation failed", "blockNr", input.BlockNumber, "moduleRoot", input.ModuleRoot, "jit", input.Jit, "err", err)
+		return nil, err
+	}
+	log.Info("validated block", "blockNr", input.BlockNumber, "moduleRoot", input.ModuleRoot, "jit", input.Jit)
+	return &gsEnd, nil
+}
+
+func (a *ValidationServerAPI) Status(ctx context.Context) (*ValidationWorkerStatus, error) {
+	return &ValidationWorkerStatus{
+		Running: atomic.LoadInt32(&a.running),
+		Limit:   int32(cap(a.limit)),
+	}, nil
+}
+
+func StartValidationServer(ctx context.Context, addr string, port uint64, serverTimeouts genericconf.HTTPServerTimeoutConfig, api *ValidationServerAPI) (*http.Server, error) {
+	listener, err := net.Listen("tcp", fmt.Sprintf("%s:%d", addr, port))
+	if err != nil {
+		return nil, err
+	}
+	rpcServer := rpc.NewServer()
+	if err := rpcServer.RegisterName("validation", api); err != nil {
+		return nil, err
+	}
+
+	srv := &http.Server{
+		Handler:           rpcServer,
+		ReadTimeout:       serverTimeouts.ReadTimeout,
+		ReadHeaderTimeout: serverTimeouts.ReadHeaderTimeout,
+		WriteTimeout:      serverTimeouts.WriteTimeout,
+		IdleTimeout:       serverTimeouts.IdleTimeout,
+	}
+	go func() {
+		err := srv.Serve(listener)
+		if err != nil && err != http.ErrServerClosed {
+			log.Error("validation server stopped", "err", err)
+		}
+	}()
+	go func() {
+		<-ctx.Done()
+		_ = srv.Shutdown(context.Background())
+	}()
+	return srv, nil
+}
@@ -6,6 +6,7 @@ package wsbroadcastserver
 import (
 	"context"
 	"encoding/json"
+	"io"
 	"math/rand"
 	"net"
 	"strconv"
@@ -32,18 +33,23 @@ type ClientConnection struct {
 	Name            string
 	clientManager   *ClientManager
 	requestedSeqNum arbutil.MessageIndex
+	clientIP        string
+	connectedAt     time.Time
 
 	lastHeardUnix int64
+	bytesSent     uint64
 	out           chan []byte
 }
 
-func NewClientConnection(conn net.Conn, desc *netpoll.Desc, clientManager *ClientManager, requestedSeqNum arbutil.MessageIndex) *ClientConnection {
+func NewClientConnection(conn net.Conn, desc *netpoll.Desc, clientManager *ClientManager, requestedSeqNum arbutil.MessageIndex, clientIP string) *ClientConnection {
 	return &ClientConnection{
 		conn:            conn,
 		desc:            desc,
 		Name:            conn.RemoteAddr().String() + strconv.Itoa(rand.Intn(10)),
 		clientManager:   clientManager,
 		requestedSeqNum: requestedSeqNum,
+		clientIP:        clientIP,
+		connectedAt:     time.Now(),
 		lastHeardUnix:   time.Now().Unix(),
 		out:             make(chan []byte, clientManager.config().MaxSendQueue),
 	}
@@ -93,6 +99,26 @@ func (cc *ClientConnection) GetLastHeard() time.Time {
 	return time.Unix(atomic.LoadInt64(&cc.lastHeardUnix), 0)
 }
 
+func (cc *ClientConnection) ClientIP() string {
+	return cc.clientIP
+}
+
+func (cc *ClientConnection) BytesSent() uint64 {
+	return atomic.LoadUint64(&cc.bytesSent)
+}
+
+func (cc *ClientConnection) Info() ClientInfo {
+	return ClientInfo{
+		Name:            cc.Name,
+		IP:              cc.clientIP,
+		ConnectedAt:     cc.connectedAt,
+		LastHeard:       cc.GetLastHeard(),
+		RequestedSeqNum: cc.requestedSeqNum,
+		BytesSent:       cc.BytesSent(),
+		Lag:             len(cc.out),
+	}
+}
+
 // Receive reads next message from client's underlying connection.
 // It blocks until full message received.
 func (cc *ClientConnection) Receive(ctx context.Context, timeout time.Duration) ([]byte, ws.OpCode, error) {
@@ -116,7 +142,7 @@ func (cc *ClientConnection) readRequest(ctx context.Context, timeout time.Durati
 }
 
 func (cc *ClientConnection) Write(x interface{}) error {
-	writer := wsutil.NewWriter(cc.conn, ws.StateServerSide, ws.OpText)
+	writer := wsutil.NewWriter(&countingWriter{cc.conn, &cc.bytesSent}, ws.StateServerSide, ws.OpText)
 	encoder := json.NewEncoder(writer)
 
 	cc.ioMutex.Lock()
@@ -133,7 +159,8 @@ func (cc *ClientConnection) writeRaw(p []byte) error {
 	cc.ioMutex.Lock()
 	defer cc.ioMutex.Unlock()
 
-	_, err := cc.conn.Write(p)
+	n, err := cc.conn.Write(p)
+	atomic.AddUint64(&cc.bytesSent, uint64(n))
 
 	return err
 }
@@ -148,3 +175,15 @@ func (cc *ClientConnection) Ping() error {
 
 	return nil
 }
+
+// countingWriter wraps an io.Writer, atomically adding the number of bytes written to count.
+type countingWriter struct {
+	io.Writer
+	count *uint64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.Writer.Write(p)
+	atomic.AddUint64(w.count, uint64(n))
+	return n, err
+}
@@ -9,6 +9,7 @@ import (
 	"encoding/json"
 	"net"
 	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -40,14 +41,29 @@ type CatchupBuffer interface {
 type ClientManager struct {
 	stopwaiter.StopWaiter
 
-	clientPtrMap  map[*ClientConnection]bool
-	clientCount   int32
-	pool          *gopool.Pool
-	poller        netpoll.Poller
-	broadcastChan chan interface{}
-	clientAction  chan ClientConnectionAction
-	config        BroadcasterConfigFetcher
-	catchupBuffer CatchupBuffer
+	// Only written by the main ClientManager thread, other readers must hold clientPtrMapMutex
+	clientPtrMapMutex sync.RWMutex
+	clientPtrMap      map[*ClientConnection]bool
+	clientCount       int32
+	pool              *gopool.Pool
+	poller            netpoll.Poller
+	broadcastChan     chan interface{}
+	clientAction      chan ClientConnectionAction
+	config            BroadcasterConfigFetcher
+	catchupBuffer     CatchupBuffer
+	limiter           *ConnectionLimiter
+}
+
+// ClientInfo is a snapshot of the state of a connected client.
+type ClientInfo struct {
+	Name            string               `json:"name"`
+	IP              string               `json:"ip"`
+	ConnectedAt     time.Time            `json:"connectedAt"`
+	LastHeard       time.Time            `json:"lastHeard"`
+	RequestedSeqNum arbutil.MessageIndex `json:"requestedSeqNum"`
+	BytesSent       uint64               `json:"bytesSent"`
+	// Lag is the number of messages queued for the client but not yet written to it
+	Lag int `json:"lag"`
 }
 
 type ClientConnectionAction struct {
@@ -65,6 +81,7 @@ func NewClientManager(poller netpoll.Poller, configFetcher BroadcasterConfigFetc
 		clientAction:  make(chan ClientConnectionAction, 128),
 		config:        configFetcher,
 		catchupBuffer: catchupBuffer,
+		limiter:       NewConnectionLimiter(configFetcher),
 	}
 }
 
@@ -79,7 +96,9 @@ func (cm *ClientManager) registerClient(ctx context.Context, clientConnection *C
 	}
 
 	clientConnection.Start(ctx)
+	cm.clientPtrMapMutex.Lock()
 	cm.clientPtrMap[clientConnection] = true
+	cm.clientPtrMapMutex.Unlock()
 	clientsConnectedGauge.Inc(1)
 	atomic.AddInt32(&cm.clientCount, 1)
 
@@ -87,9 +106,11 @@ func (cm *ClientManager) registerClient(ctx context.Context, clientConnection *C
 }
 
 // Register registers new connection as a Client.
-func (cm *ClientManager) Register(conn net.Conn, desc *netpoll.Desc, requestedSeqNum arbutil.MessageIndex) *ClientConnection {
+// The connection slot for clientIP must already be reserved in cm.limiter,
+// it is released when the client is removed.
+func (cm *ClientManager) Register(conn net.Conn, desc *netpoll.Desc, requestedSeqNum arbutil.MessageIndex, clientIP string) *ClientConnection {
 	createClient := ClientConnectionAction{
-		NewClientConnection(conn, desc, cm, requestedSeqNum),
+		NewClientConnection(conn, desc, cm, requestedSeqNum, clientIP),
 		true,
 	}
 
@@ -119,6 +140,7 @@ func (cm *ClientManager) removeClientImpl(clientConnection *ClientConnection) {
 		log.Warn("Failed to close client connection", "err", err)
 	}
 
+	cm.limiter.Release(clientConnection.clientIP)
 	clientsConnectedGauge.Dec(1)
 	atomic.AddInt32(&cm.clientCount, -1)
 }
@@ -130,7 +152,9 @@ func (cm *ClientManager) removeClient(clientConnection *ClientConnection) {
 
 	cm.removeClientImpl(clientConnection)
 
+	cm.clientPtrMapMutex.Lock()
 	delete(cm.clientPtrMap, clientConnection)
+	cm.clientPtrMapMutex.Unlock()
 }
 
 func (cm *ClientManager) Remove(clientConnection *ClientConnection) {
@@ -144,6 +168,37 @@ func (cm *ClientManager) ClientCount() int32 {
 	return atomic.LoadInt32(&cm.clientCount)
 }
 
+// ClientInfos returns a snapshot of all registered clients.
+func (cm *ClientManager) ClientInfos() []ClientInfo {
+	cm.clientPtrMapMutex.RLock()
+	defer cm.clientPtrMapMutex.RUnlock()
+
+	infos := make([]ClientInfo, 0, len(cm.clientPtrMap))
+	for client := range cm.clientPtrMap {
+		infos = append(infos, client.Info())
+	}
+	return infos
+}
+
+// DisconnectClients removes all registered clients matching the filter,
+// returning the number of clients removed.
+func (cm *ClientManager) DisconnectClients(filter func(*ClientConnection) bool) int {
+	var toRemove []*ClientConnection
+	cm.clientPtrMapMutex.RLock()
+	for client := range cm.clientPtrMap {
+		if filter(client) {
+			toRemove = append(toRemove, client)
+		}
+	}
+	cm.clientPtrMapMutex.RUnlock()
+
+	for _, client := range toRemove {
+		log.Info("disconnecting client by request", "client", client.Name)
+		cm.Remove(client)
+	}
+	return len(toRemove)
+}
+
 // Broadcast sends batch item to all clients.
 func (cm *ClientManager) Broadcast(bm interface{}) {
 	cm.broadcastChan <- bm
@@ -0,0 +1,99 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package wsbroadcastserver
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/gobwas/ws"
+
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var (
+	clientsRejectedLimitCounter = metrics.NewRegisteredCounter("arb/feed/clients/rejected/limit", nil)
+	clientsRejectedAuthCounter  = metrics.NewRegisteredCounter("arb/feed/clients/rejected/auth", nil)
+)
+
+// ConnectionLimiter tracks the number of connections globally and per remote IP,
+// and rejects new connections once the configured limits are reached.
+// A limit of zero disables the corresponding check.
+type ConnectionLimiter struct {
+	mutex  sync.Mutex
+	config BroadcasterConfigFetcher
+	total  int
+	perIP  map[string]int
+}
+
+func NewConnectionLimiter(config BroadcasterConfigFetcher) *ConnectionLimiter {
+	return &ConnectionLimiter{
+		config: config,
+		perIP:  make(map[string]int),
+	}
+}
+
+// Register reserves a connection slot for ip, or returns a websocket
+// rejection error if doing so would exceed one of the limits.
+// Every successful Register must be paired with a call to Release.
+func (l *ConnectionLimiter) Register(ip string) error {
+	config := l.config()
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if config.MaxClients > 0 && l.total >= config.MaxClients {
+		clientsRejectedLimitCounter.Inc(1)
+		return ws.RejectConnectionError(
+			ws.RejectionStatus(http.StatusServiceUnavailable),
+			ws.RejectionReason(fmt.Sprintf("Too many clients connected: %d", l.total)),
+		)
+	}
+	if config.MaxClientsPerIP > 0 && l.perIP[ip] >= config.MaxClientsPerIP {
+		clientsRejectedLimitCounter.Inc(1)
+		return ws.RejectConnectionError(
+			ws.RejectionStatus(http.StatusTooManyRequests),
+			ws.RejectionReason(fmt.Sprintf("Too many clients connected from %s: %d", ip, l.perIP[ip])),
+		)
+	}
+
+	l.total++
+	l.perIP[ip]++
+	return nil
+}
+
+// Release frees the connection slot previously reserved for ip.
+func (l *ConnectionLimiter) Release(ip string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	count, ok := l.perIP[ip]
+	if !ok {
+		return
+	}
+	if count <= 1 {
+		delete(l.perIP, ip)
+	} else {
+		l.perIP[ip] = count - 1
+	}
+	l.total--
+}
+
+// ConnectionCount returns the number of connections currently registered for ip.
+func (l *ConnectionLimiter) ConnectionCount(ip string) int {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.perIP[ip]
+}
+
+func remoteIP(conn net.Conn) string {
+	addr := conn.RemoteAddr().String()
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return host
+}
@@ -0,0 +1,77 @@
+// Copyright 2021-2022, Offchain Labs, Inc.
+// For license information, see https://github.com/nitro/blob/master/LICENSE
+
+package wsbroadcastserver
+
+import (
+	"testing"
+)
+
+func TestConnectionLimiter(t *testing.T) {
+	config := DefaultTestBroadcasterConfig
+	config.MaxClients = 3
+	config.MaxClientsPerIP = 2
+	l := NewConnectionLimiter(func() *BroadcasterConfig { return &config })
+
+	ip1 := "10.0.0.1"
+	ip2 := "10.0.0.2"
+	ip3 := "10.0.0.3"
+
+	if err := l.Register(ip1); err != nil {
+		t.Fatal("first connection from ip1 rejected", err)
+	}
+	if err := l.Register(ip1); err != nil {
+		t.Fatal("second connection from ip1 rejected", err)
+	}
+	if err := l.Register(ip1); err == nil {
+		t.Fatal("third connection from ip1 should exceed per-IP limit")
+	}
+	if err := l.Register(ip2); err != nil {
+		t.Fatal("first connection from ip2 rejected", err)
+	}
+	if err := l.Register(ip3); err == nil {
+		t.Fatal("connection from ip3 should exceed global limit")
+	}
+
+	l.Release(ip1)
+	if l.ConnectionCount(ip1) != 1 {
+		t.Fatal("expected one remaining connection from ip1, got", l.ConnectionCount(ip1))
+	}
+	if err := l.Register(ip3); err != nil {
+		t.Fatal("connection from ip3 rejected after release", err)
+	}
+
+	// Releasing an unknown IP must not free up a slot
+	l.Release("10.0.0.4")
+	if err := l.Register(ip2); err == nil {
+		t.Fatal("connection from ip2 should exceed global limit")
+	}
+
+	// Limits are read on every registration so they can be hot reloaded
+	config.MaxClients = 0
+	config.MaxClientsPerIP = 0
+	for i := 0; i < 5; i++ {
+		if err := l.Register(ip1); err != nil {
+			t.Fatal("connection rejected with limits disabled", err)
+		}
+	}
+}
+
+func TestIsAuthorized(t *testing.T) {
+	if !isAuthorized(nil, nil) {
+		t.Error("no tokens configured should allow all clients")
+	}
+	if !isAuthorized([]string{""}, nil) {
+		t.Error("empty token configured should allow all clients")
+	}
+	tokens := []string{"first", "second"}
+	if isAuthorized(tokens, nil) {
+		t.Error("missing token accepted")
+	}
+	if isAuthorized(tokens, []byte("third")) {
+		t.Error("invalid token accepted")
+	}
+	if !isAuthorized(tokens, []byte("second")) {
+		t.Error("valid token rejected")
+	}
+}
@@ -5,6 +5,7 @@ package wsbroadcastserver
 
 import (
 	"context"
+	"crypto/subtle"
 	"fmt"
 	"net"
 	"net/http"
@@ -29,23 +30,27 @@ const (
 	HTTPHeaderFeedClientVersion       = "Arbitrum-Feed-Client-Version"
 	HTTPHeaderRequestedSequenceNumber = "Arbitrum-Requested-Sequence-Number"
 	HTTPHeaderChainId                 = "Arbitrum-Chain-Id"
+	HTTPHeaderFeedAuthToken           = "Arbitrum-Feed-Auth-Token"
 	FeedServerVersion                 = 2
 	FeedClientVersion                 = 2
 )
 
 type BroadcasterConfig struct {
-	Enable         bool          `koanf:"enable"`
-	Signed         bool          `koanf:"signed"`
-	Addr           string        `koanf:"addr"`                         // TODO(magic) needs tcp server restart on change
-	IOTimeout      time.Duration `koanf:"io-timeout" reload:"hot"`      // reloading will affect only new connections
-	Port           string        `koanf:"port"`                         // TODO(magic) needs tcp server restart on change
-	Ping           time.Duration `koanf:"ping" reload:"hot"`            // reloaded value will change future ping intervals
-	ClientTimeout  time.Duration `koanf:"client-timeout" reload:"hot"`  // reloaded value will affect all clients (next time the timeout is checked)
-	Queue          int           `koanf:"queue"`                        // TODO(magic) ClientManager.pool needs to be recreated on change
-	Workers        int           `koanf:"workers"`                      // TODO(magic) ClientManager.pool needs to be recreated on change
-	MaxSendQueue   int           `koanf:"max-send-queue" reload:"hot"`  // reloaded value will affect only new connections
-	RequireVersion bool          `koanf:"require-version" reload:"hot"` // reloaded value will affect only future upgrades to websocket
-	DisableSigning bool          `koanf:"disable-signing"`
+	Enable          bool          `koanf:"enable"`
+	Signed          bool          `koanf:"signed"`
+	Addr            string        `koanf:"addr"`                         // TODO(magic) needs tcp server restart on change
+	IOTimeout       time.Duration `koanf:"io-timeout" reload:"hot"`      // reloading will affect only new connections
+	Port            string        `koanf:"port"`                         // TODO(magic) needs tcp server restart on change
+	Ping            time.Duration `koanf:"ping" reload:"hot"`            // reloaded value will change future ping intervals
+	ClientTimeout   time.Duration `koanf:"client-timeout" reload:"hot"`  // reloaded value will affect all clients (next time the timeout is checked)
+	Queue           int           `koanf:"queue"`                        // TODO(magic) ClientManager.pool needs to be recreated on change
+	Workers         int           `koanf:"workers"`                      // TODO(magic) ClientManager.pool needs to be recreated on change
+	MaxSendQueue    int           `koanf:"max-send-queue" reload:"hot"`  // reloaded value will affect only new connections
+	RequireVersion  bool          `koanf:"require-version" reload:"hot"` // reloaded value will affect only future upgrades to websocket
+	DisableSigning  bool          `koanf:"disable-signing"`
+	MaxClients      int           `koanf:"max-clients" reload:"hot"`        // reloaded value will affect only new connections, existing clients are kept
+	MaxClientsPerIP int           `koanf:"max-clients-per-ip" reload:"hot"` // reloaded value will affect only new connections, existing clients are kept
+	AuthTokens      []string      `koanf:"auth-tokens" reload:"hot"`        // reloaded value will affect only future upgrades to websocket
 }
 
 type BroadcasterConfigFetcher func() *BroadcasterConfig
@@ -63,36 +68,45 @@ func BroadcasterConfigAddOptions(prefix string, f *flag.FlagSet) {
 	f.Int(prefix+".max-send-queue", DefaultBroadcasterConfig.MaxSendQueue, "maximum number of messages allowed to accumulate before client is disconnected")
 	f.Bool(prefix+".require-version", DefaultBroadcasterConfig.RequireVersion, "don't connect if client version not present")
 	f.Bool(prefix+".disable-signing", DefaultBroadcasterConfig.DisableSigning, "don't sign feed messages")
+	f.Int(prefix+".max-clients", DefaultBroadcasterConfig.MaxClients, "maximum number of connected clients, 0 for unlimited")
+	f.Int(prefix+".max-clients-per-ip", DefaultBroadcasterConfig.MaxClientsPerIP, "maximum number of connected clients from a single IP address, 0 for unlimited")
+	f.StringSlice(prefix+".auth-tokens", DefaultBroadcasterConfig.AuthTokens, "if set, clients must provide one of these tokens in the "+HTTPHeaderFeedAuthToken+" HTTP header to connect")
 }
 
 var DefaultBroadcasterConfig = BroadcasterConfig{
-	Enable:         false,
-	Signed:         false,
-	Addr:           "",
-	IOTimeout:      5 * time.Second,
-	Port:           "9642",
-	Ping:           5 * time.Second,
-	ClientTimeout:  15 * time.Second,
-	Queue:          100,
-	Workers:        100,
-	MaxSendQueue:   4096,
-	RequireVersion: false,
-	DisableSigning: true,
+	Enable:          false,
+	Signed:          false,
+	Addr:            "",
+	IOTimeout:       5 * time.Second,
+	Port:            "9642",
+	Ping:            5 * time.Second,
+	ClientTimeout:   15 * time.Second,
+	Queue:           100,
+	Workers:         100,
+	MaxSendQueue:    4096,
+	RequireVersion:  false,
+	DisableSigning:  true,
+	MaxClients:      0,
+	MaxClientsPerIP: 0,
+	AuthTokens:      []string{},
 }
 
 var DefaultTestBroadcasterConfig = BroadcasterConfig{
-	Enable:         false,
-	Signed:         false,
-	Addr:           "0.0.0.0",
-	IOTimeout:      2 * time.Second,
-	Port:           "0",
-	Ping:           5 * time.Second,
-	ClientTimeout:  15 * time.Second,
-	Queue:          1,
-	Workers:        100,
-	MaxSendQueue:   4096,
-	RequireVersion: false,
-	DisableSigning: false,
+	Enable:          false,
+	Signed:          false,
+	Addr:            "0.0.0.0",
+	IOTimeout:       2 * time.Second,
+	Port:            "0",
+	Ping:            5 * time.Second,
+	ClientTimeout:   15 * time.Second,
+	Queue:           1,
+	Workers:         100,
+	MaxSendQueue:    4096,
+	RequireVersion:  false,
+	DisableSigning:  false,
+	MaxClients:      0,
+	MaxClientsPerIP: 0,
+	AuthTokens:      []string{},
 }
 
 type WSBroadcastServer struct {
@@ -170,10 +184,15 @@ func (s *WSBroadcastServer) StartWithHeader(ctx context.Context, header ws.Hands
 
 		var feedClientVersionSeen bool
 		var requestedSeqNum arbutil.MessageIndex
+		var authToken []byte
+		clientIP := remoteIP(conn)
+		connectionRegistered := false
 		upgrader := ws.Upgrader{
 			OnHeader: func(key []byte, value []byte) error {
 				headerName := string(key)
-				if headerName == HTTPHeaderFeedClientVersion {
+				if headerName == HTTPHeaderFeedAuthToken {
+					authToken = append([]byte{}, value...)
+				} else if headerName == HTTPHeaderFeedClientVersion {
 					feedClientVersion, err := strconv.ParseUint(string(value), 0, 64)
 					if err != nil {
 						return err
@@ -202,6 +221,17 @@ func (s *WSBroadcastServer) StartWithHeader(ctx context.Context, header ws.Hands
 						ws.RejectionReason(HTTPHeaderFeedClientVersion+" HTTP header missing"),
 					)
 				}
+				if !isAuthorized(s.config().AuthTokens, authToken) {
+					clientsRejectedAuthCounter.Inc(1)
+					return nil, ws.RejectConnectionError(
+						ws.RejectionStatus(http.StatusUnauthorized),
+						ws.RejectionReason("missing or invalid "+HTTPHeaderFeedAuthToken+" HTTP header"),
+					)
+				}
+				if err := s.clientManager.limiter.Register(clientIP); err != nil {
+					return nil, err
+				}
+				connectionRegistered = true
 				return header, nil
 			},
 		}
@@ -210,6 +240,9 @@ func (s *WSBroadcastServer) StartWithHeader(ctx context.Context, header ws.Hands
 		hs, err := upgrader.Upgrade(safeConn)
 		if err != nil {
 			log.Warn("websocket upgrade error", "connection_name", nameConn(safeConn), "err", err)
+			if connectionRegistered {
+				s.clientManager.limiter.Release(clientIP)
+			}
 			_ = safeConn.Close()
 			return
 		}
@@ -220,12 +253,13 @@ func (s *WSBroadcastServer) StartWithHeader(ctx context.Context, header ws.Hands
 		desc, err := netpoll.HandleRead(conn)
 		if err != nil {
 			log.Warn("error in HandleRead", "connection-name", nameConn(safeConn), "err", err)
+			s.clientManager.limiter.Release(clientIP)
 			_ = conn.Close()
 			return
 		}
 
 		// Register incoming client in clientManager.
-		client := s.clientManager.Register(safeConn, desc, requestedSeqNum)
+		client := s.clientManager.Register(safeConn, desc, requestedSeqNum, clientIP)
 
 		// Subscribe to events about conn.
 		err = s.poller.Start(desc, func(ev netpoll.Event) {
@@ -384,6 +418,38 @@ func (s *WSBroadcastServer) ClientCount() int32 {
 	return s.clientManager.ClientCount()
 }
 
+// ClientInfos returns a snapshot of all currently connected clients.
+func (s *WSBroadcastServer) ClientInfos() []ClientInfo {
+	return s.clientManager.ClientInfos()
+}
+
+// DisconnectClient forcibly disconnects all clients with the given name,
+// returning the number of clients disconnected.
+func (s *WSBroadcastServer) DisconnectClient(name string) int {
+	return s.clientManager.DisconnectClients(func(cc *ClientConnection) bool { return cc.Name == name })
+}
+
+// DisconnectIP forcibly disconnects all clients connected from the given IP,
+// returning the number of clients disconnected.
+func (s *WSBroadcastServer) DisconnectIP(ip string) int {
+	return s.clientManager.DisconnectClients(func(cc *ClientConnection) bool { return cc.clientIP == ip })
+}
+
+// isAuthorized returns true if no tokens are configured, or if token matches one of them.
+func isAuthorized(tokens []string, token []byte) bool {
+	authRequired := false
+	for _, allowed := range tokens {
+		if allowed == "" {
+			continue
+		}
+		authRequired = true
+		if subtle.ConstantTimeCompare([]byte(allowed), token) == 1 {
+			return true
+		}
+	}
+	return !authRequired
+}
+
 // deadliner is a wrapper around net.Conn that sets read/write deadlines before
 // every Read() or Write() call.
 type deadliner struct {